@@ -0,0 +1,188 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// The findings of the static analyzers run by the build (clang-tidy, clippy, Error Prone and
+// Android Lint) are normalized by findings_to_sarif into one SARIF file per module, at
+// $OUT_DIR/soong/analysis_findings/<module dir>/<module>.sarif, and merged into
+// $OUT_DIR/soong/analysis_findings.sarif.  `m analysis-findings` builds all of them.
+//
+// Like the baselines of Android Lint, a findings_baseline.sarif file in the directory of a module
+// lists the known findings of the modules in that directory.  Every finding in the SARIF files has
+// a baselineState of "unchanged" if it is in the baseline, or "new" otherwise.  The baseline of a
+// directory is regenerated by merging the SARIF files of its modules, e.g. with:
+//
+//   findings_to_sarif -o <dir>/findings_baseline.sarif out/soong/analysis_findings/<dir>/*.sarif
+//
+// `m analysis-findings-check` fails if there are new findings in the modules listed in the
+// ANALYSIS_FINDINGS_CHANGED_MODULES environment variable, or in any module if it is not set, so
+// that presubmit only fails on findings introduced by a change.
+
+func init() {
+	RegisterSingletonType("analysis_findings", analysisFindingsSingletonFactory)
+}
+
+const (
+	analysisFindingsDir          = "analysis_findings"
+	analysisFindingsBaselineFile = "findings_baseline.sarif"
+
+	envAnalysisFindingsChangedModules = "ANALYSIS_FINDINGS_CHANGED_MODULES"
+)
+
+var (
+	// The findings_to_sarif tool, also used by the clang-tidy baseline rules of cc modules.
+	_ = pctx.HostBinToolVariable("FindingsToSarifCmd", "findings_to_sarif")
+
+	findingsToSarifRule = pctx.AndroidStaticRule("findingsToSarif", blueprint.RuleParams{
+		Command:        "${FindingsToSarifCmd} -o $out @${out}.rsp",
+		CommandDeps:    []string{"${FindingsToSarifCmd}"},
+		Rspfile:        "${out}.rsp",
+		RspfileContent: "${args}",
+		Restat:         true,
+	}, "args")
+)
+
+// AnalysisFindingsInfo holds the outputs of the static analyzers that ran on a variant of a module.
+type AnalysisFindingsInfo struct {
+	// The text outputs of clang-tidy, clippy and Error Prone.
+	ClangTidy  Paths
+	Clippy     Paths
+	ErrorProne Paths
+
+	// SARIF files written directly by analyzers, e.g. Android Lint.
+	Sarif Paths
+}
+
+var AnalysisFindingsProvider = blueprint.NewProvider(AnalysisFindingsInfo{})
+
+// SetAnalysisFindingsProvider sets the AnalysisFindingsProvider of the module if any analyzer ran
+// on it.
+func SetAnalysisFindingsProvider(ctx ModuleContext, info AnalysisFindingsInfo) {
+	if len(info.ClangTidy) > 0 || len(info.Clippy) > 0 || len(info.ErrorProne) > 0 || len(info.Sarif) > 0 {
+		ctx.SetProvider(AnalysisFindingsProvider, info)
+	}
+}
+
+func analysisFindingsSingletonFactory() Singleton {
+	return &analysisFindingsSingleton{}
+}
+
+type analysisFindingsSingleton struct{}
+
+type analysisFindingsModule struct {
+	name string
+	dir  string
+	info AnalysisFindingsInfo
+}
+
+func (s *analysisFindingsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	// All the variants of a module contribute to the SARIF file of the module.
+	modules := make(map[string]*analysisFindingsModule)
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() || !ctx.ModuleHasProvider(module, AnalysisFindingsProvider) {
+			return
+		}
+		info := ctx.ModuleProvider(module, AnalysisFindingsProvider).(AnalysisFindingsInfo)
+		dir := ctx.ModuleDir(module)
+		key := filepath.Join(dir, ctx.ModuleName(module))
+		m := modules[key]
+		if m == nil {
+			m = &analysisFindingsModule{name: ctx.ModuleName(module), dir: dir}
+			modules[key] = m
+		}
+		m.info.ClangTidy = append(m.info.ClangTidy, info.ClangTidy...)
+		m.info.Clippy = append(m.info.Clippy, info.Clippy...)
+		m.info.ErrorProne = append(m.info.ErrorProne, info.ErrorProne...)
+		m.info.Sarif = append(m.info.Sarif, info.Sarif...)
+	})
+
+	var moduleSarifs Paths
+	for _, key := range SortedKeys(modules) {
+		moduleSarifs = append(moduleSarifs, buildModuleFindingsSarif(ctx, modules[key]))
+	}
+
+	merged := PathForOutput(ctx, analysisFindingsDir+".sarif")
+	ctx.Build(pctx, BuildParams{
+		Rule:        findingsToSarifRule,
+		Description: "merge analysis findings",
+		Output:      merged,
+		Inputs:      moduleSarifs,
+		Args: map[string]string{
+			"args": strings.Join(moduleSarifs.Strings(), " "),
+		},
+	})
+
+	checkArgs := []string{"-check"}
+	if changed := ctx.Config().Getenv(envAnalysisFindingsChangedModules); changed != "" {
+		checkArgs = append(checkArgs, "-changed_modules "+changed)
+	}
+	check := PathForOutput(ctx, analysisFindingsDir+"_check.sarif")
+	ctx.Build(pctx, BuildParams{
+		Rule:        findingsToSarifRule,
+		Description: "check analysis findings",
+		Output:      check,
+		Input:       merged,
+		Args: map[string]string{
+			"args": strings.Join(append(checkArgs, merged.String()), " "),
+		},
+	})
+
+	ctx.Phony("analysis-findings", merged)
+	ctx.Phony("analysis-findings-check", check)
+}
+
+// buildModuleFindingsSarif adds the rule that normalizes the findings of a module into a SARIF
+// file, and returns the SARIF file.
+func buildModuleFindingsSarif(ctx SingletonContext, m *analysisFindingsModule) Path {
+	output := PathForOutput(ctx, analysisFindingsDir, m.dir, m.name+".sarif")
+
+	args := []string{"-module " + m.name}
+	var inputs Paths
+	addInputs := func(flag string, paths Paths) {
+		for _, path := range SortedUniquePaths(paths) {
+			if flag != "" {
+				args = append(args, flag)
+			}
+			args = append(args, path.String())
+			inputs = append(inputs, path)
+		}
+	}
+	addInputs("-clang-tidy", m.info.ClangTidy)
+	addInputs("-clippy", m.info.Clippy)
+	addInputs("-errorprone", m.info.ErrorProne)
+	if baseline := ExistentPathForSource(ctx, m.dir, analysisFindingsBaselineFile); baseline.Valid() {
+		args = append(args, "-baseline "+baseline.String())
+		inputs = append(inputs, baseline.Path())
+	}
+	addInputs("", m.info.Sarif)
+
+	ctx.Build(pctx, BuildParams{
+		Rule:        findingsToSarifRule,
+		Description: "analysis findings " + m.name,
+		Output:      output,
+		Inputs:      inputs,
+		Args: map[string]string{
+			"args": strings.Join(args, " "),
+		},
+	})
+	return output
+}
@@ -90,6 +90,7 @@ type CmdArgs struct {
 	BazelApiBp2buildDir string
 	ModuleGraphFile     string
 	ModuleActionsFile   string
+	QueryGraphFile      string
 	DocFile             string
 
 	MultitreeBuild bool
@@ -126,6 +127,10 @@ const (
 	// Create a JSON representation of the module graph and exit.
 	GenerateModuleGraph
 
+	// Analyze the modules and write the module graph that soong_ui --query runs queries
+	// against, with the dependency tags, install paths and outputs of every module, and exit.
+	GenerateQueryGraph
+
 	// Generate a documentation file for module type definitions and exit.
 	GenerateDocFile
 
@@ -227,6 +232,7 @@ type config struct {
 	outDir         string // The output directory (usually out/)
 	soongOutDir    string
 	moduleListFile string // the path to the file which lists blueprint files to parse.
+	queryGraphFile string // the path to the module graph to write in GenerateQueryGraph mode.
 
 	runGoTests bool
 
@@ -481,6 +487,7 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 		multilibConflicts: make(map[ArchType]bool),
 
 		moduleListFile:            cmdArgs.ModuleListFile,
+		queryGraphFile:            cmdArgs.QueryGraphFile,
 		fs:                        pathtools.NewOsFs(absSrcDir),
 		mixedBuildDisabledModules: make(map[string]struct{}),
 		mixedBuildEnabledModules:  make(map[string]struct{}),
@@ -594,6 +601,7 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 	setBuildMode(cmdArgs.BazelQueryViewDir, GenerateQueryView)
 	setBuildMode(cmdArgs.BazelApiBp2buildDir, ApiBp2build)
 	setBuildMode(cmdArgs.ModuleGraphFile, GenerateModuleGraph)
+	setBuildMode(cmdArgs.QueryGraphFile, GenerateQueryGraph)
 	setBuildMode(cmdArgs.DocFile, GenerateDocFile)
 	setBazelMode(cmdArgs.BazelModeDev, "--bazel-mode-dev", BazelDevMode)
 	setBazelMode(cmdArgs.BazelMode, "--bazel-mode", BazelProdMode)
@@ -1575,8 +1583,14 @@ func (c *config) HWASanEnabledForPath(path string) bool {
 	return HasAnyPrefix(path, c.productVariables.HWASanIncludePaths)
 }
 
+// VendorConfig returns the Soong config variables of the namespace. The variables read through it
+// are recorded for the Soong config report.
 func (c *config) VendorConfig(name string) VendorConfig {
-	return soongconfig.Config(c.productVariables.VendorVars[name])
+	return soongConfigReadRecorder{
+		SoongConfig: soongconfig.Config(c.productVariables.VendorVars[name]),
+		namespace:   name,
+		readers:     soongConfigReadersForConfig(Config{c}),
+	}
 }
 
 func (c *config) NdkAbis() bool {
@@ -1917,6 +1931,27 @@ func (c *config) RBEWrapper() string {
 	return c.GetenvWithDefault("RBE_WRAPPER", remoteexec.DefaultWrapperPath)
 }
 
+// SboxNamespaces returns true if all sandboxed RuleBuilder commands with sandboxed inputs should
+// be run in Linux namespaces.
+func (c *config) SboxNamespaces() bool {
+	return c.IsEnvTrue("SOONG_SBOX_NAMESPACES")
+}
+
+// SboxCacheDir returns the directory of the local cache of the outputs of sandboxed RuleBuilder
+// commands, or an empty string if the cache is disabled.
+func (c *config) SboxCacheDir() string {
+	return c.Getenv("SOONG_SBOX_CACHE_DIR")
+}
+
+// SboxCacheMaxSize returns the maximum size in bytes of the local cache of the outputs of
+// sandboxed RuleBuilder commands.
+func (c *config) SboxCacheMaxSize() int64 {
+	if size, err := strconv.ParseInt(c.Getenv("SOONG_SBOX_CACHE_MAX_SIZE"), 10, 64); err == nil {
+		return size
+	}
+	return 10 * 1024 * 1024 * 1024
+}
+
 // UseHostMusl returns true if the host target has been configured to build against musl libc.
 func (c *config) UseHostMusl() bool {
 	return Bool(c.productVariables.HostMusl)
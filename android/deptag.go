@@ -43,3 +43,20 @@ func IsInstallDepNeededTag(tag blueprint.DependencyTag) bool {
 	}
 	return false
 }
+
+// Dependency tags can implement this interface to name the property of the parent that added the
+// dependency, e.g. shared_libs, so that tools that explain why a module depends on another module
+// can refer to the properties that users write in Android.bp files.
+type PropertyNameDependencyTag interface {
+	// DependencyPropertyName returns the name of the property that added the dependency.
+	DependencyPropertyName() string
+}
+
+// DependencyTagPropertyName returns the name of the property that added a dependency with the
+// given tag, or an empty string if the dependency tag doesn't implement PropertyNameDependencyTag.
+func DependencyTagPropertyName(tag blueprint.DependencyTag) string {
+	if p, ok := tag.(PropertyNameDependencyTag); ok {
+		return p.DependencyPropertyName()
+	}
+	return ""
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// This file implements a single model of the modules of the tree for IDEs and other tools.  For
+// every module it records the sources, generated sources, include and class paths, flags,
+// dependencies and output artifacts, with a section for each language that holds the details
+// specific to it.  The model is written to $OUT_DIR/soong/ide_info.json by the ide_info
+// singleton, and the language specific project files (module_bp_java_deps.json,
+// module_bp_cc_deps.json, compile_commands.json, CMakeLists.txt, rust-project.json) are generated
+// as views of it.  Modules are keyed by their name qualified with their namespace in the model,
+// and by their name alone in module_bp_java_deps.json and module_bp_cc_deps.json.
+//
+// Each language package registers an IdeInfoContributor with RegisterIdeInfoContributor that fills
+// in the model for its modules.  All the variants of a module contribute to the same entry.
+//
+// Collecting the model visits every variant of every module, so it is only collected when one of
+// its views is requested by one of the environment variables in ideInfoEnvVariables and
+// ideInfoListEnvVariables.  The Java and cc views are generated on every build, and only collect
+// the Java and cc sections of the model otherwise.
+
+func init() {
+	RegisterSingletonType("ide_info", ideInfoSingletonFactory)
+}
+
+const (
+	// IdeInfoSchemaVersion is the version of the schema of ide_info.json.  It is incremented
+	// whenever a change is made that is not backwards compatible.
+	IdeInfoSchemaVersion = 2
+
+	ideInfoJsonFileName = "ide_info.json"
+)
+
+var (
+	// The boolean environment variables that request a view of the model.
+	ideInfoEnvVariables = []string{
+		"SOONG_GEN_CMAKEFILES",
+		"SOONG_GEN_COMPDB",
+		"SOONG_GEN_RUST_PROJECT",
+	}
+	// The environment variables that request a view of the model for a list of modules or
+	// directories.
+	ideInfoListEnvVariables = []string{
+		"SOONG_GEN_COMPDB_FILTER",
+		"SOONG_IDE_WORKSPACE_MODULES",
+	}
+)
+
+// IdeInfoRequested returns true if one of the views of the model of the modules for IDEs is
+// requested, in which case the whole model is collected.
+func IdeInfoRequested(config Config) bool {
+	for _, name := range ideInfoEnvVariables {
+		if config.IsEnvTrue(name) {
+			return true
+		}
+	}
+	for _, name := range ideInfoListEnvVariables {
+		if config.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// IdeModuleInfo is the model of a module for IDEs.
+type IdeModuleInfo struct {
+	// The name that the module is recorded under in the model, see IdeInfoContext.IdeModuleName.
+	Name string `json:"name"`
+	// The name of the module without its namespace.
+	Module_name string `json:"module_name"`
+	// The directories of the Android.bp files that define the module.
+	Dirs      []string `json:"dirs,omitempty"`
+	Languages []string `json:"languages,omitempty"`
+
+	Srcs           []string `json:"srcs,omitempty"`
+	Generated_srcs []string `json:"generated_srcs,omitempty"`
+	Include_dirs   []string `json:"include_dirs,omitempty"`
+	Class_path     []string `json:"class_path,omitempty"`
+	Deps           []string `json:"dependencies,omitempty"`
+	Outputs        []string `json:"outputs,omitempty"`
+	// The unstripped executables of the host variants of tests, which can be run in a debugger.
+	Host_test_binaries []string `json:"host_test_binaries,omitempty"`
+
+	Java   *IdeInfo       `json:"java,omitempty"`
+	Kotlin *IdeKotlinInfo `json:"kotlin,omitempty"`
+	Cc     []*IdeCcInfo   `json:"cc,omitempty"`
+	Rust   *IdeRustInfo   `json:"rust,omitempty"`
+	Python *IdePythonInfo `json:"python,omitempty"`
+}
+
+// IdeKotlinInfo holds the Kotlin specific parts of the model of a module.
+type IdeKotlinInfo struct {
+	Common_srcs []string `json:"common_srcs,omitempty"`
+	Flags       []string `json:"flags,omitempty"`
+}
+
+// IdeCcInfo holds the C/C++ specific parts of the model of a module for one of the architectures
+// and OSes that it is built for.  It lists the sources of all the variants for that architecture
+// and OS, and the flags of the first of them.  The flags have the ninja variables in them
+// expanded.  The IdeCcInfo of the primary device architecture comes first if there is one.
+type IdeCcInfo struct {
+	Arch         string   `json:"arch"`
+	Os           string   `json:"os"`
+	C_compiler   string   `json:"c_compiler"`
+	Cpp_compiler string   `json:"cpp_compiler"`
+	Srcs         []string `json:"srcs,omitempty"`
+
+	Global_common_flags  []string `json:"global_common_flags,omitempty"`
+	Local_common_flags   []string `json:"local_common_flags,omitempty"`
+	Global_c_flags       []string `json:"global_c_flags,omitempty"`
+	Local_c_flags        []string `json:"local_c_flags,omitempty"`
+	Global_conly_flags   []string `json:"global_conly_flags,omitempty"`
+	Local_conly_flags    []string `json:"local_conly_flags,omitempty"`
+	Global_cpp_flags     []string `json:"global_cpp_flags,omitempty"`
+	Local_cpp_flags      []string `json:"local_cpp_flags,omitempty"`
+	System_include_flags []string `json:"system_include_flags,omitempty"`
+}
+
+// IdeRustInfo holds the Rust specific parts of the model of a module, from the variant of the
+// module that compiles its crate for the primary device target, or for the build OS for host
+// modules.  Modules that have no such variant use the first variant that compiles the crate.
+type IdeRustInfo struct {
+	Target      string   `json:"target"`
+	Crate_name  string   `json:"crate_name,omitempty"`
+	Crate_root  string   `json:"crate_root,omitempty"`
+	Edition     string   `json:"edition,omitempty"`
+	Features    []string `json:"features,omitempty"`
+	Proc_macro  bool     `json:"proc_macro,omitempty"`
+	Out_dir     string   `json:"out_dir,omitempty"`
+	Rustc       string   `json:"rustc,omitempty"`
+	Rustc_flags []string `json:"rustc_flags,omitempty"`
+	Output      string   `json:"output,omitempty"`
+}
+
+// IdePythonInfo holds the Python specific parts of the model of a module.
+type IdePythonInfo struct {
+	Pkg_path string   `json:"pkg_path,omitempty"`
+	Data     []string `json:"data,omitempty"`
+}
+
+// IdeModuleInfos is the model of all the modules, keyed by IdeModuleInfo.Name.
+type IdeModuleInfos map[string]*IdeModuleInfo
+
+// ByModuleName returns the model of the modules keyed by their names without their namespace, the
+// keys of the module_bp_java_deps.json and module_bp_cc_deps.json views that existing IDE tools
+// look modules up by.  If modules in different namespaces have the same name, the module that is
+// not in a namespace is kept, and otherwise the first in the order of the qualified names.
+func (infos IdeModuleInfos) ByModuleName() map[string]*IdeModuleInfo {
+	ret := make(map[string]*IdeModuleInfo, len(infos))
+	for _, name := range SortedKeys(infos) {
+		info := infos[name]
+		if _, exists := ret[info.Module_name]; !exists || name == info.Module_name {
+			ret[info.Module_name] = info
+		}
+	}
+	return ret
+}
+
+// IdeInfoContext is the context of an IdeInfoContributor.
+type IdeInfoContext interface {
+	SingletonContext
+
+	// IdeModuleName returns the name that a module is recorded under in the model.  This is the
+	// name of the module without any prebuilt_ prefix, so that a prebuilt and its source module
+	// share an entry, qualified as //<namespace>:<name> for modules in a soong_namespace.
+	IdeModuleName(module Module) string
+}
+
+// IdeInfoContributor fills in the model of a module for the modules of a language.  It is called
+// for every variant of every enabled module, and returns true if it added anything to info.
+type IdeInfoContributor func(ctx IdeInfoContext, module Module, info *IdeModuleInfo) bool
+
+var ideInfoContributors []IdeInfoContributor
+
+// RegisterIdeInfoContributor registers a function that fills in the model of modules for IDEs.
+// It must be called from an init function.
+func RegisterIdeInfoContributor(contributor IdeInfoContributor) {
+	ideInfoContributors = append(ideInfoContributors, contributor)
+}
+
+var ideModuleInfosKey = NewOnceKey("ideModuleInfos")
+
+// CollectIdeModuleInfos returns the model of all the modules for IDEs.  The model is only
+// collected once, by the first singleton that asks for it.
+func CollectIdeModuleInfos(ctx SingletonContext) IdeModuleInfos {
+	return ctx.Config().Once(ideModuleInfosKey, func() interface{} {
+		return collectIdeModuleInfos(ctx, ideInfoContributors)
+	}).(IdeModuleInfos)
+}
+
+// CollectIdeModuleInfosWith returns the parts of the model of the modules that are filled in by
+// the given contributors, or the whole model if IdeInfoRequested.  It is used by the views that
+// are generated on every build.
+func CollectIdeModuleInfosWith(ctx SingletonContext, contributors ...IdeInfoContributor) IdeModuleInfos {
+	if IdeInfoRequested(ctx.Config()) {
+		return CollectIdeModuleInfos(ctx)
+	}
+	return collectIdeModuleInfos(ctx, contributors)
+}
+
+type ideInfoContext struct {
+	SingletonContext
+
+	// The directories of the soong_namespace modules.
+	namespaces map[string]bool
+}
+
+// ideModuleBaseName returns the name of a module in the model without its namespace.
+func ideModuleBaseName(module Module) string {
+	if m, ok := module.(IDECustomizedModuleName); ok {
+		return m.IDECustomizedModuleName()
+	}
+	if m, ok := module.(IDEInfo); ok {
+		return m.BaseModuleName()
+	}
+	return module.base().BaseModuleName()
+}
+
+func (ctx *ideInfoContext) IdeModuleName(module Module) string {
+	name := ideModuleBaseName(module)
+
+	// Modules are in the namespace of the closest directory above them that has one.
+	for dir := ctx.ModuleDir(module); dir != "." && dir != "/" && dir != ""; dir = filepath.Dir(dir) {
+		if ctx.namespaces[dir] {
+			return "//" + dir + ":" + name
+		}
+	}
+	return name
+}
+
+func collectIdeModuleInfos(sctx SingletonContext, contributors []IdeInfoContributor) IdeModuleInfos {
+	ctx := &ideInfoContext{SingletonContext: sctx, namespaces: make(map[string]bool)}
+	ctx.VisitAllModules(func(module Module) {
+		if n, ok := module.(*NamespaceModule); ok {
+			ctx.namespaces[n.namespace.Path] = true
+		}
+	})
+
+	infos := make(IdeModuleInfos)
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+
+		// Prevent including both prebuilts and matching source modules when one replaces the other.
+		if !IsModulePreferred(module) {
+			return
+		}
+
+		name := ctx.IdeModuleName(module)
+		info := infos[name]
+		if info == nil {
+			info = &IdeModuleInfo{Name: name, Module_name: ideModuleBaseName(module)}
+		}
+
+		contributed := false
+		for _, contributor := range contributors {
+			if contributor(ctx, module, info) {
+				contributed = true
+			}
+		}
+		if !contributed {
+			return
+		}
+
+		info.Dirs = append(info.Dirs, ctx.ModuleDir(module))
+		infos[name] = info
+	})
+
+	for _, info := range infos {
+		info.Dirs = FirstUniqueStrings(info.Dirs)
+		info.Languages = SortedUniqueStrings(info.Languages)
+		info.Srcs = FirstUniqueStrings(info.Srcs)
+		info.Generated_srcs = FirstUniqueStrings(info.Generated_srcs)
+		info.Include_dirs = FirstUniqueStrings(info.Include_dirs)
+		info.Class_path = FirstUniqueStrings(info.Class_path)
+		info.Deps = FirstUniqueStrings(info.Deps)
+		info.Outputs = FirstUniqueStrings(info.Outputs)
+		info.Host_test_binaries = FirstUniqueStrings(info.Host_test_binaries)
+	}
+	return infos
+}
+
+// ideInfoJson is the format of ide_info.json.
+type ideInfoJson struct {
+	Version int            `json:"version"`
+	Modules IdeModuleInfos `json:"modules"`
+}
+
+func ideInfoSingletonFactory() Singleton {
+	return &ideInfoSingleton{}
+}
+
+type ideInfoSingleton struct{}
+
+func (s *ideInfoSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !IdeInfoRequested(ctx.Config()) {
+		return
+	}
+	infos := CollectIdeModuleInfos(ctx)
+
+	path := PathForOutput(ctx, ideInfoJsonFileName)
+	if err := writeIdeInfoJson(infos, path); err != nil {
+		ctx.Errorf("%s", err)
+		return
+	}
+
+	// This is necessary to satisfy the dangling rules check as this file is written by Soong rather than a rule.
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: path,
+	})
+}
+
+func writeIdeInfoJson(infos IdeModuleInfos, path WritablePath) error {
+	buf, err := json.MarshalIndent(ideInfoJson{Version: IdeInfoSchemaVersion, Modules: infos}, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshal of IDE info failed: %s", err)
+	}
+	if err := WriteFileToOutputDir(path, buf, 0666); err != nil {
+		return fmt.Errorf("Writing IDE info to %s failed: %s", path.String(), err)
+	}
+	return nil
+}
@@ -15,6 +15,7 @@
 package android
 
 import (
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -114,6 +115,7 @@ func RegisterLicensesPackageMapper(ctx RegisterMutatorsContext) {
 // This goes after defaults expansion so that it can pick up default licenses and before visibility enforcement.
 func RegisterLicensesPropertyGatherer(ctx RegisterMutatorsContext) {
 	ctx.BottomUp("licensesPropertyGatherer", licensesPropertyGatherer).Parallel()
+	registerPolicyViolationErrorsMutator(ctx, "licensesPropertyGatherer")
 }
 
 // Registers the function that verifies the licenses and license_kinds dependency types for each module.
@@ -259,6 +261,21 @@ func mergeNamedPathProps(prop *NamedPaths, values ...NamedPath) {
 	*prop = SortedUniqueNamedPaths(*prop)
 }
 
+// requiredLicensesSeverity returns how module types without an applicable licenses property are
+// reported, and false if they are not. ANDROID_REQUIRE_LICENSES may be set to false to disable
+// the check, or to a severity accepted by ParseSeverity, e.g. "warning", to stage its rollout.
+// Any other value reports them as errors.
+func requiredLicensesSeverity(config Config) (Severity, bool) {
+	value := config.Getenv("ANDROID_REQUIRE_LICENSES")
+	if config.IsEnvFalse("ANDROID_REQUIRE_LICENSES") {
+		return SeverityError, false
+	}
+	if severity, err := ParseSeverity(value); err == nil {
+		return severity, true
+	}
+	return SeverityError, true
+}
+
 // Get the licenses property falling back to the package default.
 func getLicenses(ctx BaseModuleContext, module Module) []string {
 	if exemptFromRequiredApplicableLicensesProperty(module) {
@@ -267,8 +284,13 @@ func getLicenses(ctx BaseModuleContext, module Module) []string {
 
 	primaryProperty := module.base().primaryLicensesProperty
 	if primaryProperty == nil {
-		if !ctx.Config().IsEnvFalse("ANDROID_REQUIRE_LICENSES") {
-			ctx.ModuleErrorf("module type %q must have an applicable licenses property", ctx.OtherModuleType(module))
+		if severity, required := requiredLicensesSeverity(ctx.Config()); required {
+			reportPolicyViolation(ctx, severity, PolicyViolation{
+				Kind:    PolicyViolationLicenses,
+				RuleId:  "licenses-required",
+				Reason:  "modules must have an applicable licenses property",
+				Message: fmt.Sprintf("module type %q must have an applicable licenses property", ctx.OtherModuleType(module)),
+			})
 		}
 		return nil
 	}
@@ -306,6 +328,7 @@ func exemptFromRequiredApplicableLicensesProperty(module Module) bool {
 	case "*android.genNoticeModule": // contains license texts as data
 	case "*android.NamespaceModule": // just partitions things, doesn't add anything
 	case "*android.soongConfigModuleTypeModule": // creates aliases for modules with licenses
+	case "*android.neverallowRuleModule": // only declares a rule for other modules
 	case "*android.soongConfigModuleTypeImport": // creates aliases for modules with licenses
 	case "*android.soongConfigStringVariableDummyModule": // used for creating aliases
 	case "*android.soongConfigBoolVariableDummyModule": // used for creating aliases
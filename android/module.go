@@ -770,6 +770,12 @@ type commonProperties struct {
 	// more details.
 	Visibility []string
 
+	// How dependencies on this module that are not allowed by its visibility are reported:
+	// "error" (the default), "warning", or "error_after:<yyyy-mm-dd>" to report them as warnings
+	// until the given date and as errors from then on. This allows the visibility of a module to
+	// be restricted while giving the modules that depend on it time to migrate.
+	Visibility_severity *string
+
 	// Describes the licenses applicable to this module. Must reference license modules.
 	Licenses []string
 
@@ -1516,6 +1522,9 @@ type ModuleBase struct {
 
 	// The path to the generated license metadata file for the module.
 	licenseMetadataFile WritablePath
+
+	// The direct dependencies of the module and their tags, only recorded for the query graph.
+	queryGraphDeps []queryGraphDep
 }
 
 // A struct containing all relevant information about a Bazel target converted via bp2build.
@@ -2357,6 +2366,10 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 	// ignored.
 	ctx.baseModuleContext.strictVisitDeps = !m.IsCommonOSVariant()
 
+	if ctx.config.BuildMode == GenerateQueryGraph {
+		m.queryGraphDeps = collectQueryGraphDeps(ctx)
+	}
+
 	if ctx.config.captureBuild {
 		ctx.ruleParams = make(map[blueprint.Rule]blueprint.RuleParams)
 	}
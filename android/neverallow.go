@@ -15,6 +15,8 @@
 package android
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"reflect"
@@ -44,7 +46,9 @@ import (
 // - it has none of the "Without" properties matched (same rules as above)
 
 func registerNeverallowMutator(ctx RegisterMutatorsContext) {
+	ctx.BottomUp("neverallow_rules", neverallowRuleGathererMutator).Parallel()
 	ctx.BottomUp("neverallow", neverallowMutator).Parallel()
+	registerPolicyViolationErrorsMutator(ctx, "neverallow")
 }
 
 var neverallows = []Rule{}
@@ -279,7 +283,7 @@ func neverallowMutator(ctx BottomUpMutatorContext) {
 
 	osClass := ctx.Module().Target().Os.Class
 
-	for _, r := range neverallowRules(ctx.Config()) {
+	for _, r := range allNeverallowRules(ctx.Config()) {
 		n := r.(*rule)
 		if !n.appliesToPath(dir) {
 			continue
@@ -301,7 +305,14 @@ func neverallowMutator(ctx BottomUpMutatorContext) {
 			continue
 		}
 
-		ctx.ModuleErrorf("violates " + n.String())
+		reportPolicyViolation(ctx, n.severity, PolicyViolation{
+			Kind:       PolicyViolationNeverallow,
+			RuleId:     n.id(),
+			Rule:       n.String(),
+			Reason:     n.reason,
+			Properties: n.props.propertyNames(),
+			Message:    "violates " + n.String(),
+		})
 	}
 }
 
@@ -394,6 +405,19 @@ func (r *ruleProperty) String() string {
 
 type ruleProperties []ruleProperty
 
+// propertyNames returns the names of the properties, as written in Android.bp files.
+func (r ruleProperties) propertyNames() []string {
+	var names []string
+	for _, p := range r {
+		var parts []string
+		for _, field := range p.fields {
+			parts = append(parts, proptools.PropertyNameForField(field))
+		}
+		names = append(names, strings.Join(parts, "."))
+	}
+	return names
+}
+
 func (r ruleProperties) String() string {
 	var s []string
 	for _, r := range r {
@@ -425,6 +449,8 @@ type Rule interface {
 	WithoutMatcher(properties string, matcher ValueMatcher) Rule
 
 	Because(reason string) Rule
+
+	WithSeverity(severity Severity) Rule
 }
 
 type rule struct {
@@ -445,6 +471,15 @@ type rule struct {
 	unlessProps ruleProperties
 
 	onlyBootclasspathJar bool
+
+	// The label of the neverallow_rule module that declared the rule, if any.
+	name string
+
+	// The directories the rule is restricted to regardless of paths, if any.
+	scopePaths []string
+
+	// How violations of the rule are reported, SeverityError unless set by WithSeverity.
+	severity Severity
 }
 
 // Create a new NeverAllow rule.
@@ -531,11 +566,22 @@ func (r *rule) Because(reason string) Rule {
 	return r
 }
 
+// WithSeverity specifies how violations of this rule are reported. A new rule can be added with
+// SeverityWarning or SeverityErrorAfter to give existing violations time to be fixed before it
+// fails the build.
+func (r *rule) WithSeverity(severity Severity) Rule {
+	r.severity = severity
+	return r
+}
+
 func (r *rule) String() string {
 	s := []string{"neverallow requirements. Not allowed:"}
 	if len(r.paths) > 0 {
 		s = append(s, fmt.Sprintf("in dirs: %q", r.paths))
 	}
+	if len(r.scopePaths) > 0 {
+		s = append(s, fmt.Sprintf("within dirs: %q", r.scopePaths))
+	}
 	if len(r.moduleTypes) > 0 {
 		s = append(s, fmt.Sprintf("module types: %q", r.moduleTypes))
 	}
@@ -566,10 +612,20 @@ func (r *rule) String() string {
 	return strings.Join(s, "\n\t")
 }
 
+// id returns an identifier for the rule that is stable as long as the rule does not change.
+func (r *rule) id() string {
+	if r.name != "" {
+		return r.name
+	}
+	hash := sha256.Sum256([]byte(r.String()))
+	return "neverallow-" + hex.EncodeToString(hash[:6])
+}
+
 func (r *rule) appliesToPath(dir string) bool {
 	includePath := len(r.paths) == 0 || HasAnyPrefix(dir, r.paths)
 	excludePath := HasAnyPrefix(dir, r.unlessPaths)
-	return includePath && !excludePath
+	inScope := len(r.scopePaths) == 0 || HasAnyPrefix(dir, r.scopePaths)
+	return includePath && !excludePath && inScope
 }
 
 func (r *rule) appliesToDirectDeps(ctx BottomUpMutatorContext) bool {
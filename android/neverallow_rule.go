@@ -0,0 +1,300 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The neverallow_rule module type allows neverallow rules to be declared in Android.bp files
+// instead of in Go, e.g.:
+//
+//	neverallow_rule {
+//	    name: "no_vendor_available_libs",
+//	    in: ["vendor/acme/libs"],
+//	    module_types: ["cc_library"],
+//	    with: ["vendor_available=true"],
+//	    because: "acme libraries must not be available to the vendor partition",
+//	    severity: "error_after:2024-06-01",
+//	}
+//
+// The rules are checked by the neverallow mutator together with the rules registered with
+// AddNeverAllowRules. A rule only applies to modules in the same soong_namespace as the
+// neverallow_rule module, or, if the neverallow_rule module is not in a soong_namespace, to
+// modules in the directory of the neverallow_rule module and its subdirectories. This allows
+// a vendor tree to constrain its own directories without being able to affect the rest of the
+// tree.
+
+func init() {
+	RegisterNeverallowRuleBuildComponents(InitRegistrationContext)
+}
+
+// Register the neverallow_rule module type.
+func RegisterNeverallowRuleBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterModuleType("neverallow_rule", NeverallowRuleFactory)
+}
+
+var PrepareForTestWithNeverallowRuleModule = FixtureRegisterWithContext(RegisterNeverallowRuleBuildComponents)
+
+type neverallowRuleProperties struct {
+	// Directories that the rule applies to. If empty the rule applies to all the directories the
+	// module is allowed to constrain.
+	In []string
+
+	// Directories that the rule does not apply to.
+	Not_in []string
+
+	// Module types that the rule applies to. If empty the rule applies to all module types.
+	Module_types []string
+
+	// Module types that the rule does not apply to.
+	Not_module_types []string
+
+	// Property matchers that must all match for the rule to apply. Each matcher is the name of a
+	// property, with nested properties separated by '.', followed by one of:
+	//   =<value>                  the property is equal to value, "=*" matches any value
+	//   .starts-with(<prefix>)    the property starts with prefix
+	//   .regexp(<regexp>)         the property matches the regular expression
+	//   .not-in-list(<a>,<b>,...) the property is not one of the listed values
+	//   .is-set                   the property is set to a non-empty value
+	With []string
+
+	// Property matchers, using the same syntax as with, that prevent the rule from applying if any
+	// of them matches.
+	Without []string
+
+	// Modules that must not be direct dependencies of the modules the rule applies to.
+	In_direct_deps []string
+
+	// OS classes that the rule applies to, one or more of "device", "host" and "generic". If
+	// empty the rule applies to all OS classes.
+	Os_class []string
+
+	// The reason for the rule, reported when it is violated.
+	Because *string
+
+	// How violations of the rule are reported: "error" (the default), "warning", or
+	// "error_after:<yyyy-mm-dd>" to report them as warnings until the given date and as errors
+	// from then on.
+	Severity *string
+}
+
+type neverallowRuleModule struct {
+	ModuleBase
+
+	properties neverallowRuleProperties
+}
+
+func (m *neverallowRuleModule) DepsMutator(ctx BottomUpMutatorContext) {
+	// Nothing to do.
+}
+
+func (m *neverallowRuleModule) GenerateAndroidBuildActions(ModuleContext) {
+	// Nothing to do.
+}
+
+// neverallow_rule declares a neverallow rule that is enforced for the modules in the same
+// soong_namespace, or in the same directory and subdirectories if it is not in a namespace.
+func NeverallowRuleFactory() Module {
+	module := &neverallowRuleModule{}
+
+	base := module.base()
+	module.AddProperties(&base.nameProperties, &module.properties)
+
+	initAndroidModuleBase(module)
+
+	return module
+}
+
+var neverallowMatcherRegexp = regexp.MustCompile(
+	`^([a-z0-9_.]+?)(?:=(.*)|\.starts-with\((.*)\)|\.regexp\((.*)\)|\.not-in-list\((.*)\)|(\.is-set))$`)
+
+// parseNeverallowMatcher parses a property matcher of a neverallow_rule module, returning the
+// property name and the matcher.
+func parseNeverallowMatcher(expr string) (string, ValueMatcher, error) {
+	match := neverallowMatcherRegexp.FindStringSubmatchIndex(expr)
+	if match == nil {
+		return "", nil, fmt.Errorf("invalid property matcher %q", expr)
+	}
+
+	group := func(i int) (string, bool) {
+		if match[2*i] < 0 {
+			return "", false
+		}
+		return expr[match[2*i]:match[2*i+1]], true
+	}
+
+	property, _ := group(1)
+	if value, ok := group(2); ok {
+		return property, selectMatcher(value), nil
+	}
+	if prefix, ok := group(3); ok {
+		return property, StartsWith(prefix), nil
+	}
+	if re, ok := group(4); ok {
+		compiled, err := regexp.Compile(re)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid regexp in property matcher %q: %s", expr, err)
+		}
+		return property, &regexMatcher{compiled}, nil
+	}
+	if list, ok := group(5); ok {
+		return property, NotInList(strings.Split(list, ",")), nil
+	}
+	return property, isSetMatcherInstance, nil
+}
+
+func parseOsClass(class string) (OsClass, error) {
+	for _, c := range []OsClass{Generic, Device, Host} {
+		if c.String() == class {
+			return c, nil
+		}
+	}
+	return Generic, fmt.Errorf("unknown os class %q, expected one of \"device\", \"host\" or \"generic\"", class)
+}
+
+// neverallowRuleScope returns the directory that a neverallow_rule module may constrain.
+func neverallowRuleScope(ctx BaseModuleContext) string {
+	if ns := ctx.Namespace(); ns != nil && ns.Path != "." {
+		return ns.Path
+	}
+	return ctx.ModuleDir()
+}
+
+// rule converts the properties of the module into a Rule, reporting any invalid properties as
+// errors.
+func (m *neverallowRuleModule) rule(ctx BaseModuleContext) Rule {
+	scope := neverallowRuleScope(ctx)
+
+	r := NeverAllow().(*rule)
+	r.name = "//" + ctx.ModuleDir() + ":" + ctx.ModuleName()
+	if scope != "." {
+		r.scopePaths = cleanPaths([]string{scope})
+	}
+
+	for _, in := range m.properties.In {
+		if len(r.scopePaths) > 0 && !HasAnyPrefix(filepath.Clean(in)+"/", r.scopePaths) {
+			ctx.PropertyErrorf("in", "%q is outside of %q, the only directory this rule may constrain", in, scope)
+		}
+	}
+	r.In(m.properties.In...)
+	r.NotIn(m.properties.Not_in...)
+	r.ModuleType(m.properties.Module_types...)
+	r.NotModuleType(m.properties.Not_module_types...)
+	r.InDirectDeps(m.properties.In_direct_deps...)
+
+	for _, expr := range m.properties.With {
+		property, matcher, err := parseNeverallowMatcher(expr)
+		if err != nil {
+			ctx.PropertyErrorf("with", "%s", err)
+			continue
+		}
+		r.WithMatcher(property, matcher)
+	}
+
+	for _, expr := range m.properties.Without {
+		property, matcher, err := parseNeverallowMatcher(expr)
+		if err != nil {
+			ctx.PropertyErrorf("without", "%s", err)
+			continue
+		}
+		r.WithoutMatcher(property, matcher)
+	}
+
+	for _, class := range m.properties.Os_class {
+		osClass, err := parseOsClass(class)
+		if err != nil {
+			ctx.PropertyErrorf("os_class", "%s", err)
+			continue
+		}
+		r.WithOsClass(osClass)
+	}
+
+	if m.properties.Because != nil {
+		r.Because(*m.properties.Because)
+	}
+
+	if m.properties.Severity != nil {
+		severity, err := ParseSeverity(*m.properties.Severity)
+		if err != nil {
+			ctx.PropertyErrorf("severity", "%s", err)
+		}
+		r.WithSeverity(severity)
+	}
+
+	return r
+}
+
+type neverallowModuleRules struct {
+	sync.Mutex
+	rules map[string]Rule
+}
+
+var neverallowModuleRulesKey = NewOnceKey("neverallowModuleRules")
+
+func neverallowModuleRulesForConfig(config Config) *neverallowModuleRules {
+	return config.Once(neverallowModuleRulesKey, func() interface{} {
+		return &neverallowModuleRules{rules: make(map[string]Rule)}
+	}).(*neverallowModuleRules)
+}
+
+// neverallowRuleGathererMutator collects the rules declared by neverallow_rule modules so that
+// they can be checked by the neverallow mutator.
+func neverallowRuleGathererMutator(ctx BottomUpMutatorContext) {
+	m, ok := ctx.Module().(*neverallowRuleModule)
+	if !ok {
+		return
+	}
+
+	r := m.rule(ctx)
+	if ctx.Failed() {
+		return
+	}
+
+	rules := neverallowModuleRulesForConfig(ctx.Config())
+	rules.Lock()
+	defer rules.Unlock()
+	rules.rules[r.(*rule).name] = r
+}
+
+var allNeverallowRulesKey = NewOnceKey("allNeverallowRules")
+
+// allNeverallowRules returns the rules registered with AddNeverAllowRules, followed by the rules
+// declared by neverallow_rule modules sorted by module name. It must not be called before
+// neverallowRuleGathererMutator has run on all modules.
+func allNeverallowRules(config Config) []Rule {
+	return config.Once(allNeverallowRulesKey, func() interface{} {
+		moduleRules := neverallowModuleRulesForConfig(config)
+		moduleRules.Lock()
+		defer moduleRules.Unlock()
+
+		names := make([]string, 0, len(moduleRules.rules))
+		for name := range moduleRules.rules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		rules := append([]Rule(nil), neverallowRules(config)...)
+		for _, name := range names {
+			rules = append(rules, moduleRules.rules[name])
+		}
+		return rules
+	}).([]Rule)
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+var neverallowRuleTests = []struct {
+	name           string
+	fs             MockFS
+	expectedErrors []string
+}{
+	{
+		name: "rule applies in its own directory",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "no_vendor_available",
+					module_types: ["cc_library"],
+					with: ["vendor_available=true"],
+					because: "acme libraries are not vendor available",
+				}`),
+			"vendor/acme/libs/Android.bp": []byte(`
+				cc_library {
+					name: "libacme",
+					vendor_available: true,
+				}`),
+		},
+		expectedErrors: []string{
+			`module "libacme": violates neverallow requirements`,
+			`acme libraries are not vendor available`,
+		},
+	},
+	{
+		name: "rule does not apply outside its directory",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "no_vendor_available",
+					with: ["vendor_available=true"],
+				}`),
+			"vendor/other/Android.bp": []byte(`
+				cc_library {
+					name: "libother",
+					vendor_available: true,
+				}`),
+		},
+	},
+	{
+		name: "property matchers",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "no_include_dirs",
+					with: ["include_dirs.starts-with(art/)"],
+					without: ["sdk_version.is-set"],
+				}`),
+			"vendor/acme/libs/Android.bp": []byte(`
+				cc_library {
+					name: "libart_includes",
+					include_dirs: ["art/libdexfile"],
+				}
+
+				cc_library {
+					name: "libart_includes_sdk",
+					include_dirs: ["art/libdexfile"],
+					sdk_version: "current",
+				}`),
+		},
+		expectedErrors: []string{
+			`module "libart_includes": violates neverallow requirements`,
+		},
+	},
+	{
+		name: "in outside of scope",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "reach_out",
+					in: ["frameworks/base"],
+				}`),
+		},
+		expectedErrors: []string{
+			`in: "frameworks/base" is outside of "vendor/acme"`,
+		},
+	},
+	{
+		name: "invalid matcher",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "bad_matcher",
+					with: ["vendor_available.equals(true)", "sdk_version.regexp(()"],
+					os_class: ["phone"],
+				}`),
+		},
+		expectedErrors: []string{
+			`with: invalid property matcher "vendor_available.equals\(true\)"`,
+			`with: invalid regexp in property matcher`,
+			`os_class: unknown os class "phone"`,
+		},
+	},
+	{
+		name: "warning severity",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "no_vendor_available",
+					with: ["vendor_available=true"],
+					severity: "error_after:2999-01-01",
+				}`),
+			"vendor/acme/libs/Android.bp": []byte(`
+				cc_library {
+					name: "libacme",
+					vendor_available: true,
+				}`),
+		},
+	},
+	{
+		name: "invalid severity",
+		fs: MockFS{
+			"vendor/acme/Android.bp": []byte(`
+				neverallow_rule {
+					name: "bad_severity",
+					severity: "error_after:June",
+				}`),
+		},
+		expectedErrors: []string{
+			`severity: invalid date "June" in severity "error_after:June", expected yyyy-mm-dd`,
+		},
+	},
+}
+
+func TestNeverallowRule(t *testing.T) {
+	for _, test := range neverallowRuleTests {
+		t.Run(test.name, func(t *testing.T) {
+			GroupFixturePreparers(
+				prepareForNeverAllowTest,
+				PrepareForTestWithNeverallowRuleModule,
+				PrepareForTestWithNeverallowRules([]Rule{}),
+				test.fs.AddToFixture(),
+			).
+				ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern(test.expectedErrors)).
+				RunTest(t)
+		})
+	}
+}
+
+func TestNeverallowRuleWithoutLicenses(t *testing.T) {
+	// neverallow_rule modules only declare rules, so they don't need an applicable licenses
+	// property even when licenses are required.
+	GroupFixturePreparers(
+		PrepareForTestWithLicenses,
+		PrepareForTestWithNeverallowRuleModule,
+		PrepareForTestWithNeverallowRules([]Rule{}),
+		FixtureAddTextFile("vendor/acme/Android.bp", `
+			neverallow_rule {
+				name: "no_vendor_available",
+				with: ["vendor_available=true"],
+			}`),
+	).RunTest(t)
+}
+
+func TestParseNeverallowMatcher(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		property string
+		matcher  string
+	}{
+		{"vendor_available=true", "vendor_available", "=true"},
+		{"vndk.enabled=*", "vndk.enabled", "=*"},
+		{"sdk_version.starts-with(core_)", "sdk_version", ".starts-with(core_)"},
+		{"name.regexp(^lib.*$)", "name", ".regexp(^lib.*$)"},
+		{"sdk_version.not-in-list(current,system_current)", "sdk_version", ".not-in-list(current,system_current)"},
+		{"stubs.symbol_file.is-set", "stubs.symbol_file", ".is-set"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			property, matcher, err := parseNeverallowMatcher(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertStringEquals(t, "property", tc.property, property)
+			AssertStringEquals(t, "matcher", tc.matcher, matcher.String())
+		})
+	}
+}
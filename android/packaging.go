@@ -208,6 +208,13 @@ func (PackagingItemAlwaysDepTag) IsPackagingItem() bool {
 	return true
 }
 
+// DependencyPropertyName returns "deps" as the dependencies are added by AddDeps.
+func (PackagingItemAlwaysDepTag) DependencyPropertyName() string {
+	return "deps"
+}
+
+var _ PropertyNameDependencyTag = PackagingItemAlwaysDepTag{}
+
 // See PackageModule.AddDeps
 func (p *PackagingBase) AddDeps(ctx BottomUpMutatorContext, depTag blueprint.DependencyTag) {
 	for _, t := range p.getSupportedTargets(ctx) {
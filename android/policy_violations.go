@@ -0,0 +1,431 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"android/soong/sarif"
+
+	"github.com/google/blueprint/parser"
+)
+
+// Policy violations (neverallow, visibility and licenses) are reported as module errors, and are
+// also collected into machine readable reports so that tools, e.g. presubmit bots, can aggregate
+// them and annotate the Android.bp files that caused them.
+//
+// A policy may instead be given a warning Severity, or one that turns from a warning into an error
+// on a given date, so that a new policy can be announced and rolled out without breaking the
+// trees that still violate it. Warnings do not fail the build, they are summarized in
+// policyViolationWarningsFile next to the reports, which soong_ui prints at the end of soong on
+// every build, including the builds that do not rerun soong_build. The date of an "error_after"
+// severity is only checked when soong_build runs, so violations become errors in the first build
+// that regenerates the ninja file after the date.
+//
+// Violations are collected in memory and the reports are written once per run. Errors reported by
+// a mutator stop soong_build before any singletons run, so the violations that are errors are
+// reported by a policy violation errors mutator registered right after each mutator that checks a
+// policy, which writes the reports before reporting the errors. Otherwise the
+// policyViolationsSingleton writes them at the end of the run.
+
+const (
+	policyViolationsJsonFile  = "policy_violations.json"
+	policyViolationsSarifFile = "policy_violations.sarif"
+
+	// policyViolationWarningsFile must match the file read by soong_ui in ui/build/soong.go.
+	policyViolationWarningsFile = "policy_violation_warnings.txt"
+)
+
+const (
+	PolicyViolationNeverallow = "neverallow"
+	PolicyViolationVisibility = "visibility"
+	PolicyViolationLicenses   = "licenses"
+)
+
+// Severity describes how violations of a build policy are reported.
+type Severity struct {
+	warning bool
+
+	// If set, violations are reported as warnings before this time and as errors from it on.
+	errorAfter time.Time
+}
+
+var (
+	// SeverityError reports violations as errors, this is the default.
+	SeverityError = Severity{}
+
+	// SeverityWarning reports violations as warnings.
+	SeverityWarning = Severity{warning: true}
+)
+
+const severityErrorAfterPrefix = "error_after:"
+const severityDateLayout = "2006-01-02"
+
+// SeverityErrorAfter reports violations as warnings until the given date (UTC), and as errors
+// from that date on.
+func SeverityErrorAfter(year int, month time.Month, day int) Severity {
+	return Severity{errorAfter: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseSeverity parses a severity from a property or environment variable, one of "error",
+// "warning" or "error_after:<yyyy-mm-dd>".
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "error":
+		return SeverityError, nil
+	case "warning":
+		return SeverityWarning, nil
+	}
+	if strings.HasPrefix(s, severityErrorAfterPrefix) {
+		date := strings.TrimPrefix(s, severityErrorAfterPrefix)
+		t, err := time.Parse(severityDateLayout, date)
+		if err != nil {
+			return SeverityError, fmt.Errorf("invalid date %q in severity %q, expected yyyy-mm-dd", date, s)
+		}
+		return Severity{errorAfter: t}, nil
+	}
+	return SeverityError, fmt.Errorf("unknown severity %q, expected \"error\", \"warning\" or \"%s<yyyy-mm-dd>\"",
+		s, severityErrorAfterPrefix)
+}
+
+func (s Severity) String() string {
+	switch {
+	case s.warning:
+		return "warning"
+	case !s.errorAfter.IsZero():
+		return severityErrorAfterPrefix + s.errorAfter.Format(severityDateLayout)
+	default:
+		return "error"
+	}
+}
+
+// isError returns true if violations are reported as errors at the given time.
+func (s Severity) isError(now time.Time) bool {
+	if s.warning {
+		return false
+	}
+	return s.errorAfter.IsZero() || !now.Before(s.errorAfter)
+}
+
+// PolicyViolation describes a single violation of a build policy.
+type PolicyViolation struct {
+	// The kind of policy that was violated, one of PolicyViolationNeverallow,
+	// PolicyViolationVisibility or PolicyViolationLicenses.
+	Kind string `json:"kind"`
+
+	// An identifier for the rule that was violated.
+	RuleId string `json:"rule_id"`
+
+	// A description of the rule that was violated.
+	Rule string `json:"rule,omitempty"`
+
+	// The reason for the rule, as passed to Rule.Because.
+	Reason string `json:"reason,omitempty"`
+
+	// The name and type of the module that violated the rule.
+	Module     string `json:"module"`
+	ModuleType string `json:"module_type"`
+
+	// The properties of the module that matched the rule, if any.
+	Properties []string `json:"properties,omitempty"`
+
+	// The Android.bp file that defines the module, and the line of the module definition in that
+	// file if it could be found.
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+
+	// The error message reported for the violation.
+	Message string `json:"message"`
+
+	// How the violation was reported, "error" or "warning".
+	Severity string `json:"severity"`
+
+	// The date from which the violation will be reported as an error if it is currently reported
+	// as a warning because of an "error_after" severity.
+	ErrorAfter string `json:"error_after,omitempty"`
+}
+
+func (v PolicyViolation) isWarning() bool {
+	return v.Severity == "warning"
+}
+
+type policyViolations struct {
+	sync.Mutex
+	violations []PolicyViolation
+	seen       map[string]bool
+
+	// errors holds the messages of the violations that are errors for each module variant until
+	// policyViolationErrorsMutator reports them.
+	errors map[Module][]string
+
+	// lines caches the line of each module definition in each Android.bp file, see
+	// moduleDefinitionLines.
+	lines map[string]map[string]int
+
+	// written is set once the reports have been written by policyViolationErrorsMutator.
+	written bool
+}
+
+var policyViolationsKey = NewOnceKey("policyViolations")
+
+func policyViolationsForConfig(config Config) *policyViolations {
+	return config.Once(policyViolationsKey, func() interface{} {
+		return &policyViolations{
+			seen:   make(map[string]bool),
+			errors: make(map[Module][]string),
+			lines:  make(map[string]map[string]int),
+		}
+	}).(*policyViolations)
+}
+
+// PolicyViolations returns the policy violations recorded so far, sorted by file and module.
+func PolicyViolations(config Config) []PolicyViolation {
+	p := policyViolationsForConfig(config)
+	p.Lock()
+	defer p.Unlock()
+	return p.sortedLocked(config)
+}
+
+// sortedLocked returns the violations sorted by file and module, with the line of each module
+// definition filled in.
+func (p *policyViolations) sortedLocked(config Config) []PolicyViolation {
+	violations := append([]PolicyViolation(nil), p.violations...)
+	for i := range violations {
+		lines, ok := p.lines[violations[i].File]
+		if !ok {
+			lines = moduleDefinitionLines(config, violations[i].File)
+			p.lines[violations[i].File] = lines
+		}
+		violations[i].Line = lines[violations[i].Module]
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].File != violations[j].File {
+			return violations[i].File < violations[j].File
+		}
+		return violations[i].Module < violations[j].Module
+	})
+	return violations
+}
+
+// PolicyViolationWarnings returns the policy violations that were reported as warnings, sorted by
+// file and module.
+func PolicyViolationWarnings(config Config) []PolicyViolation {
+	return policyViolationWarnings(PolicyViolations(config))
+}
+
+func policyViolationWarnings(violations []PolicyViolation) []PolicyViolation {
+	var warnings []PolicyViolation
+	for _, v := range violations {
+		if v.isWarning() {
+			warnings = append(warnings, v)
+		}
+	}
+	return warnings
+}
+
+// FormatPolicyViolationWarnings returns the policy violations that were reported as warnings in a
+// form suitable for printing at the end of the build, or an empty string if there are none.
+func FormatPolicyViolationWarnings(config Config) string {
+	return formatPolicyViolationWarnings(PolicyViolations(config))
+}
+
+func formatPolicyViolationWarnings(violations []PolicyViolation) string {
+	warnings := policyViolationWarnings(violations)
+	if len(warnings) == 0 {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "warning: %d policy violation(s) were reported as warnings:\n", len(warnings))
+	for _, v := range warnings {
+		location := v.File
+		if v.Line != 0 {
+			location = fmt.Sprintf("%s:%d", v.File, v.Line)
+		}
+		fmt.Fprintf(sb, "%s: module %q: %s\n", location, v.Module, v.Message)
+		if v.ErrorAfter != "" {
+			fmt.Fprintf(sb, "    This will be an error from %s.\n", v.ErrorAfter)
+		}
+	}
+	return sb.String()
+}
+
+// reportPolicyViolation records a violation of a build policy by the current module, as an error
+// or as a warning depending on the severity of the policy. The module specific fields of the
+// violation are filled in from ctx. Violations are recorded once per module even if they are
+// reported by multiple variants. The errors are reported by policyViolationErrorsMutator, which
+// must be registered after the mutator that calls this.
+func reportPolicyViolation(ctx BaseModuleContext, severity Severity, violation PolicyViolation) {
+	violation.Module = ctx.ModuleName()
+	violation.ModuleType = ctx.ModuleType()
+	violation.File = ctx.BlueprintsFile()
+
+	isError := severity.isError(time.Now())
+	if isError {
+		violation.Severity = "error"
+	} else {
+		violation.Severity = "warning"
+		if !severity.errorAfter.IsZero() {
+			violation.ErrorAfter = severity.errorAfter.Format(severityDateLayout)
+		}
+	}
+
+	p := policyViolationsForConfig(ctx.Config())
+	p.Lock()
+	defer p.Unlock()
+	key := fmt.Sprintf("%s %s %s %s", violation.Kind, violation.RuleId, violation.File, violation.Module)
+	if !p.seen[key] {
+		p.seen[key] = true
+		p.violations = append(p.violations, violation)
+	}
+	if isError {
+		p.errors[ctx.Module()] = append(p.errors[ctx.Module()], violation.Message)
+	}
+}
+
+// registerPolicyViolationErrorsMutator registers a mutator that reports the violations recorded as
+// errors by the mutators registered before it. It must be registered right after each mutator
+// that calls reportPolicyViolation, with a name derived from that of the mutator.
+func registerPolicyViolationErrorsMutator(ctx RegisterMutatorsContext, name string) {
+	ctx.BottomUp(name+"_errors", policyViolationErrorsMutator).Parallel()
+}
+
+// policyViolationErrorsMutator reports the violations that are errors for the current module.
+// soong_build stops at the end of the mutator pass that reported them, so the reports are written
+// first.
+func policyViolationErrorsMutator(ctx BottomUpMutatorContext) {
+	p := policyViolationsForConfig(ctx.Config())
+	p.Lock()
+	messages := p.errors[ctx.Module()]
+	delete(p.errors, ctx.Module())
+	if len(messages) > 0 && !p.written {
+		p.written = true
+		if err := writePolicyViolationReports(ctx, p.sortedLocked(ctx.Config())); err != nil {
+			ctx.ModuleErrorf("failed to write policy violation reports: %s", err)
+		}
+	}
+	p.Unlock()
+
+	for _, message := range messages {
+		ctx.ModuleErrorf("%s", message)
+	}
+}
+
+// moduleDefinitionLines returns the line of the definition of each module in an Android.bp file,
+// by name. The file is parsed with the same parser as soong_build, so the lines are those of the
+// module positions that blueprint reports errors at.
+func moduleDefinitionLines(config Config, file string) map[string]int {
+	lines := make(map[string]int)
+	f, err := config.fs.Open(file)
+	if err != nil {
+		return lines
+	}
+	defer f.Close()
+
+	bp, _ := parser.ParseAndEval(file, f, parser.NewScope(nil))
+	if bp == nil {
+		return lines
+	}
+	for _, def := range bp.Defs {
+		module, ok := def.(*parser.Module)
+		if !ok {
+			continue
+		}
+		if prop, ok := module.GetProperty("name"); ok {
+			if name, ok := prop.Value.Eval().(*parser.String); ok {
+				lines[name.Value] = module.TypePos.Line
+			}
+		}
+	}
+	return lines
+}
+
+func policyViolationsToSarif(violations []PolicyViolation) *sarif.Log {
+	run := sarif.NewRun("soong")
+
+	for _, v := range violations {
+		short := v.Reason
+		if short == "" {
+			short = v.Kind + " violation"
+		}
+		full := v.Rule
+		if full == "" {
+			full = short
+		}
+		run.AddRule(sarif.Rule{
+			Id:               v.RuleId,
+			ShortDescription: sarif.Message{Text: short},
+			FullDescription:  sarif.Message{Text: full},
+		})
+
+		result := sarif.Result{
+			RuleId:    v.RuleId,
+			Level:     v.Severity,
+			Message:   sarif.Message{Text: fmt.Sprintf("module %q: %s", v.Module, v.Message)},
+			Locations: []sarif.Location{sarif.NewLocation(v.File, v.Line, 0)},
+		}
+		result.SetProperty(sarif.ModuleProperty, v.Module)
+		result.SetProperty("module_type", v.ModuleType)
+		run.Results = append(run.Results, result)
+	}
+
+	return sarif.NewLog(run)
+}
+
+func writePolicyViolationReports(ctx PathContext, violations []PolicyViolation) error {
+	if violations == nil {
+		violations = []PolicyViolation{}
+	}
+
+	data, err := json.MarshalIndent(violations, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := WriteFileToOutputDir(PathForOutput(ctx, policyViolationsJsonFile), data, 0666); err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(policyViolationsToSarif(violations), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := WriteFileToOutputDir(PathForOutput(ctx, policyViolationsSarifFile), data, 0666); err != nil {
+		return err
+	}
+
+	warnings := formatPolicyViolationWarnings(violations)
+	return WriteFileToOutputDir(PathForOutput(ctx, policyViolationWarningsFile), []byte(warnings), 0666)
+}
+
+func init() {
+	RegisterSingletonType("policy_violations", policyViolationsSingletonFactory)
+}
+
+func policyViolationsSingletonFactory() Singleton {
+	return &policyViolationsSingleton{}
+}
+
+type policyViolationsSingleton struct{}
+
+func (s *policyViolationsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if err := writePolicyViolationReports(ctx, PolicyViolations(ctx.Config())); err != nil {
+		ctx.Errorf("failed to write policy violation reports: %s", err)
+	}
+}
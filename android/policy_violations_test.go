@@ -0,0 +1,249 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"android/soong/sarif"
+)
+
+func TestPolicyViolationsReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForNeverAllowTest,
+		PrepareForTestWithNeverallowRules([]Rule{
+			NeverAllow().In("other").With("vndk.enabled", "true").Because("it is not allowed"),
+		}),
+		FixtureAddTextFile("other/Android.bp", `
+cc_library {
+	name: "libok",
+}
+
+cc_library {
+	name: "libother",
+	vndk: {
+		enabled: true,
+	},
+}`),
+	).
+		ExtendWithErrorHandler(FixtureExpectsOneErrorPattern(`module "libother": violates neverallow`)).
+		RunTest(t)
+
+	violations := PolicyViolations(result.Config)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation, got %#v", violations)
+	}
+
+	v := violations[0]
+	AssertStringEquals(t, "kind", PolicyViolationNeverallow, v.Kind)
+	AssertStringEquals(t, "module", "libother", v.Module)
+	AssertStringEquals(t, "module type", "cc_library", v.ModuleType)
+	AssertStringEquals(t, "reason", "it is not allowed", v.Reason)
+	AssertStringEquals(t, "file", "other/Android.bp", v.File)
+	AssertIntEquals(t, "line", 6, v.Line)
+	AssertDeepEquals(t, "properties", []string{"vndk.enabled"}, v.Properties)
+	AssertStringDoesContain(t, "rule id", v.RuleId, "neverallow-")
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), policyViolationsSarifFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarif.Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("expected one SARIF result, got %s", data)
+	}
+	location := log.Runs[0].Results[0].Locations[0].PhysicalLocation
+	AssertStringEquals(t, "SARIF rule id", v.RuleId, log.Runs[0].Results[0].RuleId)
+	AssertStringEquals(t, "SARIF uri", "other/Android.bp", location.ArtifactLocation.Uri)
+	AssertIntEquals(t, "SARIF line", 6, location.Region.StartLine)
+}
+
+func TestPolicyViolationsReportMultipleErrors(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForNeverAllowTest,
+		PrepareForTestWithNeverallowRules([]Rule{
+			NeverAllow().In("other").With("vndk.enabled", "true"),
+		}),
+		FixtureAddTextFile("other/Android.bp", `
+cc_library {
+	name: "libfoo",
+	vndk: {
+		enabled: true,
+	},
+}
+
+cc_library {
+	name: "libbar",
+	vndk: {
+		enabled: true,
+	},
+}`),
+	).
+		ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`module "libfoo": violates neverallow`,
+			`module "libbar": violates neverallow`,
+		})).
+		RunTest(t)
+
+	// The reports are written once, after both violations have been recorded.
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), policyViolationsJsonFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var violations []PolicyViolation
+	if err := json.Unmarshal(data, &violations); err != nil {
+		t.Fatal(err)
+	}
+	if len(violations) != 2 {
+		t.Fatalf("expected two violations, got %s", data)
+	}
+	AssertStringEquals(t, "first module", "libbar", violations[0].Module)
+	AssertIntEquals(t, "first line", 9, violations[0].Line)
+	AssertStringEquals(t, "second module", "libfoo", violations[1].Module)
+	AssertIntEquals(t, "second line", 2, violations[1].Line)
+}
+
+func TestPolicyViolationWarnings(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForNeverAllowTest,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterSingletonType("policy_violations", policyViolationsSingletonFactory)
+		}),
+		PrepareForTestWithNeverallowRules([]Rule{
+			NeverAllow().In("other").With("vndk.enabled", "true").
+				Because("it is being deprecated").
+				WithSeverity(SeverityErrorAfter(2999, time.January, 1)),
+			NeverAllow().In("other").With("vendor_available", "true").
+				Because("it is discouraged").
+				WithSeverity(SeverityWarning),
+		}),
+		FixtureAddTextFile("other/Android.bp", `
+cc_library {
+	name: "libvndk",
+	vndk: {
+		enabled: true,
+	},
+}
+
+cc_library {
+	name: "libvendor",
+	vendor_available: true,
+}`),
+	).RunTest(t)
+
+	warnings := PolicyViolationWarnings(result.Config)
+	if len(warnings) != 2 {
+		t.Fatalf("expected two warnings, got %#v", warnings)
+	}
+	AssertStringEquals(t, "module", "libvendor", warnings[0].Module)
+	AssertStringEquals(t, "severity", "warning", warnings[0].Severity)
+	AssertStringEquals(t, "error after", "", warnings[0].ErrorAfter)
+	AssertStringEquals(t, "module", "libvndk", warnings[1].Module)
+	AssertStringEquals(t, "severity", "warning", warnings[1].Severity)
+	AssertStringEquals(t, "error after", "2999-01-01", warnings[1].ErrorAfter)
+
+	formatted := FormatPolicyViolationWarnings(result.Config)
+	AssertStringDoesContain(t, "formatted warnings", formatted, "2 policy violation(s) were reported as warnings")
+	AssertStringDoesContain(t, "formatted warnings", formatted, `other/Android.bp:2: module "libvndk": violates neverallow`)
+	AssertStringDoesContain(t, "formatted warnings", formatted, "This will be an error from 2999-01-01.")
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), policyViolationsSarifFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarif.Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range log.Runs[0].Results {
+		AssertStringEquals(t, "SARIF level", "warning", r.Level)
+	}
+
+	data, err = os.ReadFile(filepath.Join(result.Config.SoongOutDir(), policyViolationWarningsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "persisted warnings", formatted, string(data))
+}
+
+func TestPolicyViolationErrorAfter(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForNeverAllowTest,
+		PrepareForTestWithNeverallowRules([]Rule{
+			NeverAllow().In("other").With("vndk.enabled", "true").
+				WithSeverity(SeverityErrorAfter(2000, time.January, 1)),
+		}),
+		FixtureAddTextFile("other/Android.bp", `
+cc_library {
+	name: "libvndk",
+	vndk: {
+		enabled: true,
+	},
+}`),
+	).
+		ExtendWithErrorHandler(FixtureExpectsOneErrorPattern(`module "libvndk": violates neverallow`)).
+		RunTest(t)
+
+	violations := PolicyViolations(result.Config)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation, got %#v", violations)
+	}
+	AssertStringEquals(t, "severity", "error", violations[0].Severity)
+	AssertStringEquals(t, "formatted warnings", "", FormatPolicyViolationWarnings(result.Config))
+}
+
+func TestParseSeverity(t *testing.T) {
+	testCases := []struct {
+		in      string
+		want    Severity
+		wantErr string
+	}{
+		{in: "error", want: SeverityError},
+		{in: "warning", want: SeverityWarning},
+		{in: "error_after:2024-06-01", want: SeverityErrorAfter(2024, time.June, 1)},
+		{in: "error_after:06/01/2024", wantErr: `invalid date "06/01/2024"`},
+		{in: "fatal", wantErr: `unknown severity "fatal"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseSeverity(tc.in)
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, got)
+				}
+				AssertStringDoesContain(t, "error", err.Error(), tc.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertDeepEquals(t, "severity", tc.want, got)
+			AssertStringEquals(t, "string", tc.in, got.String())
+		})
+	}
+
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	AssertBoolEquals(t, "error is an error", true, SeverityError.isError(now))
+	AssertBoolEquals(t, "warning is an error", false, SeverityWarning.isError(now))
+	AssertBoolEquals(t, "error_after today is an error", true, SeverityErrorAfter(2024, time.June, 1).isError(now))
+	AssertBoolEquals(t, "error_after tomorrow is an error", false, SeverityErrorAfter(2024, time.June, 2).isError(now))
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"android/soong/query"
+
+	"github.com/google/blueprint"
+)
+
+// This file implements the query_graph singleton, which writes the module graph that
+// soong_ui --query runs queries against when soong_build runs in GenerateQueryGraph mode.  The
+// graph has every variant of every module, with the tags of its dependencies, its install paths,
+// its outputs and the files it places in packages.  The dependency tags are only available while
+// the modules generate their build actions, so they are only recorded in GenerateQueryGraph mode
+// to keep them out of normal builds.
+
+func init() {
+	RegisterSingletonType("query_graph", queryGraphSingletonFactory)
+}
+
+// queryGraphDep is a dependency of a module recorded for the query graph.
+type queryGraphDep struct {
+	module blueprint.Module
+	tag    blueprint.DependencyTag
+	// Whether the packaging specs of the dependency are packaged with those of the module, see
+	// computeInstallDeps and PackagingBase.GatherPackagingSpecs.
+	packaging bool
+}
+
+// collectQueryGraphDeps records the direct dependencies of the module and their tags.
+func collectQueryGraphDeps(ctx *moduleContext) []queryGraphDep {
+	_, isPackage := ctx.Module().(PackageModule)
+	var deps []queryGraphDep
+	ctx.VisitDirectDepsBlueprint(func(dep blueprint.Module) {
+		tag := ctx.OtherModuleDependencyTag(dep)
+		packaging := false
+		if depModule, ok := dep.(Module); ok {
+			packaging = isInstallDepNeeded(depModule, tag)
+			if pi, ok := tag.(PackagingItem); ok && isPackage && pi.IsPackagingItem() {
+				packaging = true
+			}
+		}
+		deps = append(deps, queryGraphDep{module: dep, tag: tag, packaging: packaging})
+	})
+	return deps
+}
+
+// queryGraphTagName returns the name of a dependency tag in the query graph, which is the name of
+// its type followed by the value of its name field if it has one, e.g. cc.dependencyTag(header).
+func queryGraphTagName(tag blueprint.DependencyTag) string {
+	if tag == nil {
+		return ""
+	}
+	name := strings.TrimPrefix(reflect.TypeOf(tag).String(), "*")
+	if v := reflect.Indirect(reflect.ValueOf(tag)); v.Kind() == reflect.Struct {
+		for _, field := range []string{"name", "Name"} {
+			if f := v.FieldByName(field); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+				return name + "(" + f.String() + ")"
+			}
+		}
+	}
+	return name
+}
+
+func queryGraphSingletonFactory() Singleton {
+	return &queryGraphSingleton{}
+}
+
+type queryGraphSingleton struct{}
+
+func (s *queryGraphSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if ctx.Config().BuildMode != GenerateQueryGraph {
+		return
+	}
+
+	graph := &query.Graph{}
+	ids := make(map[blueprint.Module]int)
+	var modules []Module
+	ctx.VisitAllModules(func(module Module) {
+		ids[module] = len(modules)
+		modules = append(modules, module)
+	})
+
+	for id, module := range modules {
+		m := &query.Module{
+			Id:       id,
+			Name:     ctx.ModuleName(module),
+			Type:     ctx.ModuleType(module),
+			Variant:  ctx.ModuleSubDir(module),
+			Dir:      ctx.ModuleDir(module),
+			Disabled: !module.Enabled(),
+		}
+		for _, dep := range module.base().queryGraphDeps {
+			// Dependencies on modules that are not Soong modules, e.g. bootstrap_go_package, are
+			// not in the graph.
+			if depId, ok := ids[dep.module]; ok {
+				m.Deps = append(m.Deps, query.Dep{
+					Module:    depId,
+					Tag:       queryGraphTagName(dep.tag),
+					Property:  DependencyTagPropertyName(dep.tag),
+					Packaging: dep.packaging,
+				})
+			}
+		}
+		for _, ps := range module.PackagingSpecs() {
+			m.Packaging_specs = append(m.Packaging_specs, query.PackagingSpec{
+				Path:      ps.RelPathInPackage(),
+				Partition: ps.Partition(),
+			})
+		}
+		for _, install := range module.FilesToInstall() {
+			m.Install_paths = append(m.Install_paths, install.String())
+		}
+		if producer, ok := module.(OutputFileProducer); ok && module.Enabled() {
+			if outputs, err := producer.OutputFiles(""); err == nil {
+				m.Outputs = outputs.Strings()
+			}
+		}
+		graph.Modules = append(graph.Modules, m)
+	}
+
+	rel, err := filepath.Rel(ctx.Config().SoongOutDir(), ctx.Config().queryGraphFile)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		ctx.Errorf("the query graph %q must be in %q", ctx.Config().queryGraphFile,
+			ctx.Config().SoongOutDir())
+		return
+	}
+	path := PathForOutput(ctx, rel)
+	if err := writeQueryGraph(graph, path); err != nil {
+		ctx.Errorf("%s", err)
+	}
+}
+
+// writeQueryGraph always writes the query graph, even if it did not change, as the ninja rule that
+// runs soong_build in GenerateQueryGraph mode expects its output to be newer than its inputs.
+func writeQueryGraph(graph *query.Graph, path WritablePath) error {
+	absPath := absolutePath(path.String())
+	if err := os.MkdirAll(filepath.Dir(absPath), 0777); err != nil {
+		return fmt.Errorf("Writing the query graph to %s failed: %s", path.String(), err)
+	}
+	f, err := os.Create(absPath)
+	if err != nil {
+		return fmt.Errorf("Writing the query graph to %s failed: %s", path.String(), err)
+	}
+	defer f.Close()
+	if err := graph.Write(f); err != nil {
+		return fmt.Errorf("Writing the query graph to %s failed: %s", path.String(), err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"android/soong/query"
+
+	"github.com/google/blueprint"
+)
+
+type queryGraphTestDepTag struct {
+	blueprint.BaseDependencyTag
+	name string
+}
+
+func (t queryGraphTestDepTag) DependencyPropertyName() string {
+	return t.name + "_libs"
+}
+
+func (t queryGraphTestDepTag) InstallDepNeeded() bool {
+	return t.name == "shared"
+}
+
+type queryGraphTestModule struct {
+	ModuleBase
+	properties struct {
+		Shared_libs []string
+		Static_libs []string
+	}
+	output Path
+}
+
+func (m *queryGraphTestModule) DepsMutator(ctx BottomUpMutatorContext) {
+	ctx.AddDependency(ctx.Module(), queryGraphTestDepTag{name: "shared"}, m.properties.Shared_libs...)
+	ctx.AddDependency(ctx.Module(), queryGraphTestDepTag{name: "static"}, m.properties.Static_libs...)
+}
+
+func (m *queryGraphTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	output := PathForModuleOut(ctx, ctx.ModuleName()+".so")
+	ctx.Build(pctx, BuildParams{
+		Rule:   Touch,
+		Output: output,
+	})
+	m.output = output
+	ctx.InstallFile(PathForModuleInstall(ctx, "lib"), ctx.ModuleName()+".so", output)
+}
+
+func (m *queryGraphTestModule) OutputFiles(tag string) (Paths, error) {
+	return Paths{m.output}, nil
+}
+
+func queryGraphTestModuleFactory() Module {
+	module := &queryGraphTestModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidArchModule(module, DeviceSupported, MultilibCommon)
+	return module
+}
+
+func TestQueryGraph(t *testing.T) {
+	bp := `
+		test_module {
+			name: "libfoo",
+			shared_libs: ["libbar"],
+			static_libs: ["libbaz"],
+		}
+
+		test_module {
+			name: "libbar",
+			static_libs: ["libbaz"],
+		}
+
+		test_module {
+			name: "libbaz",
+		}
+	`
+
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		FixtureAddTextFile("a/Android.bp", bp),
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("test_module", queryGraphTestModuleFactory)
+			ctx.RegisterSingletonType("query_graph", queryGraphSingletonFactory)
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.BuildMode = GenerateQueryGraph
+			config.queryGraphFile = filepath.Join(config.SoongOutDir(), "module-query-graph.json")
+		}),
+	).RunTest(t)
+
+	// The graph is written with WriteFileToOutputDir, so it is not an output of the singleton.
+	f, err := os.Open(filepath.Join(result.Config.SoongOutDir(), "module-query-graph.json"))
+	if err != nil {
+		t.Fatalf("the query graph has not been written: %s", err)
+	}
+	defer f.Close()
+	graph, err := query.ReadGraph(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules := make(map[string]*query.Module)
+	for _, m := range graph.Modules {
+		modules[m.Name] = m
+	}
+	libfoo := modules["libfoo"]
+	if libfoo == nil {
+		t.Fatalf("expected libfoo in the query graph")
+	}
+	AssertStringEquals(t, "type", "test_module", libfoo.Type)
+	AssertStringEquals(t, "variant", "android_common", libfoo.Variant)
+	AssertStringEquals(t, "dir", "a", libfoo.Dir)
+	AssertStringPathsRelativeToTopEquals(t, "outputs", result.Config,
+		[]string{"out/soong/.intermediates/a/libfoo/android_common/libfoo.so"}, libfoo.Outputs)
+	AssertStringPathsRelativeToTopEquals(t, "install paths", result.Config,
+		[]string{"out/soong/target/product/test_device/system/lib/libfoo.so"}, libfoo.Install_paths)
+	AssertDeepEquals(t, "packaging specs",
+		[]query.PackagingSpec{{Path: "lib/libfoo.so", Partition: "system"}}, libfoo.Packaging_specs)
+
+	var deps []string
+	for _, dep := range libfoo.Deps {
+		deps = append(deps, fmt.Sprintf("%s %s %v", graph.Modules[dep.Module].Name, dep.Property, dep.Packaging))
+	}
+	AssertArrayString(t, "deps", []string{"libbar shared_libs true", "libbaz static_libs false"}, deps)
+
+	e, err := query.Parse("somepath(libfoo, libbaz)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := query.Evaluate(graph, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var path []string
+	for i, m := range r.Modules {
+		path = append(path, m.Label())
+		if i+1 < len(r.Modules) {
+			for _, dep := range m.Deps {
+				if dep.Module == r.Modules[i+1].Id {
+					path = append(path, dep.Tag)
+				}
+			}
+		}
+	}
+	AssertArrayString(t, "somepath", []string{"//a:libfoo", "android.queryGraphTestDepTag(static)", "//a:libbaz"}, path)
+}
+
+func TestQueryGraphTagName(t *testing.T) {
+	type unnamedTag struct {
+		blueprint.BaseDependencyTag
+	}
+	AssertStringEquals(t, "nil", "", queryGraphTagName(nil))
+	AssertStringEquals(t, "named", "android.queryGraphTestDepTag(shared)",
+		queryGraphTagName(queryGraphTestDepTag{name: "shared"}))
+	AssertStringEquals(t, "pointer", "android.queryGraphTestDepTag(shared)",
+		queryGraphTagName(&queryGraphTestDepTag{name: "shared"}))
+	AssertStringEquals(t, "unnamed", "android.unnamedTag", queryGraphTagName(unnamedTag{}))
+}
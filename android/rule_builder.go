@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -38,6 +39,9 @@ const sboxOutSubDir = "out"
 const sboxToolsSubDir = "tools"
 const sboxOutDir = sboxSandboxBaseDir + "/" + sboxOutSubDir
 
+// The file in the output directory that sbox appends the statistics of its cache to.
+const sboxCacheStatsFile = "sbox_cache_stats.jsonl"
+
 // RuleBuilder provides an alternative to ModuleContext.Rule and ModuleContext.Build to add a command line to the build
 // graph.
 type RuleBuilder struct {
@@ -55,6 +59,7 @@ type RuleBuilder struct {
 	outDir           WritablePath
 	sboxTools        bool
 	sboxInputs       bool
+	sboxNamespaces   bool
 	sboxManifestPath WritablePath
 	missingDeps      []string
 }
@@ -181,6 +186,19 @@ func (r *RuleBuilder) SandboxInputs() *RuleBuilder {
 	return r
 }
 
+// SandboxNamespaces runs the command in Linux user, mount and network namespaces in which only the
+// sandbox directory and a minimal read-only root are visible, so that the command fails if it
+// reads any inputs that were not declared.  It requires SandboxInputs(), and has no effect when
+// not building on Linux.  Namespace sandboxing can also be enabled for all rules that use
+// SandboxInputs() by setting SOONG_SBOX_NAMESPACES=true.
+func (r *RuleBuilder) SandboxNamespaces() *RuleBuilder {
+	if !r.sboxInputs {
+		panic("SandboxNamespaces() must be called after SandboxInputs()")
+	}
+	r.sboxNamespaces = true
+	return r
+}
+
 // Install associates an output of the rule with an install location, which can be retrieved later using
 // RuleBuilder.Installs.
 func (r *RuleBuilder) Install(from Path, to string) {
@@ -635,6 +653,19 @@ func (r *RuleBuilder) Build(name string, desc string) {
 			sboxCmd.Flag("--write-if-changed")
 		}
 
+		if r.sboxInputs && (r.sboxNamespaces || r.ctx.Config().SboxNamespaces()) &&
+			r.ctx.Config().BuildOS == Linux && r.rbeParams == nil {
+			sboxCmd.Flag("--nsjail").PrebuiltBuildTool(r.ctx, "nsjail")
+		}
+
+		// Commands with sandboxed inputs can be cached, as all of their inputs are known to sbox.
+		// Commands run remotely are cached by RBE instead.
+		if cacheDir := r.ctx.Config().SboxCacheDir(); cacheDir != "" && r.sboxInputs && r.rbeParams == nil {
+			sboxCmd.FlagWithArg("--cache-dir ", cacheDir).
+				FlagWithArg("--cache-max-size ", strconv.FormatInt(r.ctx.Config().SboxCacheMaxSize(), 10)).
+				FlagWithArg("--cache-stats ", PathForOutput(r.ctx, sboxCacheStatsFile).String())
+		}
+
 		// Replace the command string, and add the sbox tool and manifest textproto to the
 		// dependencies of the final sbox rule.
 		commandString = sboxCmd.buf.String()
@@ -792,3 +792,43 @@ func TestRuleBuilderHashInputs(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleBuilderSboxEnvironment(t *testing.T) {
+	bp := `
+		rule_builder_test {
+			name: "foo_sbox",
+			srcs: ["in"],
+			sbox: true,
+		}
+		rule_builder_test {
+			name: "foo_sbox_inputs",
+			srcs: ["in"],
+			sbox: true,
+			sbox_inputs: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForRuleBuilderTest,
+		FixtureWithRootAndroidBp(bp),
+		FixtureMergeEnv(map[string]string{
+			"SOONG_SBOX_NAMESPACES":     "true",
+			"SOONG_SBOX_CACHE_DIR":      "/tmp/sbox_cache",
+			"SOONG_SBOX_CACHE_MAX_SIZE": "1000",
+		}),
+	).RunTest(t)
+
+	nsjail := "--nsjail prebuilts/build-tools/" + result.Config.PrebuiltOS() + "/bin/nsjail"
+	cache := "--cache-dir /tmp/sbox_cache --cache-max-size 1000 --cache-stats out/soong/sbox_cache_stats.jsonl"
+
+	// Only commands with sandboxed inputs can be run in namespaces or cached.
+	command := result.ModuleForTests("foo_sbox", "").Output("gen/foo_sbox").RuleParams.Command
+	AssertStringDoesNotContain(t, "sbox command", command, "--nsjail")
+	AssertStringDoesNotContain(t, "sbox command", command, "--cache-dir")
+
+	command = result.ModuleForTests("foo_sbox_inputs", "").Output("gen/foo_sbox_inputs").RuleParams.Command
+	if result.Config.BuildOS == Linux {
+		AssertStringDoesContain(t, "sbox inputs command", command, nsjail)
+	}
+	AssertStringDoesContain(t, "sbox inputs command", command, cache)
+}
@@ -270,6 +270,7 @@ func (s *singletonContextAdaptor) ModuleVariantsFromName(referer Module, name st
 			depQualified := qualifiedModuleName{depDir, depName}
 			// Targets are always visible to other targets in their own package.
 			if depQualified.pkg != qualified.pkg {
+				recordVisibilityConsumer(s.Config(), depQualified, qualified.pkg)
 				rule := effectiveVisibilityRules(s.Config(), depQualified)
 				if !rule.matches(qualified) {
 					s.ModuleErrorf(referer, "module %q references %q which is not visible to this module\nYou may need to add %q to its visibility",
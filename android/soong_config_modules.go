@@ -272,6 +272,61 @@ type soongConfigModuleTypeModule struct {
 //	SOONG_CONFIG_acme_width := 200
 //
 // Then libacme_foo would build with cflags "-DGENERIC -DSOC_A -DFEATURE".
+//
+// A module type can also read list_variables, whose value is a space separated list, and
+// int_variables, whose value must be an integer, and can combine its variables with all_of,
+// any_of and not:
+//
+//	soong_config_module_type {
+//	    name: "acme_cc_defaults",
+//	    module_type: "cc_defaults",
+//	    config_namespace: "acme",
+//	    variables: ["board"],
+//	    bool_variables: ["feature"],
+//	    list_variables: ["features"],
+//	    int_variables: ["api_level"],
+//	    properties: ["cflags"],
+//	}
+//
+//	soong_config_string_variable {
+//	    name: "board",
+//	    values: ["soc_a", "soc_b"],
+//	}
+//
+//	acme_cc_defaults {
+//	    name: "acme_defaults",
+//	    soong_config_variables: {
+//	        features: {
+//	            cflags: ["-DFEATURE_%s"],
+//	        },
+//	        api_level: {
+//	            cflags: ["-DAPI_LEVEL=%d"],
+//	        },
+//	        all_of: {
+//	            variables: {
+//	                feature: true,
+//	                board: "soc_a",
+//	                api_level: {
+//	                    at_least: 30,
+//	                },
+//	            },
+//	            cflags: ["-DFAST_SOC_A"],
+//	            conditions_default: {
+//	                cflags: ["-DSLOW"],
+//	            },
+//	        },
+//	    },
+//	}
+//
+// With SOONG_CONFIG_acme_features := a b, a string with %s in a list property is repeated for
+// each element of the list, so acme_defaults would have cflags "-DFEATURE_a -DFEATURE_b". The
+// conditions_default of a list variable is used when the variable is unspecified or empty.
+//
+// The properties of all_of are used when all of its variables match, those of any_of when one of
+// them matches and those of not when its single variable does not match. The value of a string
+// variable must be one of its declared values, and an int variable matches a range given by
+// at_least and at_most. Modules that set list or int variables, all_of, any_of or not are not
+// supported by bp2build.
 func SoongConfigModuleTypeFactory() Module {
 	module := &soongConfigModuleTypeModule{}
 
@@ -397,6 +452,8 @@ func loadSoongConfigModuleTypeDefinition(ctx LoadHookContext, from string) map[s
 
 		if ctx.Config().BuildMode == Bp2build {
 			ctx.Config().Bp2buildSoongConfigDefinitions.AddVars(mtDef)
+		} else {
+			recordSoongConfigDefinition(ctx.Config(), mtDef)
 		}
 
 		globalModuleTypes := ctx.moduleFactories()
@@ -472,11 +529,18 @@ func configModuleFactory(factory blueprint.ModuleFactory, moduleType *soongconfi
 			// creating the custom module with the factory.
 			AddLoadHook(module, func(ctx LoadHookContext) {
 				if m, ok := module.(Bazelable); ok {
+					// The all_of, any_of and not conditions are replaced with properties named
+					// after their config settings, which are converted like bool variables.
+					props, err := ctx.Config().Bp2buildSoongConfigDefinitions.AddConditions(moduleType, conditionalProps.Interface())
+					if err != nil {
+						ctx.ModuleErrorf("%s", err)
+						return
+					}
 					m.SetBaseModuleType(moduleType.BaseModuleType)
 					// Instead of applying all properties, keep the entire conditionalProps struct as
 					// part of the custom module so dependent modules can create the selects accordingly
 					m.setNamespacedVariableProps(namespacedVariableProperties{
-						moduleType.ConfigNamespace: []interface{}{conditionalProps.Interface()},
+						moduleType.ConfigNamespace: []interface{}{props},
 					})
 				}
 			})
@@ -485,6 +549,7 @@ func configModuleFactory(factory blueprint.ModuleFactory, moduleType *soongconfi
 			// conditional on Soong config variables by reading the product
 			// config variables from Make.
 			AddLoadHook(module, func(ctx LoadHookContext) {
+				recordSoongConfigModuleTypeUse(ctx.Config(), ctx.ModuleType(), moduleType)
 				config := ctx.Config().VendorConfig(moduleType.ConfigNamespace)
 				newProps, err := soongconfig.PropertiesToApply(moduleType, conditionalProps, config)
 				if err != nil {
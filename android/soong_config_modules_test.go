@@ -338,6 +338,158 @@ func TestSoongConfigModule(t *testing.T) {
 	})
 }
 
+func TestSoongConfigModuleListIntAndConditions(t *testing.T) {
+	bp := `
+		soong_config_module_type {
+			name: "acme_test",
+			module_type: "test",
+			config_namespace: "acme",
+			variables: ["board"],
+			bool_variables: ["feature"],
+			list_variables: ["features"],
+			int_variables: ["api_level"],
+			properties: ["cflags"],
+		}
+
+		soong_config_string_variable {
+			name: "board",
+			values: ["soc_a", "soc_b"],
+		}
+
+		acme_test {
+			name: "foo",
+			cflags: ["-DGENERIC"],
+			soong_config_variables: {
+				features: {
+					cflags: ["-DFEATURE_%s"],
+					conditions_default: {
+						cflags: ["-DNO_FEATURES"],
+					},
+				},
+				api_level: {
+					cflags: ["-DAPI_LEVEL=%d"],
+				},
+				all_of: {
+					variables: {
+						feature: true,
+						board: "soc_a",
+						api_level: {
+							at_least: 30,
+						},
+					},
+					cflags: ["-DFAST_SOC_A"],
+					conditions_default: {
+						cflags: ["-DSLOW"],
+					},
+				},
+				not: {
+					variables: {
+						board: "soc_b",
+					},
+					cflags: ["-DNOT_SOC_B"],
+				},
+			},
+		}
+    `
+
+	fixtureForVendorVars := func(vars map[string]map[string]string) FixturePreparer {
+		return FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = vars
+		})
+	}
+
+	testCases := []struct {
+		name             string
+		vars             map[string]string
+		fooExpectedFlags []string
+	}{
+		{
+			name:             "unset",
+			vars:             map[string]string{},
+			fooExpectedFlags: []string{"-DGENERIC", "-DNO_FEATURES", "-DSLOW", "-DNOT_SOC_B"},
+		},
+		{
+			name: "set",
+			vars: map[string]string{
+				"features":  "a b",
+				"api_level": "30",
+				"feature":   "true",
+				"board":     "soc_a",
+			},
+			fooExpectedFlags: []string{"-DGENERIC", "-DFEATURE_a", "-DFEATURE_b", "-DAPI_LEVEL=30", "-DFAST_SOC_A", "-DNOT_SOC_B"},
+		},
+		{
+			name: "old api level",
+			vars: map[string]string{
+				"api_level": "29",
+				"feature":   "true",
+				"board":     "soc_a",
+			},
+			fooExpectedFlags: []string{"-DGENERIC", "-DNO_FEATURES", "-DAPI_LEVEL=29", "-DSLOW", "-DNOT_SOC_B"},
+		},
+		{
+			name: "soc_b",
+			vars: map[string]string{
+				"board": "soc_b",
+			},
+			fooExpectedFlags: []string{"-DGENERIC", "-DNO_FEATURES", "-DSLOW"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := GroupFixturePreparers(
+				fixtureForVendorVars(map[string]map[string]string{"acme": tc.vars}),
+				PrepareForTestWithDefaults,
+				PrepareForTestWithSoongConfigModuleBuildComponents,
+				prepareForSoongConfigTestModule,
+				FixtureWithRootAndroidBp(bp),
+			).RunTest(t)
+
+			foo := result.ModuleForTests("foo", "").Module().(*soongConfigTestModule)
+			AssertDeepEquals(t, "foo cflags", tc.fooExpectedFlags, foo.props.Cflags)
+		})
+	}
+}
+
+func TestSoongConfigModuleConditionUndeclaredValue(t *testing.T) {
+	bp := `
+		soong_config_module_type {
+			name: "acme_test",
+			module_type: "test",
+			config_namespace: "acme",
+			variables: ["board"],
+			properties: ["cflags"],
+		}
+
+		soong_config_string_variable {
+			name: "board",
+			values: ["soc_a", "soc_b"],
+		}
+
+		acme_test {
+			name: "foo",
+			soong_config_variables: {
+				any_of: {
+					variables: {
+						board: "soc_c",
+					},
+					cflags: ["-DSOC_C"],
+				},
+			},
+		}
+    `
+
+	GroupFixturePreparers(
+		PrepareForTestWithDefaults,
+		PrepareForTestWithSoongConfigModuleBuildComponents,
+		prepareForSoongConfigTestModule,
+		FixtureWithRootAndroidBp(bp),
+	).ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+		`soong_config_variables.any_of.variables.board: "soc_c" is not one of the values \[soc_a soc_b\]`,
+	})).RunTest(t)
+}
+
 func TestNonExistentPropertyInSoongConfigModule(t *testing.T) {
 	bp := `
 		soong_config_module_type {
@@ -0,0 +1,309 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"android/soong/android/soongconfig"
+)
+
+// The Soong config report cross-references the SOONG_CONFIG variables set by the product against
+// the variables read by the soong_config_module_type definitions loaded in the build. It is
+// written to out/soong/soong_config_report.json and lists:
+//   - unused: variables that are set but that no module type reads, often left behind after the
+//     modules that read them were removed, or misspelled.
+//   - undeclared: variables that are read by the module types of modules in the build, or by Go
+//     code through Config.VendorConfig, but that are not set, so the modules use their
+//     conditions_default properties.
+//   - invalid_values: string variables set to a value that is not one of their declared values.
+//
+// With SOONG_CONFIG_STRICT=true every entry of the report is a build error. Variables that are only
+// read by Make are not known to Soong, so they are listed in soongConfigMakeOnlyVariables to keep
+// them from being reported as unused.
+
+const soongConfigReportFile = "soong_config_report.json"
+
+func soongConfigStrict(config Config) bool {
+	return config.IsEnvTrue("SOONG_CONFIG_STRICT")
+}
+
+// soongConfigMakeOnlyVariables maps each namespace to the variables of the namespace that are
+// only read by Make, and so are not reported as unused.
+var soongConfigMakeOnlyVariables = map[string][]string{}
+
+var soongConfigMakeOnlyVariablesKey = NewOnceKey("soongConfigMakeOnlyVariables")
+
+func soongConfigMakeOnlyVariablesForConfig(config Config) map[string][]string {
+	return config.Once(soongConfigMakeOnlyVariablesKey, func() interface{} {
+		// No test variables were set by PrepareForTestWithSoongConfigMakeOnlyVariables, use the
+		// global list
+		return soongConfigMakeOnlyVariables
+	}).(map[string][]string)
+}
+
+// Prepares for a test by overriding the Soong config variables that are only read by Make.
+func PrepareForTestWithSoongConfigMakeOnlyVariables(variables map[string][]string) FixturePreparer {
+	return FixtureModifyConfig(func(config Config) {
+		config.Once(soongConfigMakeOnlyVariablesKey, func() interface{} { return variables })
+	})
+}
+
+// soongConfigVariableReader records the uses of a Soong config variable by module types.
+type soongConfigVariableReader struct {
+	// The soong_config_module_types that read the variable.
+	moduleTypes map[string]bool
+
+	// The soong_config_module_types that read the variable and are used by modules.
+	used map[string]bool
+
+	// The values declared for the variable by soong_config_string_variable modules.
+	values map[string]bool
+
+	// Whether the variable was read through Config.VendorConfig.
+	read bool
+}
+
+// soongConfigReaders maps each namespace and variable to the modules that read it.
+type soongConfigReaders struct {
+	sync.Mutex
+	readers map[string]map[string]*soongConfigVariableReader
+}
+
+var soongConfigReadersKey = NewOnceKey("soongConfigReaders")
+
+func soongConfigReadersForConfig(config Config) *soongConfigReaders {
+	return config.Once(soongConfigReadersKey, func() interface{} {
+		return &soongConfigReaders{readers: make(map[string]map[string]*soongConfigVariableReader)}
+	}).(*soongConfigReaders)
+}
+
+// reader returns the reader of a variable, creating it if necessary. It must be called with the
+// lock held.
+func (r *soongConfigReaders) reader(namespace, variable string) *soongConfigVariableReader {
+	if r.readers[namespace] == nil {
+		r.readers[namespace] = make(map[string]*soongConfigVariableReader)
+	}
+	reader := r.readers[namespace][variable]
+	if reader == nil {
+		reader = &soongConfigVariableReader{
+			moduleTypes: make(map[string]bool),
+			used:        make(map[string]bool),
+			values:      make(map[string]bool),
+		}
+		r.readers[namespace][variable] = reader
+	}
+	return reader
+}
+
+// recordSoongConfigDefinition records the variables read by the module types of a loaded
+// SoongConfigDefinition.
+func recordSoongConfigDefinition(config Config, mtDef *soongconfig.SoongConfigDefinition) {
+	r := soongConfigReadersForConfig(config)
+	r.Lock()
+	defer r.Unlock()
+
+	for name, moduleType := range mtDef.ModuleTypes {
+		for variable, values := range moduleType.ReadVariables() {
+			reader := r.reader(moduleType.ConfigNamespace, variable)
+			reader.moduleTypes[name] = true
+			for _, value := range values {
+				reader.values[value] = true
+			}
+		}
+	}
+}
+
+// recordSoongConfigModuleTypeUse records that a module of the named soong_config_module_type
+// reads the variables of moduleType.
+func recordSoongConfigModuleTypeUse(config Config, name string, moduleType *soongconfig.ModuleType) {
+	r := soongConfigReadersForConfig(config)
+	r.Lock()
+	defer r.Unlock()
+
+	for variable := range moduleType.ReadVariables() {
+		r.reader(moduleType.ConfigNamespace, variable).used[name] = true
+	}
+}
+
+// soongConfigReadRecorder is the VendorConfig returned by Config.VendorConfig. It records the
+// variables that are read through it, so that the variables read by Go code, for example through
+// use_source_config_var, are not reported as unused.
+type soongConfigReadRecorder struct {
+	soongconfig.SoongConfig
+	namespace string
+	readers   *soongConfigReaders
+}
+
+func (c soongConfigReadRecorder) record(name string) {
+	c.readers.Lock()
+	defer c.readers.Unlock()
+	c.readers.reader(c.namespace, name).read = true
+}
+
+func (c soongConfigReadRecorder) Bool(name string) bool {
+	c.record(name)
+	return c.SoongConfig.Bool(name)
+}
+
+func (c soongConfigReadRecorder) String(name string) string {
+	c.record(name)
+	return c.SoongConfig.String(name)
+}
+
+func (c soongConfigReadRecorder) IsSet(name string) bool {
+	c.record(name)
+	return c.SoongConfig.IsSet(name)
+}
+
+// soongConfigReportVariable is an entry of the Soong config report.
+type soongConfigReportVariable struct {
+	Namespace string `json:"namespace"`
+	Variable  string `json:"variable"`
+
+	// The value of the variable in the product config, if it is set.
+	Value string `json:"value,omitempty"`
+
+	// The soong_config_module_types that read the variable.
+	ModuleTypes []string `json:"module_types,omitempty"`
+
+	// The values declared for a string variable.
+	Values []string `json:"values,omitempty"`
+}
+
+type soongConfigReport struct {
+	Unused        []soongConfigReportVariable `json:"unused"`
+	Undeclared    []soongConfigReportVariable `json:"undeclared"`
+	InvalidValues []soongConfigReportVariable `json:"invalid_values"`
+}
+
+// buildSoongConfigReport cross-references the variables set in vendorVars against the readers.
+// The variables in makeOnly are read by Make, and are not reported as unused.
+func buildSoongConfigReport(vendorVars map[string]map[string]string,
+	readers map[string]map[string]*soongConfigVariableReader,
+	makeOnly map[string][]string) soongConfigReport {
+
+	report := soongConfigReport{
+		Unused:        []soongConfigReportVariable{},
+		Undeclared:    []soongConfigReportVariable{},
+		InvalidValues: []soongConfigReportVariable{},
+	}
+
+	for _, namespace := range SortedKeys(vendorVars) {
+		for _, variable := range SortedKeys(vendorVars[namespace]) {
+			value := vendorVars[namespace][variable]
+			reader := readers[namespace][variable]
+			if reader == nil {
+				if InList(variable, makeOnly[namespace]) {
+					continue
+				}
+				report.Unused = append(report.Unused, soongConfigReportVariable{
+					Namespace: namespace,
+					Variable:  variable,
+					Value:     value,
+				})
+			} else if len(reader.values) > 0 && value != "" && !reader.values[value] {
+				report.InvalidValues = append(report.InvalidValues, soongConfigReportVariable{
+					Namespace:   namespace,
+					Variable:    variable,
+					Value:       value,
+					ModuleTypes: SortedKeys(reader.moduleTypes),
+					Values:      SortedKeys(reader.values),
+				})
+			}
+		}
+	}
+
+	for _, namespace := range SortedKeys(readers) {
+		for _, variable := range SortedKeys(readers[namespace]) {
+			reader := readers[namespace][variable]
+			if _, set := vendorVars[namespace][variable]; !set && (len(reader.used) > 0 || reader.read) {
+				report.Undeclared = append(report.Undeclared, soongConfigReportVariable{
+					Namespace:   namespace,
+					Variable:    variable,
+					ModuleTypes: SortedKeys(reader.used),
+				})
+			}
+		}
+	}
+
+	return report
+}
+
+// soongConfigStrictErrors returns the errors reported for the report with SOONG_CONFIG_STRICT.
+func soongConfigStrictErrors(report soongConfigReport) []string {
+	var errors []string
+	for _, v := range report.Unused {
+		errors = append(errors, fmt.Sprintf(
+			"SOONG_CONFIG_%s_%s is set but is not read by any soong_config_module_type or by Soong",
+			v.Namespace, v.Variable))
+	}
+	for _, v := range report.Undeclared {
+		readBy := "Soong"
+		if len(v.ModuleTypes) > 0 {
+			readBy = fmt.Sprintf("%q", v.ModuleTypes)
+		}
+		errors = append(errors, fmt.Sprintf("SOONG_CONFIG_%s_%s is read by %s but is not set",
+			v.Namespace, v.Variable, readBy))
+	}
+	for _, v := range report.InvalidValues {
+		errors = append(errors, fmt.Sprintf(
+			"SOONG_CONFIG_%s_%s is set to %q, which is not one of the values %q declared by %q",
+			v.Namespace, v.Variable, v.Value, v.Values, v.ModuleTypes))
+	}
+	return errors
+}
+
+func init() {
+	RegisterSoongConfigReportBuildComponents(InitRegistrationContext)
+}
+
+// Register the soong_config_report singleton.
+func RegisterSoongConfigReportBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("soong_config_report", soongConfigReportSingletonFactory)
+}
+
+var PrepareForTestWithSoongConfigReport = FixtureRegisterWithContext(RegisterSoongConfigReportBuildComponents)
+
+func soongConfigReportSingletonFactory() Singleton {
+	return &soongConfigReportSingleton{}
+}
+
+type soongConfigReportSingleton struct{}
+
+func (s *soongConfigReportSingleton) GenerateBuildActions(ctx SingletonContext) {
+	readers := soongConfigReadersForConfig(ctx.Config())
+	readers.Lock()
+	report := buildSoongConfigReport(ctx.Config().productVariables.VendorVars, readers.readers,
+		soongConfigMakeOnlyVariablesForConfig(ctx.Config()))
+	readers.Unlock()
+
+	if soongConfigStrict(ctx.Config()) {
+		for _, err := range soongConfigStrictErrors(report) {
+			ctx.Errorf("%s", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal Soong config report: %s", err)
+		return
+	}
+	if err := WriteFileToOutputDir(PathForOutput(ctx, soongConfigReportFile), data, 0666); err != nil {
+		ctx.Errorf("failed to write Soong config report: %s", err)
+	}
+}
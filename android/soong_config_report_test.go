@@ -0,0 +1,177 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var soongConfigReportBp = `
+	soong_config_module_type {
+		name: "acme_test",
+		module_type: "test",
+		config_namespace: "acme",
+		bool_variables: ["feature"],
+		properties: ["cflags"],
+	}
+
+	soong_config_module_type {
+		name: "acme_unused_test",
+		module_type: "test",
+		config_namespace: "acme",
+		variables: ["board"],
+		bool_variables: ["unused_module_type_feature"],
+		properties: ["cflags"],
+	}
+
+	soong_config_string_variable {
+		name: "board",
+		values: ["soc_a", "soc_b"],
+	}
+
+	acme_test {
+		name: "foo",
+	}
+`
+
+func TestSoongConfigReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithDefaults,
+		PrepareForTestWithSoongConfigModuleBuildComponents,
+		PrepareForTestWithSoongConfigReport,
+		prepareForSoongConfigTestModule,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = map[string]map[string]string{
+				"acme": {
+					"board":                      "soc_a",
+					"featrue":                    "true",
+					"unused_module_type_feature": "true",
+				},
+				"other": {
+					"width": "200",
+				},
+			}
+		}),
+		FixtureWithRootAndroidBp(soongConfigReportBp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), soongConfigReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report soongConfigReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	want := soongConfigReport{
+		Unused: []soongConfigReportVariable{
+			{Namespace: "acme", Variable: "featrue", Value: "true"},
+			{Namespace: "other", Variable: "width", Value: "200"},
+		},
+		Undeclared: []soongConfigReportVariable{
+			{Namespace: "acme", Variable: "feature", ModuleTypes: []string{"acme_test"}},
+		},
+		InvalidValues: []soongConfigReportVariable{},
+	}
+	AssertDeepEquals(t, "Soong config report", want, report)
+}
+
+func TestSoongConfigReportGoReads(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithSoongConfigReport,
+		FixtureRegisterWithContext(registerTestPrebuiltBuildComponents),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = map[string]map[string]string{
+				"acme": {
+					"use_source": "true",
+				},
+			}
+		}),
+		FixtureWithRootAndroidBp(`
+			source {
+				name: "foo",
+			}
+
+			prebuilt {
+				name: "foo",
+				use_source_config_var: {config_namespace: "acme", var_name: "use_source"},
+				srcs: ["prebuilt_file"],
+			}
+
+			source {
+				name: "bar",
+			}
+
+			prebuilt {
+				name: "bar",
+				use_source_config_var: {config_namespace: "other", var_name: "use_source"},
+				srcs: ["prebuilt_file"],
+			}
+		`),
+		FixtureAddFile("prebuilt_file", nil),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), soongConfigReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report soongConfigReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	// Variables read by use_source_config_var are neither unused when set nor missed when unset.
+	want := soongConfigReport{
+		Unused: []soongConfigReportVariable{},
+		Undeclared: []soongConfigReportVariable{
+			{Namespace: "other", Variable: "use_source"},
+		},
+		InvalidValues: []soongConfigReportVariable{},
+	}
+	AssertDeepEquals(t, "Soong config report", want, report)
+}
+
+func TestSoongConfigReportStrict(t *testing.T) {
+	GroupFixturePreparers(
+		PrepareForTestWithDefaults,
+		PrepareForTestWithSoongConfigModuleBuildComponents,
+		PrepareForTestWithSoongConfigReport,
+		PrepareForTestWithSoongConfigMakeOnlyVariables(map[string][]string{
+			"acme": {"make_only"},
+		}),
+		prepareForSoongConfigTestModule,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.VendorVars = map[string]map[string]string{
+				"acme": {
+					"board":     "soc_c",
+					"featrue":   "true",
+					"make_only": "true",
+				},
+			}
+		}),
+		FixtureMergeEnv(map[string]string{"SOONG_CONFIG_STRICT": "true"}),
+		FixtureWithRootAndroidBp(soongConfigReportBp),
+	).
+		ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`\QSOONG_CONFIG_acme_featrue is set but is not read by any soong_config_module_type or by Soong\E`,
+			`\QSOONG_CONFIG_acme_feature is read by ["acme_test"] but is not set\E`,
+			`\QSOONG_CONFIG_acme_board is set to "soc_c", which is not one of the values ["soc_a" "soc_b"] declared by ["acme_unused_test"]\E`,
+		})).
+		RunTest(t)
+}
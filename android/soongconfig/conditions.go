@@ -0,0 +1,472 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soongconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/starlark_fmt"
+)
+
+// The soong_config_variables of a module can combine the variables of its module type with
+// all_of, any_of and not, e.g.:
+//
+//	soong_config_variables: {
+//	    all_of: {
+//	        variables: {
+//	            feature: true,
+//	            board: "soc_a",
+//	            api_level: {
+//	                at_least: 30,
+//	            },
+//	        },
+//	        cflags: ["-DFAST_SOC_A"],
+//	        conditions_default: {
+//	            cflags: ["-DSLOW"],
+//	        },
+//	    },
+//	}
+//
+// The properties of all_of are applied when all of the listed variables match, those of any_of
+// when at least one of them matches, and those of not when its single listed variable does not
+// match; conditions_default is applied otherwise. Each of all_of, any_of and not can be used once
+// per module. Each variable of the module type can be listed under variables with:
+//
+//	bool variables     true or false
+//	string variables   one of the values declared by its soong_config_string_variable
+//	value variables    a value
+//	list variables     an element of the list
+//	int variables      a struct with at_least and/or at_most
+//
+// An int variable that is not set does not match.
+
+const (
+	allOf        = "all_of"
+	anyOf        = "any_of"
+	notCondition = "not"
+
+	// conditionVariablesProperty is the property of all_of, any_of and not that lists the
+	// variables.
+	conditionVariablesProperty = "variables"
+)
+
+var conditionOps = []string{allOf, anyOf, notCondition}
+
+// intCondition is the type of an int variable in the variables of all_of, any_of and not.
+type intCondition struct {
+	// the variable matches if it is set to a value greater than or equal to at_least.
+	At_least *int64
+
+	// the variable matches if it is set to a value less than or equal to at_most.
+	At_most *int64
+}
+
+// conditionVariableType returns the type of a variable in the variables of all_of, any_of and not.
+func conditionVariableType(v soongConfigVariable) reflect.Type {
+	switch v.(type) {
+	case *boolVariable:
+		return reflect.TypeOf((*bool)(nil))
+	case *stringVariable, *valueVariable, *listVariable:
+		return reflect.TypeOf((*string)(nil))
+	case *intVariable:
+		return reflect.TypeOf(intCondition{})
+	default:
+		panic(fmt.Errorf("Unsupported variable type: %+v", v))
+	}
+}
+
+// conditionPropertiesType returns the type of all_of, any_of and not: the affectable properties
+// typ, followed by the variables and a conditions_default field. The affectable properties must
+// come first and conditions_default last for conditionAffectableProperties.
+func conditionPropertiesType(variables []soongConfigVariable, typ reflect.Type) reflect.Type {
+	var variableFields []reflect.StructField
+	for _, v := range variables {
+		variableFields = append(variableFields, reflect.StructField{
+			Name: proptools.FieldNameForProperty(v.variableProperty()),
+			Type: conditionVariableType(v),
+		})
+	}
+
+	sTyp := typ.Elem()
+	var fields []reflect.StructField
+	for i := 0; i < sTyp.NumField(); i++ {
+		fields = append(fields, sTyp.Field(i))
+	}
+	fields = append(fields,
+		reflect.StructField{
+			Name: proptools.FieldNameForProperty(conditionVariablesProperty),
+			Type: reflect.StructOf(variableFields),
+		},
+		reflect.StructField{
+			Name: proptools.FieldNameForProperty(conditionsDefault),
+			Type: typ,
+		})
+
+	return reflect.PtrTo(reflect.StructOf(fields))
+}
+
+// conditionsPropertiesToApply returns the properties of the all_of, any_of and not fields of
+// soongConfigVariables to apply to the module.
+func conditionsPropertiesToApply(variables []soongConfigVariable, soongConfigVariables reflect.Value,
+	config SoongConfig) ([]interface{}, error) {
+
+	var ret []interface{}
+	for _, op := range conditionOps {
+		values := soongConfigVariables.FieldByName(proptools.FieldNameForProperty(op))
+		// If the condition was not referenced in the module, there are no properties to apply.
+		if !values.IsValid() || values.IsNil() || values.Elem().IsZero() {
+			continue
+		}
+
+		match, err := evaluateCondition(op, variables, values.Elem().Elem(), config)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			ret = append(ret, conditionAffectableProperties(values).Interface())
+		} else {
+			ret = append(ret, conditionsDefaultField(values.Elem().Elem()).Interface())
+		}
+	}
+	return ret, nil
+}
+
+// conditionAffectableProperties returns the affectable properties of values, the value of all_of,
+// any_of or not, without its variables and conditions_default. Unlike removeDefault, the
+// variables are removed even if conditions_default is not set.
+func conditionAffectableProperties(values reflect.Value) reflect.Value {
+	v := values.Elem().Elem()
+	res := reflect.New(conditionsDefaultField(v).Type().Elem())
+	for i := 0; i < res.Elem().NumField(); i++ {
+		res.Elem().Field(i).Set(v.Field(i))
+	}
+	return res
+}
+
+// evaluateCondition returns whether the variables listed in props, the value of the all_of,
+// any_of or not property op, match config.
+func evaluateCondition(op string, variables []soongConfigVariable, props reflect.Value,
+	config SoongConfig) (bool, error) {
+
+	conditionVariables := props.FieldByName(proptools.FieldNameForProperty(conditionVariablesProperty))
+
+	var matches []bool
+	for i, v := range variables {
+		value := conditionVariables.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		match, err := variableMatches(v, value, config)
+		if err != nil {
+			return false, fmt.Errorf("soong_config_variables.%s.%s.%s: %s",
+				op, conditionVariablesProperty, v.variableProperty(), err)
+		}
+		matches = append(matches, match)
+	}
+
+	if err := checkConditionVariableCount(op, len(matches)); err != nil {
+		return false, err
+	}
+	switch op {
+	case allOf, anyOf:
+		for _, match := range matches {
+			if match == (op == anyOf) {
+				return match, nil
+			}
+		}
+		return op == allOf, nil
+	default:
+		return !matches[0], nil
+	}
+}
+
+// checkConditionVariableCount returns an error if count variables can't be listed in the all_of,
+// any_of or not property op.
+func checkConditionVariableCount(op string, count int) error {
+	switch op {
+	case allOf, anyOf:
+		if count == 0 {
+			return fmt.Errorf("soong_config_variables.%s: at least one variable must be set", op)
+		}
+	case notCondition:
+		if count != 1 {
+			return fmt.Errorf("soong_config_variables.%s: exactly one variable must be set, found %d",
+				op, count)
+		}
+	default:
+		return fmt.Errorf("soong_config_variables.%s: unknown condition", op)
+	}
+	return nil
+}
+
+// variableMatches returns whether the variable v matches value, which is of the type returned by
+// conditionVariableType, in config.
+func variableMatches(v soongConfigVariable, value reflect.Value, config SoongConfig) (bool, error) {
+	switch v := v.(type) {
+	case *boolVariable:
+		return config.Bool(v.variable) == value.Elem().Bool(), nil
+	case *stringVariable:
+		s := value.Elem().String()
+		if !InList(s, v.values) {
+			return false, fmt.Errorf("%q is not one of the values %v", s, v.values)
+		}
+		return config.String(v.variable) == s, nil
+	case *valueVariable:
+		return config.String(v.variable) == value.Elem().String(), nil
+	case *listVariable:
+		return InList(value.Elem().String(), strings.Fields(config.String(v.variable))), nil
+	case *intVariable:
+		c := value.Interface().(intCondition)
+		if !config.IsSet(v.variable) {
+			return false, nil
+		}
+		i, err := intConfigValue(config, v.variable)
+		if err != nil {
+			return false, err
+		}
+		return (c.At_least == nil || i >= *c.At_least) && (c.At_most == nil || i <= *c.At_most), nil
+	default:
+		return false, fmt.Errorf("unsupported variable type %T", v)
+	}
+}
+
+// Bp2buildCondition is an all_of, any_of or not condition of the soong_config_variables of a
+// module, that bp2build generates a config setting for.
+type Bp2buildCondition struct {
+	// all_of, any_of or not.
+	Op string
+
+	// The values of the variables listed by the condition, keyed by <namespace>__<variable>: true
+	// or false for bool variables, the value for string, value and list variables, and
+	// at_least:<n> and/or at_most:<n> for int variables.
+	Variables map[string][]string
+}
+
+// String emits the condition as a Starlark dictionary.
+func (c Bp2buildCondition) String(indentLevel int) string {
+	return starlark_fmt.PrintDict(map[string]string{
+		"op":        fmt.Sprintf("%q", c.Op),
+		"variables": starlark_fmt.PrintStringListDict(c.Variables, indentLevel+1),
+	}, indentLevel)
+}
+
+// AddConditions returns the soong_config_variables properties of a module for bp2build.  Each of
+// its all_of, any_of and not conditions is replaced with a property named after the config
+// setting of the condition, which bp2build converts to a select() like the properties of a bool
+// variable, and the condition is added to defs for bp2build to generate the config setting.
+//
+// For example, the all_of condition above is replaced with:
+//
+//	all_of__api_level_at_least_30__board_soc_a__feature_true: {
+//	    cflags: ["-DFAST_SOC_A"],
+//	    conditions_default: {
+//	        cflags: ["-DSLOW"],
+//	    },
+//	},
+//
+// whose select key is acme__all_of__api_level_at_least_30__board_soc_a__feature_true if the
+// module type is in the acme namespace.
+func (defs *Bp2BuildSoongConfigDefinitions) AddConditions(moduleType *ModuleType,
+	props interface{}) (interface{}, error) {
+
+	soongConfigVariables := reflect.ValueOf(props).Elem().FieldByName(SoongConfigProperty)
+	if err := checkBp2buildListVariables(moduleType.Variables, soongConfigVariables); err != nil {
+		return nil, err
+	}
+
+	var fields []reflect.StructField
+	var values []reflect.Value
+	conditions := make(map[string]Bp2buildCondition)
+	for i := 0; i < soongConfigVariables.NumField(); i++ {
+		field := soongConfigVariables.Type().Field(i)
+		value := soongConfigVariables.Field(i)
+		op := proptools.PropertyNameForField(field.Name)
+		if !InList(op, conditionOps) {
+			fields = append(fields, field)
+			values = append(values, value)
+			continue
+		}
+		// If the condition was not referenced in the module, there is nothing to convert.
+		if value.IsNil() || value.Elem().IsZero() {
+			continue
+		}
+
+		namespace := moduleType.ConfigNamespace
+		variables, err := bp2buildConditionVariables(namespace, moduleType.Variables, op, value.Elem().Elem())
+		if err != nil {
+			return nil, err
+		}
+		property := bp2buildConditionProperty(namespace, op, variables)
+		conditions[strings.ToLower(namespace+"__"+property)] = Bp2buildCondition{Op: op, Variables: variables}
+
+		fields = append(fields, reflect.StructField{
+			Name: proptools.FieldNameForProperty(property),
+			Type: emptyInterfaceType,
+		})
+		values = append(values, conditionWithDefault(value))
+	}
+
+	bp2buildSoongConfigVarsLock.Lock()
+	defer bp2buildSoongConfigVarsLock.Unlock()
+	if defs.Conditions == nil {
+		defs.Conditions = make(map[string]Bp2buildCondition)
+	}
+	for name, condition := range conditions {
+		if existing, ok := defs.Conditions[name]; ok && !reflect.DeepEqual(existing, condition) {
+			return nil, fmt.Errorf("soong_config_variables.%s: conflicts with another condition with the config setting %q",
+				condition.Op, name)
+		}
+		defs.Conditions[name] = condition
+	}
+
+	ret := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: SoongConfigProperty,
+		Type: reflect.StructOf(fields),
+	}}))
+	converted := ret.Elem().Field(0)
+	for i, value := range values {
+		converted.Field(i).Set(value)
+	}
+	return ret.Interface(), nil
+}
+
+// bp2buildConditionVariables returns the Bp2buildCondition.Variables of props, the value of the
+// all_of, any_of or not property op.
+func bp2buildConditionVariables(namespace string, variables []soongConfigVariable, op string,
+	props reflect.Value) (map[string][]string, error) {
+
+	conditionVariables := props.FieldByName(proptools.FieldNameForProperty(conditionVariablesProperty))
+
+	ret := make(map[string][]string)
+	for i, v := range variables {
+		value := conditionVariables.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		var matchers []string
+		switch v := v.(type) {
+		case *boolVariable:
+			matchers = []string{strconv.FormatBool(value.Elem().Bool())}
+		case *stringVariable:
+			s := value.Elem().String()
+			if !InList(s, v.values) {
+				return nil, fmt.Errorf("soong_config_variables.%s.%s.%s: %q is not one of the values %v",
+					op, conditionVariablesProperty, v.variableProperty(), s, v.values)
+			}
+			matchers = []string{s}
+		case *valueVariable, *listVariable:
+			matchers = []string{value.Elem().String()}
+		case *intVariable:
+			c := value.Interface().(intCondition)
+			if c.At_least != nil {
+				matchers = append(matchers, "at_least:"+strconv.FormatInt(*c.At_least, 10))
+			}
+			if c.At_most != nil {
+				matchers = append(matchers, "at_most:"+strconv.FormatInt(*c.At_most, 10))
+			}
+		default:
+			return nil, fmt.Errorf("soong_config_variables.%s.%s.%s: unsupported variable type %T",
+				op, conditionVariablesProperty, v.variableProperty(), v)
+		}
+		ret[namespace+"__"+v.variableProperty()] = matchers
+	}
+
+	if err := checkConditionVariableCount(op, len(ret)); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// bp2buildConditionProperty returns the name of the property that replaces a condition for
+// bp2build, made of op and the variables of the condition.
+func bp2buildConditionProperty(namespace, op string, variables map[string][]string) string {
+	parts := []string{op}
+	for _, key := range sortedStringKeys(variables) {
+		variable := strings.TrimPrefix(key, namespace+"__")
+		parts = append(parts, strings.Join(append([]string{variable}, variables[key]...), "_"))
+	}
+	return strings.ToLower(CanonicalizeToProperty(strings.Join(parts, "__")))
+}
+
+// conditionWithDefault returns the affectable properties and conditions_default of values, the
+// value of all_of, any_of or not, in the layout of the properties of a bool variable.
+func conditionWithDefault(values reflect.Value) reflect.Value {
+	v := values.Elem().Elem()
+	defaultField := conditionsDefaultField(v)
+
+	withDefault := reflect.New(emptyInterfaceType).Elem()
+	initializePropertiesWithDefault(withDefault, defaultField.Type())
+	res := reflect.New(withDefault.Elem().Type().Elem())
+	for i := 0; i < defaultField.Type().Elem().NumField(); i++ {
+		res.Elem().Field(i).Set(v.Field(i))
+	}
+	conditionsDefaultField(res.Elem()).Set(defaultField)
+	return res
+}
+
+// checkBp2buildListVariables returns an error if a list property of a list variable in
+// soongConfigVariables contains %s.  Each element of the list is substituted into it, which
+// select() can't express.
+func checkBp2buildListVariables(variables []soongConfigVariable, soongConfigVariables reflect.Value) error {
+	for i, v := range variables {
+		if _, ok := v.(*listVariable); !ok {
+			continue
+		}
+		value := soongConfigVariables.Field(i)
+		if value.IsNil() || value.Elem().IsZero() {
+			continue
+		}
+		if property := listPropertyWithSubstitution(removeDefault(value).Elem(), ""); property != "" {
+			return fmt.Errorf("soong_config_variables.%s.%s: %%s in the list properties of list variables is not supported by bp2build",
+				v.variableProperty(), property)
+		}
+	}
+	return nil
+}
+
+// listPropertyWithSubstitution returns the name of the first list property in v that contains %s.
+func listPropertyWithSubstitution(v reflect.Value, prefix string) string {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		name := prefix + proptools.PropertyNameForField(v.Type().Field(i).Name)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		switch field.Kind() {
+		case reflect.Struct:
+			if property := listPropertyWithSubstitution(field, name+"."); property != "" {
+				return property
+			}
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				if strings.Contains(field.Index(j).String(), "%s") {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
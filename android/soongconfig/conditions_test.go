@@ -0,0 +1,357 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soongconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+type conditionVariables struct {
+	Feature   *bool
+	Features  *string
+	Api_level intCondition
+	Board     *string
+}
+
+type conditionProps struct {
+	A                  *string
+	B                  bool
+	Variables          conditionVariables
+	Conditions_default *properties
+}
+
+type conditionSoongConfigVars struct {
+	Feature   interface{}
+	Features  interface{}
+	Api_level interface{}
+	Board     interface{}
+	All_of    interface{}
+	Any_of    interface{}
+	Not       interface{}
+}
+
+// conditionModuleType returns a module type whose variables are in the same order as the fields
+// of conditionVariables.
+func conditionModuleType() *ModuleType {
+	mt, _ := newModuleType(&ModuleTypeProperties{
+		Module_type:      "foo",
+		Config_namespace: "bar",
+		Bool_variables:   []string{"feature"},
+		List_variables:   []string{"features"},
+		Int_variables:    []string{"api_level"},
+		Properties:       []string{"a", "b"},
+	})
+	mt.Variables = append(mt.Variables, &stringVariable{
+		baseVariable: baseVariable{"board"},
+		values:       []string{"soc_a", "soc_b"},
+	})
+	return mt
+}
+
+func Test_conditionsPropertiesToApply(t *testing.T) {
+	mt := conditionModuleType()
+
+	allOfProps := &properties{A: proptools.StringPtr("all_of")}
+	anyOfProps := &properties{A: proptools.StringPtr("any_of")}
+	notProps := &properties{B: true}
+	conditionsDefault := &properties{A: proptools.StringPtr("default")}
+
+	props := reflect.ValueOf(conditionSoongConfigVars{
+		All_of: &conditionProps{
+			A: allOfProps.A,
+			Variables: conditionVariables{
+				Feature:   proptools.BoolPtr(true),
+				Board:     proptools.StringPtr("soc_a"),
+				Api_level: intCondition{At_least: proptools.Int64Ptr(30)},
+			},
+			Conditions_default: conditionsDefault,
+		},
+		Any_of: &conditionProps{
+			A: anyOfProps.A,
+			Variables: conditionVariables{
+				Board:    proptools.StringPtr("soc_b"),
+				Features: proptools.StringPtr("c"),
+			},
+		},
+		Not: &conditionProps{
+			B: notProps.B,
+			Variables: conditionVariables{
+				Api_level: intCondition{At_most: proptools.Int64Ptr(29)},
+			},
+		},
+	})
+
+	testCases := []struct {
+		name      string
+		config    SoongConfig
+		wantProps []interface{}
+	}{
+		{
+			name:      "no_vendor_config",
+			config:    Config(map[string]string{}),
+			wantProps: []interface{}{conditionsDefault, (*properties)(nil), notProps},
+		},
+		{
+			name: "partially_true",
+			config: Config(map[string]string{
+				"feature":   "true",
+				"board":     "soc_a",
+				"api_level": "29",
+			}),
+			wantProps: []interface{}{conditionsDefault, (*properties)(nil), (*properties)(nil)},
+		},
+		{
+			name: "all_of",
+			config: Config(map[string]string{
+				"feature":   "true",
+				"board":     "soc_a",
+				"api_level": "30",
+			}),
+			wantProps: []interface{}{allOfProps, (*properties)(nil), notProps},
+		},
+		{
+			name: "any_of_list",
+			config: Config(map[string]string{
+				"board":    "soc_a",
+				"features": "b c",
+			}),
+			wantProps: []interface{}{conditionsDefault, anyOfProps, notProps},
+		},
+	}
+
+	for _, tc := range testCases {
+		gotProps, err := conditionsPropertiesToApply(mt.Variables, props, tc.config)
+		if err != nil {
+			t.Errorf("%s: Unexpected error in conditionsPropertiesToApply: %s", tc.name, err)
+		}
+
+		if !reflect.DeepEqual(gotProps, tc.wantProps) {
+			t.Errorf("%s: Expected %s, got %s", tc.name, tc.wantProps, gotProps)
+		}
+	}
+}
+
+func Test_conditionsPropertiesToApply_Error(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vars     conditionSoongConfigVars
+		expected string
+	}{
+		{
+			name: "undeclared_value",
+			vars: conditionSoongConfigVars{
+				All_of: &conditionProps{
+					Variables: conditionVariables{
+						Board: proptools.StringPtr("soc_c"),
+					},
+				},
+			},
+			expected: `soong_config_variables.all_of.variables.board: "soc_c" is not one of the values [soc_a soc_b]`,
+		},
+		{
+			name: "no_variables",
+			vars: conditionSoongConfigVars{
+				Any_of: &conditionProps{
+					A: proptools.StringPtr("a"),
+				},
+			},
+			expected: `soong_config_variables.any_of: at least one variable must be set`,
+		},
+		{
+			name: "not_two_variables",
+			vars: conditionSoongConfigVars{
+				Not: &conditionProps{
+					Variables: conditionVariables{
+						Feature: proptools.BoolPtr(true),
+						Board:   proptools.StringPtr("soc_a"),
+					},
+				},
+			},
+			expected: `soong_config_variables.not: exactly one variable must be set, found 2`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			props := reflect.ValueOf(tc.vars)
+			_, err := conditionsPropertiesToApply(conditionModuleType().Variables, props, Config(map[string]string{}))
+			if err == nil {
+				t.Fatalf("Expected an error, got nil")
+			} else if err.Error() != tc.expected {
+				t.Fatalf("Error message was not correct, expected %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
+
+func Test_CreateProperties_Conditions(t *testing.T) {
+	mt := conditionModuleType()
+	props := CreateProperties([]interface{}{&struct {
+		A *string
+		B bool
+	}{}}, mt)
+
+	vars := props.Elem().FieldByName(SoongConfigProperty)
+	for _, op := range conditionOps {
+		if _, ok := vars.Type().FieldByName(proptools.FieldNameForProperty(op)); !ok {
+			t.Fatalf("Expected a field for %s in %s", op, vars.Type())
+		}
+	}
+
+	notType := vars.FieldByName("Not").Elem().Type().Elem()
+	for i, name := range []string{"A", "B", "Variables", "Conditions_default"} {
+		if got := notType.Field(i).Name; got != name {
+			t.Errorf("Expected field %d of not to be %s, got %s", i, name, got)
+		}
+	}
+	variablesType := notType.Field(2).Type
+	for i, name := range []string{"Feature", "Features", "Api_level", "Board"} {
+		if got := variablesType.Field(i).Name; got != name {
+			t.Errorf("Expected variable %d to be %s, got %s", i, name, got)
+		}
+	}
+}
+
+func Test_AddConditions(t *testing.T) {
+	conditionsDefault := &properties{A: proptools.StringPtr("default")}
+	props := &struct {
+		Soong_config_variables conditionSoongConfigVars
+	}{
+		Soong_config_variables: conditionSoongConfigVars{
+			Feature: &boolVarProps{A: proptools.StringPtr("feature")},
+			All_of: &conditionProps{
+				A: proptools.StringPtr("all_of"),
+				Variables: conditionVariables{
+					Feature:   proptools.BoolPtr(true),
+					Board:     proptools.StringPtr("soc_a"),
+					Api_level: intCondition{At_least: proptools.Int64Ptr(30)},
+				},
+				Conditions_default: conditionsDefault,
+			},
+			Not: &conditionProps{
+				B: true,
+				Variables: conditionVariables{
+					Features: proptools.StringPtr("c"),
+				},
+			},
+		},
+	}
+
+	defs := &Bp2BuildSoongConfigDefinitions{}
+	got, err := defs.AddConditions(conditionModuleType(), props)
+	if err != nil {
+		t.Fatalf("Unexpected error in AddConditions: %s", err)
+	}
+
+	vars := reflect.ValueOf(got).Elem().FieldByName(SoongConfigProperty)
+	for i, name := range []string{"Feature", "Features", "Api_level", "Board",
+		"All_of__api_level_at_least_30__board_soc_a__feature_true", "Not__features_c"} {
+		if got := vars.Type().Field(i).Name; got != name {
+			t.Errorf("Expected field %d to be %s, got %s", i, name, got)
+		}
+	}
+	if vars.NumField() != 6 {
+		t.Errorf("Expected 6 fields, got %s", vars.Type())
+	}
+	if got := vars.FieldByName("Feature").Interface(); got != props.Soong_config_variables.Feature {
+		t.Errorf("Expected the feature variable to be unchanged, got %#v", got)
+	}
+
+	allOf := vars.FieldByName("All_of__api_level_at_least_30__board_soc_a__feature_true").Elem().Elem()
+	if got := *allOf.FieldByName("A").Interface().(*string); got != "all_of" {
+		t.Errorf("Expected a to be all_of, got %q", got)
+	}
+	if got := allOf.FieldByName("Conditions_default").Interface(); got != conditionsDefault {
+		t.Errorf("Expected conditions_default %#v, got %#v", conditionsDefault, got)
+	}
+	if allOf.FieldByName("Variables").IsValid() {
+		t.Errorf("Expected no variables in %s", allOf.Type())
+	}
+
+	wantConditions := map[string]Bp2buildCondition{
+		"bar__all_of__api_level_at_least_30__board_soc_a__feature_true": {
+			Op: "all_of",
+			Variables: map[string][]string{
+				"bar__feature":   {"true"},
+				"bar__api_level": {"at_least:30"},
+				"bar__board":     {"soc_a"},
+			},
+		},
+		"bar__not__features_c": {
+			Op:        "not",
+			Variables: map[string][]string{"bar__features": {"c"}},
+		},
+	}
+	if !reflect.DeepEqual(defs.Conditions, wantConditions) {
+		t.Errorf("Expected conditions %#v, got %#v", wantConditions, defs.Conditions)
+	}
+}
+
+func Test_AddConditions_Error(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vars     conditionSoongConfigVars
+		expected string
+	}{
+		{
+			name: "undeclared_value",
+			vars: conditionSoongConfigVars{
+				All_of: &conditionProps{
+					Variables: conditionVariables{
+						Board: proptools.StringPtr("soc_c"),
+					},
+				},
+			},
+			expected: `soong_config_variables.all_of.variables.board: "soc_c" is not one of the values [soc_a soc_b]`,
+		},
+		{
+			name: "not_two_variables",
+			vars: conditionSoongConfigVars{
+				Not: &conditionProps{
+					Variables: conditionVariables{
+						Feature: proptools.BoolPtr(true),
+						Board:   proptools.StringPtr("soc_a"),
+					},
+				},
+			},
+			expected: `soong_config_variables.not: exactly one variable must be set, found 2`,
+		},
+		{
+			name: "list_substitution",
+			vars: conditionSoongConfigVars{
+				Features: &listVarProps{A: []string{"-D%s"}},
+			},
+			expected: `soong_config_variables.features.a: %s in the list properties of list variables is not supported by bp2build`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			props := &struct {
+				Soong_config_variables conditionSoongConfigVars
+			}{tc.vars}
+			defs := &Bp2BuildSoongConfigDefinitions{}
+			_, err := defs.AddConditions(conditionModuleType(), props)
+			if err == nil {
+				t.Fatalf("Expected an error, got nil")
+			} else if err.Error() != tc.expected {
+				t.Fatalf("Error message was not correct, expected %q, got %q", tc.expected, err.Error())
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"io"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -120,6 +121,14 @@ type ModuleTypeProperties struct {
 	// inserted into the properties with %s substitution.
 	Value_variables []string
 
+	// the list of SOONG_CONFIG variables that this module type will read as space separated
+	// lists. Each element of the list will be inserted into the properties with %s substitution.
+	List_variables []string
+
+	// the list of integer SOONG_CONFIG variables that this module type will read. The value will
+	// be inserted into the properties with %d or %s substitution.
+	Int_variables []string
+
 	// the list of properties that this module type will extend.
 	Properties []string
 }
@@ -237,12 +246,18 @@ type SoongConfigDefinition struct {
 }
 
 // Bp2BuildSoongConfigDefinition keeps a global record of all soong config
-// string vars, bool vars and value vars created by every
-// soong_config_module_type in this build.
+// string vars, bool vars, value vars, list vars and int vars created by every
+// soong_config_module_type in this build, and of the all_of, any_of and not
+// conditions used by the modules.
 type Bp2BuildSoongConfigDefinitions struct {
 	StringVars map[string]map[string]bool
 	BoolVars   map[string]bool
 	ValueVars  map[string]bool
+	ListVars   map[string]bool
+	IntVars    map[string]bool
+
+	// Conditions are keyed by the select key of the condition, see AddConditions.
+	Conditions map[string]Bp2buildCondition
 }
 
 var bp2buildSoongConfigVarsLock sync.Mutex
@@ -266,6 +281,12 @@ func (defs *Bp2BuildSoongConfigDefinitions) AddVars(mtDef *SoongConfigDefinition
 	if defs.ValueVars == nil {
 		defs.ValueVars = make(map[string]bool)
 	}
+	if defs.ListVars == nil {
+		defs.ListVars = make(map[string]bool)
+	}
+	if defs.IntVars == nil {
+		defs.IntVars = make(map[string]bool)
+	}
 	// varCache contains a cache of string variables namespace + property
 	// The same variable may be used in multiple module types (for example, if need support
 	// for cc_default and java_default), only need to process once
@@ -294,6 +315,10 @@ func (defs *Bp2BuildSoongConfigDefinitions) AddVars(mtDef *SoongConfigDefinition
 				defs.BoolVars[key] = true
 			} else if _, ok := v.(*valueVariable); ok {
 				defs.ValueVars[key] = true
+			} else if _, ok := v.(*listVariable); ok {
+				defs.ListVars[key] = true
+			} else if _, ok := v.(*intVariable); ok {
+				defs.IntVars[key] = true
 			} else {
 				panic(fmt.Errorf("Unsupported variable type: %+v", v))
 			}
@@ -336,6 +361,22 @@ func (defs Bp2BuildSoongConfigDefinitions) String() string {
 
 	ret += "soong_config_string_variables = "
 	ret += starlark_fmt.PrintStringListDict(stringVars, 0)
+	ret += "\n\n"
+
+	ret += "soong_config_list_variables = "
+	ret += starlark_fmt.PrintBoolDict(defs.ListVars, 0)
+	ret += "\n\n"
+
+	ret += "soong_config_int_variables = "
+	ret += starlark_fmt.PrintBoolDict(defs.IntVars, 0)
+	ret += "\n\n"
+
+	conditions := make(map[string]string, len(defs.Conditions))
+	for k, v := range defs.Conditions {
+		conditions[k] = v.String(1)
+	}
+	ret += "soong_config_conditions = "
+	ret += starlark_fmt.PrintDict(conditions, 0)
 
 	return ret
 }
@@ -382,6 +423,16 @@ func CreateProperties(factoryProps []interface{}, moduleType *ModuleType) reflec
 		})
 	}
 
+	// all_of, any_of and not follow the variables, see conditions.go.
+	if len(moduleType.Variables) > 0 {
+		for _, op := range conditionOps {
+			fields = append(fields, reflect.StructField{
+				Name: proptools.FieldNameForProperty(op),
+				Type: emptyInterfaceType,
+			})
+		}
+	}
+
 	typ := reflect.StructOf([]reflect.StructField{{
 		Name: SoongConfigProperty,
 		Type: reflect.StructOf(fields),
@@ -393,6 +444,12 @@ func CreateProperties(factoryProps []interface{}, moduleType *ModuleType) reflec
 	for i, c := range moduleType.Variables {
 		c.initializeProperties(structConditions.Field(i), affectablePropertiesType)
 	}
+	if len(moduleType.Variables) > 0 {
+		conditionType := conditionPropertiesType(moduleType.Variables, affectablePropertiesType)
+		for _, op := range conditionOps {
+			structConditions.FieldByName(proptools.FieldNameForProperty(op)).Set(reflect.Zero(conditionType))
+		}
+	}
 
 	return props
 }
@@ -535,7 +592,11 @@ func PropertiesToApply(moduleType *ModuleType, props reflect.Value, config Soong
 			ret = append(ret, ps)
 		}
 	}
-	return ret, nil
+	ps, err := conditionsPropertiesToApply(moduleType.Variables, props, config)
+	if err != nil {
+		return nil, err
+	}
+	return append(ret, ps...), nil
 }
 
 type ModuleType struct {
@@ -547,6 +608,21 @@ type ModuleType struct {
 	variableNames        []string
 }
 
+// ReadVariables returns the names of the Soong config variables that the module type reads. Each
+// name is mapped to the values declared for a string variable, or to nil for the other variables.
+func (m *ModuleType) ReadVariables() map[string][]string {
+	ret := make(map[string][]string)
+	for _, v := range m.Variables {
+		switch v := v.(type) {
+		case *stringVariable:
+			ret[v.variable] = v.values
+		case interface{ variableName() string }:
+			ret[v.variableName()] = nil
+		}
+	}
+	return ret
+}
+
 func newModuleType(props *ModuleTypeProperties) (*ModuleType, []error) {
 	mt := &ModuleType{
 		affectableProperties: props.Properties,
@@ -555,6 +631,12 @@ func newModuleType(props *ModuleTypeProperties) (*ModuleType, []error) {
 		variableNames:        props.Variables,
 	}
 
+	for _, property := range props.Properties {
+		if strings.SplitN(property, ".", 2)[0] == conditionVariablesProperty {
+			return nil, []error{fmt.Errorf("properties %q is reserved", conditionVariablesProperty)}
+		}
+	}
+
 	for _, name := range props.Bool_variables {
 		if err := checkVariableName(name); err != nil {
 			return nil, []error{fmt.Errorf("bool_variables %s", err)}
@@ -575,14 +657,38 @@ func newModuleType(props *ModuleTypeProperties) (*ModuleType, []error) {
 		})
 	}
 
+	for _, name := range props.List_variables {
+		if err := checkVariableName(name); err != nil {
+			return nil, []error{fmt.Errorf("list_variables %s", err)}
+		}
+
+		mt.Variables = append(mt.Variables, &listVariable{
+			baseVariable: baseVariable{
+				variable: name,
+			},
+		})
+	}
+
+	for _, name := range props.Int_variables {
+		if err := checkVariableName(name); err != nil {
+			return nil, []error{fmt.Errorf("int_variables %s", err)}
+		}
+
+		mt.Variables = append(mt.Variables, &intVariable{
+			baseVariable: baseVariable{
+				variable: name,
+			},
+		})
+	}
+
 	return mt, nil
 }
 
 func checkVariableName(name string) error {
 	if name == "" {
 		return fmt.Errorf("name must not be blank")
-	} else if name == conditionsDefault {
-		return fmt.Errorf("%q is reserved", conditionsDefault)
+	} else if name == conditionsDefault || InList(name, conditionOps) {
+		return fmt.Errorf("%q is reserved", name)
 	}
 	return nil
 }
@@ -612,6 +718,11 @@ func (c *baseVariable) variableProperty() string {
 	return CanonicalizeToProperty(c.variable)
 }
 
+// variableName returns the name of the variable as it is set in the product config.
+func (c *baseVariable) variableName() string {
+	return c.variable
+}
+
 type stringVariable struct {
 	baseVariable
 	values []string
@@ -783,7 +894,22 @@ func (s *valueVariable) PropertiesToApply(config SoongConfig, values reflect.Val
 	}
 	configValue := config.String(s.variable)
 
-	values = removeDefault(values)
+	return substituteIntoProperties(s.variable, removeDefault(values), func(field reflect.Value) error {
+		if field.Kind() == reflect.String {
+			return printfIntoProperty(field, configValue)
+		}
+		for j := 0; j < field.Len(); j++ {
+			if err := printfIntoProperty(field.Index(j), configValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// substituteIntoProperties calls substitute for each string and list of strings property set in
+// values, which was returned by removeDefault, and returns values.
+func substituteIntoProperties(variable string, values reflect.Value, substitute func(field reflect.Value) error) (interface{}, error) {
 	propStruct := values.Elem()
 	if !propStruct.IsValid() {
 		return nil, nil
@@ -796,30 +922,165 @@ func (s *valueVariable) PropertiesToApply(config SoongConfig, values reflect.Val
 				continue
 			}
 			field = field.Elem()
+			kind = field.Kind()
 		}
 		switch kind {
-		case reflect.String:
-			err := printfIntoProperty(field, configValue)
-			if err != nil {
-				return nil, fmt.Errorf("soong_config_variables.%s.%s: %s", s.variable, propStruct.Type().Field(i).Name, err)
-			}
-		case reflect.Slice:
-			for j := 0; j < field.Len(); j++ {
-				err := printfIntoProperty(field.Index(j), configValue)
-				if err != nil {
-					return nil, fmt.Errorf("soong_config_variables.%s.%s: %s", s.variable, propStruct.Type().Field(i).Name, err)
-				}
+		case reflect.String, reflect.Slice:
+			if err := substitute(field); err != nil {
+				return nil, fmt.Errorf("soong_config_variables.%s.%s: %s", variable, propStruct.Type().Field(i).Name, err)
 			}
 		case reflect.Bool:
 			// Nothing to do
 		default:
-			return nil, fmt.Errorf("soong_config_variables.%s.%s: unsupported property type %q", s.variable, propStruct.Type().Field(i).Name, kind)
+			return nil, fmt.Errorf("soong_config_variables.%s.%s: unsupported property type %q", variable, propStruct.Type().Field(i).Name, kind)
 		}
 	}
 
 	return values.Interface(), nil
 }
 
+// Struct to allow conditions set based on a list variable, whose value is a space separated list
+// of elements. Each string in a list property that contains %s is replaced with one string for
+// each element of the list, and %s in other string properties is replaced with the whole list.
+type listVariable struct {
+	baseVariable
+}
+
+func (l *listVariable) variableValuesType() reflect.Type {
+	return emptyInterfaceType
+}
+
+// initializeProperties initializes a property to zero value of typ with an additional conditions
+// default field.
+func (l *listVariable) initializeProperties(v reflect.Value, typ reflect.Type) {
+	initializePropertiesWithDefault(v, typ)
+}
+
+// PropertiesToApply returns an interface{} value based on initializeProperties to be applied to
+// the module. If the variable was not set or is empty, conditions_default interface will be
+// returned; otherwise, the interface in values, without conditions_default will be returned with
+// all appropriate string substitutions based on the elements of the list.
+func (l *listVariable) PropertiesToApply(config SoongConfig, values reflect.Value) (interface{}, error) {
+	// If this variable was not referenced in the module, there are no properties to apply.
+	if !values.IsValid() || values.Elem().IsZero() {
+		return nil, nil
+	}
+	elements := strings.Fields(config.String(l.variable))
+	if len(elements) == 0 {
+		return conditionsDefaultField(values.Elem().Elem()).Interface(), nil
+	}
+
+	return substituteIntoProperties(l.variable, removeDefault(values), func(field reflect.Value) error {
+		if field.Kind() == reflect.String {
+			return printfIntoProperty(field, strings.Join(elements, " "))
+		}
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported property type %q", field.Type())
+		}
+		var expanded []string
+		for j := 0; j < field.Len(); j++ {
+			s := field.Index(j).String()
+			if !strings.Contains(s, "%") {
+				expanded = append(expanded, s)
+				continue
+			}
+			for _, element := range elements {
+				v := reflect.New(field.Type().Elem()).Elem()
+				v.SetString(s)
+				if err := printfIntoProperty(v, element); err != nil {
+					return err
+				}
+				expanded = append(expanded, v.String())
+			}
+		}
+		field.Set(reflect.ValueOf(expanded))
+		return nil
+	})
+}
+
+// Struct to allow conditions set based on an integer variable, supporting string substitution.
+type intVariable struct {
+	baseVariable
+}
+
+func (n *intVariable) variableValuesType() reflect.Type {
+	return emptyInterfaceType
+}
+
+// initializeProperties initializes a property to zero value of typ with an additional conditions
+// default field.
+func (n *intVariable) initializeProperties(v reflect.Value, typ reflect.Type) {
+	initializePropertiesWithDefault(v, typ)
+}
+
+// PropertiesToApply returns an interface{} value based on initializeProperties to be applied to
+// the module. If the variable was not set, conditions_default interface will be returned;
+// otherwise, the interface in values, without conditions_default will be returned with all
+// appropriate %d and %s substitutions. It is an error for the variable to be set to a value that
+// is not an integer.
+func (n *intVariable) PropertiesToApply(config SoongConfig, values reflect.Value) (interface{}, error) {
+	// If this variable was not referenced in the module, there are no properties to apply.
+	if !values.IsValid() || values.Elem().IsZero() {
+		return nil, nil
+	}
+	if !config.IsSet(n.variable) {
+		return conditionsDefaultField(values.Elem().Elem()).Interface(), nil
+	}
+	configValue, err := intConfigValue(config, n.variable)
+	if err != nil {
+		return nil, err
+	}
+
+	return substituteIntoProperties(n.variable, removeDefault(values), func(field reflect.Value) error {
+		if field.Kind() == reflect.String {
+			return printfIntIntoProperty(field, configValue)
+		}
+		for j := 0; j < field.Len(); j++ {
+			if err := printfIntIntoProperty(field.Index(j), configValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// intConfigValue returns the value of an integer variable, or an error if it is not an integer.
+func intConfigValue(config SoongConfig, variable string) (int64, error) {
+	value := config.String(variable)
+	i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Soong config property %q must be an integer, found %q", variable, value)
+	}
+	return i, nil
+}
+
+// printfIntIntoProperty substitutes configValue into a property with a single %d or %s. A literal
+// '%' can be written as "%%".
+func printfIntIntoProperty(propertyValue reflect.Value, configValue int64) error {
+	s := propertyValue.String()
+	if !strings.Contains(s, "%") {
+		return nil
+	}
+
+	verbs := strings.ReplaceAll(s, "%%", "")
+	switch {
+	case strings.Count(verbs, "%") > 1:
+		return fmt.Errorf("int variable properties only support a single %%d or %%s")
+	case strings.Contains(verbs, "%d"):
+		s = fmt.Sprintf(s, configValue)
+	case strings.Contains(verbs, "%s"):
+		s = fmt.Sprintf(s, strconv.FormatInt(configValue, 10))
+	case strings.Contains(verbs, "%"):
+		return fmt.Errorf("unsupported %% in int variable property")
+	default:
+		s = strings.ReplaceAll(s, "%%", "%")
+	}
+
+	propertyValue.Set(reflect.ValueOf(s))
+
+	return nil
+}
+
 func printfIntoProperty(propertyValue reflect.Value, configValue string) error {
 	s := propertyValue.String()
 
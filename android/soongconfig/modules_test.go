@@ -414,6 +414,208 @@ func Test_PropertiesToApply_String_Error(t *testing.T) {
 	}
 }
 
+type listProperties struct {
+	A []string
+	B *string
+}
+
+type listVarProps struct {
+	A                  []string
+	B                  *string
+	Conditions_default *listProperties
+}
+
+type listSoongConfigVars struct {
+	List_var interface{}
+}
+
+func Test_PropertiesToApply_ListVariable(t *testing.T) {
+	mt, _ := newModuleType(&ModuleTypeProperties{
+		Module_type:      "foo",
+		Config_namespace: "bar",
+		List_variables:   []string{"list_var"},
+		Properties:       []string{"a", "b"},
+	})
+	conditionsDefault := &listProperties{
+		A: []string{"default"},
+	}
+	props := func() reflect.Value {
+		return reflect.ValueOf(&struct {
+			Soong_config_variables listSoongConfigVars
+		}{
+			Soong_config_variables: listSoongConfigVars{
+				List_var: &listVarProps{
+					A:                  []string{"-DFOO", "-DFEATURE_%s"},
+					B:                  proptools.StringPtr("features: %s"),
+					Conditions_default: conditionsDefault,
+				},
+			},
+		})
+	}
+
+	testCases := []struct {
+		name      string
+		config    SoongConfig
+		wantProps []interface{}
+	}{
+		{
+			name:      "no_vendor_config",
+			config:    Config(map[string]string{}),
+			wantProps: []interface{}{conditionsDefault},
+		},
+		{
+			name:      "empty_list",
+			config:    Config(map[string]string{"list_var": " "}),
+			wantProps: []interface{}{conditionsDefault},
+		},
+		{
+			name:   "list",
+			config: Config(map[string]string{"list_var": "a  b"}),
+			wantProps: []interface{}{&listProperties{
+				A: []string{"-DFOO", "-DFEATURE_a", "-DFEATURE_b"},
+				B: proptools.StringPtr("features: a b"),
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		gotProps, err := PropertiesToApply(mt, props(), tc.config)
+		if err != nil {
+			t.Errorf("%s: Unexpected error in PropertiesToApply: %s", tc.name, err)
+		}
+
+		if !reflect.DeepEqual(gotProps, tc.wantProps) {
+			t.Errorf("%s: Expected %v, got %v", tc.name, tc.wantProps, gotProps)
+		}
+	}
+}
+
+type intSoongConfigVars struct {
+	Int_var interface{}
+}
+
+func Test_PropertiesToApply_IntVariable(t *testing.T) {
+	mt, _ := newModuleType(&ModuleTypeProperties{
+		Module_type:      "foo",
+		Config_namespace: "bar",
+		Int_variables:    []string{"int_var"},
+		Properties:       []string{"a", "b"},
+	})
+	conditionsDefault := &listProperties{
+		A: []string{"default"},
+	}
+	props := func() reflect.Value {
+		return reflect.ValueOf(&struct {
+			Soong_config_variables intSoongConfigVars
+		}{
+			Soong_config_variables: intSoongConfigVars{
+				Int_var: &listVarProps{
+					A:                  []string{"-DAPI=%d"},
+					B:                  proptools.StringPtr("%s"),
+					Conditions_default: conditionsDefault,
+				},
+			},
+		})
+	}
+
+	gotProps, err := PropertiesToApply(mt, props(), Config(map[string]string{}))
+	if err != nil {
+		t.Errorf("Unexpected error in PropertiesToApply: %s", err)
+	} else if want := []interface{}{conditionsDefault}; !reflect.DeepEqual(gotProps, want) {
+		t.Errorf("Expected %v, got %v", want, gotProps)
+	}
+
+	gotProps, err = PropertiesToApply(mt, props(), Config(map[string]string{"int_var": "31"}))
+	want := []interface{}{&listProperties{
+		A: []string{"-DAPI=31"},
+		B: proptools.StringPtr("31"),
+	}}
+	if err != nil {
+		t.Errorf("Unexpected error in PropertiesToApply: %s", err)
+	} else if !reflect.DeepEqual(gotProps, want) {
+		t.Errorf("Expected %v, got %v", want, gotProps)
+	}
+
+	_, err = PropertiesToApply(mt, props(), Config(map[string]string{"int_var": "thirty"}))
+	expected := `Soong config property "int_var" must be an integer, found "thirty"`
+	if err == nil {
+		t.Fatalf("Expected an error, got nil")
+	} else if err.Error() != expected {
+		t.Fatalf("Error message was not correct, expected %q, got %q", expected, err.Error())
+	}
+}
+
+func Test_PropertiesToApply_IntVariable_Printf(t *testing.T) {
+	mt, _ := newModuleType(&ModuleTypeProperties{
+		Module_type:      "foo",
+		Config_namespace: "bar",
+		Int_variables:    []string{"int_var"},
+		Properties:       []string{"a", "b"},
+	})
+	props := func(a []string) reflect.Value {
+		return reflect.ValueOf(&struct {
+			Soong_config_variables intSoongConfigVars
+		}{
+			Soong_config_variables: intSoongConfigVars{
+				Int_var: &listVarProps{
+					A:                  a,
+					Conditions_default: &listProperties{},
+				},
+			},
+		})
+	}
+	config := Config(map[string]string{"int_var": "7"})
+
+	gotProps, err := PropertiesToApply(mt, props([]string{"-DPERCENT=%d%%", "%%d", "100%%"}), config)
+	want := []interface{}{&listProperties{
+		A: []string{"-DPERCENT=7%", "%d", "100%"},
+	}}
+	if err != nil {
+		t.Errorf("Unexpected error in PropertiesToApply: %s", err)
+	} else if !reflect.DeepEqual(gotProps, want) {
+		t.Errorf("Expected %v, got %v", want, gotProps)
+	}
+
+	errorCases := map[string]string{
+		"%d %s": "soong_config_variables.int_var.A: int variable properties only support a single %d or %s",
+		"%x":    "soong_config_variables.int_var.A: unsupported % in int variable property",
+	}
+	for s, expected := range errorCases {
+		_, err := PropertiesToApply(mt, props([]string{s}), config)
+		if err == nil {
+			t.Errorf("%q: Expected an error, got nil", s)
+		} else if err.Error() != expected {
+			t.Errorf("%q: Error message was not correct, expected %q, got %q", s, expected, err.Error())
+		}
+	}
+}
+
+func Test_ReadVariables(t *testing.T) {
+	mt, _ := newModuleType(&ModuleTypeProperties{
+		Module_type:      "foo",
+		Config_namespace: "bar",
+		Bool_variables:   []string{"bool_var"},
+		Value_variables:  []string{"value_var"},
+		List_variables:   []string{"list_var"},
+		Int_variables:    []string{"int_var"},
+	})
+	mt.Variables = append(mt.Variables, &stringVariable{
+		baseVariable: baseVariable{"string_var"},
+		values:       []string{"a", "b"},
+	})
+
+	want := map[string][]string{
+		"bool_var":   nil,
+		"value_var":  nil,
+		"list_var":   nil,
+		"int_var":    nil,
+		"string_var": []string{"a", "b"},
+	}
+	if got := mt.ReadVariables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
 func Test_Bp2BuildSoongConfigDefinitionsAddVars(t *testing.T) {
 	testCases := []struct {
 		desc     string
@@ -458,6 +660,8 @@ func Test_Bp2BuildSoongConfigDefinitionsAddVars(t *testing.T) {
 				},
 				BoolVars:  map[string]bool{"foo__bool_var": true},
 				ValueVars: map[string]bool{"foo__variable_var": true},
+				ListVars:  map[string]bool{},
+				IntVars:   map[string]bool{},
 			},
 		},
 		{
@@ -498,6 +702,32 @@ func Test_Bp2BuildSoongConfigDefinitionsAddVars(t *testing.T) {
 				},
 				BoolVars:  map[string]bool{"foo__bool_var": true},
 				ValueVars: map[string]bool{"foo__variable_var": true},
+				ListVars:  map[string]bool{},
+				IntVars:   map[string]bool{},
+			},
+		},
+		{
+			desc: "list and int",
+			defs: []*SoongConfigDefinition{
+				&SoongConfigDefinition{
+					ModuleTypes: map[string]*ModuleType{
+						"a": &ModuleType{
+							ConfigNamespace: "foo",
+							Variables: []soongConfigVariable{
+								newBoolVariable("bool_var"),
+								&listVariable{baseVariable: baseVariable{"list_var"}},
+								&intVariable{baseVariable: baseVariable{"int_var"}},
+							},
+						},
+					},
+				},
+			},
+			expected: Bp2BuildSoongConfigDefinitions{
+				StringVars: map[string]map[string]bool{},
+				BoolVars:   map[string]bool{"foo__bool_var": true},
+				ValueVars:  map[string]bool{},
+				ListVars:   map[string]bool{"foo__list_var": true},
+				IntVars:    map[string]bool{"foo__int_var": true},
 			},
 		},
 	}
@@ -531,7 +761,13 @@ func Test_Bp2BuildSoongConfigDefinitions(t *testing.T) {
 
 soong_config_value_variables = {}
 
-soong_config_string_variables = {}`}, {
+soong_config_string_variables = {}
+
+soong_config_list_variables = {}
+
+soong_config_int_variables = {}
+
+soong_config_conditions = {}`}, {
 			desc: "only bool",
 			defs: Bp2BuildSoongConfigDefinitions{
 				BoolVars: map[string]bool{
@@ -544,7 +780,13 @@ soong_config_string_variables = {}`}, {
 
 soong_config_value_variables = {}
 
-soong_config_string_variables = {}`}, {
+soong_config_string_variables = {}
+
+soong_config_list_variables = {}
+
+soong_config_int_variables = {}
+
+soong_config_conditions = {}`}, {
 			desc: "only value vars",
 			defs: Bp2BuildSoongConfigDefinitions{
 				ValueVars: map[string]bool{
@@ -557,7 +799,13 @@ soong_config_value_variables = {
     "value_var": True,
 }
 
-soong_config_string_variables = {}`}, {
+soong_config_string_variables = {}
+
+soong_config_list_variables = {}
+
+soong_config_int_variables = {}
+
+soong_config_conditions = {}`}, {
 			desc: "only string vars",
 			defs: Bp2BuildSoongConfigDefinitions{
 				StringVars: map[string]map[string]bool{
@@ -578,7 +826,13 @@ soong_config_string_variables = {
         "choice2",
         "choice3",
     ],
-}`}, {
+}
+
+soong_config_list_variables = {}
+
+soong_config_int_variables = {}
+
+soong_config_conditions = {}`}, {
 			desc: "all vars",
 			defs: Bp2BuildSoongConfigDefinitions{
 				BoolVars: map[string]bool{
@@ -619,6 +873,54 @@ soong_config_string_variables = {
         "bar",
         "foo",
     ],
+}
+
+soong_config_list_variables = {}
+
+soong_config_int_variables = {}
+
+soong_config_conditions = {}`},
+		{
+			desc: "list and int vars and conditions",
+			defs: Bp2BuildSoongConfigDefinitions{
+				ListVars: map[string]bool{
+					"list_var": true,
+				},
+				IntVars: map[string]bool{
+					"int_var": true,
+				},
+				Conditions: map[string]Bp2buildCondition{
+					"ns__all_of__int_var_at_least_30__list_var_a": {
+						Op: "all_of",
+						Variables: map[string][]string{
+							"ns__int_var":  {"at_least:30"},
+							"ns__list_var": {"a"},
+						},
+					},
+				},
+			},
+			expected: `soong_config_bool_variables = {}
+
+soong_config_value_variables = {}
+
+soong_config_string_variables = {}
+
+soong_config_list_variables = {
+    "list_var": True,
+}
+
+soong_config_int_variables = {
+    "int_var": True,
+}
+
+soong_config_conditions = {
+    "ns__all_of__int_var_at_least_30__list_var_a": {
+        "op": "all_of",
+        "variables": {
+            "ns__int_var": ["at_least:30"],
+            "ns__list_var": ["a"],
+        },
+    },
 }`},
 	}
 	for _, test := range testCases {
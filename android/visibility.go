@@ -233,6 +233,7 @@ func RegisterVisibilityRuleGatherer(ctx RegisterMutatorsContext) {
 // This must be registered after the deps have been resolved.
 func RegisterVisibilityRuleEnforcer(ctx RegisterMutatorsContext) {
 	ctx.TopDown("visibilityRuleEnforcer", visibilityRuleEnforcer).Parallel()
+	registerPolicyViolationErrorsMutator(ctx, "visibilityRuleEnforcer")
 }
 
 // Checks the per-module visibility rule lists before defaults expansion.
@@ -324,6 +325,12 @@ func visibilityRuleGatherer(ctx BottomUpMutatorContext) {
 			}
 		}
 	}
+
+	if severity := m.base().commonProperties.Visibility_severity; severity != nil {
+		if _, err := ParseSeverity(*severity); err != nil {
+			ctx.PropertyErrorf("visibility_severity", "%s", err)
+		}
+	}
 }
 
 func parseRules(ctx BaseModuleContext, currentPkg, property string, visibility []string) compositeRule {
@@ -457,13 +464,33 @@ func visibilityRuleEnforcer(ctx TopDownMutatorContext) {
 			return
 		}
 
+		recordVisibilityConsumer(ctx.Config(), depQualified, qualified.pkg)
+
 		rule := effectiveVisibilityRules(ctx.Config(), depQualified)
 		if !rule.matches(qualified) {
-			ctx.ModuleErrorf("depends on %s which is not visible to this module\nYou may need to add %q to its visibility", depQualified, "//"+ctx.ModuleDir())
+			reportPolicyViolation(ctx, visibilitySeverity(dep), PolicyViolation{
+				Kind:   PolicyViolationVisibility,
+				RuleId: "visibility",
+				Rule:   fmt.Sprintf("%s is visible to %s", depQualified, strings.Join(rule.Strings(), ", ")),
+				Reason: "modules may only depend on modules that are visible to them",
+				Message: fmt.Sprintf("depends on %s which is not visible to this module\nYou may need to add %q to its visibility",
+					depQualified, "//"+ctx.ModuleDir()),
+			})
 		}
 	})
 }
 
+// visibilitySeverity returns how dependencies on the module that are not allowed by its
+// visibility are reported. Invalid values are reported by visibilityRuleGatherer.
+func visibilitySeverity(module Module) Severity {
+	if s := module.base().commonProperties.Visibility_severity; s != nil {
+		if severity, err := ParseSeverity(*s); err == nil {
+			return severity
+		}
+	}
+	return SeverityError
+}
+
 // Default visibility is public.
 var defaultVisibility = compositeRule{publicRule{}}
 
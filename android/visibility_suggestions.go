@@ -0,0 +1,238 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// Visibility suggestions are the most restrictive visibility rules that each module could have
+// without breaking the build, computed from the dependencies that are checked against the
+// visibility rules. They help to tighten the visibility of the many modules that are
+// //visibility:public only because nobody knew which modules use them.
+//
+// The suggestions are computed when SOONG_SUGGEST_VISIBILITY=true and written to
+// out/soong/visibility_suggestions.json, which can be applied to the Android.bp files with
+// bpfix -visibility_suggestions. They only cover the modules whose visibility is public or
+// includes a __subpackages__ rule, and only reflect the dependencies of the current product, so
+// they should be checked against the other products that build the modules before they are
+// applied.
+
+const visibilitySuggestionsFile = "visibility_suggestions.json"
+
+func suggestVisibilityEnabled(config Config) bool {
+	return config.IsEnvTrue("SOONG_SUGGEST_VISIBILITY")
+}
+
+// visibilityConsumers maps each module to the packages of the modules that depend on it from
+// other packages.
+type visibilityConsumers struct {
+	sync.Mutex
+	consumers map[qualifiedModuleName]map[string]bool
+}
+
+var visibilityConsumersKey = NewOnceKey("visibilityConsumers")
+
+func visibilityConsumersForConfig(config Config) *visibilityConsumers {
+	return config.Once(visibilityConsumersKey, func() interface{} {
+		return &visibilityConsumers{consumers: make(map[qualifiedModuleName]map[string]bool)}
+	}).(*visibilityConsumers)
+}
+
+// recordVisibilityConsumer records that a module in the consumer package uses the module, if
+// visibility suggestions are enabled. It is called wherever a use of a module is checked
+// against its visibility rules.
+func recordVisibilityConsumer(config Config, module qualifiedModuleName, consumer string) {
+	if !suggestVisibilityEnabled(config) {
+		return
+	}
+
+	c := visibilityConsumersForConfig(config)
+	c.Lock()
+	defer c.Unlock()
+	if c.consumers[module] == nil {
+		c.consumers[module] = make(map[string]bool)
+	}
+	c.consumers[module][consumer] = true
+}
+
+// suggestVisibility returns the most restrictive visibility rules that allow a module in pkg to
+// be used by modules in the consumer packages. A consumer package that contains other consumer
+// packages is given a __subpackages__ rule that covers them instead of a rule for each of them.
+func suggestVisibility(pkg string, consumers []string) compositeRule {
+	outsideVendor := !isAncestor("vendor", pkg)
+
+	var packages []string
+	vendor := false
+	for _, c := range consumers {
+		if c == pkg {
+			continue
+		}
+		if outsideVendor && isAncestor("vendor", c) {
+			// Packages outside //vendor can only make themselves visible to all of it.
+			vendor = true
+			continue
+		}
+		packages = append(packages, c)
+	}
+	packages = SortedUniqueStrings(packages)
+
+	var rules compositeRule
+	for _, p := range packages {
+		covered, containsOthers := false, false
+		for _, other := range packages {
+			if other == p {
+				continue
+			}
+			covered = covered || isAncestor(other, p)
+			containsOthers = containsOthers || isAncestor(p, other)
+		}
+		switch {
+		case covered:
+		case containsOthers:
+			rules = append(rules, subpackagesRule{p})
+		default:
+			rules = append(rules, packageRule{p})
+		}
+	}
+	if vendor {
+		rules = append(rules, subpackagesRule{"vendor"})
+	}
+
+	if len(rules) == 0 {
+		return compositeRule{privateRule{}}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].String() < rules[j].String() })
+	return rules
+}
+
+// isBroadVisibility returns true if the rules include //visibility:public or a __subpackages__
+// rule, the rules that visibility suggestions try to replace.
+func isBroadVisibility(rules compositeRule) bool {
+	for _, r := range rules {
+		switch r.(type) {
+		case publicRule, subpackagesRule:
+			return true
+		}
+	}
+	return false
+}
+
+// visibilitySuggestion is an entry of the visibility suggestions file.
+type visibilitySuggestion struct {
+	Module     string `json:"module"`
+	ModuleType string `json:"module_type"`
+	File       string `json:"file"`
+
+	// The name of the property that sets the visibility of the module.
+	Property string `json:"property"`
+
+	// The effective visibility rules of the module and the suggested rules.
+	Current   []string `json:"current"`
+	Suggested []string `json:"suggested"`
+
+	// The packages of the modules that use the module, outside of its own package.
+	Consumers []string `json:"consumers"`
+}
+
+func init() {
+	RegisterVisibilitySuggestionsBuildComponents(InitRegistrationContext)
+}
+
+// Register the visibility_suggestions singleton.
+func RegisterVisibilitySuggestionsBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("visibility_suggestions", visibilitySuggestionsSingletonFactory)
+}
+
+var PrepareForTestWithVisibilitySuggestions = FixtureRegisterWithContext(RegisterVisibilitySuggestionsBuildComponents)
+
+func visibilitySuggestionsSingletonFactory() Singleton {
+	return &visibilitySuggestionsSingleton{}
+}
+
+// visibilitySuggestionsSingleton writes the visibility suggestions. It must be registered after
+// the singletons that resolve references to modules with ModuleVariantsFromName so that their
+// uses are recorded.
+type visibilitySuggestionsSingleton struct{}
+
+func (s *visibilitySuggestionsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !suggestVisibilityEnabled(ctx.Config()) {
+		return
+	}
+
+	consumers := visibilityConsumersForConfig(ctx.Config())
+	consumers.Lock()
+	defer consumers.Unlock()
+
+	suggestions := []visibilitySuggestion{}
+	seen := make(map[qualifiedModuleName]bool)
+	ctx.VisitAllModules(func(m Module) {
+		if _, ok := m.(Defaults); ok {
+			return
+		}
+		property := m.base().primaryVisibilityProperty
+		if property == nil {
+			return
+		}
+
+		qualified := createQualifiedModuleName(ctx.ModuleName(m), ctx.ModuleDir(m))
+		if seen[qualified] {
+			return
+		}
+		seen[qualified] = true
+
+		current := effectiveVisibilityRules(ctx.Config(), qualified)
+		if !isBroadVisibility(current) {
+			return
+		}
+
+		packages := SortedKeys(consumers.consumers[qualified])
+		suggested := suggestVisibility(qualified.pkg, packages)
+		if current.String() == suggested.String() {
+			return
+		}
+
+		if packages == nil {
+			packages = []string{}
+		}
+		suggestions = append(suggestions, visibilitySuggestion{
+			Module:     qualified.name,
+			ModuleType: ctx.ModuleType(m),
+			File:       ctx.BlueprintFile(m),
+			Property:   property.getName(),
+			Current:    current.Strings(),
+			Suggested:  suggested.Strings(),
+			Consumers:  packages,
+		})
+	})
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].File != suggestions[j].File {
+			return suggestions[i].File < suggestions[j].File
+		}
+		return suggestions[i].Module < suggestions[j].Module
+	})
+
+	data, err := json.MarshalIndent(suggestions, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal visibility suggestions: %s", err)
+		return
+	}
+	if err := WriteFileToOutputDir(PathForOutput(ctx, visibilitySuggestionsFile), data, 0666); err != nil {
+		ctx.Errorf("failed to write visibility suggestions: %s", err)
+	}
+}
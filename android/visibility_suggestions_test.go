@@ -0,0 +1,177 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuggestVisibility(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pkg       string
+		consumers []string
+		want      []string
+	}{
+		{
+			name: "no consumers",
+			pkg:  "top",
+			want: []string{"//visibility:private"},
+		},
+		{
+			name:      "packages",
+			pkg:       "top",
+			consumers: []string{"other", "another"},
+			want:      []string{"//another", "//other"},
+		},
+		{
+			name:      "subpackages",
+			pkg:       "top",
+			consumers: []string{"other", "other/nested", "other/nested/deeper", "otherwise"},
+			want:      []string{"//other:__subpackages__", "//otherwise"},
+		},
+		{
+			name:      "own subpackages",
+			pkg:       "top",
+			consumers: []string{"top/nested"},
+			want:      []string{"//top/nested"},
+		},
+		{
+			name:      "vendor from outside vendor",
+			pkg:       "top",
+			consumers: []string{"vendor/acme", "vendor/other"},
+			want:      []string{"//vendor:__subpackages__"},
+		},
+		{
+			name:      "vendor from inside vendor",
+			pkg:       "vendor/acme",
+			consumers: []string{"vendor/other"},
+			want:      []string{"//vendor/other"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := suggestVisibility(tc.pkg, tc.consumers)
+			AssertDeepEquals(t, "suggested visibility", tc.want, got.Strings())
+		})
+	}
+}
+
+func TestVisibilitySuggestions(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithDefaults,
+		PrepareForTestWithPackageModule,
+		PrepareForTestWithVisibility,
+		PrepareForTestWithVisibilitySuggestions,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("mock_library", newMockLibraryModule)
+		}),
+		FixtureMergeEnv(map[string]string{"SOONG_SUGGEST_VISIBILITY": "true"}),
+		MockFS{
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libpublic",
+					visibility: ["//visibility:public"],
+				}
+
+				mock_library {
+					name: "libunused",
+				}
+
+				mock_library {
+					name: "libprivate",
+					visibility: ["//visibility:private"],
+				}
+
+				mock_library {
+					name: "libsamepackage",
+					deps: ["libpublic", "libprivate"],
+				}`),
+			"other/Android.bp": []byte(`
+				mock_library {
+					name: "libother",
+					deps: ["libpublic"],
+				}`),
+			"other/nested/Android.bp": []byte(`
+				mock_library {
+					name: "libnested",
+					deps: ["libpublic", "libother"],
+					visibility: ["//visibility:public"],
+				}`),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	data, err := os.ReadFile(filepath.Join(result.Config.SoongOutDir(), visibilitySuggestionsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suggestions []visibilitySuggestion
+	if err := json.Unmarshal(data, &suggestions); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []visibilitySuggestion{
+		{
+			Module:     "libother",
+			ModuleType: "mock_library",
+			File:       "other/Android.bp",
+			Property:   "visibility",
+			Current:    []string{"//visibility:public"},
+			Suggested:  []string{"//other/nested"},
+			Consumers:  []string{"other/nested"},
+		},
+		{
+			Module:     "libnested",
+			ModuleType: "mock_library",
+			File:       "other/nested/Android.bp",
+			Property:   "visibility",
+			Current:    []string{"//visibility:public"},
+			Suggested:  []string{"//visibility:private"},
+			Consumers:  []string{},
+		},
+		{
+			Module:     "libpublic",
+			ModuleType: "mock_library",
+			File:       "top/Android.bp",
+			Property:   "visibility",
+			Current:    []string{"//visibility:public"},
+			Suggested:  []string{"//other:__subpackages__"},
+			Consumers:  []string{"other", "other/nested"},
+		},
+		{
+			Module:     "libsamepackage",
+			ModuleType: "mock_library",
+			File:       "top/Android.bp",
+			Property:   "visibility",
+			Current:    []string{"//visibility:public"},
+			Suggested:  []string{"//visibility:private"},
+			Consumers:  []string{},
+		},
+		{
+			Module:     "libunused",
+			ModuleType: "mock_library",
+			File:       "top/Android.bp",
+			Property:   "visibility",
+			Current:    []string{"//visibility:public"},
+			Suggested:  []string{"//visibility:private"},
+			Consumers:  []string{},
+		},
+	}
+	AssertDeepEquals(t, "visibility suggestions", want, suggestions)
+}
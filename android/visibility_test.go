@@ -57,6 +57,53 @@ var visibilityTests = []struct {
 		},
 		expectedErrors: []string{`visibility: invalid visibility pattern "//"`},
 	},
+	{
+		name: "invalid visibility_severity",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libexample",
+					visibility: ["//visibility:private"],
+					visibility_severity: "sometimes",
+				}`),
+		},
+		expectedErrors: []string{`visibility_severity: unknown severity "sometimes"`},
+	},
+	{
+		name: "visibility_severity warning",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libexample",
+					visibility: ["//visibility:private"],
+					visibility_severity: "warning",
+				}`),
+			"other/Android.bp": []byte(`
+				mock_library {
+					name: "libother",
+					deps: ["libexample"],
+				}`),
+		},
+	},
+	{
+		name: "visibility_severity error_after a past date",
+		fs: MockFS{
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libexample",
+					visibility: ["//visibility:private"],
+					visibility_severity: "error_after:2000-01-01",
+				}`),
+			"other/Android.bp": []byte(`
+				mock_library {
+					name: "libother",
+					deps: ["libexample"],
+				}`),
+		},
+		expectedErrors: []string{
+			`module "libother": depends on //top:libexample which is not visible to this module`,
+		},
+	},
 	{
 		name: "invalid visibility: empty module",
 		fs: MockFS{
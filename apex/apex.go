@@ -201,6 +201,10 @@ type apexBundleProperties struct {
 	// in a special way that include the digest of the lib file under /lib(64)?
 	Dynamic_common_lib_apex *bool
 
+	// The maximum sizes of this APEX in bytes. The build fails, listing the largest files and
+	// modules in the APEX, when the APEX file or the compressed APEX file exceed their budget.
+	Size_budget apexSizeBudgetProperties
+
 	// Canonical name of this APEX bundle. Used to determine the path to the
 	// activated APEX on device (i.e. /apex/<apexVariationName>), and used for the
 	// apex mutator variations. For override_apex modules, this is the name of the
@@ -485,6 +489,9 @@ type apexBundle struct {
 
 	isCompressed bool
 
+	// The size report of this APEX, which breaks down its size by module and file type.
+	sizeReport android.WritablePath
+
 	// Path of API coverage generate file
 	nativeApisUsedByModuleFile   android.ModuleOutPath
 	nativeApisBackedByModuleFile android.ModuleOutPath
@@ -652,6 +659,9 @@ type dependencyTag struct {
 	blueprint.BaseDependencyTag
 	name string
 
+	// The property of the APEX that lists the dependency, e.g. native_shared_libs.
+	property string
+
 	// Determines if the dependent will be part of the APEX payload. Can be false for the
 	// dependencies to the signing key module, etc.
 	payload bool
@@ -674,6 +684,10 @@ func (d *dependencyTag) ExportMember() bool {
 	return true
 }
 
+func (d *dependencyTag) DependencyPropertyName() string {
+	return d.property
+}
+
 func (d *dependencyTag) String() string {
 	return fmt.Sprintf("apex.dependencyTag{%q}", d.name)
 }
@@ -683,27 +697,28 @@ func (d *dependencyTag) ReplaceSourceWithPrebuilt() bool {
 }
 
 var _ android.ReplaceSourceWithPrebuilt = &dependencyTag{}
+var _ android.PropertyNameDependencyTag = &dependencyTag{}
 var _ android.SdkMemberDependencyTag = &dependencyTag{}
 
 var (
-	androidAppTag   = &dependencyTag{name: "androidApp", payload: true}
-	bpfTag          = &dependencyTag{name: "bpf", payload: true}
-	certificateTag  = &dependencyTag{name: "certificate"}
+	androidAppTag   = &dependencyTag{name: "androidApp", property: "apps", payload: true}
+	bpfTag          = &dependencyTag{name: "bpf", property: "bpfs", payload: true}
+	certificateTag  = &dependencyTag{name: "certificate", property: "certificate"}
 	dclaTag         = &dependencyTag{name: "dcla"}
-	executableTag   = &dependencyTag{name: "executable", payload: true}
-	fsTag           = &dependencyTag{name: "filesystem", payload: true}
-	bcpfTag         = &dependencyTag{name: "bootclasspathFragment", payload: true, sourceOnly: true, memberType: java.BootclasspathFragmentSdkMemberType}
-	sscpfTag        = &dependencyTag{name: "systemserverclasspathFragment", payload: true, sourceOnly: true, memberType: java.SystemServerClasspathFragmentSdkMemberType}
-	compatConfigTag = &dependencyTag{name: "compatConfig", payload: true, sourceOnly: true, memberType: java.CompatConfigSdkMemberType}
-	javaLibTag      = &dependencyTag{name: "javaLib", payload: true}
-	jniLibTag       = &dependencyTag{name: "jniLib", payload: true}
-	keyTag          = &dependencyTag{name: "key"}
-	prebuiltTag     = &dependencyTag{name: "prebuilt", payload: true}
-	rroTag          = &dependencyTag{name: "rro", payload: true}
-	sharedLibTag    = &dependencyTag{name: "sharedLib", payload: true}
+	executableTag   = &dependencyTag{name: "executable", property: "binaries", payload: true}
+	fsTag           = &dependencyTag{name: "filesystem", property: "filesystems", payload: true}
+	bcpfTag         = &dependencyTag{name: "bootclasspathFragment", property: "bootclasspath_fragments", payload: true, sourceOnly: true, memberType: java.BootclasspathFragmentSdkMemberType}
+	sscpfTag        = &dependencyTag{name: "systemserverclasspathFragment", property: "systemserverclasspath_fragments", payload: true, sourceOnly: true, memberType: java.SystemServerClasspathFragmentSdkMemberType}
+	compatConfigTag = &dependencyTag{name: "compatConfig", property: "compat_configs", payload: true, sourceOnly: true, memberType: java.CompatConfigSdkMemberType}
+	javaLibTag      = &dependencyTag{name: "javaLib", property: "java_libs", payload: true}
+	jniLibTag       = &dependencyTag{name: "jniLib", property: "jni_libs", payload: true}
+	keyTag          = &dependencyTag{name: "key", property: "key"}
+	prebuiltTag     = &dependencyTag{name: "prebuilt", property: "prebuilts", payload: true}
+	rroTag          = &dependencyTag{name: "rro", property: "rros", payload: true}
+	sharedLibTag    = &dependencyTag{name: "sharedLib", property: "native_shared_libs", payload: true}
 	testForTag      = &dependencyTag{name: "test for"}
-	testTag         = &dependencyTag{name: "test", payload: true}
-	shBinaryTag     = &dependencyTag{name: "shBinary", payload: true}
+	testTag         = &dependencyTag{name: "test", property: "tests", payload: true}
+	shBinaryTag     = &dependencyTag{name: "shBinary", property: "sh_binaries", payload: true}
 )
 
 // TODO(jiyong): shorten this function signature
@@ -70,6 +70,7 @@ func init() {
 	pctx.HostBinToolVariable("sload_f2fs", "sload_f2fs")
 	pctx.HostBinToolVariable("make_erofs", "make_erofs")
 	pctx.HostBinToolVariable("apex_compression_tool", "apex_compression_tool")
+	pctx.HostBinToolVariable("apex_size_report", "apex_size_report")
 	pctx.HostBinToolVariable("dexdeps", "dexdeps")
 	pctx.HostBinToolVariable("apex_sepolicy_tests", "apex_sepolicy_tests")
 	pctx.HostBinToolVariable("deapexer", "deapexer")
@@ -879,6 +880,12 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 	if suffix == imageApexSuffix {
 		validations = append(validations, runApexSepolicyTests(ctx, unsignedOutputFile.OutputPath))
 	}
+	// The size report checks the size budget, so it is a validation of the APEX when it has one.
+	sizeReport := android.PathForModuleOut(ctx, a.Name()+suffix+"-size_report.json")
+	budget, compressedBudget := a.sizeBudgets(ctx)
+	if budget > 0 || compressedBudget > 0 {
+		validations = append(validations, sizeReport)
+	}
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        rule,
 		Description: "signapk",
@@ -900,6 +907,7 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 
 	installSuffix := suffix
 	a.setCompression(ctx)
+	var compressedOutputFile android.Path
 	if a.isCompressed {
 		unsignedCompressedOutputFile := android.PathForModuleOut(ctx, a.Name()+imageCapexSuffix+".unsigned")
 
@@ -928,9 +936,12 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 			Args:        args,
 		})
 		a.outputFile = signedCompressedOutputFile
+		compressedOutputFile = signedCompressedOutputFile
 		installSuffix = imageCapexSuffix
 	}
 
+	a.buildSizeReport(ctx, sizeReport, signedOutputFile, compressedOutputFile, budget, compressedBudget)
+
 	if !a.installable() {
 		a.SkipInstall()
 	}
@@ -0,0 +1,202 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apex
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+	"android/soong/bloaty"
+)
+
+// Every APEX that is built as a single file writes a <name>-size_report.json file, which breaks
+// its size down by module and by file type, and lists the sizes of the files it packages. The
+// sections of the cc and rust binaries, which are measured by bloaty.MeasureSizeForPaths, are
+// included too. The reports of all the APEXes are merged into apex_size_report.json by the
+// apex_size_report singleton, and can be built with `m apex_size_report`.
+//
+// An APEX with a size_budget checks its size against the budget at build time, and the build
+// fails with the list of the largest files and modules of the APEX if it exceeds it.
+
+var (
+	apexSizeReportMergeRule = pctx.AndroidStaticRule("apexSizeReportMergeRule", blueprint.RuleParams{
+		Command:        "${apex_size_report} merge -o ${out} -l ${out}.rsp",
+		CommandDeps:    []string{"${apex_size_report}"},
+		Rspfile:        "${out}.rsp",
+		RspfileContent: "${in}",
+	})
+)
+
+const apexSizeReportFilename = "apex_size_report.json"
+
+func init() {
+	registerApexSizeReportBuildComponents(android.InitRegistrationContext)
+}
+
+func registerApexSizeReportBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterSingletonType("apex_size_report", apexSizeReportSingletonFactory)
+}
+
+type apexSizeBudgetProperties struct {
+	// The maximum size of the APEX file in bytes.
+	Uncompressed *int64
+
+	// The maximum size of the compressed APEX file in bytes. Only checked when the APEX is
+	// compressed.
+	Compressed *int64
+}
+
+// sizeBudgets returns the uncompressed and compressed size budgets of the APEX, or 0 when there is
+// no budget.
+func (a *apexBundle) sizeBudgets(ctx android.ModuleContext) (int64, int64) {
+	budget := func(property string, value *int64) int64 {
+		if value == nil {
+			return 0
+		}
+		if *value <= 0 {
+			ctx.PropertyErrorf("size_budget."+property, "must be a positive number of bytes, got %d", *value)
+			return 0
+		}
+		return *value
+	}
+	return budget("uncompressed", a.properties.Size_budget.Uncompressed),
+		budget("compressed", a.properties.Size_budget.Compressed)
+}
+
+// name returns the name of the file class used in the size report, which is its key in classes.
+func (class apexFileClass) name() string {
+	switch class {
+	case app:
+		return "app"
+	case appSet:
+		return "appSet"
+	case etc:
+		return "etc"
+	case goBinary:
+		return "goBinary"
+	case javaSharedLib:
+		return "javaSharedLib"
+	case nativeExecutable:
+		return "nativeExecutable"
+	case nativeSharedLib:
+		return "nativeSharedLib"
+	case nativeTest:
+		return "nativeTest"
+	case pyBinary:
+		return "pyBinary"
+	case shBinary:
+		return "shBinary"
+	default:
+		return "unknown"
+	}
+}
+
+// buildSizeReport writes the size report of the APEX to sizeReport. capex is the compressed APEX
+// file, or nil if the APEX is not compressed.
+func (a *apexBundle) buildSizeReport(ctx android.ModuleContext, sizeReport android.WritablePath,
+	apexFile android.Path, capex android.Path, budget, compressedBudget int64) {
+
+	var lines []string
+	var implicits android.Paths
+	for _, fi := range a.filesInfo {
+		// Files that are symlinks to the system partition do not take up space in the APEX.
+		if a.linkToSystemLib && fi.transitiveDep && fi.availableToPlatform() {
+			continue
+		}
+		bloatyFile := ""
+		implicits = append(implicits, fi.builtFile)
+		if fi.module != nil {
+			if sizeFile := bloaty.SizeFileForMeasuredPath(ctx, fi.module, fi.builtFile); sizeFile.Valid() {
+				bloatyFile = sizeFile.String()
+				implicits = append(implicits, sizeFile.Path())
+			}
+		}
+		moduleName := fi.androidMkModuleName
+		if moduleName == "" {
+			moduleName = fi.builtFile.Base()
+		}
+		lines = append(lines, strings.Join([]string{
+			moduleName, fi.class.name(), fi.path(), fi.builtFile.String(), bloatyFile}, "\t"))
+	}
+	sort.Strings(lines)
+
+	contents := sizeReport.ReplaceExtension(ctx, "txt")
+	android.WriteFileRule(ctx, contents, strings.Join(lines, "\n"))
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	cmd := rule.Command().
+		BuiltTool("apex_size_report").
+		Text("report").
+		FlagWithArg("-name ", a.Name()).
+		FlagWithInput("-contents ", contents).
+		FlagWithInput("-apex ", apexFile)
+	if capex != nil {
+		cmd.FlagWithInput("-capex ", capex)
+	}
+	if budget > 0 {
+		cmd.FlagWithArg("-budget ", strconv.FormatInt(budget, 10))
+	}
+	if capex != nil && compressedBudget > 0 {
+		cmd.FlagWithArg("-compressed_budget ", strconv.FormatInt(compressedBudget, 10))
+	}
+	cmd.Implicits(android.SortedUniquePaths(implicits)).
+		FlagWithOutput("-o ", sizeReport)
+	rule.Build("apex_size_report", "apex size report "+a.Name())
+
+	a.sizeReport = sizeReport
+}
+
+func apexSizeReportSingletonFactory() android.Singleton {
+	return &apexSizeReportSingleton{}
+}
+
+// apexSizeReportSingleton merges the size reports of all the APEXes.
+type apexSizeReportSingleton struct {
+	output android.Path
+}
+
+func (s *apexSizeReportSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var reports android.Paths
+	ctx.VisitAllModules(func(module android.Module) {
+		if a, ok := module.(*apexBundle); ok && a.Enabled() && a.sizeReport != nil {
+			reports = append(reports, a.sizeReport)
+		}
+	})
+	if len(reports) == 0 {
+		return
+	}
+
+	output := android.PathForOutput(ctx, apexSizeReportFilename)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        apexSizeReportMergeRule,
+		Description: "merge apex size reports",
+		Inputs:      android.SortedUniquePaths(reports),
+		Output:      output,
+	})
+	ctx.Phony("apex_size_report", output)
+	s.output = output
+}
+
+func (s *apexSizeReportSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.output != nil {
+		ctx.DistForGoalWithFilename("apex_size_report", s.output, apexSizeReportFilename)
+	}
+}
+
+var _ android.SingletonMakeVarsProvider = (*apexSizeReportSingleton)(nil)
@@ -0,0 +1,163 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+const sizeReportBp = `
+	apex_key {
+		name: "myapex.key",
+		public_key: "testkey.avbpubkey",
+		private_key: "testkey.pem",
+	}
+
+	cc_library {
+		name: "libcc",
+		srcs: ["mylib.cpp"],
+		system_shared_libs: [],
+		stl: "none",
+		apex_available: ["myapex", "otherapex"],
+	}
+
+	rust_ffi_shared {
+		name: "libfoo.ffi",
+		srcs: ["foo.rs"],
+		crate_name: "foo",
+		apex_available: ["myapex"],
+	}
+`
+
+func TestApexSizeReport(t *testing.T) {
+	ctx := testApex(t, sizeReportBp+`
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["libcc", "libfoo.ffi"],
+			updatable: false,
+		}
+
+		apex {
+			name: "otherapex",
+			key: "myapex.key",
+			native_shared_libs: ["libcc"],
+			updatable: false,
+		}
+	`)
+
+	module := ctx.ModuleForTests("myapex", "android_common_myapex_image")
+
+	// The contents list the module, file type and built file of each file in the APEX, and the
+	// bloaty output of the files whose size is measured.
+	contents := android.ContentFromFileRuleForTests(t, module.Output("myapex.apex-size_report.txt"))
+	lines := strings.Split(strings.TrimSpace(contents), "\n")
+	found := map[string][]string{}
+	for _, line := range lines {
+		fields := strings.Split(line, "\t")
+		android.AssertIntEquals(t, "fields in "+line, 5, len(fields))
+		found[fields[0]] = fields
+	}
+	android.AssertStringListContains(t, "modules", android.SortedKeys(found), "libcc")
+	android.AssertStringListContains(t, "modules", android.SortedKeys(found), "libfoo.ffi")
+	android.AssertStringEquals(t, "file type of libcc", "nativeSharedLib", found["libcc"][1])
+	android.AssertStringEquals(t, "path of libcc", "lib64/libcc.so", found["libcc"][2])
+	for _, name := range []string{"libcc", "libfoo.ffi"} {
+		if !strings.HasSuffix(found[name][4], ".bloaty.csv") {
+			t.Errorf("expected bloaty output for %s, got %q", name, found[name][4])
+		}
+	}
+	fooBloaty := found["libfoo.ffi"][4]
+
+	report := module.Output("myapex.apex-size_report.json")
+	command := android.StringRelativeToTop(ctx.Config(), report.RuleParams.Command)
+	android.AssertStringDoesContain(t, "report command", command, "apex_size_report report -name myapex ")
+	android.AssertStringDoesContain(t, "report command", command, "-apex out/soong/.intermediates/myapex/android_common_myapex_image/myapex.apex ")
+	android.AssertStringDoesNotContain(t, "report command", command, "-budget")
+	android.AssertStringDoesNotContain(t, "report command", command, "-capex")
+	android.AssertStringListContains(t, "report inputs",
+		android.StringsRelativeToTop(ctx.Config(), report.Implicits.Strings()),
+		android.StringRelativeToTop(ctx.Config(), fooBloaty))
+
+	// Without a budget the report is not a validation of the APEX.
+	android.AssertPathsRelativeToTopEquals(t, "validations", []string{
+		"out/soong/.intermediates/myapex/android_common_myapex_image/sepolicy_tests.timestamp",
+	}, module.Output("myapex.apex").Validations)
+
+	// The symbol sizes of the APEX variants are attributed to the APEXes, and libcc is not measured
+	// for the system partition as it is not available to the platform.
+	symbols := android.ContentFromFileRuleForTests(t,
+		ctx.SingletonForTests("file_metrics").Output("binary_symbol_sizes.lst"))
+	android.AssertStringDoesContain(t, "symbol sizes", symbols, "\tlibcc\t.\tapex\tmyapex\n")
+	android.AssertStringDoesContain(t, "symbol sizes", symbols, "\tlibcc\t.\tapex\totherapex\n")
+	android.AssertStringDoesNotContain(t, "symbol sizes", symbols, "\tlibcc\t.\tsystem\t")
+
+	merge := ctx.SingletonForTests("apex_size_report").Output("apex_size_report.json")
+	android.AssertPathsRelativeToTopEquals(t, "merged reports", []string{
+		"out/soong/.intermediates/myapex/android_common_myapex_image/myapex.apex-size_report.json",
+		"out/soong/.intermediates/otherapex/android_common_otherapex_image/otherapex.apex-size_report.json",
+	}, merge.Inputs)
+}
+
+func TestApexSizeBudget(t *testing.T) {
+	ctx := testApex(t, sizeReportBp+`
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["libcc"],
+			compressible: true,
+			updatable: false,
+			size_budget: {
+				uncompressed: 2000000,
+				compressed: 1000000,
+			},
+		}
+	`,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.CompressedApex = proptools.BoolPtr(true)
+		}),
+	)
+
+	module := ctx.ModuleForTests("myapex", "android_common_myapex_image")
+	report := module.Output("myapex.apex-size_report.json")
+	command := android.StringRelativeToTop(ctx.Config(), report.RuleParams.Command)
+	android.AssertStringDoesContain(t, "report command", command, "-capex out/soong/.intermediates/myapex/android_common_myapex_image/myapex.capex ")
+	android.AssertStringDoesContain(t, "report command", command, "-budget 2000000 ")
+	android.AssertStringDoesContain(t, "report command", command, "-compressed_budget 1000000 ")
+
+	// The budget is checked whenever the APEX is built.
+	android.AssertPathsRelativeToTopEquals(t, "validations", []string{
+		"out/soong/.intermediates/myapex/android_common_myapex_image/sepolicy_tests.timestamp",
+		"out/soong/.intermediates/myapex/android_common_myapex_image/myapex.apex-size_report.json",
+	}, module.Output("myapex.apex").Validations)
+}
+
+func TestApexSizeBudgetErrors(t *testing.T) {
+	testApexError(t, `size_budget.uncompressed: must be a positive number of bytes, got -1`, sizeReportBp+`
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			updatable: false,
+			size_budget: {
+				uncompressed: -1,
+			},
+		}
+	`)
+}
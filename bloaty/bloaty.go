@@ -14,20 +14,31 @@
 
 // Package bloaty implements a singleton that measures binary (e.g. ELF
 // executable, shared library or Rust rlib) section sizes at build time.
+//
+// It also attributes the sizes of the unstripped executables and shared
+// libraries of cc and rust modules to their symbols and compile units. These
+// sizes are aggregated by module, directory and partition into
+// binary_symbol_sizes.pb.gz, which is built by `m binary_symbol_sizes`, and two
+// of them can be compared with bloaty_diff.
 package bloaty
 
 import (
+	"strings"
+
 	"android/soong/android"
 
 	"github.com/google/blueprint"
 )
 
 const bloatyDescriptorExt = ".bloaty.csv"
+const bloatySymbolsDescriptorExt = ".bloaty.symbols.csv"
 const protoFilename = "binary_sizes.pb.gz"
+const symbolsProtoFilename = "binary_symbol_sizes.pb.gz"
 
 var (
-	fileSizeMeasurerKey blueprint.ProviderKey
-	pctx                = android.NewPackageContext("android/soong/bloaty")
+	fileSizeMeasurerKey   blueprint.ProviderKey
+	symbolSizeMeasurerKey blueprint.ProviderKey
+	pctx                  = android.NewPackageContext("android/soong/bloaty")
 
 	// bloaty is used to measure a binary section sizes.
 	bloaty = pctx.AndroidStaticRule("bloaty",
@@ -36,6 +47,14 @@ var (
 			CommandDeps: []string{"${bloaty}"},
 		})
 
+	// bloatySymbols is used to measure the sizes of the symbols of an
+	// unstripped binary, grouped by compile unit.
+	bloatySymbols = pctx.AndroidStaticRule("bloatySymbols",
+		blueprint.RuleParams{
+			Command:     "${bloaty} -n 0 --csv -d compileunits,symbols ${in} > ${out}",
+			CommandDeps: []string{"${bloaty}"},
+		})
+
 	// The bloaty merger script is used to combine the outputs from bloaty
 	// into a single protobuf.
 	bloatyMerger = pctx.AndroidStaticRule("bloatyMerger",
@@ -45,6 +64,15 @@ var (
 			Rspfile:        "${out}.lst",
 			RspfileContent: "${in}",
 		})
+
+	// bloatySymbolsMerger combines the symbol sizes listed in a manifest,
+	// which also lists the module, directory and partition of each binary,
+	// into a single protobuf.
+	bloatySymbolsMerger = pctx.AndroidStaticRule("bloatySymbolsMerger",
+		blueprint.RuleParams{
+			Command:     "${bloatyMerger} --symbols ${in} ${out}",
+			CommandDeps: []string{"${bloatyMerger}"},
+		})
 )
 
 func init() {
@@ -53,6 +81,7 @@ func init() {
 	pctx.HostBinToolVariable("bloatyMerger", "bloaty_merger")
 	android.RegisterSingletonType("file_metrics", fileSizesSingleton)
 	fileSizeMeasurerKey = blueprint.NewProvider(measuredFiles{})
+	symbolSizeMeasurerKey = blueprint.NewProvider(measuredSymbolFiles{})
 }
 
 // measuredFiles contains the paths of the files measured by a module.
@@ -69,14 +98,81 @@ func MeasureSizeForPaths(ctx android.ModuleContext, paths ...android.OptionalPat
 		if !p.Valid() {
 			continue
 		}
-		if p, ok := p.Path().(android.WritablePath); ok {
+		// Only the files built by the module are measured, the size files are
+		// written next to them.
+		if p, ok := p.Path().(android.ModuleOutPath); ok {
 			mf.paths = append(mf.paths, p)
 		}
 	}
 	ctx.SetProvider(fileSizeMeasurerKey, mf)
 }
 
-type sizesSingleton struct{}
+// apexPartition is the partition recorded for the binaries of APEX variants.
+const apexPartition = "apex"
+
+// measuredSymbolFiles contains the paths of the unstripped binaries of a
+// module whose sizes are attributed to symbols, and where the module is
+// installed.
+type measuredSymbolFiles struct {
+	paths     []android.ModuleOutPath
+	partition string
+
+	// The APEXes the binaries are installed in, for APEX variants.
+	apexes []string
+}
+
+// MeasureSymbolSizesForPaths should be called by the producers of executables
+// and shared libraries with the unstripped outputs of their installed
+// variants, to attribute their sizes to symbols and compile units. The
+// binaries of APEX variants are attributed to the APEXes they are installed
+// in. It must only be called once per module; it will panic otherwise.
+func MeasureSymbolSizesForPaths(ctx android.ModuleContext, paths ...android.OptionalPath) {
+	mf := measuredSymbolFiles{}
+	apexInfo := ctx.Provider(android.ApexInfoProvider).(android.ApexInfo)
+	if ctx.Host() {
+		mf.partition = "host"
+	} else if !apexInfo.IsForPlatform() {
+		mf.partition = apexPartition
+		mf.apexes = android.SortedUniqueStrings(apexInfo.InApexVariants)
+	} else {
+		mf.partition = android.PathForModuleInstall(ctx).Partition()
+	}
+	for _, p := range paths {
+		if !p.Valid() {
+			continue
+		}
+		// Prebuilts are not measured, as their unstripped outputs are not
+		// built.
+		if p, ok := p.Path().(android.ModuleOutPath); ok {
+			mf.paths = append(mf.paths, p)
+		}
+	}
+	ctx.SetProvider(symbolSizeMeasurerKey, mf)
+}
+
+// SizeFileForMeasuredPath returns the bloaty output for a file built by another module, if that
+// module measures the size of the file with MeasureSizeForPaths.
+func SizeFileForMeasuredPath(ctx android.ModuleContext, module blueprint.Module, path android.Path) android.OptionalPath {
+	if path == nil || !ctx.OtherModuleHasProvider(module, fileSizeMeasurerKey) {
+		return android.OptionalPath{}
+	}
+	filePaths := ctx.OtherModuleProvider(module, fileSizeMeasurerKey).(measuredFiles)
+	for _, p := range filePaths.paths {
+		if p.String() == path.String() {
+			return android.OptionalPathForPath(sizeFileForPath(ctx, p.(android.ModuleOutPath)))
+		}
+	}
+	return android.OptionalPath{}
+}
+
+// sizeFileForPath returns the path of the bloaty output for a measured file.
+func sizeFileForPath(ctx android.PathContext, filePath android.ModuleOutPath) android.OutputPath {
+	return filePath.InSameDir(ctx, filePath.Base()+bloatyDescriptorExt)
+}
+
+type sizesSingleton struct {
+	symbolSizes android.Path
+}
 
 func fileSizesSingleton() android.Singleton {
 	return &sizesSingleton{}
@@ -91,7 +187,7 @@ func (singleton *sizesSingleton) GenerateBuildActions(ctx android.SingletonConte
 		filePaths := ctx.ModuleProvider(m, fileSizeMeasurerKey).(measuredFiles)
 		for _, path := range filePaths.paths {
 			filePath := path.(android.ModuleOutPath)
-			sizeFile := filePath.InSameDir(ctx, filePath.Base()+bloatyDescriptorExt)
+			sizeFile := sizeFileForPath(ctx, filePath)
 			ctx.Build(pctx, android.BuildParams{
 				Rule:        bloaty,
 				Description: "bloaty " + filePath.Rel(),
@@ -107,8 +203,61 @@ func (singleton *sizesSingleton) GenerateBuildActions(ctx android.SingletonConte
 		Inputs: android.SortedUniquePaths(deps),
 		Output: android.PathForOutput(ctx, protoFilename),
 	})
+
+	singleton.buildSymbolSizes(ctx)
+}
+
+// buildSymbolSizes measures the symbol sizes of the binaries of all the
+// modules that call MeasureSymbolSizesForPaths. The measurements are not part
+// of checkbuild, as they are much slower than the section sizes.
+func (singleton *sizesSingleton) buildSymbolSizes(ctx android.SingletonContext) {
+	var manifest []string
+	var deps android.Paths
+	ctx.VisitAllModules(func(m android.Module) {
+		if !ctx.ModuleHasProvider(m, symbolSizeMeasurerKey) {
+			return
+		}
+		filePaths := ctx.ModuleProvider(m, symbolSizeMeasurerKey).(measuredSymbolFiles)
+		for _, filePath := range filePaths.paths {
+			sizeFile := filePath.InSameDir(ctx, filePath.Base()+bloatySymbolsDescriptorExt)
+			ctx.Build(pctx, android.BuildParams{
+				Rule:        bloatySymbols,
+				Description: "bloaty symbols " + filePath.Rel(),
+				Input:       filePath,
+				Output:      sizeFile,
+			})
+			deps = append(deps, sizeFile)
+			line := []string{sizeFile.String(), ctx.ModuleName(m), ctx.ModuleDir(m), filePaths.partition}
+			if len(filePaths.apexes) == 0 {
+				manifest = append(manifest, strings.Join(append(line, ""), "\t"))
+			}
+			// A binary shared by several APEXes takes up space in each of them.
+			for _, apex := range filePaths.apexes {
+				manifest = append(manifest, strings.Join(append(line, apex), "\t"))
+			}
+		}
+	})
+	if len(deps) == 0 {
+		return
+	}
+
+	manifestFile := android.PathForOutput(ctx, "binary_symbol_sizes.lst")
+	android.WriteFileRule(ctx, manifestFile, strings.Join(android.SortedUniqueStrings(manifest), "\n"))
+
+	output := android.PathForOutput(ctx, symbolsProtoFilename)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:      bloatySymbolsMerger,
+		Input:     manifestFile,
+		Implicits: android.SortedUniquePaths(deps),
+		Output:    output,
+	})
+	ctx.Phony("binary_symbol_sizes", output)
+	singleton.symbolSizes = output
 }
 
 func (singleton *sizesSingleton) MakeVars(ctx android.MakeVarsContext) {
 	ctx.DistForGoalWithFilename("checkbuild", android.PathForOutput(ctx, protoFilename), protoFilename)
+	if singleton.symbolSizes != nil {
+		ctx.DistForGoalWithFilename("binary_symbol_sizes", singleton.symbolSizes, symbolsProtoFilename)
+	}
 }
@@ -0,0 +1,216 @@
+# Copyright 2023 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Bloaty Symbol Size Diff
+
+Compares the binary_symbol_sizes.pb.gz protobufs of two builds, and reports the
+partitions, APEXes, directories, modules, namespaces (C++ namespaces or Rust
+crates) and symbols whose sizes changed, largest changes first. For instance:
+
+    $ bloaty_diff old/binary_symbol_sizes.pb.gz new/binary_symbol_sizes.pb.gz
+
+"""
+
+import argparse
+import gzip
+import sys
+
+# pylint: disable=import-error
+import file_sections_pb2
+
+GLOBAL_NAMESPACE = "(global)"
+
+
+def read_metrics(path):
+    """Reads a SymbolSizeMetrics proto.
+
+    Args:
+      path: The path to the gzip compressed protobuf.
+
+    Returns:
+      A file_sections_pb2.SymbolSizeMetrics.
+    """
+    metrics = file_sections_pb2.SymbolSizeMetrics()
+    with gzip.open(path, "rb") as input_proto:
+        metrics.ParseFromString(input_proto.read())
+    return metrics
+
+
+def namespace(symbol):
+    """Returns the namespace or crate of a demangled symbol.
+
+    Args:
+      symbol: A demangled symbol name, e.g. android::base::Foo() or
+          <core::fmt::Error as core::fmt::Debug>::fmt.
+
+    Returns:
+      The outermost namespace of the symbol, e.g. android or core.
+    """
+    name = symbol.lstrip("<&*")
+    if name.startswith("mut "):
+        name = name[len("mut "):]
+    # Ignore the namespaces of the parameters.
+    name = name.split("(", 1)[0]
+    if "::" not in name:
+        return GLOBAL_NAMESPACE
+    return name.split("::", 1)[0]
+
+
+def aggregate_sizes(aggregates):
+    """Returns the file sizes of a repeated SizeAggregate field by name."""
+    return {a.name: a.file_size for a in aggregates}
+
+
+def symbol_sizes(metrics):
+    """Returns the file sizes of the symbols, summed across binaries.
+
+    Args:
+      metrics: A file_sections_pb2.SymbolSizeMetrics.
+
+    Returns:
+      A dict from "module: symbol" to the file size of the symbol.
+    """
+    sizes = {}
+    for binary in metrics.binaries:
+        for symbol in binary.symbols:
+            key = binary.module + ": " + symbol.name
+            sizes[key] = sizes.get(key, 0) + symbol.file_size
+    return sizes
+
+
+def namespace_sizes(metrics):
+    """Returns the file sizes of the symbols, summed by namespace."""
+    sizes = {}
+    for binary in metrics.binaries:
+        for symbol in binary.symbols:
+            key = namespace(symbol.name)
+            sizes[key] = sizes.get(key, 0) + symbol.file_size
+    return sizes
+
+
+def compile_unit_sizes(metrics):
+    """Returns the file sizes of the compile units, summed across binaries."""
+    sizes = {}
+    for binary in metrics.binaries:
+        for compile_unit in binary.compile_units:
+            key = binary.module + ": " + compile_unit.name
+            sizes[key] = sizes.get(key, 0) + compile_unit.file_size
+    return sizes
+
+
+def diff_sizes(old, new):
+    """Compares two dicts of sizes.
+
+    Args:
+      old: A dict from names to the sizes in the old build.
+      new: A dict from names to the sizes in the new build.
+
+    Returns:
+      A list of (name, old size, new size) tuples of the sizes that changed,
+      largest changes first.
+    """
+    changes = []
+    for name in set(old) | set(new):
+        old_size = old.get(name, 0)
+        new_size = new.get(name, 0)
+        if old_size != new_size:
+            changes.append((name, old_size, new_size))
+    return sorted(changes, key=lambda c: (-abs(c[2] - c[1]), c[0]))
+
+
+def format_changes(title, changes, limit):
+    """Formats a section of the report.
+
+    Args:
+      title: The title of the section.
+      changes: A list of (name, old size, new size) tuples.
+      limit: The maximum number of changes to list, or 0 for all of them.
+
+    Returns:
+      The lines of the section.
+    """
+    lines = ["%s:" % title]
+    if not changes:
+        lines.append("  (no changes)")
+    shown = changes[:limit] if limit else changes
+    for name, old_size, new_size in shown:
+        if old_size == 0:
+            status = "added"
+        elif new_size == 0:
+            status = "removed"
+        else:
+            status = "%d -> %d" % (old_size, new_size)
+        lines.append("  %+10d  %s (%s)" % (new_size - old_size, name, status))
+    if len(shown) < len(changes):
+        lines.append("  ... and %d more" % (len(changes) - len(shown)))
+    return lines
+
+
+def diff_metrics(old, new, limit):
+    """Compares the SymbolSizeMetrics of two builds.
+
+    Args:
+      old: The file_sections_pb2.SymbolSizeMetrics of the old build.
+      new: The file_sections_pb2.SymbolSizeMetrics of the new build.
+      limit: The maximum number of changes to list in each section, or 0 for
+          all of them.
+
+    Returns:
+      The lines of the report.
+    """
+    old_total = sum(p.file_size for p in old.partitions)
+    new_total = sum(p.file_size for p in new.partitions)
+    lines = ["Total: %d -> %d (%+d)" % (old_total, new_total,
+                                        new_total - old_total)]
+    sections = [
+        ("Partitions", aggregate_sizes(old.partitions),
+         aggregate_sizes(new.partitions)),
+        ("APEXes", aggregate_sizes(old.apexes), aggregate_sizes(new.apexes)),
+        ("Directories", aggregate_sizes(old.directories),
+         aggregate_sizes(new.directories)),
+        ("Modules", aggregate_sizes(old.modules),
+         aggregate_sizes(new.modules)),
+        ("Namespaces and crates", namespace_sizes(old), namespace_sizes(new)),
+        ("Compile units", compile_unit_sizes(old), compile_unit_sizes(new)),
+        ("Symbols", symbol_sizes(old), symbol_sizes(new)),
+    ]
+    for title, old_sizes, new_sizes in sections:
+        lines.append("")
+        lines.extend(format_changes(title, diff_sizes(old_sizes, new_sizes),
+                                    limit))
+    return lines
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("old_proto", help="Symbol sizes of the old build.")
+    parser.add_argument("new_proto", help="Symbol sizes of the new build.")
+    parser.add_argument("--limit", type=int, default=20,
+                        help="Maximum number of changes listed in each " +
+                        "section, 0 for all of them.")
+    parser.add_argument("-o", "--output",
+                        help="Output file, defaults to stdout.")
+    args = parser.parse_args()
+
+    lines = diff_metrics(read_metrics(args.old_proto),
+                         read_metrics(args.new_proto), args.limit)
+    report = "\n".join(lines) + "\n"
+    if args.output:
+        with open(args.output, "w") as output:
+            output.write(report)
+    else:
+        sys.stdout.write(report)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,114 @@
+# Copyright 2023 Google Inc. All rights reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+import unittest
+
+# pylint: disable=import-error
+import bloaty_diff
+import file_sections_pb2
+
+
+def make_metrics(binaries):
+    """Creates a SymbolSizeMetrics from (module, partition, symbols) tuples."""
+    metrics = file_sections_pb2.SymbolSizeMetrics()
+    modules = {}
+    partitions = {}
+    for module, partition, symbols in binaries:
+        binary = metrics.binaries.add()
+        binary.module = module
+        binary.partition = partition
+        compile_units = {}
+        for name, compile_unit, size in symbols:
+            symbol = binary.symbols.add()
+            symbol.name = name
+            symbol.compile_unit = compile_unit
+            symbol.file_size = size
+            compile_units[compile_unit] = compile_units.get(compile_unit, 0) + size
+            modules[module] = modules.get(module, 0) + size
+            partitions[partition] = partitions.get(partition, 0) + size
+        for name, size in compile_units.items():
+            binary.compile_units.add(name=name, file_size=size)
+    for name, size in modules.items():
+        metrics.modules.add(name=name, file_size=size)
+    for name, size in partitions.items():
+        metrics.partitions.add(name=name, file_size=size)
+    return metrics
+
+
+class BloatyDiffTestCase(unittest.TestCase):
+    def test_namespace(self):
+        self.assertEqual(bloaty_diff.namespace("android::base::Foo()"),
+                         "android")
+        self.assertEqual(
+            bloaty_diff.namespace("<core::fmt::Error as core::fmt::Debug>::fmt"),
+            "core")
+        self.assertEqual(bloaty_diff.namespace("<&mut alloc::vec::Vec<u8>>::push"),
+                         "alloc")
+        self.assertEqual(bloaty_diff.namespace("main"),
+                         bloaty_diff.GLOBAL_NAMESPACE)
+        self.assertEqual(bloaty_diff.namespace("foo(std::string)"),
+                         bloaty_diff.GLOBAL_NAMESPACE)
+
+    def test_diff_sizes(self):
+        changes = bloaty_diff.diff_sizes({"a": 10, "b": 5, "c": 3},
+                                         {"a": 12, "c": 3, "d": 20})
+        self.assertEqual(changes, [("d", 0, 20), ("b", 5, 0), ("a", 10, 12)])
+
+    def test_diff_metrics(self):
+        old = make_metrics([
+            ("libfoo", "system", [("foo::a()", "a.cpp", 100),
+                                  ("foo::b()", "b.cpp", 50)]),
+        ])
+        new = make_metrics([
+            ("libfoo", "system", [("foo::a()", "a.cpp", 120)]),
+            ("libbar", "vendor", [("<bar::Bar as core::fmt::Debug>::fmt",
+                                   "bar.rs", 30)]),
+        ])
+
+        lines = bloaty_diff.diff_metrics(old, new, 2)
+        self.assertEqual(lines, [
+            "Total: 150 -> 150 (+0)",
+            "",
+            "Partitions:",
+            "         -30  system (150 -> 120)",
+            "         +30  vendor (added)",
+            "",
+            "APEXes:",
+            "  (no changes)",
+            "",
+            "Directories:",
+            "  (no changes)",
+            "",
+            "Modules:",
+            "         +30  libbar (added)",
+            "         -30  libfoo (150 -> 120)",
+            "",
+            "Namespaces and crates:",
+            "         +30  bar (added)",
+            "         -30  foo (150 -> 120)",
+            "",
+            "Compile units:",
+            "         -50  libfoo: b.cpp (removed)",
+            "         +30  libbar: bar.rs (added)",
+            "  ... and 1 more",
+            "",
+            "Symbols:",
+            "         -50  libfoo: foo::b() (removed)",
+            "         +30  libbar: <bar::Bar as core::fmt::Debug>::fmt (added)",
+            "  ... and 1 more",
+        ])
+
+
+if __name__ == '__main__':
+    suite = unittest.TestLoader().loadTestsFromTestCase(BloatyDiffTestCase)
+    unittest.TextTestRunner(verbosity=2).run(suite)
@@ -18,11 +18,21 @@ a first argument and the output as second. For instance:
 
     $ bloaty_merger binary_sizes.lst binary_sizes.pb.gz
 
+With --symbols, the list is a manifest of the symbol sizes of binaries, where
+each line has the tab separated path of a .bloaty.symbols.csv file and the
+module, directory, partition and APEX of the binary. The sizes of the symbols
+that are loaded in memory are aggregated by compile unit, module, directory,
+partition and APEX; the debug and symbol tables, which are stripped from the
+installed binaries, are dropped:
+
+    $ bloaty_merger --symbols binary_symbol_sizes.lst binary_symbol_sizes.pb.gz
+
 """
 
 import argparse
 import csv
 import gzip
+import os
 
 # pylint: disable=import-error
 import ninja_rsp
@@ -30,6 +40,7 @@ import ninja_rsp
 import file_sections_pb2
 
 BLOATY_EXTENSION = ".bloaty.csv"
+BLOATY_SYMBOLS_EXTENSION = ".bloaty.symbols.csv"
 
 
 def parse_csv(path):
@@ -74,12 +85,153 @@ def create_file_size_metrics(input_list, output_proto):
         output.write(metrics.SerializeToString())
 
 
+def add_size(aggregates, name, file_size, vm_size):
+    """Adds sizes to the aggregate with the given name.
+
+    Args:
+      aggregates: A dict from names to [file_size, vm_size] lists.
+      name: The name of the aggregate.
+      file_size: The file size to add.
+      vm_size: The size in memory to add.
+    """
+    sizes = aggregates.setdefault(name, [0, 0])
+    sizes[0] += file_size
+    sizes[1] += vm_size
+
+
+def append_aggregates(field, aggregates):
+    """Appends aggregates to a repeated SizeAggregate field, largest first.
+
+    Args:
+      field: The repeated SizeAggregate field.
+      aggregates: A dict from names to [file_size, vm_size] lists.
+    """
+    for name, (file_size, vm_size) in sorted(
+            aggregates.items(), key=lambda item: (-item[1][0], item[0])):
+        aggregate = field.add()
+        aggregate.name = name
+        aggregate.file_size = file_size
+        aggregate.vm_size = vm_size
+
+
+def parent_directories(directory):
+    """Returns a directory and all of its parent directories.
+
+    Args:
+      directory: A directory relative to $ANDROID_TOP, e.g. external/foo/bar.
+
+    Returns:
+      The list of the directory and its parents, e.g. external/foo/bar,
+      external/foo and external.
+    """
+    directories = []
+    while directory and directory != ".":
+        directories.append(directory)
+        directory = os.path.dirname(directory)
+    return directories or ["."]
+
+
+def parse_symbols_csv(path, module, directory, partition, apex=""):
+    """Parses a Bloaty-generated CSV file of symbol sizes into a protobuf.
+
+    The CSV file is generated with `-d compileunits,symbols`. Rows that are not
+    loaded in memory, like the [section .debug_info] rows of the sections that
+    are stripped from the installed binary, are dropped.
+
+    Args:
+      path: The filepath to the CSV file, relative to $ANDROID_TOP.
+      module: The name of the module that builds the binary.
+      directory: The directory of the module.
+      partition: The partition the module is installed to.
+      apex: The APEX the binary is installed in, if any.
+
+    Returns:
+      A file_sections_pb2.Binary.
+    """
+    binary = file_sections_pb2.Binary()
+    binary.path = path
+    if path.endswith(BLOATY_SYMBOLS_EXTENSION):
+        binary.path = path[: -len(BLOATY_SYMBOLS_EXTENSION)]
+    binary.module = module
+    binary.directory = directory
+    binary.partition = partition
+    if apex:
+        binary.apex = apex
+
+    symbols = []
+    compile_units = {}
+    with open(path, newline='') as csv_file:
+        for row in csv.DictReader(csv_file):
+            if int(row["vmsize"]) == 0:
+                continue
+            symbol = file_sections_pb2.SymbolDescriptor()
+            symbol.name = row["symbols"]
+            symbol.compile_unit = row["compileunits"]
+            symbol.vm_size = int(row["vmsize"])
+            symbol.file_size = int(row["filesize"])
+            symbols.append(symbol)
+            add_size(compile_units, symbol.compile_unit, symbol.file_size,
+                     symbol.vm_size)
+
+    binary.symbols.extend(
+        sorted(symbols, key=lambda s: (-s.file_size, s.name, s.compile_unit)))
+    append_aggregates(binary.compile_units, compile_units)
+    return binary
+
+
+def create_symbol_size_metrics(manifest, output_proto):
+    """Creates a SymbolSizeMetrics proto from a manifest of CSV files.
+
+    Args:
+      manifest: The path to the manifest. Each line has the tab separated path
+          to a CSV file and the module, directory, partition and APEX of the
+          binary. The APEX is empty for binaries that are not in an APEX.
+      output_proto: The path for the output protobuf. It will be compressed
+          using gzip.
+    """
+    metrics = file_sections_pb2.SymbolSizeMetrics()
+    modules = {}
+    directories = {}
+    partitions = {}
+    apexes = {}
+    with open(manifest) as manifest_file:
+        for line in manifest_file:
+            line = line.rstrip("\n")
+            if not line:
+                continue
+            csv_path, module, directory, partition, apex = line.split("\t")
+            binary = parse_symbols_csv(csv_path, module, directory, partition,
+                                       apex)
+            metrics.binaries.append(binary)
+
+            file_size = sum(s.file_size for s in binary.symbols)
+            vm_size = sum(s.vm_size for s in binary.symbols)
+            add_size(modules, module, file_size, vm_size)
+            for d in parent_directories(directory):
+                add_size(directories, d, file_size, vm_size)
+            add_size(partitions, partition, file_size, vm_size)
+            if apex:
+                add_size(apexes, apex, file_size, vm_size)
+
+    append_aggregates(metrics.modules, modules)
+    append_aggregates(metrics.directories, directories)
+    append_aggregates(metrics.partitions, partitions)
+    append_aggregates(metrics.apexes, apexes)
+    with gzip.open(output_proto, "wb") as output:
+        output.write(metrics.SerializeToString())
+
+
 def main():
     parser = argparse.ArgumentParser()
+    parser.add_argument("--symbols", action="store_true",
+                        help="The input is a manifest of symbol size files.")
     parser.add_argument("input_list_file", help="List of bloaty csv files.")
     parser.add_argument("output_proto", help="Output proto.")
     args = parser.parse_args()
-    create_file_size_metrics(args.input_list_file, args.output_proto)
+    if args.symbols:
+        create_symbol_size_metrics(args.input_list_file, args.output_proto)
+    else:
+        create_file_size_metrics(args.input_list_file, args.output_proto)
 
 
 if __name__ == '__main__':
@@ -61,6 +61,71 @@ class BloatyMergerTestCase(fake_filesystem_unittest.TestCase):
         with gzip.open("output.pb.gz", "rb") as output:
             metrics.ParseFromString(output.read())
 
+    def test_parse_symbols_csv(self):
+        csv_content = ("compileunits,symbols,vmsize,filesize\n"
+                       "foo.cpp,foo(),10,12\n"
+                       "bar.rs,\"<bar::Bar as core::fmt::Debug>::fmt\",30,32\n"
+                       "foo.cpp,\"foo(int, int)\",5,6\n"
+                       "[section .debug_info],[section .debug_info],0,100\n")
+        self.fs.create_file("out/libfoo.so.bloaty.symbols.csv",
+                            contents=csv_content)
+        pb = bloaty_merger.parse_symbols_csv("out/libfoo.so.bloaty.symbols.csv",
+                                             "libfoo", "external/foo", "vendor")
+        self.assertEqual(pb.path, "out/libfoo.so")
+        self.assertEqual(pb.module, "libfoo")
+        self.assertEqual(pb.directory, "external/foo")
+        self.assertEqual(pb.partition, "vendor")
+        self.assertEqual([s.name for s in pb.symbols],
+                         ["<bar::Bar as core::fmt::Debug>::fmt", "foo()",
+                          "foo(int, int)"])
+        self.assertEqual(pb.symbols[0].compile_unit, "bar.rs")
+        self.assertEqual(pb.symbols[0].vm_size, 30)
+        self.assertEqual(pb.symbols[0].file_size, 32)
+        self.assertEqual([(c.name, c.file_size, c.vm_size)
+                          for c in pb.compile_units],
+                         [("bar.rs", 32, 30), ("foo.cpp", 18, 15)])
+
+    def test_parent_directories(self):
+        self.assertEqual(bloaty_merger.parent_directories("external/foo/bar"),
+                         ["external/foo/bar", "external/foo", "external"])
+        self.assertEqual(bloaty_merger.parent_directories("."), ["."])
+
+    def test_create_symbol_metrics(self):
+        manifest = ("libfoo.so.bloaty.symbols.csv\tlibfoo\texternal/foo\tsystem\t\n"
+                    "libbar.so.bloaty.symbols.csv\tlibbar\texternal/bar\tvendor\t\n"
+                    "foo.bloaty.symbols.csv\tfoo\texternal/foo/bin\tsystem\t\n"
+                    "libbaz.so.bloaty.symbols.csv\tlibbaz\texternal/baz\tapex\t"
+                    "com.android.baz\n")
+        header = "compileunits,symbols,vmsize,filesize\n"
+        self.fs.create_file("symbols.lst", contents=manifest)
+        self.fs.create_file("libfoo.so.bloaty.symbols.csv",
+                            contents=header + "foo.cpp,foo(),10,10\n")
+        self.fs.create_file("libbar.so.bloaty.symbols.csv",
+                            contents=header + "bar.cpp,bar(),20,20\n")
+        self.fs.create_file("foo.bloaty.symbols.csv",
+                            contents=header + "main.cpp,main,5,5\n")
+        self.fs.create_file("libbaz.so.bloaty.symbols.csv",
+                            contents=header + "baz.cpp,baz(),2,2\n")
+
+        bloaty_merger.create_symbol_size_metrics("symbols.lst", "output.pb.gz")
+
+        metrics = file_sections_pb2.SymbolSizeMetrics()
+        with gzip.open("output.pb.gz", "rb") as output:
+            metrics.ParseFromString(output.read())
+        self.assertEqual(len(metrics.binaries), 4)
+        self.assertEqual(metrics.binaries[3].apex, "com.android.baz")
+        self.assertEqual([(m.name, m.file_size) for m in metrics.modules],
+                         [("libbar", 20), ("libfoo", 10), ("foo", 5),
+                          ("libbaz", 2)])
+        self.assertEqual([(d.name, d.file_size) for d in metrics.directories],
+                         [("external", 37), ("external/bar", 20),
+                          ("external/foo", 15), ("external/foo/bin", 5),
+                          ("external/baz", 2)])
+        self.assertEqual([(p.name, p.file_size) for p in metrics.partitions],
+                         [("vendor", 20), ("system", 15), ("apex", 2)])
+        self.assertEqual([(a.name, a.file_size) for a in metrics.apexes],
+                         [("com.android.baz", 2)])
+
 
 if __name__ == '__main__':
     suite = unittest.TestLoader().loadTestsFromTestCase(BloatyMergerTestCase)
@@ -147,6 +147,106 @@ custom_cc_library_static {
 )`}})
 }
 
+func TestSoongConfigModuleType_ListIntAndConditions(t *testing.T) {
+	bp := `
+soong_config_module_type {
+	name: "custom_cc_library_static",
+	module_type: "cc_library_static",
+	config_namespace: "acme",
+	bool_variables: ["feature1"],
+	list_variables: ["features"],
+	int_variables: ["api_level"],
+	properties: ["cflags"],
+}
+
+custom_cc_library_static {
+	name: "foo",
+	bazel_module: { bp2build_available: true },
+	host_supported: true,
+	soong_config_variables: {
+		features: {
+			cflags: ["-DHAS_FEATURES"],
+		},
+		api_level: {
+			cflags: ["-DAPI_LEVEL=%d"],
+		},
+		all_of: {
+			variables: {
+				feature1: true,
+				api_level: {
+					at_least: 30,
+				},
+			},
+			cflags: ["-DFAST"],
+			conditions_default: {
+				cflags: ["-DSLOW"],
+			},
+		},
+		not: {
+			variables: {
+				feature1: true,
+			},
+			cflags: ["-DNO_FEATURE1"],
+		},
+	},
+}
+`
+
+	runSoongConfigModuleTypeTest(t, Bp2buildTestCase{
+		Description:                "soong config variables - generates selects for list and int variables and conditions",
+		ModuleTypeUnderTest:        "cc_library_static",
+		ModuleTypeUnderTestFactory: cc.LibraryStaticFactory,
+		Blueprint:                  bp,
+		ExpectedBazelTargets: []string{`cc_library_static(
+    name = "foo",
+    copts = select({
+        "//build/bazel/product_variables:acme__all_of__api_level_at_least_30__feature1_true": ["-DFAST"],
+        "//conditions:default": ["-DSLOW"],
+    }) + select({
+        "//build/bazel/product_variables:acme__api_level": ["-DAPI_LEVEL=$(Api_level)"],
+        "//conditions:default": [],
+    }) + select({
+        "//build/bazel/product_variables:acme__features": ["-DHAS_FEATURES"],
+        "//conditions:default": [],
+    }) + select({
+        "//build/bazel/product_variables:acme__not__feature1_true": ["-DNO_FEATURE1"],
+        "//conditions:default": [],
+    }),
+    local_includes = ["."],
+)`}})
+}
+
+func TestSoongConfigModuleType_ListSubstitutionUnsupported(t *testing.T) {
+	bp := `
+soong_config_module_type {
+	name: "custom_cc_library_static",
+	module_type: "cc_library_static",
+	config_namespace: "acme",
+	list_variables: ["features"],
+	properties: ["cflags"],
+}
+
+custom_cc_library_static {
+	name: "foo",
+	bazel_module: { bp2build_available: true },
+	host_supported: true,
+	soong_config_variables: {
+		features: {
+			cflags: ["-DFEATURE_%s"],
+		},
+	},
+}
+`
+
+	runSoongConfigModuleTypeTest(t, Bp2buildTestCase{
+		Description:                "soong config variables - %s in the list properties of list variables is not supported",
+		ModuleTypeUnderTest:        "cc_library_static",
+		ModuleTypeUnderTestFactory: cc.LibraryStaticFactory,
+		Blueprint:                  bp,
+		ExpectedErr:                fmt.Errorf(`soong_config_variables.features.cflags: %%s in the list properties of list variables is not supported by bp2build`),
+	})
+}
+
 func TestSoongConfigModuleType_StringVar(t *testing.T) {
 	bp := `
 soong_config_string_variable {
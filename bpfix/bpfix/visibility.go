@@ -0,0 +1,111 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file implements the fix that applies the visibility suggestions computed by soong_build
+
+package bpfix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/google/blueprint/parser"
+)
+
+// visibilityOverride is the rule that makes the visibility of a module replace the visibility of
+// its defaults instead of being appended to it.
+const visibilityOverride = "//visibility:override"
+
+// VisibilitySuggestion is an entry of the visibility_suggestions.json file that soong_build writes
+// when SOONG_SUGGEST_VISIBILITY=true.
+type VisibilitySuggestion struct {
+	Module string `json:"module"`
+	// The Android.bp file that defines the module, relative to the root of the source tree.
+	File string `json:"file"`
+	// The name of the property that sets the visibility of the module.
+	Property  string   `json:"property"`
+	Suggested []string `json:"suggested"`
+}
+
+// ReadVisibilitySuggestions reads the suggestions from a visibility_suggestions.json file.
+func ReadVisibilitySuggestions(r io.Reader) ([]VisibilitySuggestion, error) {
+	var suggestions []VisibilitySuggestion
+	if err := json.NewDecoder(r).Decode(&suggestions); err != nil {
+		return nil, fmt.Errorf("failed to parse visibility suggestions: %s", err)
+	}
+	return suggestions, nil
+}
+
+// AddVisibilitySuggestions adds a fix that sets the visibility of the modules to the suggested
+// rules. The suggestions are matched to the file being fixed by its path relative to the root of
+// the source tree, so the paths of the files passed to bpfix must be relative to it.
+func (r FixRequest) AddVisibilitySuggestions(suggestions []VisibilitySuggestion) (result FixRequest) {
+	result.steps = append([]FixStep(nil), r.steps...)
+	result.steps = append(result.steps, FixStep{
+		Name: "tightenVisibility",
+		Fix: func(f *Fixer) error {
+			return tightenVisibility(f, suggestions)
+		},
+	})
+	return result
+}
+
+func tightenVisibility(f *Fixer, suggestions []VisibilitySuggestion) error {
+	file := filepath.Clean(f.tree.Name)
+	for _, def := range f.tree.Defs {
+		mod, ok := def.(*parser.Module)
+		if !ok {
+			continue
+		}
+		name, ok := getLiteralStringPropertyValue(mod, "name")
+		if !ok {
+			continue
+		}
+		for _, s := range suggestions {
+			if s.Module == name && filepath.Clean(s.File) == file {
+				setVisibility(mod, s.Property, s.Suggested)
+			}
+		}
+	}
+	return nil
+}
+
+// setVisibility sets the visibility property of the module to the rules. A property that is not a
+// literal list of strings, e.g. one that refers to a variable, is left alone as the variable may
+// be used by other modules.
+//
+// The visibility of a module is appended to the visibility of its defaults, so the rules of a
+// module with defaults start with //visibility:override to replace a wider visibility, e.g.
+// //visibility:public, inherited from the defaults.
+func setVisibility(mod *parser.Module, property string, rules []string) {
+	if _, ok := mod.GetProperty("defaults"); ok && property == "visibility" && !inList(visibilityOverride, rules) {
+		rules = append([]string{visibilityOverride}, rules...)
+	}
+
+	list := &parser.List{}
+	for _, rule := range rules {
+		list.Values = append(list.Values, &parser.String{Value: rule})
+	}
+
+	prop, ok := mod.GetProperty(property)
+	if !ok {
+		mod.Properties = append(mod.Properties, &parser.Property{Name: property, Value: list})
+		return
+	}
+	if _, ok := getLiteralListPropertyValue(mod, property); ok {
+		prop.Value = list
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfix
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTightenVisibility(t *testing.T) {
+	suggestions := []VisibilitySuggestion{
+		{Module: "libfoo", File: "<testcase>", Property: "visibility", Suggested: []string{"//other"}},
+		{Module: "libbar", File: "<testcase>", Property: "visibility",
+			Suggested: []string{"//other:__subpackages__", "//vendor:__subpackages__"}},
+		{Module: "libbaz", File: "<testcase>", Property: "visibility", Suggested: []string{"//other"}},
+		{Module: "libqux", File: "other/Android.bp", Property: "visibility", Suggested: []string{"//other"}},
+		{Module: "libquux", File: "<testcase>", Property: "visibility", Suggested: []string{"//other"}},
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			name: "add property",
+			in: `
+				cc_library {
+					name: "libfoo",
+				}
+			`,
+			out: `
+				cc_library {
+					name: "libfoo",
+					visibility: ["//other"],
+				}
+			`,
+		},
+		{
+			name: "replace property",
+			in: `
+				cc_library {
+					name: "libbar",
+					visibility: ["//visibility:public"],
+					srcs: ["bar.c"],
+				}
+			`,
+			out: `
+				cc_library {
+					name: "libbar",
+					visibility: [
+						"//other:__subpackages__",
+						"//vendor:__subpackages__",
+					],
+					srcs: ["bar.c"],
+				}
+			`,
+		},
+		{
+			name: "variable",
+			in: `
+				public = ["//visibility:public"]
+				cc_library {
+					name: "libbaz",
+					visibility: public,
+				}
+			`,
+			out: `
+				public = ["//visibility:public"]
+				cc_library {
+					name: "libbaz",
+					visibility: public,
+				}
+			`,
+		},
+		{
+			name: "defaults",
+			in: `
+				cc_defaults {
+					name: "public_defaults",
+					visibility: ["//visibility:public"],
+				}
+				cc_library {
+					name: "libquux",
+					defaults: ["public_defaults"],
+				}
+			`,
+			out: `
+				cc_defaults {
+					name: "public_defaults",
+					visibility: ["//visibility:public"],
+				}
+				cc_library {
+					name: "libquux",
+					defaults: ["public_defaults"],
+					visibility: [
+						"//visibility:override",
+						"//other",
+					],
+				}
+			`,
+		},
+		{
+			name: "other file",
+			in: `
+				cc_library {
+					name: "libqux",
+				}
+			`,
+			out: `
+				cc_library {
+					name: "libqux",
+				}
+			`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runPass(t, test.in, test.out, func(fixer *Fixer) error {
+				return tightenVisibility(fixer, suggestions)
+			})
+		})
+	}
+}
+
+func TestReadVisibilitySuggestions(t *testing.T) {
+	in := `[
+		{
+			"module": "libfoo",
+			"module_type": "cc_library",
+			"file": "top/Android.bp",
+			"property": "visibility",
+			"current": ["//visibility:public"],
+			"suggested": ["//other"],
+			"consumers": ["other"]
+		}
+	]`
+	got, err := ReadVisibilitySuggestions(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VisibilitySuggestion{
+		{Module: "libfoo", File: "top/Android.bp", Property: "visibility", Suggested: []string{"//other"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+}
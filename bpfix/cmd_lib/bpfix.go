@@ -38,6 +38,11 @@ var (
 	list   = flag.Bool("l", false, "list files whose formatting differs from bpfmt's")
 	write  = flag.Bool("w", false, "write result to (source) file instead of stdout")
 	doDiff = flag.Bool("d", false, "display diffs instead of rewriting files")
+
+	visibilitySuggestions = flag.String("visibility_suggestions", "",
+		"set the visibility of modules to the suggestions in the given visibility_suggestions.json "+
+			"file written by soong_build instead of applying the other fixes, the Android.bp files "+
+			"listed in the suggestions are fixed if no paths are given")
 )
 
 var (
@@ -133,7 +138,20 @@ func Run() {
 
 	fixRequest := bpfix.NewFixRequest().AddAll()
 
-	if flag.NArg() == 0 {
+	paths := flag.Args()
+	if *visibilitySuggestions != "" {
+		suggestions, err := readVisibilitySuggestions(*visibilitySuggestions)
+		if err != nil {
+			report(err)
+			return
+		}
+		fixRequest = bpfix.NewFixRequest().AddVisibilitySuggestions(suggestions)
+		if len(paths) == 0 {
+			paths = visibilitySuggestionFiles(suggestions)
+		}
+	}
+
+	if len(paths) == 0 {
 		if *write {
 			fmt.Fprintln(os.Stderr, "error: cannot use -w with standard input")
 			exitCode = 2
@@ -145,8 +163,7 @@ func Run() {
 		return
 	}
 
-	for i := 0; i < flag.NArg(); i++ {
-		path := flag.Arg(i)
+	for _, path := range paths {
 		switch dir, err := os.Stat(path); {
 		case err != nil:
 			report(err)
@@ -160,6 +177,28 @@ func Run() {
 	}
 }
 
+func readVisibilitySuggestions(path string) ([]bpfix.VisibilitySuggestion, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bpfix.ReadVisibilitySuggestions(f)
+}
+
+// visibilitySuggestionFiles returns the Android.bp files that define the modules in the suggestions.
+func visibilitySuggestionFiles(suggestions []bpfix.VisibilitySuggestion) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, s := range suggestions {
+		if !seen[s.File] {
+			seen[s.File] = true
+			files = append(files, s.File)
+		}
+	}
+	return files
+}
+
 func diff(b1, b2 []byte) (data []byte, err error) {
 	f1, err := ioutil.TempFile("", "bpfix")
 	if err != nil {
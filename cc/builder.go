@@ -227,6 +227,27 @@ var (
 			Platform: map[string]string{remoteexec.PoolKey: "${config.REClangTidyPool}"},
 		}, []string{"cFlags", "ccCmd", "clangCmd", "tidyCmd", "tidyFlags", "tidyVars"}, []string{})
 
+	// Rule to fail on findings of the checks treated as errors that are not in the tidy baseline.
+	// The output is only written when there are no such findings.
+	tidyBaselineCheck = pctx.AndroidStaticRule("tidyBaselineCheck",
+		blueprint.RuleParams{
+			Command:        "${android.FindingsToSarifCmd} -o $out -baseline $baseline -check -check_rules $checks @${out}.rsp",
+			CommandDeps:    []string{"${android.FindingsToSarifCmd}"},
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "${args}",
+			Restat:         true,
+		}, "args", "baseline", "checks")
+
+	// Rule to write all the findings of clang-tidy into a tidy baseline.
+	tidyBaseline = pctx.AndroidStaticRule("tidyBaseline",
+		blueprint.RuleParams{
+			Command:        "${android.FindingsToSarifCmd} -o $out @${out}.rsp",
+			CommandDeps:    []string{"${android.FindingsToSarifCmd}"},
+			Rspfile:        "${out}.rsp",
+			RspfileContent: "${args}",
+			Restat:         true,
+		}, "args")
+
 	_ = pctx.SourcePathVariable("yasmCmd", "prebuilts/misc/${config.HostPrebuiltTag}/yasm/yasm")
 
 	// Rule for invoking yasm to compile .asm assembly files.
@@ -382,6 +403,9 @@ type builderFlags struct {
 	sAbiDump      bool
 	emitXrefs     bool
 
+	tidyBaseline       android.OptionalPath
+	tidyChecksAsErrors string
+
 	assemblerWithCpp bool // True if .s files should be processed with the c preprocessor.
 
 	systemIncludeFlags string
@@ -408,33 +432,36 @@ type StripFlags struct {
 
 // Objects is a collection of file paths corresponding to outputs for C++ related build statements.
 type Objects struct {
-	objFiles      android.Paths
-	tidyFiles     android.Paths
-	tidyDepFiles  android.Paths // link dependent .tidy files
-	coverageFiles android.Paths
-	sAbiDumpFiles android.Paths
-	kytheFiles    android.Paths
+	objFiles               android.Paths
+	tidyFiles              android.Paths
+	tidyBaselineCheckFiles android.Paths
+	tidyDepFiles           android.Paths // link dependent .tidy and tidy baseline check files
+	coverageFiles          android.Paths
+	sAbiDumpFiles          android.Paths
+	kytheFiles             android.Paths
 }
 
 func (a Objects) Copy() Objects {
 	return Objects{
-		objFiles:      append(android.Paths{}, a.objFiles...),
-		tidyFiles:     append(android.Paths{}, a.tidyFiles...),
-		tidyDepFiles:  append(android.Paths{}, a.tidyDepFiles...),
-		coverageFiles: append(android.Paths{}, a.coverageFiles...),
-		sAbiDumpFiles: append(android.Paths{}, a.sAbiDumpFiles...),
-		kytheFiles:    append(android.Paths{}, a.kytheFiles...),
+		objFiles:               append(android.Paths{}, a.objFiles...),
+		tidyFiles:              append(android.Paths{}, a.tidyFiles...),
+		tidyBaselineCheckFiles: append(android.Paths{}, a.tidyBaselineCheckFiles...),
+		tidyDepFiles:           append(android.Paths{}, a.tidyDepFiles...),
+		coverageFiles:          append(android.Paths{}, a.coverageFiles...),
+		sAbiDumpFiles:          append(android.Paths{}, a.sAbiDumpFiles...),
+		kytheFiles:             append(android.Paths{}, a.kytheFiles...),
 	}
 }
 
 func (a Objects) Append(b Objects) Objects {
 	return Objects{
-		objFiles:      append(a.objFiles, b.objFiles...),
-		tidyFiles:     append(a.tidyFiles, b.tidyFiles...),
-		tidyDepFiles:  append(a.tidyDepFiles, b.tidyDepFiles...),
-		coverageFiles: append(a.coverageFiles, b.coverageFiles...),
-		sAbiDumpFiles: append(a.sAbiDumpFiles, b.sAbiDumpFiles...),
-		kytheFiles:    append(a.kytheFiles, b.kytheFiles...),
+		objFiles:               append(a.objFiles, b.objFiles...),
+		tidyFiles:              append(a.tidyFiles, b.tidyFiles...),
+		tidyBaselineCheckFiles: append(a.tidyBaselineCheckFiles, b.tidyBaselineCheckFiles...),
+		tidyDepFiles:           append(a.tidyDepFiles, b.tidyDepFiles...),
+		coverageFiles:          append(a.coverageFiles, b.coverageFiles...),
+		sAbiDumpFiles:          append(a.sAbiDumpFiles, b.sAbiDumpFiles...),
+		kytheFiles:             append(a.kytheFiles, b.kytheFiles...),
 	}
 }
 
@@ -729,20 +756,53 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 
 	}
 
+	// With a tidy baseline, clang-tidy is run without -warnings-as-errors, and the baseline check
+	// fails on the findings of tidy_checks_as_errors that are not in the baseline instead.
+	var tidyBaselineCheckFiles android.Paths
+	if flags.tidyBaseline.Valid() && len(tidyFiles) > 0 {
+		tidyBaselineCheckFiles = android.Paths{transformTidyToBaselineCheck(ctx, subdir, tidyFiles, flags)}
+	}
+
 	var tidyDepFiles android.Paths
 	if flags.needTidyFiles {
-		tidyDepFiles = tidyFiles
+		tidyDepFiles = append(android.Paths{}, tidyFiles...)
+		tidyDepFiles = append(tidyDepFiles, tidyBaselineCheckFiles...)
 	}
 	return Objects{
-		objFiles:      objFiles,
-		tidyFiles:     tidyFiles,
-		tidyDepFiles:  tidyDepFiles,
-		coverageFiles: coverageFiles,
-		sAbiDumpFiles: sAbiDumpFiles,
-		kytheFiles:    kytheFiles,
+		objFiles:               objFiles,
+		tidyFiles:              tidyFiles,
+		tidyBaselineCheckFiles: tidyBaselineCheckFiles,
+		tidyDepFiles:           tidyDepFiles,
+		coverageFiles:          coverageFiles,
+		sAbiDumpFiles:          sAbiDumpFiles,
+		kytheFiles:             kytheFiles,
 	}
 }
 
+// Generate a rule for checking the findings of clang-tidy against the tidy baseline of a module.
+func transformTidyToBaselineCheck(ctx ModuleContext, subdir string, tidyFiles android.Paths,
+	flags builderFlags) android.Path {
+
+	outputFile := android.PathForModuleObj(ctx, subdir, "tidy_baseline_check.sarif")
+	var args []string
+	for _, tidyFile := range tidyFiles {
+		args = append(args, "-clang-tidy", tidyFile.String())
+	}
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        tidyBaselineCheck,
+		Description: "clang-tidy baseline check " + ctx.ModuleName(),
+		Output:      outputFile,
+		Inputs:      tidyFiles,
+		Implicit:    flags.tidyBaseline.Path(),
+		Args: map[string]string{
+			"args":     strings.Join(args, " "),
+			"baseline": flags.tidyBaseline.String(),
+			"checks":   flags.tidyChecksAsErrors,
+		},
+	})
+	return outputFile
+}
+
 // Generate a rule for compiling multiple .o files to a static library (.a)
 func transformObjToStaticLib(ctx android.ModuleContext,
 	objFiles android.Paths, wholeStaticLibs android.Paths,
@@ -29,6 +29,7 @@ import (
 
 	"android/soong/android"
 	"android/soong/bazel/cquery"
+	"android/soong/bloaty"
 	"android/soong/cc/config"
 	"android/soong/fuzz"
 	"android/soong/genrule"
@@ -225,6 +226,9 @@ type Flags struct {
 	SAbiDump      bool // True if header abi dumps should be generated.
 	EmitXrefs     bool // If true, generate Ninja rules to generate emitXrefs input files for Kythe
 
+	TidyBaseline       android.OptionalPath // The accepted clang-tidy findings.
+	TidyChecksAsErrors string               // Checks whose findings are errors if not in TidyBaseline.
+
 	// The instruction set required for clang ("arm" or "thumb").
 	RequiredInstructionSet string
 	// The target-device system path to the dynamic linker.
@@ -730,6 +734,22 @@ func (d libraryDependencyTag) InstallDepNeeded() bool {
 
 var _ android.InstallNeededDependencyTag = libraryDependencyTag{}
 
+// DependencyPropertyName returns the property that lists the library.
+func (d libraryDependencyTag) DependencyPropertyName() string {
+	switch {
+	case d.header():
+		return "header_libs"
+	case d.shared():
+		return "shared_libs"
+	case d.wholeStatic:
+		return "whole_static_libs"
+	default:
+		return "static_libs"
+	}
+}
+
+var _ android.PropertyNameDependencyTag = libraryDependencyTag{}
+
 // dependencyTag is used for tagging miscellaneous dependency types that don't fit into
 // libraryDependencyTag.  Each tag object is created globally and reused for multiple
 // dependencies (although since the object contains no references, assigning a tag to a
@@ -879,6 +899,8 @@ type Module struct {
 	objFiles android.Paths
 	// Tidy .tidy file output paths for this compilation module
 	tidyFiles android.Paths
+	// Tidy baseline check output paths for this compilation module
+	tidyBaselineCheckFiles android.Paths
 
 	// For apex variants, this is set as apex.min_sdk_version
 	apexSdkVersion android.ApiLevel
@@ -2077,6 +2099,8 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		c.kytheFiles = objs.kytheFiles
 		c.objFiles = objs.objFiles
 		c.tidyFiles = objs.tidyFiles
+		c.tidyBaselineCheckFiles = objs.tidyBaselineCheckFiles
+		android.SetAnalysisFindingsProvider(ctx, android.AnalysisFindingsInfo{ClangTidy: c.tidyFiles})
 	}
 
 	if c.linker != nil {
@@ -2098,6 +2122,13 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	}
 
 	c.maybeInstall(ctx, apexInfo)
+
+	// Measure the sections of the installed executables and shared libraries, and attribute their
+	// sizes to their symbols.
+	if ((c.CcLibraryInterface() && c.Shared() && !c.IsStubs()) || c.Binary()) && InstalledVariant(c, apexInfo) {
+		bloaty.MeasureSizeForPaths(ctx, c.outputFile, android.OptionalPathForPath(c.UnstrippedOutputFile()))
+		bloaty.MeasureSymbolSizesForPaths(ctx, android.OptionalPathForPath(c.UnstrippedOutputFile()))
+	}
 }
 
 func (c *Module) maybeUnhideFromMake() {
@@ -3786,6 +3817,24 @@ func installable(c LinkableInterface, apexInfo android.ApexInfo) bool {
 	return false
 }
 
+// InstalledVariant returns true if the variant is installed, either to a partition or, for an APEX
+// variant, in its APEXes. Variants that are not installable or hidden from Make, like the extra
+// variants of sanitizers and coverage, and platform variants that are not available to the
+// platform are not installed.
+func InstalledVariant(c LinkableInterface, apexInfo android.ApexInfo) bool {
+	if !c.EverInstallable() || !proptools.BoolDefault(c.Installable(), true) || c.PreventInstall() ||
+		c.HiddenFromMake() || c.IsHideFromMake() || !c.OutputFile().Valid() {
+		return false
+	}
+	if !apexInfo.IsForPlatform() {
+		return true
+	}
+	if am, ok := c.(android.ApexModule); ok && am.NotAvailableForPlatform() {
+		return false
+	}
+	return !c.IsSkipInstall()
+}
+
 func (c *Module) AndroidMkWriteAdditionalDependenciesForSourceAbiDiff(w io.Writer) {
 	if c.linker != nil {
 		if library, ok := c.linker.(*libraryDecorator); ok {
@@ -26,6 +26,7 @@ import (
 
 	"android/soong/android"
 	"android/soong/bazel/cquery"
+	"android/soong/bloaty"
 )
 
 func init() {
@@ -5228,3 +5229,56 @@ func TestDclaLibraryInApex(t *testing.T) {
 	expectedOutputFiles := []string{"outputbase/execroot/__main__/foo.so"}
 	android.AssertDeepEquals(t, "output files", expectedOutputFiles, outputFiles.Strings())
 }
+
+// Test that the sections and the symbol sizes are only measured for the installed variants of
+// executables and shared libraries.
+func TestSymbolSizes(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		bloaty.PrepareForTestWithBloatyDefaultModules,
+	).RunTestWithBp(t, `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+		}
+
+		cc_library_shared {
+			name: "libuninstallable",
+			srcs: ["foo.c"],
+			installable: false,
+		}
+
+		cc_library_static {
+			name: "libstatic",
+			srcs: ["foo.c"],
+		}
+
+		cc_binary {
+			name: "foo",
+			srcs: ["foo.c"],
+		}
+	`)
+
+	m := result.SingletonForTests("file_metrics")
+	manifest := android.ContentFromFileRuleForTests(t, m.Output("binary_symbol_sizes.lst"))
+	android.AssertStringDoesContain(t, "manifest", manifest,
+		"libfoo/android_arm64_armv8-a_shared/unstripped/libfoo.so.bloaty.symbols.csv\tlibfoo\t.\tsystem\t\n")
+	android.AssertStringDoesContain(t, "manifest", manifest,
+		"foo/android_arm64_armv8-a/unstripped/foo.bloaty.symbols.csv\tfoo\t.\tsystem\t\n")
+	android.AssertStringDoesNotContain(t, "manifest", manifest, "libuninstallable")
+	android.AssertStringDoesNotContain(t, "manifest", manifest, "libstatic")
+
+	for _, sizeFile := range []string{
+		"out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared/libfoo.so.bloaty.csv",
+		"out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared/unstripped/libfoo.so.bloaty.csv",
+		"out/soong/.intermediates/foo/android_arm64_armv8-a/foo.bloaty.csv",
+	} {
+		m.Output(sizeFile)
+	}
+	for _, output := range m.AllOutputs() {
+		if strings.Contains(output, "libuninstallable") || strings.Contains(output, "libstatic") {
+			t.Errorf("unexpected bloaty output %s", output)
+		}
+	}
+}
@@ -17,7 +17,6 @@ package cc
 import (
 	"encoding/json"
 	"fmt"
-	"path"
 	"sort"
 	"strings"
 
@@ -27,7 +26,8 @@ import (
 // This singleton collects cc modules' source and flags into to a json file.
 // It does so for generating CMakeLists.txt project files needed data when
 // either make, mm, mma, mmm or mmma is called.
-// The info file is generated in $OUT/module_bp_cc_depend.json.
+// The info file is generated in $OUT/module_bp_cc_depend.json.  It is a view of the cc section
+// of the model of the modules collected by android.CollectIdeModuleInfos.
 
 func init() {
 	android.RegisterSingletonType("ccdeps_generator", ccDepsGeneratorSingleton)
@@ -85,21 +85,32 @@ func (c *ccdepsGeneratorSingleton) GenerateBuildActions(ctx android.SingletonCon
 	moduleDeps := ccDeps{}
 	moduleInfos := map[string]ccIdeInfo{}
 
-	// Track which projects have already had CMakeLists.txt generated to keep the first
-	// variant for each project.
-	seenProjects := map[string]bool{}
-
 	pathToCC, _ := evalVariable(ctx, "${config.ClangBin}/")
 	moduleDeps.C_clang = fmt.Sprintf("%s%s", buildCMakePath(pathToCC), cClang)
 	moduleDeps.Cpp_clang = fmt.Sprintf("%s%s", buildCMakePath(pathToCC), cppClang)
 
-	ctx.VisitAllModules(func(module android.Module) {
-		if ccModule, ok := module.(*Module); ok {
-			if compiledModule, ok := ccModule.compiler.(CompiledInterface); ok {
-				generateCLionProjectData(ctx, compiledModule, ccModule, seenProjects, moduleInfos)
-			}
+	// Only keep the DeviceArch variant module.
+	deviceArch := ctx.DeviceConfig().DeviceArch()
+	for name, info := range android.CollectIdeModuleInfosWith(ctx, ccIdeInfoContributor).ByModuleName() {
+		if len(info.Cc) == 0 || info.Cc[0].Arch != deviceArch {
+			continue
 		}
-	})
+		ccInfo := info.Cc[0]
+		moduleInfos[name] = ccIdeInfo{
+			Path:                 info.Dirs,
+			Srcs:                 ccInfo.Srcs,
+			Global_Common_Flags:  parseCCParameters(ccInfo.Global_common_flags),
+			Local_Common_Flags:   parseCCParameters(ccInfo.Local_common_flags),
+			Global_C_flags:       parseCCParameters(ccInfo.Global_c_flags),
+			Local_C_flags:        parseCCParameters(ccInfo.Local_c_flags),
+			Global_C_only_flags:  parseCCParameters(ccInfo.Global_conly_flags),
+			Local_C_only_flags:   parseCCParameters(ccInfo.Local_conly_flags),
+			Global_Cpp_flags:     parseCCParameters(ccInfo.Global_cpp_flags),
+			Local_Cpp_flags:      parseCCParameters(ccInfo.Local_cpp_flags),
+			System_include_flags: parseCCParameters(ccInfo.System_include_flags),
+			Module_name:          name,
+		}
+	}
 
 	moduleDeps.Modules = moduleInfos
 
@@ -125,15 +136,10 @@ func (c *ccdepsGeneratorSingleton) MakeVars(ctx android.MakeVarsContext) {
 	ctx.DistForGoal("general-tests", c.outputPath)
 }
 
-func parseCompilerCCParameters(ctx android.SingletonContext, params []string) ccParameters {
+// parseCCParameters categorizes flags that have had their ninja variables expanded.
+func parseCCParameters(params []string) ccParameters {
 	compilerParams := ccParameters{}
 
-	cparams := []string{}
-	for _, param := range params {
-		param, _ = evalVariable(ctx, param)
-		cparams = append(cparams, param)
-	}
-
 	// Soong does not guarantee that each flag will be in an individual string. e.g: The
 	// input received could be:
 	// params = {"-isystem", "path/to/system"}
@@ -141,7 +147,7 @@ func parseCompilerCCParameters(ctx android.SingletonContext, params []string) cc
 	// params = {"-isystem path/to/system"}
 	// To normalize the input, we split all strings with the "space" character and consolidate
 	// all tokens into a flattened parameters list
-	cparams = normalizeParameters(cparams)
+	cparams := normalizeParameters(params)
 
 	for i := 0; i < len(cparams); i++ {
 		param := cparams[i]
@@ -179,48 +185,6 @@ func parseCompilerCCParameters(ctx android.SingletonContext, params []string) cc
 	return compilerParams
 }
 
-func generateCLionProjectData(ctx android.SingletonContext, compiledModule CompiledInterface,
-	ccModule *Module, seenProjects map[string]bool, moduleInfos map[string]ccIdeInfo) {
-	srcs := compiledModule.Srcs()
-	if len(srcs) == 0 {
-		return
-	}
-
-	// Only keep the DeviceArch variant module.
-	if ctx.DeviceConfig().DeviceArch() != ccModule.ModuleBase.Arch().ArchType.Name {
-		return
-	}
-
-	clionProjectLocation := getCMakeListsForModule(ccModule, ctx)
-	if seenProjects[clionProjectLocation] {
-		return
-	}
-
-	seenProjects[clionProjectLocation] = true
-
-	name := ccModule.ModuleBase.Name()
-	dpInfo := moduleInfos[name]
-
-	dpInfo.Path = append(dpInfo.Path, path.Dir(ctx.BlueprintFile(ccModule)))
-	dpInfo.Srcs = append(dpInfo.Srcs, srcs.Strings()...)
-	dpInfo.Path = android.FirstUniqueStrings(dpInfo.Path)
-	dpInfo.Srcs = android.FirstUniqueStrings(dpInfo.Srcs)
-
-	dpInfo.Global_Common_Flags = parseCompilerCCParameters(ctx, ccModule.flags.Global.CommonFlags)
-	dpInfo.Local_Common_Flags = parseCompilerCCParameters(ctx, ccModule.flags.Local.CommonFlags)
-	dpInfo.Global_C_flags = parseCompilerCCParameters(ctx, ccModule.flags.Global.CFlags)
-	dpInfo.Local_C_flags = parseCompilerCCParameters(ctx, ccModule.flags.Local.CFlags)
-	dpInfo.Global_C_only_flags = parseCompilerCCParameters(ctx, ccModule.flags.Global.ConlyFlags)
-	dpInfo.Local_C_only_flags = parseCompilerCCParameters(ctx, ccModule.flags.Local.ConlyFlags)
-	dpInfo.Global_Cpp_flags = parseCompilerCCParameters(ctx, ccModule.flags.Global.CppFlags)
-	dpInfo.Local_Cpp_flags = parseCompilerCCParameters(ctx, ccModule.flags.Local.CppFlags)
-	dpInfo.System_include_flags = parseCompilerCCParameters(ctx, ccModule.flags.SystemIncludeFlags)
-
-	dpInfo.Module_name = name
-
-	moduleInfos[name] = dpInfo
-}
-
 type Deal struct {
 	Name    string
 	ideInfo ccIdeInfo
@@ -26,7 +26,9 @@ import (
 
 // This singleton generates CMakeLists.txt files. It does so for each blueprint Android.bp resulting in a cc.Module
 // when either make, mm, mma, mmm or mmma is called. CMakeLists.txt files are generated in a separate folder
-// structure (see variable CLionOutputProjectsDirectory for root).
+// structure (see variable CLionOutputProjectsDirectory for root).  A project is generated for each architecture
+// and OS that a module is built for, as a view of the cc section of the model of the modules collected by
+// android.CollectIdeModuleInfos.
 
 func init() {
 	android.RegisterSingletonType("cmakelists_generator", cMakeListsGeneratorSingleton)
@@ -61,17 +63,13 @@ func (c *cmakelistsGeneratorSingleton) GenerateBuildActions(ctx android.Singleto
 
 	outputDebugInfo = (getEnvVariable(envVariableGenerateDebugInfo, ctx) == envVariableTrue)
 
-	// Track which projects have already had CMakeLists.txt generated to keep the first
-	// variant for each project.
-	seenProjects := map[string]bool{}
-
-	ctx.VisitAllModules(func(module android.Module) {
-		if ccModule, ok := module.(*Module); ok {
-			if compiledModule, ok := ccModule.compiler.(CompiledInterface); ok {
-				generateCLionProject(compiledModule, ctx, ccModule, seenProjects)
-			}
+	infos := android.CollectIdeModuleInfos(ctx)
+	for _, name := range android.SortedStringKeys(infos) {
+		info := infos[name]
+		for _, ccInfo := range info.Cc {
+			generateCLionProject(ctx, info, ccInfo)
 		}
-	})
+	}
 
 	// Link all handmade CMakeLists.txt aggregate from
 	//     BASE/development/ide/clion to
@@ -117,22 +115,13 @@ func linkAggregateCMakeListsFiles(path string, info os.FileInfo, err error) erro
 	return nil
 }
 
-func generateCLionProject(compiledModule CompiledInterface, ctx android.SingletonContext, ccModule *Module,
-	seenProjects map[string]bool) {
-	srcs := compiledModule.Srcs()
-	if len(srcs) == 0 {
+func generateCLionProject(ctx android.SingletonContext, info *android.IdeModuleInfo, ccInfo *android.IdeCcInfo) {
+	if len(ccInfo.Srcs) == 0 {
 		return
 	}
 
-	// Only write CMakeLists.txt for the first variant of each architecture of each module
-	clionprojectLocation := getCMakeListsForModule(ccModule, ctx)
-	if seenProjects[clionprojectLocation] {
-		return
-	}
-
-	seenProjects[clionprojectLocation] = true
-
 	// Ensure the directory hosting the cmakelists.txt exists
+	clionprojectLocation := getCMakeListsForModule(info, ccInfo)
 	projectDir := path.Dir(clionprojectLocation)
 	os.MkdirAll(projectDir, os.ModePerm)
 
@@ -146,61 +135,60 @@ func generateCLionProject(compiledModule CompiledInterface, ctx android.Singleto
 	f.WriteString("# To improve project view in Clion    :\n")
 	f.WriteString("# Tools > CMake > Change Project Root  \n\n")
 	f.WriteString(fmt.Sprintf("cmake_minimum_required(VERSION %s)\n", minimumCMakeVersionSupported))
-	f.WriteString(fmt.Sprintf("project(%s)\n", ccModule.ModuleBase.Name()))
+	f.WriteString(fmt.Sprintf("project(%s)\n", info.Module_name))
 	f.WriteString(fmt.Sprintf("set(ANDROID_ROOT %s)\n\n", android.AbsSrcDirForExistingUseCases()))
 
-	pathToCC, _ := evalVariable(ctx, "${config.ClangBin}/")
-	f.WriteString(fmt.Sprintf("set(CMAKE_C_COMPILER \"%s%s\")\n", buildCMakePath(pathToCC), "clang"))
-	f.WriteString(fmt.Sprintf("set(CMAKE_CXX_COMPILER \"%s%s\")\n", buildCMakePath(pathToCC), "clang++"))
+	f.WriteString(fmt.Sprintf("set(CMAKE_C_COMPILER \"%s\")\n", buildCMakePath(ccInfo.C_compiler)))
+	f.WriteString(fmt.Sprintf("set(CMAKE_CXX_COMPILER \"%s\")\n", buildCMakePath(ccInfo.Cpp_compiler)))
 
 	// Add all sources to the project.
 	f.WriteString("list(APPEND\n")
 	f.WriteString("     SOURCE_FILES\n")
-	for _, src := range srcs {
-		f.WriteString(fmt.Sprintf("    ${ANDROID_ROOT}/%s\n", src.String()))
+	for _, src := range ccInfo.Srcs {
+		f.WriteString(fmt.Sprintf("    ${ANDROID_ROOT}/%s\n", src))
 	}
 	f.WriteString(")\n")
 
 	// Add all header search path and compiler parameters (-D, -W, -f, -XXXX)
 	f.WriteString("\n# GLOBAL ALL FLAGS:\n")
-	globalAllParameters := parseCompilerParameters(ccModule.flags.Global.CommonFlags, ctx, f)
+	globalAllParameters := parseCompilerParameters(ccInfo.Global_common_flags, ctx, f)
 	translateToCMake(globalAllParameters, f, true, true)
 
 	f.WriteString("\n# LOCAL ALL FLAGS:\n")
-	localAllParameters := parseCompilerParameters(ccModule.flags.Local.CommonFlags, ctx, f)
+	localAllParameters := parseCompilerParameters(ccInfo.Local_common_flags, ctx, f)
 	translateToCMake(localAllParameters, f, true, true)
 
 	f.WriteString("\n# GLOBAL CFLAGS:\n")
-	globalCParameters := parseCompilerParameters(ccModule.flags.Global.CFlags, ctx, f)
+	globalCParameters := parseCompilerParameters(ccInfo.Global_c_flags, ctx, f)
 	translateToCMake(globalCParameters, f, true, true)
 
 	f.WriteString("\n# LOCAL CFLAGS:\n")
-	localCParameters := parseCompilerParameters(ccModule.flags.Local.CFlags, ctx, f)
+	localCParameters := parseCompilerParameters(ccInfo.Local_c_flags, ctx, f)
 	translateToCMake(localCParameters, f, true, true)
 
 	f.WriteString("\n# GLOBAL C ONLY FLAGS:\n")
-	globalConlyParameters := parseCompilerParameters(ccModule.flags.Global.ConlyFlags, ctx, f)
+	globalConlyParameters := parseCompilerParameters(ccInfo.Global_conly_flags, ctx, f)
 	translateToCMake(globalConlyParameters, f, true, false)
 
 	f.WriteString("\n# LOCAL C ONLY FLAGS:\n")
-	localConlyParameters := parseCompilerParameters(ccModule.flags.Local.ConlyFlags, ctx, f)
+	localConlyParameters := parseCompilerParameters(ccInfo.Local_conly_flags, ctx, f)
 	translateToCMake(localConlyParameters, f, true, false)
 
 	f.WriteString("\n# GLOBAL CPP FLAGS:\n")
-	globalCppParameters := parseCompilerParameters(ccModule.flags.Global.CppFlags, ctx, f)
+	globalCppParameters := parseCompilerParameters(ccInfo.Global_cpp_flags, ctx, f)
 	translateToCMake(globalCppParameters, f, false, true)
 
 	f.WriteString("\n# LOCAL CPP FLAGS:\n")
-	localCppParameters := parseCompilerParameters(ccModule.flags.Local.CppFlags, ctx, f)
+	localCppParameters := parseCompilerParameters(ccInfo.Local_cpp_flags, ctx, f)
 	translateToCMake(localCppParameters, f, false, true)
 
 	f.WriteString("\n# GLOBAL SYSTEM INCLUDE FLAGS:\n")
-	globalIncludeParameters := parseCompilerParameters(ccModule.flags.SystemIncludeFlags, ctx, f)
+	globalIncludeParameters := parseCompilerParameters(ccInfo.System_include_flags, ctx, f)
 	translateToCMake(globalIncludeParameters, f, true, true)
 
 	// Add project executable.
 	f.WriteString(fmt.Sprintf("\nadd_executable(%s ${SOURCE_FILES})\n",
-		cleanExecutableName(ccModule.ModuleBase.Name())))
+		cleanExecutableName(info.Module_name)))
 }
 
 func cleanExecutableName(s string) string {
@@ -467,12 +455,12 @@ func evalVariable(ctx android.SingletonContext, str string) (string, error) {
 	return "", err
 }
 
-func getCMakeListsForModule(module *Module, ctx android.SingletonContext) string {
+func getCMakeListsForModule(info *android.IdeModuleInfo, ccInfo *android.IdeCcInfo) string {
 	return filepath.Join(android.AbsSrcDirForExistingUseCases(),
 		cLionOutputProjectsDirectory,
-		path.Dir(ctx.BlueprintFile(module)),
-		module.ModuleBase.Name()+"-"+
-			module.ModuleBase.Arch().ArchType.Name+"-"+
-			module.ModuleBase.Os().Name,
+		info.Dirs[0],
+		info.Module_name+"-"+
+			ccInfo.Arch+"-"+
+			ccInfo.Os,
 		cMakeListsFilename)
 }
@@ -30,6 +30,21 @@ import (
 // at ${OUT_DIR}/soong/development/ide/compdb/compile_commands.json. It will also symlink it
 // to ${SOONG_LINK_COMPDB_TO} if set. In general this should be created by running
 // make SOONG_GEN_COMPDB=1 nothing to get all targets.
+//
+// The database can be scoped to a list of directories and module names by setting
+// SOONG_GEN_COMPDB_FILTER, for example SOONG_GEN_COMPDB_FILTER=system/core,libfoo. In that case
+// only the modules in or below one of the directories, or with one of the names, are included,
+// and a database is also written for each of the directories (or the directory of each of the
+// modules) at ${OUT_DIR}/soong/development/ide/compdb/<dir>/compile_commands.json. Setting
+// SOONG_GEN_COMPDB_FILTER implies SOONG_GEN_COMPDB.
+//
+// Entries are included for the generated sources that modules compile, and for the crate roots
+// of Rust modules. The generated sources can be built with m compdb_generated_sources. The
+// databases are only rewritten when their contents change, so that tools like clangd don't
+// reindex the tree after every build.
+//
+// The databases are a view of the model of the modules collected by
+// android.CollectIdeModuleInfos.
 
 func init() {
 	android.RegisterSingletonType("compdb_generator", compDBGeneratorSingleton)
@@ -44,23 +59,91 @@ type compdbGeneratorSingleton struct{}
 const (
 	compdbFilename                = "compile_commands.json"
 	compdbOutputProjectsDirectory = "development/ide/compdb"
+	compdbGeneratedSourcesPhony   = "compdb_generated_sources"
 
 	// Environment variables used to modify behavior of this singleton.
 	envVariableGenerateCompdb          = "SOONG_GEN_COMPDB"
 	envVariableGenerateCompdbDebugInfo = "SOONG_GEN_COMPDB_DEBUG"
 	envVariableCompdbLink              = "SOONG_LINK_COMPDB_TO"
+	envVariableCompdbFilter            = "SOONG_GEN_COMPDB_FILTER"
 )
 
 // A compdb entry. The compile_commands.json file is a list of these.
-type compDbEntry struct {
+type CompdbEntry struct {
 	Directory string   `json:"directory"`
 	Arguments []string `json:"arguments"`
 	File      string   `json:"file"`
 	Output    string   `json:"output,omitempty"`
 }
 
+// compdbFilter selects the modules that are included in the database when
+// SOONG_GEN_COMPDB_FILTER is set.
+type compdbFilter struct {
+	dirs    []string
+	modules map[string]bool
+}
+
+// parseCompdbFilter parses a comma or space separated list of directories and module names.
+// Every item is treated as both, as module names and directories can't be told apart.
+func parseCompdbFilter(filter string) *compdbFilter {
+	items := strings.FieldsFunc(filter, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(items) == 0 {
+		return nil
+	}
+
+	f := &compdbFilter{modules: make(map[string]bool)}
+	for _, item := range items {
+		f.modules[item] = true
+		if dir := filepath.Clean(strings.TrimSuffix(item, "/...")); dir != "." {
+			f.dirs = append(f.dirs, dir)
+		}
+	}
+	f.dirs = android.SortedUniqueStrings(f.dirs)
+	return f
+}
+
+// databaseDirs returns the directories of the per-directory databases that the entries of a
+// module belong to, or nil if the module is not selected by the filter.
+func (f *compdbFilter) databaseDirs(name, dir string) []string {
+	var dirs []string
+	if f.modules[name] {
+		dirs = append(dirs, dir)
+	}
+	for _, filterDir := range f.dirs {
+		if dir == filterDir || strings.HasPrefix(dir, filterDir+"/") {
+			dirs = append(dirs, filterDir)
+		}
+	}
+	return android.FirstUniqueStrings(dirs)
+}
+
+// compdb collects the entries of a database, keeping only the first entry for each file.
+type compdb map[string]CompdbEntry
+
+func (db compdb) add(entry CompdbEntry) {
+	if _, ok := db[entry.File]; !ok {
+		db[entry.File] = entry
+	}
+}
+
+// marshal returns the contents of the database, sorted by file so that the contents are stable
+// across runs.
+func (db compdb) marshal(indent bool) ([]byte, error) {
+	v := make([]CompdbEntry, 0, len(db))
+	for _, file := range android.SortedStringKeys(db) {
+		v = append(v, db[file])
+	}
+	if indent {
+		return json.MarshalIndent(v, "", " ")
+	}
+	return json.Marshal(v)
+}
+
 func (c *compdbGeneratorSingleton) GenerateBuildActions(ctx android.SingletonContext) {
-	if !ctx.Config().IsEnvTrue(envVariableGenerateCompdb) {
+	filter := parseCompdbFilter(ctx.Config().Getenv(envVariableCompdbFilter))
+	if !ctx.Config().IsEnvTrue(envVariableGenerateCompdb) && filter == nil {
 		return
 	}
 
@@ -68,43 +151,57 @@ func (c *compdbGeneratorSingleton) GenerateBuildActions(ctx android.SingletonCon
 	outputCompdbDebugInfo := ctx.Config().IsEnvTrue(envVariableGenerateCompdbDebugInfo)
 
 	// We only want one entry per file. We don't care what module/isa it's from
-	m := make(map[string]compDbEntry)
-	ctx.VisitAllModules(func(module android.Module) {
-		if ccModule, ok := module.(*Module); ok {
-			if compiledModule, ok := ccModule.compiler.(CompiledInterface); ok {
-				generateCompdbProject(compiledModule, ctx, ccModule, m)
+	all := make(compdb)
+	perDir := make(map[string]compdb)
+	var generatedSources android.Paths
+	infos := android.CollectIdeModuleInfos(ctx)
+	for _, name := range android.SortedStringKeys(infos) {
+		info := infos[name]
+		var dbDirs []string
+		if filter != nil {
+			// Modules in a namespace can be selected by their name with or without the namespace.
+			for _, moduleDir := range info.Dirs {
+				dbDirs = append(dbDirs, filter.databaseDirs(name, moduleDir)...)
+				dbDirs = append(dbDirs, filter.databaseDirs(info.Module_name, moduleDir)...)
 			}
+			if len(dbDirs) == 0 {
+				continue
+			}
+			dbDirs = android.FirstUniqueStrings(dbDirs)
 		}
-	})
 
-	// Create the output file.
-	dir := android.PathForOutput(ctx, compdbOutputProjectsDirectory)
-	os.MkdirAll(filepath.Join(android.AbsSrcDirForExistingUseCases(), dir.String()), 0777)
-	compDBFile := dir.Join(ctx, compdbFilename)
-	f, err := os.Create(filepath.Join(android.AbsSrcDirForExistingUseCases(), compDBFile.String()))
-	if err != nil {
-		log.Fatalf("Could not create file %s: %s", compDBFile, err)
+		entries := compdbEntries(info)
+		for _, entry := range entries {
+			all.add(entry)
+			for _, dir := range dbDirs {
+				if perDir[dir] == nil {
+					perDir[dir] = make(compdb)
+				}
+				perDir[dir].add(entry)
+			}
+		}
+		generatedSources = append(generatedSources, compdbGeneratedSources(ctx, entries)...)
 	}
-	defer f.Close()
 
-	v := make([]compDbEntry, 0, len(m))
+	ctx.Phony(compdbGeneratedSourcesPhony, android.FirstUniquePaths(generatedSources)...)
 
-	for _, value := range m {
-		v = append(v, value)
-	}
-	var dat []byte
-	if outputCompdbDebugInfo {
-		dat, err = json.MarshalIndent(v, "", " ")
-	} else {
-		dat, err = json.Marshal(v)
-	}
-	if err != nil {
-		log.Fatalf("Failed to marshal: %s", err)
+	// Create the output files.
+	dir := android.PathForOutput(ctx, compdbOutputProjectsDirectory)
+	compDBFile := dir.Join(ctx, compdbFilename)
+	written := map[string]bool{compDBFile.String(): true}
+	writeCompdb(all, compDBFile, outputCompdbDebugInfo)
+	for _, dbDir := range android.SortedStringKeys(perDir) {
+		dirCompDBFile := dir.Join(ctx, dbDir, compdbFilename)
+		written[dirCompDBFile.String()] = true
+		writeCompdb(perDir[dbDir], dirCompDBFile, outputCompdbDebugInfo)
 	}
-	f.Write(dat)
+	removeStaleCompdbs(dir, written)
 
 	if finalLinkDir := ctx.Config().Getenv(envVariableCompdbLink); finalLinkDir != "" {
 		finalLinkPath := filepath.Join(finalLinkDir, compdbFilename)
+		if target, err := os.Readlink(finalLinkPath); err == nil && target == compDBFile.String() {
+			return
+		}
 		os.Remove(finalLinkPath)
 		if err := os.Symlink(compDBFile.String(), finalLinkPath); err != nil {
 			log.Fatalf("Unable to symlink %s to %s: %s", compDBFile, finalLinkPath, err)
@@ -112,11 +209,61 @@ func (c *compdbGeneratorSingleton) GenerateBuildActions(ctx android.SingletonCon
 	}
 }
 
+// writeCompdb writes a database, leaving the file untouched if its contents haven't changed.
+func writeCompdb(db compdb, path android.WritablePath, indent bool) {
+	dat, err := db.marshal(indent)
+	if err != nil {
+		log.Fatalf("Failed to marshal: %s", err)
+	}
+	if err := android.WriteFileToOutputDir(path, dat, 0666); err != nil {
+		log.Fatalf("Could not write file %s: %s", path, err)
+	}
+}
+
+// removeStaleCompdbs removes the per-directory databases left behind by previous runs with a
+// different filter.
+func removeStaleCompdbs(dir android.OutputPath, written map[string]bool) {
+	absDir := filepath.Join(android.AbsSrcDirForExistingUseCases(), dir.String())
+	filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != compdbFilename {
+			return nil
+		}
+		rel, err := filepath.Rel(absDir, path)
+		if err != nil {
+			return nil
+		}
+		if !written[filepath.Join(dir.String(), rel)] {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+// compdbGeneratedSources returns the paths of the entries whose files are generated by the build.
+func compdbGeneratedSources(ctx android.SingletonContext, entries []CompdbEntry) android.Paths {
+	outDir := ctx.Config().SoongOutDir() + "/"
+	var paths android.Paths
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.File, outDir) {
+			if rel, err := filepath.Rel(ctx.Config().SoongOutDir(), entry.File); err == nil {
+				paths = append(paths, android.PathForOutput(ctx, rel))
+			}
+		}
+	}
+	return paths
+}
+
 func expandAllVars(ctx android.SingletonContext, args []string) []string {
+	return EvalAndSplitArgs(ctx, pctx, args)
+}
+
+// EvalAndSplitArgs evaluates the ninja variables in args in the scope of pctx and splits the
+// results into separate arguments.  Arguments that fail to evaluate are kept as they are.
+func EvalAndSplitArgs(ctx android.SingletonContext, pctx android.PackageContext, args []string) []string {
 	var out []string
 	for _, arg := range args {
 		if arg != "" {
-			if val, err := evalAndSplitVariable(ctx, arg); err == nil {
+			if val, err := evalAndSplitVariable(ctx, pctx, arg); err == nil {
 				out = append(out, val...)
 			} else {
 				out = append(out, arg)
@@ -126,76 +273,124 @@ func expandAllVars(ctx android.SingletonContext, args []string) []string {
 	return out
 }
 
-func getArguments(src android.Path, ctx android.SingletonContext, ccModule *Module, ccPath string, cxxPath string) []string {
+func getArguments(src string, ccInfo *android.IdeCcInfo) []string {
 	var args []string
 	isCpp := false
 	isAsm := false
 	// TODO It would be better to ask soong for the types here.
 	var clangPath string
-	switch src.Ext() {
+	switch filepath.Ext(src) {
 	case ".S", ".s", ".asm":
 		isAsm = true
 		isCpp = false
-		clangPath = ccPath
+		clangPath = ccInfo.C_compiler
 	case ".c":
 		isAsm = false
 		isCpp = false
-		clangPath = ccPath
+		clangPath = ccInfo.C_compiler
 	case ".cpp", ".cc", ".cxx", ".mm":
 		isAsm = false
 		isCpp = true
-		clangPath = cxxPath
+		clangPath = ccInfo.Cpp_compiler
 	default:
-		log.Print("Unknown file extension " + src.Ext() + " on file " + src.String())
+		log.Print("Unknown file extension " + filepath.Ext(src) + " on file " + src)
 		isAsm = true
 		isCpp = false
-		clangPath = ccPath
+		clangPath = ccInfo.C_compiler
 	}
 	args = append(args, clangPath)
-	args = append(args, expandAllVars(ctx, ccModule.flags.Global.CommonFlags)...)
-	args = append(args, expandAllVars(ctx, ccModule.flags.Local.CommonFlags)...)
-	args = append(args, expandAllVars(ctx, ccModule.flags.Global.CFlags)...)
-	args = append(args, expandAllVars(ctx, ccModule.flags.Local.CFlags)...)
+	args = append(args, ccInfo.Global_common_flags...)
+	args = append(args, ccInfo.Local_common_flags...)
+	args = append(args, ccInfo.Global_c_flags...)
+	args = append(args, ccInfo.Local_c_flags...)
 	if isCpp {
-		args = append(args, expandAllVars(ctx, ccModule.flags.Global.CppFlags)...)
-		args = append(args, expandAllVars(ctx, ccModule.flags.Local.CppFlags)...)
+		args = append(args, ccInfo.Global_cpp_flags...)
+		args = append(args, ccInfo.Local_cpp_flags...)
 	} else if !isAsm {
-		args = append(args, expandAllVars(ctx, ccModule.flags.Global.ConlyFlags)...)
-		args = append(args, expandAllVars(ctx, ccModule.flags.Local.ConlyFlags)...)
+		args = append(args, ccInfo.Global_conly_flags...)
+		args = append(args, ccInfo.Local_conly_flags...)
 	}
-	args = append(args, expandAllVars(ctx, ccModule.flags.SystemIncludeFlags)...)
-	args = append(args, src.String())
+	args = append(args, ccInfo.System_include_flags...)
+	args = append(args, src)
 	return args
 }
 
-func generateCompdbProject(compiledModule CompiledInterface, ctx android.SingletonContext, ccModule *Module, builds map[string]compDbEntry) {
-	srcs := compiledModule.Srcs()
-	if len(srcs) == 0 {
-		return
-	}
-
-	pathToCC, err := ctx.Eval(pctx, "${config.ClangBin}")
-	ccPath := "/bin/false"
-	cxxPath := "/bin/false"
-	if err == nil {
-		ccPath = filepath.Join(pathToCC, "clang")
-		cxxPath = filepath.Join(pathToCC, "clang++")
-	}
-	for _, src := range srcs {
-		if _, ok := builds[src.String()]; !ok {
-			builds[src.String()] = compDbEntry{
-				Directory: android.AbsSrcDirForExistingUseCases(),
-				Arguments: getArguments(src, ctx, ccModule, ccPath, cxxPath),
-				File:      src.String(),
+// compdbEntries returns the entries for the C/C++ sources of all the variants of a module and the
+// Rust crate root of a module.  A source compiled by several variants uses the flags of the first
+// of them, which is the primary device architecture if the module is built for it.
+func compdbEntries(info *android.IdeModuleInfo) []CompdbEntry {
+	var entries []CompdbEntry
+	seen := make(map[string]bool)
+	for _, ccInfo := range info.Cc {
+		for _, src := range ccInfo.Srcs {
+			if seen[src] {
+				continue
 			}
+			seen[src] = true
+			entries = append(entries, CompdbEntry{
+				Directory: android.AbsSrcDirForExistingUseCases(),
+				Arguments: getArguments(src, ccInfo),
+				File:      src,
+			})
 		}
 	}
+	if info.Rust != nil && info.Rust.Crate_root != "" {
+		args := append([]string{info.Rust.Rustc}, info.Rust.Rustc_flags...)
+		entries = append(entries, CompdbEntry{
+			Directory: android.AbsSrcDirForExistingUseCases(),
+			Arguments: append(args, info.Rust.Crate_root),
+			File:      info.Rust.Crate_root,
+			Output:    info.Rust.Output,
+		})
+	}
+	return entries
 }
 
-func evalAndSplitVariable(ctx android.SingletonContext, str string) ([]string, error) {
+func evalAndSplitVariable(ctx android.SingletonContext, pctx android.PackageContext, str string) ([]string, error) {
 	evaluated, err := ctx.Eval(pctx, str)
 	if err == nil {
 		return strings.Fields(evaluated), nil
 	}
 	return []string{""}, err
 }
+
+// CompdbJsonForIdeModules returns the contents of a compile_commands.json with the entries of the
+// given modules of the IDE model.  The sysroots are made absolute, as clangd passes them to the
+// compiler drivers it queries for their system include directories.
+func CompdbJsonForIdeModules(infos android.IdeModuleInfos, modules []string) ([]byte, error) {
+	db := make(compdb)
+	for _, module := range modules {
+		if info := infos[module]; info != nil {
+			for _, entry := range compdbEntries(info) {
+				entry.Arguments = absSysrootArguments(entry.Directory, entry.Arguments)
+				db.add(entry)
+			}
+		}
+	}
+	return db.marshal(false)
+}
+
+// absSysrootArguments returns args with the paths of the --sysroot and -isysroot flags made
+// absolute by joining them to dir.
+func absSysrootArguments(dir string, args []string) []string {
+	abs := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	ret := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--sysroot="):
+			ret = append(ret, "--sysroot="+abs(strings.TrimPrefix(arg, "--sysroot=")))
+		case (arg == "--sysroot" || arg == "-isysroot") && i+1 < len(args):
+			ret = append(ret, arg, abs(args[i+1]))
+			i++
+		default:
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestCompdbFilter(t *testing.T) {
+	if f := parseCompdbFilter(" , "); f != nil {
+		t.Errorf("expected no filter for an empty list, got %+v", f)
+	}
+
+	f := parseCompdbFilter("system/core, libfoo,external/bar/...")
+	android.AssertDeepEquals(t, "dirs", []string{"external/bar", "libfoo", "system/core"}, f.dirs)
+
+	testCases := []struct {
+		name, dir string
+		want      []string
+	}{
+		{name: "libbase", dir: "system/core/base", want: []string{"system/core"}},
+		{name: "libcutils", dir: "system/core", want: []string{"system/core"}},
+		{name: "libcore", dir: "system/coreutils", want: nil},
+		{name: "libfoo", dir: "frameworks/foo", want: []string{"frameworks/foo"}},
+		{name: "libbar", dir: "external/bar/src", want: []string{"external/bar"}},
+		{name: "libbaz", dir: "external/baz", want: nil},
+	}
+	for _, tc := range testCases {
+		got := f.databaseDirs(tc.name, tc.dir)
+		if len(tc.want) == 0 {
+			android.AssertIntEquals(t, tc.name, 0, len(got))
+		} else {
+			android.AssertDeepEquals(t, tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestCompdbMarshal(t *testing.T) {
+	db := make(compdb)
+	db.add(CompdbEntry{Directory: "/top", Arguments: []string{"clang", "b.c"}, File: "b.c"})
+	db.add(CompdbEntry{Directory: "/top", Arguments: []string{"clang", "a.c"}, File: "a.c"})
+	db.add(CompdbEntry{Directory: "/top", Arguments: []string{"clang", "-DSECOND", "a.c"}, File: "a.c"})
+
+	dat, err := db.marshal(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"directory":"/top","arguments":["clang","a.c"],"file":"a.c"},` +
+		`{"directory":"/top","arguments":["clang","b.c"],"file":"b.c"}]`
+	android.AssertStringEquals(t, "compdb", want, string(dat))
+}
+
+func TestCompdbEntries(t *testing.T) {
+	info := &android.IdeModuleInfo{
+		Name: "libfoo",
+		Cc: []*android.IdeCcInfo{
+			{
+				Arch:               "arm64",
+				Os:                 "android",
+				C_compiler:         "clang",
+				Cpp_compiler:       "clang++",
+				Srcs:               []string{"foo.c", "foo.cpp", "arm64.c"},
+				Global_c_flags:     []string{"-O2"},
+				Local_conly_flags:  []string{"-std=gnu11"},
+				Local_cpp_flags:    []string{"-std=gnu++17"},
+				Local_common_flags: []string{"-Ifoo"},
+			},
+			{
+				Arch:           "x86_64",
+				Os:             "linux_glibc",
+				C_compiler:     "clang",
+				Cpp_compiler:   "clang++",
+				Srcs:           []string{"foo.c", "foo.cpp", "host.c"},
+				Global_c_flags: []string{"-DHOST"},
+			},
+		},
+		Rust: &android.IdeRustInfo{
+			Crate_root:  "src/lib.rs",
+			Rustc:       "rustc",
+			Rustc_flags: []string{"--edition=2021"},
+			Output:      "out/libfoo.rlib",
+		},
+	}
+
+	// The sources of all the variants are included, with the flags of the first variant that
+	// compiles them.
+	entries := compdbEntries(info)
+	var files []string
+	for _, entry := range entries {
+		files = append(files, entry.File)
+	}
+	android.AssertDeepEquals(t, "files", []string{"foo.c", "foo.cpp", "arm64.c", "host.c", "src/lib.rs"}, files)
+	android.AssertDeepEquals(t, "c args",
+		[]string{"clang", "-Ifoo", "-O2", "-std=gnu11", "foo.c"}, entries[0].Arguments)
+	android.AssertDeepEquals(t, "cpp args",
+		[]string{"clang++", "-Ifoo", "-O2", "-std=gnu++17", "foo.cpp"}, entries[1].Arguments)
+	android.AssertDeepEquals(t, "host args",
+		[]string{"clang", "-DHOST", "host.c"}, entries[3].Arguments)
+	android.AssertDeepEquals(t, "rust args",
+		[]string{"rustc", "--edition=2021", "src/lib.rs"}, entries[4].Arguments)
+	android.AssertStringEquals(t, "rust output", "out/libfoo.rlib", entries[4].Output)
+}
+
+func TestAbsSysrootArguments(t *testing.T) {
+	args := []string{"clang", "--sysroot=prebuilts/a", "-isysroot", "prebuilts/b", "--sysroot", "/c",
+		"-Ifoo", "foo.c"}
+	android.AssertDeepEquals(t, "args",
+		[]string{"clang", "--sysroot=/top/prebuilts/a", "-isysroot", "/top/prebuilts/b", "--sysroot", "/c",
+			"-Ifoo", "foo.c"},
+		absSysrootArguments("/top", args))
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path/filepath"
+	"strings"
+
+	"android/soong/android"
+)
+
+func init() {
+	android.RegisterIdeInfoContributor(ccIdeInfoContributor)
+}
+
+// ccIdeInfoContributor fills in the IDE model of the cc modules that compile sources.
+func ccIdeInfoContributor(ctx android.IdeInfoContext, module android.Module, info *android.IdeModuleInfo) bool {
+	ccModule, ok := module.(*Module)
+	if !ok {
+		return false
+	}
+	compiledModule, ok := ccModule.compiler.(CompiledInterface)
+	if !ok {
+		return false
+	}
+	srcs := compiledModule.Srcs()
+	if len(srcs) == 0 {
+		return false
+	}
+
+	info.Languages = append(info.Languages, "cc")
+	for _, src := range srcs {
+		if _, generated := src.(android.WritablePath); generated {
+			info.Generated_srcs = append(info.Generated_srcs, src.String())
+		} else {
+			info.Srcs = append(info.Srcs, src.String())
+		}
+	}
+
+	ctx.VisitDirectDeps(module, func(dep android.Module) {
+		if _, ok := dep.(*Module); ok {
+			info.Deps = append(info.Deps, ctx.IdeModuleName(dep))
+		}
+	})
+
+	if outputFile := ccModule.OutputFile(); outputFile.Valid() {
+		info.Outputs = append(info.Outputs, outputFile.String())
+	}
+	if ccModule.testBinary() && ccModule.Host() && ccModule.UnstrippedOutputFile() != nil {
+		info.Host_test_binaries = append(info.Host_test_binaries, ccModule.UnstrippedOutputFile().String())
+	}
+
+	// The variants of the same architecture and OS share an IdeCcInfo, with the flags of the
+	// first of them.
+	arch, osName := ccModule.Arch().ArchType.Name, ccModule.Os().Name
+	var ccInfo *android.IdeCcInfo
+	for _, variantInfo := range info.Cc {
+		if variantInfo.Arch == arch && variantInfo.Os == osName {
+			ccInfo = variantInfo
+			break
+		}
+	}
+	if ccInfo == nil {
+		ccInfo = newIdeCcInfo(ctx, ccModule)
+		if ccModule.Os().Class == android.Device && arch == ctx.DeviceConfig().DeviceArch() {
+			info.Cc = append([]*android.IdeCcInfo{ccInfo}, info.Cc...)
+		} else {
+			info.Cc = append(info.Cc, ccInfo)
+		}
+		info.Include_dirs = append(info.Include_dirs, includeDirsFromFlags(
+			ccInfo.Local_common_flags, ccInfo.Local_c_flags, ccInfo.System_include_flags)...)
+	}
+	ccInfo.Srcs = android.FirstUniqueStrings(append(ccInfo.Srcs, srcs.Strings()...))
+	return true
+}
+
+func newIdeCcInfo(ctx android.SingletonContext, ccModule *Module) *android.IdeCcInfo {
+	ccInfo := &android.IdeCcInfo{
+		Arch:         ccModule.Arch().ArchType.Name,
+		Os:           ccModule.Os().Name,
+		C_compiler:   "/bin/false",
+		Cpp_compiler: "/bin/false",
+
+		Global_common_flags:  expandAllVars(ctx, ccModule.flags.Global.CommonFlags),
+		Local_common_flags:   expandAllVars(ctx, ccModule.flags.Local.CommonFlags),
+		Global_c_flags:       expandAllVars(ctx, ccModule.flags.Global.CFlags),
+		Local_c_flags:        expandAllVars(ctx, ccModule.flags.Local.CFlags),
+		Global_conly_flags:   expandAllVars(ctx, ccModule.flags.Global.ConlyFlags),
+		Local_conly_flags:    expandAllVars(ctx, ccModule.flags.Local.ConlyFlags),
+		Global_cpp_flags:     expandAllVars(ctx, ccModule.flags.Global.CppFlags),
+		Local_cpp_flags:      expandAllVars(ctx, ccModule.flags.Local.CppFlags),
+		System_include_flags: expandAllVars(ctx, ccModule.flags.SystemIncludeFlags),
+	}
+	if pathToCC, err := ctx.Eval(pctx, "${config.ClangBin}"); err == nil {
+		ccInfo.C_compiler = filepath.Join(pathToCC, cClang)
+		ccInfo.Cpp_compiler = filepath.Join(pathToCC, cppClang)
+	}
+	return ccInfo
+}
+
+// includeDirsFromFlags returns the directories of the -I and -isystem flags.
+func includeDirsFromFlags(flagLists ...[]string) []string {
+	var dirs []string
+	for _, flags := range flagLists {
+		for i := 0; i < len(flags); i++ {
+			flag := flags[i]
+			switch {
+			case flag == "-I" || flag == "-isystem":
+				if i+1 < len(flags) {
+					dirs = append(dirs, flags[i+1])
+					i++
+				}
+			case strings.HasPrefix(flag, "-isystem"):
+				dirs = append(dirs, strings.TrimPrefix(flag, "-isystem"))
+			case strings.HasPrefix(flag, "-I"):
+				dirs = append(dirs, strings.TrimPrefix(flag, "-I"))
+			}
+		}
+	}
+	return dirs
+}
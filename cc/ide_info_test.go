@@ -0,0 +1,106 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestIncludeDirsFromFlags(t *testing.T) {
+	got := includeDirsFromFlags(
+		[]string{"-Ifoo/include", "-DFOO", "-I", "bar/include"},
+		[]string{"-isystem", "baz/include", "-isystemqux/include", "-Wall"})
+	want := []string{"foo/include", "bar/include", "baz/include", "qux/include"}
+	android.AssertDeepEquals(t, "include dirs", want, got)
+}
+
+func TestCcIdeInfo(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureMergeEnv(map[string]string{"SOONG_GEN_COMPDB": "1"}),
+		android.MockFS{
+			"vendor/ns/Android.bp": []byte(`
+				soong_namespace {
+				}
+				cc_library {
+					name: "libfoo",
+					srcs: ["ns.cpp"],
+				}
+			`),
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		cc_library {
+			name: "libfoo",
+			host_supported: true,
+			srcs: ["foo.cpp"],
+			arch: {
+				arm64: {
+					srcs: ["arm64.cpp"],
+				},
+			},
+			target: {
+				host: {
+					srcs: ["host.cpp"],
+				},
+			},
+		}
+	`)
+
+	// The model is written by the ide_info singleton via WriteFileToOutputDir, so it doesn't appear in
+	// the outputs of the singleton.
+	content, err := ioutil.ReadFile(filepath.Join(result.Config.SoongOutDir(), "ide_info.json"))
+	if err != nil {
+		t.Fatalf("ide_info.json has not been generated: %s", err)
+	}
+	var ideInfo struct {
+		Modules android.IdeModuleInfos
+	}
+	if err := json.Unmarshal(content, &ideInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modules in a namespace are keyed by their qualified name.
+	android.AssertStringListContains(t, "modules", android.SortedStringKeys(ideInfo.Modules), "//vendor/ns:libfoo")
+	android.AssertStringEquals(t, "module name", "libfoo", ideInfo.Modules["//vendor/ns:libfoo"].Module_name)
+
+	// The legacy views key modules by their name alone, preferring the module outside namespaces.
+	byName := ideInfo.Modules.ByModuleName()
+	android.AssertStringListDoesNotContain(t, "legacy module names", android.SortedStringKeys(byName), "//vendor/ns:libfoo")
+	android.AssertDeepEquals(t, "legacy module dirs", []string{"."}, byName["libfoo"].Dirs)
+
+	// The sources of every architecture and OS are recorded, with the primary device architecture
+	// first.
+	info := ideInfo.Modules["libfoo"]
+	if info == nil || len(info.Cc) < 2 {
+		t.Fatalf("expected the cc sections of several variants of libfoo, got %+v", info)
+	}
+	android.AssertStringEquals(t, "first arch", "arm64", info.Cc[0].Arch)
+	android.AssertStringEquals(t, "first os", "android", info.Cc[0].Os)
+	android.AssertDeepEquals(t, "arm64 srcs", []string{"foo.cpp", "arm64.cpp"}, info.Cc[0].Srcs)
+	var hostSrcs []string
+	for _, ccInfo := range info.Cc {
+		if ccInfo.Os == result.Config.BuildOS.Name {
+			hostSrcs = append(hostSrcs, ccInfo.Srcs...)
+		}
+	}
+	android.AssertStringListContains(t, "host srcs", hostSrcs, "host.cpp")
+	android.AssertStringListDoesNotContain(t, "host srcs", hostSrcs, "arm64.cpp")
+	android.AssertDeepEquals(t, "srcs", []string{"arm64.cpp", "foo.cpp", "host.cpp"}, android.SortedUniqueStrings(info.Srcs))
+}
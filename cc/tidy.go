@@ -38,6 +38,12 @@ type TidyProperties struct {
 
 	// Checks that should be treated as errors.
 	Tidy_checks_as_errors []string
+
+	// SARIF file with the accepted findings of clang-tidy, e.g. one regenerated by
+	// `m tidy_baseline-<dir>`.  If set, clang-tidy is run without -warnings-as-errors, and a
+	// separate check fails on the findings of tidy_checks_as_errors that are not in the
+	// baseline.  Defaults to the global TIDY_BASELINE file, if any.
+	Tidy_baseline *string `android:"path"`
 }
 
 type tidyFeature struct {
@@ -192,16 +198,36 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 	// If a module has tidy_checks_as_errors, add the list to -warnings-as-errors
 	// and then append the TidyGlobalNoErrorChecks.
 	if len(tidy.Properties.Tidy_checks_as_errors) > 0 {
-		tidyChecksAsErrors := "-warnings-as-errors=" +
-			strings.Join(esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors), ",") +
+		checksAsErrors := strings.Join(esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors), ",") +
 			config.TidyGlobalNoErrorChecks()
-		flags.TidyFlags = append(flags.TidyFlags, tidyChecksAsErrors)
+		// With a baseline, -warnings-as-errors is not passed to clang-tidy, so it reports all
+		// findings as warnings.  The tidy baseline check rule then fails on the findings of
+		// these checks that are not in the baseline.
+		if baseline := tidy.baseline(ctx); baseline.Valid() {
+			flags.TidyBaseline = baseline
+			flags.TidyChecksAsErrors = checksAsErrors
+		} else {
+			flags.TidyFlags = append(flags.TidyFlags, "-warnings-as-errors="+checksAsErrors)
+		}
 	}
 	return flags
 }
 
+// baseline returns the tidy_baseline of the module, or the global baseline file set by the
+// TIDY_BASELINE environment variable.
+func (tidy *tidyFeature) baseline(ctx ModuleContext) android.OptionalPath {
+	if tidy.Properties.Tidy_baseline != nil {
+		return android.OptionalPathForPath(android.PathForModuleSrc(ctx, *tidy.Properties.Tidy_baseline))
+	}
+	if global := ctx.Config().Getenv("TIDY_BASELINE"); global != "" {
+		return android.ExistentPathForSource(ctx, global)
+	}
+	return android.OptionalPath{}
+}
+
 func init() {
 	android.RegisterSingletonType("tidy_phony_targets", TidyPhonySingleton)
+	android.RegisterSingletonType("tidy_baseline", TidyBaselineSingleton)
 }
 
 // This TidyPhonySingleton generates both tidy-* and obj-* phony targets for C/C++ files.
@@ -228,6 +254,7 @@ func collectTidyObjModuleTargets(ctx android.SingletonContext, module android.Mo
 			osName := variant.Target().Os.Name
 			addToOSGroup(osName, m.objFiles, allObjFileGroups, subsetObjFileGroups)
 			addToOSGroup(osName, m.tidyFiles, allTidyFileGroups, subsetTidyFileGroups)
+			addToOSGroup(osName, m.tidyBaselineCheckFiles, allTidyFileGroups, subsetTidyFileGroups)
 		}
 	})
 
@@ -350,3 +377,44 @@ func genObjTidyPhonyTargets(ctx android.SingletonContext, module android.Module,
 		targetGroups[group] = android.PathForPhony(ctx, groupName)
 	}
 }
+
+// TidyBaselineSingleton generates tidy_baseline-* phony targets that regenerate the tidy baseline
+// of a directory from the current findings of clang-tidy in all the modules of the directory.
+// `m tidy_baseline-<dir>` writes $OUT_DIR/soong/tidy_baseline/<dir>/tidy_baseline.sarif, which can
+// be copied to the directory and used as the tidy_baseline of its modules.
+func TidyBaselineSingleton() android.Singleton {
+	return &tidyBaselineSingleton{}
+}
+
+type tidyBaselineSingleton struct{}
+
+func (t *tidyBaselineSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	tidyFilesInDir := make(map[string]android.Paths)
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(*Module); ok && m.Enabled() {
+			if len(m.tidyFiles) > 0 {
+				dir := ctx.ModuleDir(module)
+				tidyFilesInDir[dir] = append(tidyFilesInDir[dir], m.tidyFiles...)
+			}
+		}
+	})
+
+	for _, dir := range android.SortedKeys(tidyFilesInDir) {
+		tidyFiles := android.SortedUniquePaths(tidyFilesInDir[dir])
+		outputFile := android.PathForOutput(ctx, "tidy_baseline", dir, "tidy_baseline.sarif")
+		var args []string
+		for _, tidyFile := range tidyFiles {
+			args = append(args, "-clang-tidy", tidyFile.String())
+		}
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        tidyBaseline,
+			Description: "clang-tidy baseline " + dir,
+			Output:      outputFile,
+			Inputs:      tidyFiles,
+			Args: map[string]string{
+				"args": strings.Join(args, " "),
+			},
+		})
+		ctx.Phony("tidy_baseline-"+strings.Replace(filepath.Clean(dir), "/", "-", -1), outputFile)
+	}
+}
@@ -244,3 +244,66 @@ func TestWithTidy(t *testing.T) {
 		})
 	}
 }
+
+func TestTidyBaseline(t *testing.T) {
+	bp := `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			tidy_checks_as_errors: ["xyz-*"],
+			tidy_baseline: "tidy_baseline.sarif",
+		}
+		cc_library_shared {
+			name: "libbar",
+			srcs: ["bar.c"],
+			tidy_checks_as_errors: ["xyz-*"],
+		}
+		cc_library_shared {
+			name: "libbaz",
+			srcs: ["baz.c"],
+		}`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("tidy_baseline", TidyBaselineSingleton)
+		}),
+		android.FixtureMergeEnv(map[string]string{
+			"WITH_TIDY":     "1",
+			"TIDY_BASELINE": "build/tidy_baseline.sarif",
+		}),
+		android.FixtureAddTextFile("dir/Android.bp", bp),
+		android.FixtureAddFile("dir/tidy_baseline.sarif", nil),
+		android.FixtureAddFile("build/tidy_baseline.sarif", nil),
+	).RunTest(t)
+
+	variant := "android_arm64_armv8-a_shared"
+	testCases := []struct {
+		libName, baseline string
+	}{
+		{"libfoo", "dir/tidy_baseline.sarif"},
+		{"libbar", "build/tidy_baseline.sarif"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.libName, func(t *testing.T) {
+			module := result.ModuleForTests(tc.libName, variant)
+			tidyFlags := module.Rule("clangTidy").Args["tidyFlags"]
+			android.AssertStringDoesNotContain(t, "tidyFlags", tidyFlags, "-warnings-as-errors")
+
+			check := module.Rule("tidyBaselineCheck")
+			android.AssertStringEquals(t, "baseline", tc.baseline, check.Args["baseline"])
+			android.AssertStringEquals(t, "checks", "'xyz-*',${config.TidyGlobalNoErrorChecks}", check.Args["checks"])
+			android.AssertStringListContains(t, "link validations", module.Rule("ld").Validations.Strings(), check.Output.String())
+		})
+	}
+
+	// libbaz has no checks as errors, so the baseline does not apply.
+	if rule := result.ModuleForTests("libbaz", variant).MaybeRule("tidyBaselineCheck"); rule.Rule != nil {
+		t.Errorf("expected no tidy baseline check for libbaz")
+	}
+
+	baseline := result.SingletonForTests("tidy_baseline").Output("tidy_baseline/dir/tidy_baseline.sarif")
+	android.AssertStringDoesContain(t, "tidy baseline args", baseline.Args["args"],
+		"-clang-tidy out/soong/.intermediates/dir/libbaz/"+variant+"/obj/baz.tidy")
+	android.AssertStringDoesNotContain(t, "tidy baseline args", baseline.Args["args"], "tidy_baseline_check.sarif")
+}
@@ -69,6 +69,9 @@ func flagsToBuilderFlags(in Flags) builderFlags {
 		sAbiDump:      in.SAbiDump,
 		emitXrefs:     in.EmitXrefs,
 
+		tidyBaseline:       in.TidyBaseline,
+		tidyChecksAsErrors: in.TidyChecksAsErrors,
+
 		systemIncludeFlags: strings.Join(in.SystemIncludeFlags, " "),
 
 		assemblerWithCpp: in.AssemblerWithCpp,
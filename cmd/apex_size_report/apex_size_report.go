@@ -0,0 +1,192 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// apex_size_report writes the size breakdown of an APEX by module and by file type, and checks
+// the APEX against its size budget.
+//
+//	apex_size_report report -name <apex> -contents <contents.tsv> -apex <file.apex> [-capex <file.capex>]
+//	    [-budget <bytes>] [-compressed_budget <bytes>] -o <report.json>
+//	apex_size_report merge -o <out.json> -l <list of reports>
+//
+// The report command always writes the report, and exits with an error listing the largest files
+// and modules of the APEX if it exceeds its budget.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The number of files and modules listed when an APEX exceeds its budget.
+const topContributors = 10
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apex_size_report report -name <apex> -contents <contents.tsv> -apex <file.apex> [-capex <file.capex>]")
+	fmt.Fprintln(os.Stderr, "           [-budget <bytes>] [-compressed_budget <bytes>] -o <report.json>")
+	fmt.Fprintln(os.Stderr, "       apex_size_report merge -o <out.json> -l <list of reports>")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "report":
+		reportMain(os.Args[2:])
+	case "merge":
+		mergeMain(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func reportMain(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	name := flags.String("name", "", "the name of the APEX")
+	contents := flags.String("contents", "", "the list of files packaged in the APEX")
+	apex := flags.String("apex", "", "the APEX file")
+	capex := flags.String("capex", "", "the compressed APEX file, if the APEX is compressed")
+	budget := flags.Int64("budget", 0, "the maximum size of the APEX file in bytes, or 0")
+	compressedBudget := flags.Int64("compressed_budget", 0, "the maximum size of the compressed APEX file in bytes, or 0")
+	out := flags.String("o", "", "file to write the report to")
+	flags.Parse(args)
+
+	if *name == "" || *contents == "" || *apex == "" || *out == "" || flags.NArg() != 0 {
+		usage()
+	}
+
+	f, err := os.Open(*contents)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *contents, err)
+		os.Exit(1)
+	}
+	files, err := readContents(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *contents, err)
+		os.Exit(1)
+	}
+
+	report, err := buildReport(*name, files, statSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error measuring %s: %v\n", *name, err)
+		os.Exit(1)
+	}
+	report.SizeBudget = *budget
+	report.CompressedSizeBudget = *compressedBudget
+	if report.Size, err = statSize(*apex); err != nil {
+		fmt.Fprintf(os.Stderr, "Error measuring %s: %v\n", *apex, err)
+		os.Exit(1)
+	}
+	if *capex != "" {
+		if report.CompressedSize, err = statSize(*capex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error measuring %s: %v\n", *capex, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := writeJSON(*out, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+
+	if msg := report.checkBudget(topContributors); msg != "" {
+		fmt.Fprint(os.Stderr, msg)
+		os.Exit(1)
+	}
+}
+
+// mergedReport is the size breakdown of all the APEXes of a build.
+type mergedReport struct {
+	Apexes []*apexSizeReport `json:"apexes"`
+}
+
+func mergeMain(args []string) {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	out := flags.String("o", "", "file to write the merged report to")
+	list := flags.String("l", "", "file listing the reports to merge, one per line")
+	flags.Parse(args)
+
+	if *out == "" || *list == "" || flags.NArg() != 0 {
+		usage()
+	}
+
+	paths, err := readList(*list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *list, err)
+		os.Exit(1)
+	}
+
+	merged := &mergedReport{Apexes: []*apexSizeReport{}}
+	for _, path := range paths {
+		report, err := readReport(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		merged.Apexes = append(merged.Apexes, report)
+	}
+	sort.SliceStable(merged.Apexes, func(i, j int) bool {
+		return merged.Apexes[i].Apex < merged.Apexes[j].Apex
+	})
+
+	if err := writeJSON(*out, merged); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
+
+// readList reads the whitespace separated paths in a file.
+func readList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		paths = append(paths, scanner.Text())
+	}
+	return paths, scanner.Err()
+}
+
+func readReport(path string) (*apexSizeReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	report := &apexSizeReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.TrimSpace(string(data))+"\n"), 0666)
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentFile is a line of the contents file of an APEX, which lists the files that are packaged
+// in it.
+type contentFile struct {
+	module    string
+	fileType  string
+	path      string
+	builtFile string
+	// The bloaty output for the built file if it was measured, or "".
+	bloatyFile string
+}
+
+// readContents reads a contents file, in which each line has the tab separated module name, file
+// type, path in the APEX, path of the built file and optional path of the bloaty output of a file.
+func readContents(r io.Reader) ([]contentFile, error) {
+	var files []contentFile
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab separated fields, found %d", line, len(fields))
+		}
+		files = append(files, contentFile{
+			module:     fields[0],
+			fileType:   fields[1],
+			path:       fields[2],
+			builtFile:  fields[3],
+			bloatyFile: fields[4],
+		})
+	}
+	return files, scanner.Err()
+}
+
+// apexSizeReport is the size breakdown of an APEX.
+type apexSizeReport struct {
+	Apex string `json:"apex"`
+
+	// The size of the APEX file, and of the compressed APEX file if the APEX is compressed.
+	Size           int64 `json:"size"`
+	CompressedSize int64 `json:"compressed_size,omitempty"`
+
+	// The size_budget of the APEX, if it has one.
+	SizeBudget           int64 `json:"size_budget,omitempty"`
+	CompressedSizeBudget int64 `json:"compressed_size_budget,omitempty"`
+
+	// The total size of the files packaged in the APEX, before they are packaged.
+	ContentsSize int64 `json:"contents_size"`
+
+	// The sizes of the files grouped by module and by file type, largest first.
+	Modules   []sizeGroup `json:"modules"`
+	FileTypes []sizeGroup `json:"file_types"`
+
+	// The files packaged in the APEX, largest first.
+	Files []fileSize `json:"files"`
+}
+
+type sizeGroup struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Files int    `json:"files"`
+}
+
+type fileSize struct {
+	Path     string `json:"path"`
+	Module   string `json:"module"`
+	FileType string `json:"file_type"`
+	Size     int64  `json:"size"`
+
+	// The sizes of the sections of binaries measured by bloaty.
+	Sections []sectionSize `json:"sections,omitempty"`
+}
+
+type sectionSize struct {
+	Name     string `json:"name"`
+	FileSize int64  `json:"file_size"`
+	VmSize   int64  `json:"vm_size"`
+}
+
+// readBloatyCsv reads the section sizes from the CSV output of bloaty.
+func readBloatyCsv(r io.Reader) ([]sectionSize, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"sections", "vmsize", "filesize"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var sections []sectionSize
+	for _, record := range records[1:] {
+		vmSize, err := strconv.ParseInt(record[columns["vmsize"]], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		fileSize, err := strconv.ParseInt(record[columns["filesize"]], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, sectionSize{
+			Name:     record[columns["sections"]],
+			FileSize: fileSize,
+			VmSize:   vmSize,
+		})
+	}
+	return sections, nil
+}
+
+// fileSizer returns the size of a file, it is replaced in tests.
+type fileSizer func(path string) (int64, error)
+
+func statSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// buildReport measures the files of an APEX and groups their sizes by module and by file type.
+func buildReport(apex string, files []contentFile, size fileSizer) (*apexSizeReport, error) {
+	report := &apexSizeReport{
+		Apex:      apex,
+		Modules:   []sizeGroup{},
+		FileTypes: []sizeGroup{},
+		Files:     []fileSize{},
+	}
+
+	modules := make(map[string]*sizeGroup)
+	fileTypes := make(map[string]*sizeGroup)
+	add := func(groups map[string]*sizeGroup, name string, n int64) {
+		if groups[name] == nil {
+			groups[name] = &sizeGroup{Name: name}
+		}
+		groups[name].Size += n
+		groups[name].Files++
+	}
+
+	for _, f := range files {
+		n, err := size(f.builtFile)
+		if err != nil {
+			return nil, err
+		}
+		entry := fileSize{
+			Path:     f.path,
+			Module:   f.module,
+			FileType: f.fileType,
+			Size:     n,
+		}
+		if f.bloatyFile != "" {
+			entry.Sections, err = readBloatyFile(f.bloatyFile)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.bloatyFile, err)
+			}
+		}
+		report.Files = append(report.Files, entry)
+		report.ContentsSize += n
+		add(modules, f.module, n)
+		add(fileTypes, f.fileType, n)
+	}
+
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		if report.Files[i].Size != report.Files[j].Size {
+			return report.Files[i].Size > report.Files[j].Size
+		}
+		return report.Files[i].Path < report.Files[j].Path
+	})
+	report.Modules = sortedGroups(modules)
+	report.FileTypes = sortedGroups(fileTypes)
+	return report, nil
+}
+
+func readBloatyFile(path string) ([]sectionSize, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readBloatyCsv(f)
+}
+
+// sortedGroups returns the groups sorted by decreasing size.
+func sortedGroups(groups map[string]*sizeGroup) []sizeGroup {
+	ret := make([]sizeGroup, 0, len(groups))
+	for _, g := range groups {
+		ret = append(ret, *g)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Size != ret[j].Size {
+			return ret[i].Size > ret[j].Size
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// checkBudget returns a description of the ways in which the APEX exceeds its size budget, listing
+// the largest files and modules in it, or "" if it is within its budget.
+func (r *apexSizeReport) checkBudget(top int) string {
+	var sb strings.Builder
+	if r.SizeBudget > 0 && r.Size > r.SizeBudget {
+		fmt.Fprintf(&sb, "error: %s is %d bytes, which exceeds its size_budget.uncompressed of %d bytes by %d bytes.\n",
+			r.Apex, r.Size, r.SizeBudget, r.Size-r.SizeBudget)
+	}
+	if r.CompressedSizeBudget > 0 && r.CompressedSize > r.CompressedSizeBudget {
+		fmt.Fprintf(&sb, "error: compressed %s is %d bytes, which exceeds its size_budget.compressed of %d bytes by %d bytes.\n",
+			r.Apex, r.CompressedSize, r.CompressedSizeBudget, r.CompressedSize-r.CompressedSizeBudget)
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+
+	fmt.Fprintf(&sb, "The largest files in %s are:\n", r.Apex)
+	for i, f := range r.Files {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(&sb, "  %12d  %s (%s)\n", f.Size, f.Path, f.Module)
+	}
+	fmt.Fprintf(&sb, "The largest modules in %s are:\n", r.Apex)
+	for i, m := range r.Modules {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(&sb, "  %12d  %s\n", m.Size, m.Name)
+	}
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadContents(t *testing.T) {
+	files, err := readContents(strings.NewReader(
+		"libfoo\tnativeSharedLib\tlib64/libfoo.so\tout/libfoo.so\tout/libfoo.so.bloaty.csv\n" +
+			"\n" +
+			"foo-conf\tetc\tetc/foo.conf\tfoo.conf\t\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []contentFile{
+		{"libfoo", "nativeSharedLib", "lib64/libfoo.so", "out/libfoo.so", "out/libfoo.so.bloaty.csv"},
+		{"foo-conf", "etc", "etc/foo.conf", "foo.conf", ""},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %#v\ngot %#v", want, files)
+	}
+
+	if _, err := readContents(strings.NewReader("libfoo\tnativeSharedLib\n")); err == nil ||
+		err.Error() != "line 1: expected 5 tab separated fields, found 2" {
+		t.Errorf("expected field count error, got %v", err)
+	}
+}
+
+func TestReadBloatyCsv(t *testing.T) {
+	sections, err := readBloatyCsv(strings.NewReader(
+		"sections,vmsize,filesize\n" +
+			".text,1000,1024\n" +
+			".rodata,200,256\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sectionSize{
+		{Name: ".text", FileSize: 1024, VmSize: 1000},
+		{Name: ".rodata", FileSize: 256, VmSize: 200},
+	}
+	if !reflect.DeepEqual(sections, want) {
+		t.Errorf("expected %#v\ngot %#v", want, sections)
+	}
+
+	if _, err := readBloatyCsv(strings.NewReader("sections,vmsize\n.text,1000\n")); err == nil {
+		t.Errorf("expected error for missing filesize column")
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	dir := t.TempDir()
+	bloaty := filepath.Join(dir, "libfoo.so.bloaty.csv")
+	if err := os.WriteFile(bloaty, []byte("sections,vmsize,filesize\n.text,3000,3000\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := map[string]int64{
+		"libfoo.so":     4000,
+		"libfoo_jni.so": 1000,
+		"foo.jar":       2500,
+		"foo.conf":      100,
+	}
+	size := func(path string) (int64, error) {
+		if n, ok := sizes[path]; ok {
+			return n, nil
+		}
+		return 0, fmt.Errorf("unexpected file %s", path)
+	}
+
+	report, err := buildReport("com.android.foo", []contentFile{
+		{"foo-conf", "etc", "etc/foo.conf", "foo.conf", ""},
+		{"libfoo", "nativeSharedLib", "lib64/libfoo.so", "libfoo.so", bloaty},
+		{"libfoo", "nativeSharedLib", "lib64/libfoo_jni.so", "libfoo_jni.so", ""},
+		{"foo", "javaSharedLib", "javalib/foo.jar", "foo.jar", ""},
+	}, size)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &apexSizeReport{
+		Apex:         "com.android.foo",
+		ContentsSize: 7600,
+		Modules: []sizeGroup{
+			{Name: "libfoo", Size: 5000, Files: 2},
+			{Name: "foo", Size: 2500, Files: 1},
+			{Name: "foo-conf", Size: 100, Files: 1},
+		},
+		FileTypes: []sizeGroup{
+			{Name: "nativeSharedLib", Size: 5000, Files: 2},
+			{Name: "javaSharedLib", Size: 2500, Files: 1},
+			{Name: "etc", Size: 100, Files: 1},
+		},
+		Files: []fileSize{
+			{Path: "lib64/libfoo.so", Module: "libfoo", FileType: "nativeSharedLib", Size: 4000,
+				Sections: []sectionSize{{Name: ".text", FileSize: 3000, VmSize: 3000}}},
+			{Path: "javalib/foo.jar", Module: "foo", FileType: "javaSharedLib", Size: 2500},
+			{Path: "lib64/libfoo_jni.so", Module: "libfoo", FileType: "nativeSharedLib", Size: 1000},
+			{Path: "etc/foo.conf", Module: "foo-conf", FileType: "etc", Size: 100},
+		},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("expected %#v\ngot %#v", want, report)
+	}
+
+	if _, err := buildReport("com.android.foo", []contentFile{{"bar", "etc", "etc/bar", "bar", ""}}, size); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	report := &apexSizeReport{
+		Apex: "com.android.foo",
+		Size: 8000,
+		Modules: []sizeGroup{
+			{Name: "libfoo", Size: 5000, Files: 2},
+			{Name: "foo", Size: 2500, Files: 1},
+		},
+		Files: []fileSize{
+			{Path: "lib64/libfoo.so", Module: "libfoo", Size: 4000},
+			{Path: "javalib/foo.jar", Module: "foo", Size: 2500},
+			{Path: "lib64/libfoo_jni.so", Module: "libfoo", Size: 1000},
+		},
+	}
+
+	if msg := report.checkBudget(2); msg != "" {
+		t.Errorf("expected no error without a budget, got %q", msg)
+	}
+
+	report.SizeBudget = 8000
+	report.CompressedSize = 6000
+	report.CompressedSizeBudget = 6000
+	if msg := report.checkBudget(2); msg != "" {
+		t.Errorf("expected no error within the budget, got %q", msg)
+	}
+
+	report.SizeBudget = 7000
+	report.CompressedSizeBudget = 5000
+	want := "error: com.android.foo is 8000 bytes, which exceeds its size_budget.uncompressed of 7000 bytes by 1000 bytes.\n" +
+		"error: compressed com.android.foo is 6000 bytes, which exceeds its size_budget.compressed of 5000 bytes by 1000 bytes.\n" +
+		"The largest files in com.android.foo are:\n" +
+		"          4000  lib64/libfoo.so (libfoo)\n" +
+		"          2500  javalib/foo.jar (foo)\n" +
+		"The largest modules in com.android.foo are:\n" +
+		"          5000  libfoo\n" +
+		"          2500  foo\n"
+	if msg := report.checkBudget(2); msg != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, msg)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// archiveComparator compares the entries of zip files, e.g. APKs, jars and APEXes, and explains the
+// differences between the versions of the modified entries with the other comparators.
+type archiveComparator struct{}
+
+func (archiveComparator) Name() string { return "archive" }
+
+func (archiveComparator) Matches(name string, data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06"))
+}
+
+func (archiveComparator) Compare(e *explainer, a, b []byte) ([]difference, error) {
+	zrA, err := zip.NewReader(bytes.NewReader(a), int64(len(a)))
+	if err != nil {
+		return nil, err
+	}
+	zrB, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	filesA := sortedZipFiles(zrA.File)
+	filesB := sortedZipFiles(zrB.File)
+
+	var diffs []difference
+	i, j := 0, 0
+	for i < len(filesA) || j < len(filesB) {
+		switch {
+		case j == len(filesB) || (i < len(filesA) && filesA[i].Name < filesB[j].Name):
+			diffs = append(diffs, difference{Kind: "entry_removed", Name: filesA[i].Name})
+			i++
+		case i == len(filesA) || filesB[j].Name < filesA[i].Name:
+			diffs = append(diffs, difference{Kind: "entry_added", Name: filesB[j].Name})
+			j++
+		default:
+			d, err := compareZipEntries(e, filesA[i], filesB[j])
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, d...)
+			i++
+			j++
+		}
+	}
+
+	if len(diffs) == 0 && !sameZipEntryOrder(zrA.File, zrB.File) {
+		diffs = append(diffs, difference{Kind: "entry_order"})
+	}
+	return diffs, nil
+}
+
+// compareZipEntries compares the contents of two versions of an entry, or their headers if they
+// have the same contents.
+func compareZipEntries(e *explainer, a, b *zip.File) ([]difference, error) {
+	if a.CRC32 != b.CRC32 || a.UncompressedSize64 != b.UncompressedSize64 {
+		d := difference{
+			Kind: "entry_modified",
+			Name: a.Name,
+			A:    strconv.FormatUint(a.UncompressedSize64, 10) + " bytes",
+			B:    strconv.FormatUint(b.UncompressedSize64, 10) + " bytes",
+		}
+		if a.UncompressedSize64 <= maxExplainSize && b.UncompressedSize64 <= maxExplainSize {
+			dataA, err := readZipFile(a)
+			if err != nil {
+				return nil, err
+			}
+			dataB, err := readZipFile(b)
+			if err != nil {
+				return nil, err
+			}
+			d.Nested = e.explain(a.Name, dataA, dataB)
+		}
+		return []difference{d}, nil
+	}
+
+	var diffs []difference
+	if !a.Modified.Equal(b.Modified) {
+		diffs = append(diffs, difference{Kind: "entry_timestamp", Name: a.Name,
+			A: a.Modified.UTC().String(), B: b.Modified.UTC().String()})
+	}
+	if a.Mode() != b.Mode() {
+		diffs = append(diffs, difference{Kind: "entry_permissions", Name: a.Name,
+			A: a.Mode().String(), B: b.Mode().String()})
+	}
+	if a.Method != b.Method || a.CompressedSize64 != b.CompressedSize64 {
+		diffs = append(diffs, difference{Kind: "entry_compression", Name: a.Name,
+			A: fmt.Sprintf("method %d, %d bytes", a.Method, a.CompressedSize64),
+			B: fmt.Sprintf("method %d, %d bytes", b.Method, b.CompressedSize64)})
+	}
+	if !bytes.Equal(a.Extra, b.Extra) {
+		diffs = append(diffs, difference{Kind: "entry_extra", Name: a.Name})
+	}
+	return diffs, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+func sortedZipFiles(files []*zip.File) []*zip.File {
+	sorted := append([]*zip.File(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func sameZipEntryOrder(a, b []*zip.File) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// comparator explains the differences between two versions of a file in a format that it
+// understands.
+type comparator interface {
+	// Name returns the name of the comparator in the explanations, e.g. "elf".
+	Name() string
+
+	// Matches returns true if the comparator understands the file with the given name and
+	// contents.
+	Matches(name string, data []byte) bool
+
+	// Compare returns the differences between the reference version a and the primary version b
+	// of a file.  Comparators of container formats can explain the differences between the
+	// versions of the files they contain with e.
+	Compare(e *explainer, a, b []byte) ([]difference, error)
+}
+
+// comparators is the list of comparators tried in order on the modified files, the first one
+// that matches both versions of a file explains it.
+var comparators = []comparator{
+	archiveComparator{},
+	elfComparator{},
+	dexComparator{},
+	propComparator{},
+}
+
+// The maximum depth of the archives nested in the modified files that are unpacked.
+const maxExplainDepth = 4
+
+// The maximum size of the files that are read into memory to be explained.
+const maxExplainSize = 256 << 20
+
+// difference is a difference between two versions of a file.
+type difference struct {
+	// Kind classifies the difference, e.g. "build_id" or "symbol_added".
+	Kind string `json:"kind"`
+	// Name is what differs, e.g. the name of a section, a symbol, an entry or a property.
+	Name string `json:"name,omitempty"`
+	// A and B are the values in the reference and in the primary versions, if they are
+	// meaningful.
+	A string `json:"a,omitempty"`
+	B string `json:"b,omitempty"`
+	// Nested explains the differences between the two versions of a modified entry of an
+	// archive.
+	Nested *explanation `json:"nested,omitempty"`
+}
+
+// explanation is the result of comparing two versions of a file with a comparator.
+type explanation struct {
+	// The name of the comparator, empty if no comparator understands the file.
+	Comparator  string       `json:"comparator,omitempty"`
+	Differences []difference `json:"differences,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// explainer explains the differences between two versions of a file with the comparators.
+type explainer struct {
+	comparators []comparator
+	depth       int
+}
+
+func newExplainer() *explainer {
+	return &explainer{comparators: comparators}
+}
+
+// explain compares two versions of a file with the first comparator that understands them.
+// Comparators that find no difference in files that differ explain them as "unexplained".
+func (e *explainer) explain(name string, a, b []byte) *explanation {
+	if e.depth > maxExplainDepth {
+		return &explanation{Error: "too deeply nested"}
+	}
+	for _, c := range e.comparators {
+		if !c.Matches(name, a) || !c.Matches(name, b) {
+			continue
+		}
+		nested := &explainer{comparators: e.comparators, depth: e.depth + 1}
+		diffs, err := c.Compare(nested, a, b)
+		if err != nil {
+			return &explanation{Comparator: c.Name(), Error: err.Error()}
+		}
+		if len(diffs) == 0 && !bytes.Equal(a, b) {
+			diffs = []difference{{Kind: "unexplained"}}
+		}
+		return &explanation{Comparator: c.Name(), Differences: diffs}
+	}
+	return &explanation{}
+}
+
+// explainFile compares the two versions of a modified file of the target files.
+func (e *explainer) explainFile(a, b *ZipArtifactFile) *explanation {
+	if a.UncompressedSize64 > maxExplainSize || b.UncompressedSize64 > maxExplainSize {
+		return &explanation{Error: "too large to explain"}
+	}
+	dataA, err := readZipArtifactFile(a)
+	if err != nil {
+		return &explanation{Error: err.Error()}
+	}
+	dataB, err := readZipArtifactFile(b)
+	if err != nil {
+		return &explanation{Error: err.Error()}
+	}
+	return e.explain(a.Name, dataA, dataB)
+}
+
+func readZipArtifactFile(f *ZipArtifactFile) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// kinds counts the differences of each kind in the explanation, including the nested ones.
+func (x *explanation) kinds(counts map[string]int) {
+	if x == nil {
+		return
+	}
+	for _, d := range x.Differences {
+		counts[d.Kind]++
+		d.Nested.kinds(counts)
+	}
+}
+
+// write pretty-prints the explanation with a line for each difference.
+func (x *explanation) write(w io.Writer, indent string) error {
+	if x == nil {
+		return nil
+	}
+	if x.Error != "" {
+		_, err := fmt.Fprintf(w, "%serror: %s\n", indent, x.Error)
+		return err
+	}
+	for _, d := range x.Differences {
+		line := []string{x.Comparator + ":", d.Kind}
+		if d.Name != "" {
+			line = append(line, d.Name)
+		}
+		if d.A != "" || d.B != "" {
+			line = append(line, fmt.Sprintf("(%s -> %s)", d.A, d.B))
+		}
+		if _, err := fmt.Fprintf(w, "%s%s\n", indent, strings.Join(line, " ")); err != nil {
+			return err
+		}
+		if err := d.Nested.write(w, indent+"   "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedUnion returns the sorted keys of two maps.
+func sortedUnion[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
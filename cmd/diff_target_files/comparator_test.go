@@ -0,0 +1,153 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testZipEntry struct {
+	name     string
+	contents []byte
+	modified time.Time
+}
+
+func testZip(t *testing.T, entries ...testZipEntry) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: e.modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(e.contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveComparator(t *testing.T) {
+	date := time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+	propsA := []byte("# comment\nro.a=1\nro.b=2\n")
+	propsB := []byte("ro.a=1\nro.b=3\nro.c=4\n")
+	dex := testDex(testDexClass{"LFoo;", []testDexMethod{{"a", "\x01\x00"}}})
+	nestedA := testZip(t, testZipEntry{"classes.dex", dex, date}, testZipEntry{"res", []byte("a"), date})
+	nestedB := testZip(t, testZipEntry{"classes.dex", dex, date}, testZipEntry{"res", []byte("b"), date})
+
+	a := testZip(t,
+		testZipEntry{"build.prop", propsA, date},
+		testZipEntry{"app.apk", nestedA, date},
+		testZipEntry{"removed", nil, date},
+		testZipEntry{"timestamp", []byte("foo"), date})
+	b := testZip(t,
+		testZipEntry{"added", nil, date},
+		testZipEntry{"app.apk", nestedB, date},
+		testZipEntry{"build.prop", propsB, date},
+		testZipEntry{"timestamp", []byte("foo"), date.Add(time.Hour)})
+
+	x := newExplainer().explain("system/app.zip", a, b)
+	want := &explanation{
+		Comparator: "archive",
+		Differences: []difference{
+			{Kind: "entry_added", Name: "added"},
+			{Kind: "entry_modified", Name: "app.apk", A: "378 bytes", B: "378 bytes",
+				Nested: &explanation{
+					Comparator: "archive",
+					Differences: []difference{
+						{Kind: "entry_modified", Name: "res", A: "1 bytes", B: "1 bytes",
+							Nested: &explanation{}},
+					},
+				}},
+			{Kind: "entry_modified", Name: "build.prop", A: "24 bytes", B: "21 bytes",
+				Nested: &explanation{
+					Comparator: "prop",
+					Differences: []difference{
+						{Kind: "property_modified", Name: "ro.b", A: "2", B: "3"},
+						{Kind: "property_added", Name: "ro.c", B: "4"},
+					},
+				}},
+			{Kind: "entry_removed", Name: "removed"},
+			{Kind: "entry_timestamp", Name: "timestamp",
+				A: "2008-01-01 00:00:00 +0000 UTC", B: "2008-01-01 01:00:00 +0000 UTC"},
+			// archive/zip records the modification time in an extended timestamp extra field.
+			{Kind: "entry_extra", Name: "timestamp"},
+		},
+	}
+	if !reflect.DeepEqual(x, want) {
+		t.Errorf("want explanation:\n%+v\ngot:\n%+v", want, x)
+	}
+
+	buf := &strings.Builder{}
+	if err := x.write(buf, ""); err != nil {
+		t.Fatal(err)
+	}
+	wantText := strings.Join([]string{
+		"archive: entry_added added",
+		"archive: entry_modified app.apk (378 bytes -> 378 bytes)",
+		"   archive: entry_modified res (1 bytes -> 1 bytes)",
+		"archive: entry_modified build.prop (24 bytes -> 21 bytes)",
+		"   prop: property_modified ro.b (2 -> 3)",
+		"   prop: property_added ro.c ( -> 4)",
+		"archive: entry_removed removed",
+		"archive: entry_timestamp timestamp (2008-01-01 00:00:00 +0000 UTC -> 2008-01-01 01:00:00 +0000 UTC)",
+		"archive: entry_extra timestamp",
+		"",
+	}, "\n")
+	if buf.String() != wantText {
+		t.Errorf("want text:\n%s\ngot:\n%s", wantText, buf.String())
+	}
+
+	counts := make(map[string]int)
+	x.kinds(counts)
+	wantCounts := map[string]int{
+		"entry_added":       1,
+		"entry_extra":       1,
+		"entry_modified":    3,
+		"entry_removed":     1,
+		"entry_timestamp":   1,
+		"property_added":    1,
+		"property_modified": 1,
+	}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("want kinds %v, got %v", wantCounts, counts)
+	}
+}
+
+func TestArchiveComparatorOrder(t *testing.T) {
+	x := testZipEntry{"x", []byte("x"), time.Time{}}
+	y := testZipEntry{"y", []byte("y"), time.Time{}}
+	got := newExplainer().explain("a.jar", testZip(t, x, y), testZip(t, y, x))
+	want := &explanation{Comparator: "archive", Differences: []difference{{Kind: "entry_order"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want explanation %+v, got %+v", want, got)
+	}
+}
+
+func TestExplainUnknownFormat(t *testing.T) {
+	got := newExplainer().explain("foo.txt", []byte("foo"), []byte("bar"))
+	if !reflect.DeepEqual(got, &explanation{}) {
+		t.Errorf("want an empty explanation, got %+v", got)
+	}
+}
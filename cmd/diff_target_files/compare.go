@@ -56,6 +56,12 @@ type zipDiff struct {
 
 // String pretty-prints the list of files that differ between two zip files.
 func (d *zipDiff) String() string {
+	return d.format(nil)
+}
+
+// format pretty-prints the list of files that differ between two zip files, with the explanations
+// of the differences between the versions of the modified files by name, if any.
+func (d *zipDiff) format(explanations map[string]*explanation) string {
 	buf := &bytes.Buffer{}
 
 	must := func(n int, err error) {
@@ -70,6 +76,7 @@ func (d *zipDiff) String() string {
 		must(fmt.Fprintln(buf, "files modified:"))
 		for _, f := range d.modified {
 			must(fmt.Fprintf(buf, "   %v (%v bytes -> %v bytes)\n", f[0].Name, f[0].UncompressedSize64, f[1].UncompressedSize64))
+			must(0, explanations[f[0].Name].write(buf, "      "))
 			sizeChange += int64(f[1].UncompressedSize64) - int64(f[0].UncompressedSize64)
 		}
 	}
@@ -97,6 +104,16 @@ func (d *zipDiff) String() string {
 	return buf.String()
 }
 
+// explainModified explains the differences between the versions of the modified files, by name.
+func explainModified(d zipDiff) map[string]*explanation {
+	e := newExplainer()
+	explanations := make(map[string]*explanation, len(d.modified))
+	for _, f := range d.modified {
+		explanations[f[0].Name] = e.explainFile(f[0], f[1])
+	}
+	return explanations
+}
+
 func diffTargetFilesLists(a, b []*ZipArtifactFile) zipDiff {
 	i := 0
 	j := 0
@@ -0,0 +1,302 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// dexComparator compares the classes and the methods of dex files.
+type dexComparator struct{}
+
+func (dexComparator) Name() string { return "dex" }
+
+func (dexComparator) Matches(name string, data []byte) bool {
+	return bytes.HasPrefix(data, []byte("dex\n"))
+}
+
+func (dexComparator) Compare(e *explainer, a, b []byte) ([]difference, error) {
+	dexA, err := parseDex(a)
+	if err != nil {
+		return nil, err
+	}
+	dexB, err := parseDex(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []difference
+
+	// The instructions refer to strings, types, fields and methods by their index in the tables
+	// of ids, comparing them is meaningless when the tables differ.
+	sameIds := dexA.ids == dexB.ids
+	if !sameIds {
+		diffs = append(diffs, difference{Kind: "ids_modified"})
+	}
+
+	for _, class := range sortedUnion(dexA.classes, dexB.classes) {
+		cA, inA := dexA.classes[class]
+		cB, inB := dexB.classes[class]
+		switch {
+		case !inA:
+			diffs = append(diffs, difference{Kind: "class_added", Name: class})
+			continue
+		case !inB:
+			diffs = append(diffs, difference{Kind: "class_removed", Name: class})
+			continue
+		case cA.accessFlags != cB.accessFlags || cA.superclass != cB.superclass:
+			diffs = append(diffs, difference{Kind: "class_modified", Name: class})
+		}
+
+		for _, method := range sortedUnion(cA.methods, cB.methods) {
+			mA, inA := cA.methods[method]
+			mB, inB := cB.methods[method]
+			name := class + "->" + method
+			switch {
+			case !inA:
+				diffs = append(diffs, difference{Kind: "method_added", Name: name})
+			case !inB:
+				diffs = append(diffs, difference{Kind: "method_removed", Name: name})
+			case mA.accessFlags != mB.accessFlags:
+				diffs = append(diffs, difference{Kind: "method_modified", Name: name,
+					A: "access flags 0x" + strconv.FormatUint(uint64(mA.accessFlags), 16),
+					B: "access flags 0x" + strconv.FormatUint(uint64(mB.accessFlags), 16)})
+			case len(mA.code) != len(mB.code):
+				diffs = append(diffs, difference{Kind: "method_modified", Name: name,
+					A: strconv.Itoa(len(mA.code)) + " bytes of code",
+					B: strconv.Itoa(len(mB.code)) + " bytes of code"})
+			case sameIds && !bytes.Equal(mA.code, mB.code):
+				diffs = append(diffs, difference{Kind: "method_modified", Name: name})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// dexFile is the part of a dex file that dexComparator compares.
+type dexFile struct {
+	// The strings and the tables of ids of the file, see dexReader.ids.
+	ids string
+	// The classes defined in the file by descriptor.
+	classes map[string]dexClass
+}
+
+type dexClass struct {
+	accessFlags uint32
+	superclass  string
+	// The methods defined in the class by name and prototype, e.g. foo(ILjava/lang/String;)V.
+	methods map[string]dexMethod
+}
+
+type dexMethod struct {
+	accessFlags uint32
+	// The instructions of the method, empty for abstract and native methods.
+	code []byte
+}
+
+var errInvalidDex = errors.New("invalid dex file")
+
+// dexReader reads the little endian values of a dex file, it records the first out of bounds
+// read in err and returns zeroes after it.
+type dexReader struct {
+	data []byte
+	err  error
+}
+
+func (r *dexReader) bytes(off, size uint64) []byte {
+	if r.err != nil || off+size > uint64(len(r.data)) {
+		r.err = errInvalidDex
+		return nil
+	}
+	return r.data[off : off+size]
+}
+
+func (r *dexReader) u16(off uint64) uint16 {
+	if b := r.bytes(off, 2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *dexReader) u32(off uint64) uint32 {
+	if b := r.bytes(off, 4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// uleb128 returns the unsigned LEB128 value at off and the offset after it.
+func (r *dexReader) uleb128(off uint64) (uint32, uint64) {
+	var v uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := r.bytes(off, 1)
+		if b == nil {
+			return 0, off
+		}
+		off++
+		v |= uint32(b[0]&0x7f) << shift
+		if b[0]&0x80 == 0 {
+			return v, off
+		}
+	}
+	r.err = errInvalidDex
+	return 0, off
+}
+
+// table returns the number of items and the offset of a table of ids in the header.
+func (r *dexReader) table(headerOff uint64) (uint64, uint64) {
+	return uint64(r.u32(headerOff)), uint64(r.u32(headerOff + 4))
+}
+
+// Offsets in the header of the tables of ids and of the class definitions.
+const (
+	dexStringIds = 0x38
+	dexTypeIds   = 0x40
+	dexProtoIds  = 0x48
+	dexFieldIds  = 0x50
+	dexMethodIds = 0x58
+	dexClassDefs = 0x60
+)
+
+func parseDex(data []byte) (*dexFile, error) {
+	r := &dexReader{data: data}
+
+	count, off := r.table(dexStringIds)
+	strs := make([]string, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		// A string is its length in UTF-16 code units followed by its MUTF-8 bytes and a NUL.
+		_, start := r.uleb128(uint64(r.u32(off + 4*i)))
+		end := start
+		for b := r.bytes(end, 1); b != nil && b[0] != 0; b = r.bytes(end, 1) {
+			end++
+		}
+		strs = append(strs, string(r.bytes(start, end-start)))
+	}
+	str := func(i uint32) string {
+		if uint64(i) >= uint64(len(strs)) {
+			r.err = errInvalidDex
+			return ""
+		}
+		return strs[i]
+	}
+
+	count, off = r.table(dexTypeIds)
+	types := make([]string, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		types = append(types, str(r.u32(off+4*i)))
+	}
+	typ := func(i uint32) string {
+		if i == 0xffffffff {
+			return ""
+		} else if uint64(i) >= uint64(len(types)) {
+			r.err = errInvalidDex
+			return ""
+		}
+		return types[i]
+	}
+
+	count, off = r.table(dexProtoIds)
+	protos := make([]string, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		item := off + 12*i
+		var params []string
+		if paramsOff := uint64(r.u32(item + 8)); paramsOff != 0 {
+			n := uint64(r.u32(paramsOff))
+			for j := uint64(0); j < n && r.err == nil; j++ {
+				params = append(params, typ(uint32(r.u16(paramsOff+4+2*j))))
+			}
+		}
+		protos = append(protos, "("+strings.Join(params, "")+")"+typ(r.u32(item+4)))
+	}
+
+	count, off = r.table(dexMethodIds)
+	methods := make([]string, 0, count)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		item := off + 8*i
+		proto := ""
+		if p := uint64(r.u16(item + 2)); p < uint64(len(protos)) {
+			proto = protos[p]
+		} else {
+			r.err = errInvalidDex
+		}
+		methods = append(methods, str(r.u32(item+4))+proto)
+	}
+
+	fieldCount, fieldOff := r.table(dexFieldIds)
+	methodCount, methodOff := r.table(dexMethodIds)
+	ids := &strings.Builder{}
+	for _, s := range [][]string{strs, types, protos} {
+		ids.WriteString(strings.Join(s, "\x00"))
+		ids.WriteByte(0)
+	}
+	ids.Write(r.bytes(fieldOff, 8*fieldCount))
+	ids.Write(r.bytes(methodOff, 8*methodCount))
+
+	classes := make(map[string]dexClass)
+	count, off = r.table(dexClassDefs)
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		item := off + 32*i
+		class := dexClass{
+			accessFlags: r.u32(item + 4),
+			superclass:  typ(r.u32(item + 8)),
+			methods:     make(map[string]dexMethod),
+		}
+		if dataOff := uint64(r.u32(item + 24)); dataOff != 0 {
+			var staticFields, instanceFields, directMethods, virtualMethods uint32
+			p := dataOff
+			staticFields, p = r.uleb128(p)
+			instanceFields, p = r.uleb128(p)
+			directMethods, p = r.uleb128(p)
+			virtualMethods, p = r.uleb128(p)
+			for j := uint32(0); j < 2*(staticFields+instanceFields) && r.err == nil; j++ {
+				_, p = r.uleb128(p)
+			}
+			for _, n := range []uint32{directMethods, virtualMethods} {
+				// The indices of the methods in each list are encoded as differences from the
+				// previous one.
+				var methodIdx uint32
+				for j := uint32(0); j < n && r.err == nil; j++ {
+					var diff, accessFlags, codeOff uint32
+					diff, p = r.uleb128(p)
+					accessFlags, p = r.uleb128(p)
+					codeOff, p = r.uleb128(p)
+					methodIdx += diff
+					m := dexMethod{accessFlags: accessFlags}
+					if codeOff != 0 {
+						// The instructions are after a 16 byte header that ends with their
+						// number in 16 bit code units.
+						insns := uint64(r.u32(uint64(codeOff) + 12))
+						m.code = r.bytes(uint64(codeOff)+16, 2*insns)
+					}
+					if uint64(methodIdx) >= uint64(len(methods)) {
+						r.err = errInvalidDex
+						break
+					}
+					class.methods[methods[methodIdx]] = m
+				}
+			}
+		}
+		classes[typ(r.u32(item))] = class
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &dexFile{ids: ids.String(), classes: classes}, nil
+}
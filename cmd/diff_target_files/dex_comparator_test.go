@@ -0,0 +1,181 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type testDexMethod struct {
+	name string
+	// The instructions of the method, an even number of bytes.
+	code string
+}
+
+type testDexClass struct {
+	name    string
+	methods []testDexMethod
+}
+
+func appendUleb128(b []byte, v uint32) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// testDex returns a dex file that defines the classes, whose methods are all direct methods with
+// the prototype ()V.
+func testDex(classes ...testDexClass) []byte {
+	le := binary.LittleEndian
+
+	strs := []string{"V"}
+	types := []uint32{0}
+	type methodId struct{ class, name uint32 }
+	var methodIds []methodId
+	for _, c := range classes {
+		types = append(types, uint32(len(strs)))
+		strs = append(strs, c.name)
+		for _, m := range c.methods {
+			methodIds = append(methodIds, methodId{uint32(len(types) - 1), uint32(len(strs))})
+			strs = append(strs, m.name)
+		}
+	}
+
+	const headerSize = 0x70
+	stringIdsOff := uint32(headerSize)
+	typeIdsOff := stringIdsOff + 4*uint32(len(strs))
+	protoIdsOff := typeIdsOff + 4*uint32(len(types))
+	methodIdsOff := protoIdsOff + 12
+	classDefsOff := methodIdsOff + 8*uint32(len(methodIds))
+	dataOff := classDefsOff + 32*uint32(len(classes))
+
+	data := make([]byte, dataOff)
+	put := func(off, v uint32) { le.PutUint32(data[off:], v) }
+	table := func(headerOff uint32, n int, off uint32) {
+		put(headerOff, uint32(n))
+		put(headerOff+4, off)
+	}
+	copy(data, "dex\n035\x00")
+	table(dexStringIds, len(strs), stringIdsOff)
+	table(dexTypeIds, len(types), typeIdsOff)
+	table(dexProtoIds, 1, protoIdsOff)
+	table(dexMethodIds, len(methodIds), methodIdsOff)
+	table(dexClassDefs, len(classes), classDefsOff)
+
+	for i, s := range strs {
+		put(stringIdsOff+4*uint32(i), uint32(len(data)))
+		data = appendUleb128(data, uint32(len(s)))
+		data = append(append(data, s...), 0)
+	}
+	for i, s := range types {
+		put(typeIdsOff+4*uint32(i), s)
+	}
+	// The shorty and the return type of ()V are both the string and the type at index 0.
+	for i, m := range methodIds {
+		le.PutUint16(data[methodIdsOff+8*uint32(i):], uint16(m.class))
+		put(methodIdsOff+8*uint32(i)+4, m.name)
+	}
+
+	methodIdx := uint32(0)
+	for i, c := range classes {
+		var codeOffs []uint32
+		for _, m := range c.methods {
+			for len(data)%4 != 0 {
+				data = append(data, 0)
+			}
+			codeOffs = append(codeOffs, uint32(len(data)))
+			header := make([]byte, 16)
+			le.PutUint32(header[12:], uint32(len(m.code)/2))
+			data = append(append(data, header...), m.code...)
+		}
+
+		classDef := classDefsOff + 32*uint32(i)
+		put(classDef, uint32(i+1))
+		put(classDef+8, 0xffffffff)
+		put(classDef+24, uint32(len(data)))
+		data = appendUleb128(data, 0)
+		data = appendUleb128(data, 0)
+		data = appendUleb128(data, uint32(len(c.methods)))
+		data = appendUleb128(data, 0)
+		for j := range c.methods {
+			diff := uint32(1)
+			if j == 0 {
+				diff = methodIdx
+			}
+			data = appendUleb128(data, diff)
+			data = appendUleb128(data, 0x1)
+			data = appendUleb128(data, codeOffs[j])
+		}
+		methodIdx += uint32(len(c.methods))
+	}
+	return data
+}
+
+func TestDexComparator(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b []testDexClass
+		want []difference
+	}{
+		{
+			name: "classes and methods",
+			a: []testDexClass{
+				{"LFoo;", []testDexMethod{{"a", "\x01\x00"}, {"b", "\x02\x00"}}},
+				{"LBar;", []testDexMethod{{"c", "\x03\x00"}}},
+			},
+			b: []testDexClass{
+				{"LFoo;", []testDexMethod{{"a", "\x01\x00\x01\x00"}, {"d", "\x02\x00"}}},
+				{"LBaz;", []testDexMethod{{"c", "\x03\x00"}}},
+			},
+			want: []difference{
+				{Kind: "ids_modified"},
+				{Kind: "class_removed", Name: "LBar;"},
+				{Kind: "class_added", Name: "LBaz;"},
+				{Kind: "method_modified", Name: "LFoo;->a()V", A: "2 bytes of code", B: "4 bytes of code"},
+				{Kind: "method_removed", Name: "LFoo;->b()V"},
+				{Kind: "method_added", Name: "LFoo;->d()V"},
+			},
+		},
+		{
+			name: "code",
+			a:    []testDexClass{{"LFoo;", []testDexMethod{{"a", "\x01\x00"}, {"b", "\x02\x00"}}}},
+			b:    []testDexClass{{"LFoo;", []testDexMethod{{"a", "\x01\x00"}, {"b", "\x03\x00"}}}},
+			want: []difference{
+				{Kind: "method_modified", Name: "LFoo;->b()V"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			x := newExplainer().explain("classes.dex", testDex(tc.a...), testDex(tc.b...))
+			want := &explanation{Comparator: "dex", Differences: tc.want}
+			if !reflect.DeepEqual(x, want) {
+				t.Errorf("want explanation:\n%+v\ngot:\n%+v", want, x)
+			}
+		})
+	}
+}
+
+func TestDexComparatorInvalid(t *testing.T) {
+	valid := testDex(testDexClass{"LFoo;", []testDexMethod{{"a", "\x01\x00"}}})
+	x := newExplainer().explain("classes.dex", valid, valid[:len(valid)-4])
+	if x.Comparator != "dex" || x.Error != errInvalidDex.Error() {
+		t.Errorf("want an invalid dex file error, got %+v", x)
+	}
+}
@@ -26,6 +26,9 @@ var (
 	allowListFiles = newMultiString("allowlist_file", "files containing allowlist definitions")
 
 	filters = newMultiString("filter", "filter patterns to apply to files in target-files.zip before comparing")
+
+	explain    = flag.Bool("explain", false, "explain the differences between the versions of the modified files")
+	jsonReport = flag.String("json_report", "", "file to write a JSON report of the differences and their explanations to")
 )
 
 func newMultiString(name, usage string) *multiString {
@@ -73,7 +76,23 @@ func main() {
 		os.Exit(1)
 	}
 
-	fmt.Print(diff.String())
+	var explanations map[string]*explanation
+	if *explain || *jsonReport != "" {
+		explanations = explainModified(diff)
+	}
+
+	if *jsonReport != "" {
+		if err := writeJSONReport(*jsonReport, diff, explanations); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *explain {
+		fmt.Print(diff.format(explanations))
+	} else {
+		fmt.Print(diff.String())
+	}
 
 	if len(diff.modified) > 0 || len(diff.onlyInA) > 0 || len(diff.onlyInB) > 0 {
 		fmt.Fprintln(os.Stderr, "differences found")
@@ -0,0 +1,203 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+const (
+	buildIdSection = ".note.gnu.build-id"
+	ntGnuBuildId   = 3
+)
+
+// elfComparator compares the build ids, sections and symbols of ELF files.
+type elfComparator struct{}
+
+func (elfComparator) Name() string { return "elf" }
+
+func (elfComparator) Matches(name string, data []byte) bool {
+	return bytes.HasPrefix(data, []byte(elf.ELFMAG))
+}
+
+func (elfComparator) Compare(e *explainer, a, b []byte) ([]difference, error) {
+	fA, err := elf.NewFile(bytes.NewReader(a))
+	if err != nil {
+		return nil, err
+	}
+	fB, err := elf.NewFile(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []difference
+
+	idA, err := elfBuildId(fA)
+	if err != nil {
+		return nil, err
+	}
+	idB, err := elfBuildId(fB)
+	if err != nil {
+		return nil, err
+	}
+	if idA != idB {
+		diffs = append(diffs, difference{Kind: "build_id", A: idA, B: idB})
+	}
+
+	sectionDiffs, err := compareElfSections(fA, fB)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, sectionDiffs...)
+
+	symbolDiffs, err := compareElfSymbols(fA, fB)
+	if err != nil {
+		return nil, err
+	}
+	return append(diffs, symbolDiffs...), nil
+}
+
+// elfBuildId returns the hex encoded build id of an ELF file, or an empty string if it has none.
+func elfBuildId(f *elf.File) (string, error) {
+	s := f.Section(buildIdSection)
+	if s == nil {
+		return "", nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	// The section contains notes made of the sizes of their name and descriptor, their type,
+	// and their name and descriptor padded to 4 bytes.
+	align := func(n uint32) uint32 { return (n + 3) &^ 3 }
+	for len(data) >= 12 {
+		nameSize := f.ByteOrder.Uint32(data[0:])
+		descSize := f.ByteOrder.Uint32(data[4:])
+		noteType := f.ByteOrder.Uint32(data[8:])
+		data = data[12:]
+		if uint64(align(nameSize))+uint64(align(descSize)) > uint64(len(data)) {
+			break
+		}
+		name := data[:nameSize]
+		desc := data[align(nameSize) : align(nameSize)+descSize]
+		data = data[align(nameSize)+align(descSize):]
+		if noteType == ntGnuBuildId && string(bytes.TrimRight(name, "\x00")) == "GNU" {
+			return hex.EncodeToString(desc), nil
+		}
+	}
+	return "", errors.New("invalid " + buildIdSection + " section")
+}
+
+// compareElfSections returns the sections that were added, removed or modified, except for the
+// build id that is compared by elfBuildId.
+func compareElfSections(a, b *elf.File) ([]difference, error) {
+	sections := func(f *elf.File) map[string]*elf.Section {
+		ret := make(map[string]*elf.Section)
+		for _, s := range f.Sections {
+			if s.Name != "" && s.Name != buildIdSection {
+				ret[s.Name] = s
+			}
+		}
+		return ret
+	}
+	sectionsA := sections(a)
+	sectionsB := sections(b)
+
+	var diffs []difference
+	for _, name := range sortedUnion(sectionsA, sectionsB) {
+		sA, sB := sectionsA[name], sectionsB[name]
+		switch {
+		case sA == nil:
+			diffs = append(diffs, difference{Kind: "section_added", Name: name})
+		case sB == nil:
+			diffs = append(diffs, difference{Kind: "section_removed", Name: name})
+		default:
+			hashA, err := elfSectionHash(sA)
+			if err != nil {
+				return nil, err
+			}
+			hashB, err := elfSectionHash(sB)
+			if err != nil {
+				return nil, err
+			}
+			if sA.Size != sB.Size || sA.Type != sB.Type || sA.Flags != sB.Flags || hashA != hashB {
+				diffs = append(diffs, difference{Kind: "section_modified", Name: name,
+					A: strconv.FormatUint(sA.Size, 10) + " bytes",
+					B: strconv.FormatUint(sB.Size, 10) + " bytes"})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+func elfSectionHash(s *elf.Section) ([sha256.Size]byte, error) {
+	if s.Type == elf.SHT_NOBITS {
+		return [sha256.Size]byte{}, nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// compareElfSymbols returns the defined symbols that were added, removed or whose size changed,
+// the addresses of most symbols change whenever the code before them changes.
+func compareElfSymbols(a, b *elf.File) ([]difference, error) {
+	symbols := func(f *elf.File) (map[string]elf.Symbol, error) {
+		ret := make(map[string]elf.Symbol)
+		for _, read := range []func() ([]elf.Symbol, error){f.Symbols, f.DynamicSymbols} {
+			syms, err := read()
+			if err != nil && !errors.Is(err, elf.ErrNoSymbols) {
+				return nil, err
+			}
+			for _, s := range syms {
+				if s.Name != "" && s.Section != elf.SHN_UNDEF {
+					ret[s.Name] = s
+				}
+			}
+		}
+		return ret, nil
+	}
+	symbolsA, err := symbols(a)
+	if err != nil {
+		return nil, err
+	}
+	symbolsB, err := symbols(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []difference
+	for _, name := range sortedUnion(symbolsA, symbolsB) {
+		sA, inA := symbolsA[name]
+		sB, inB := symbolsB[name]
+		switch {
+		case !inA:
+			diffs = append(diffs, difference{Kind: "symbol_added", Name: name})
+		case !inB:
+			diffs = append(diffs, difference{Kind: "symbol_removed", Name: name})
+		case sA.Size != sB.Size:
+			diffs = append(diffs, difference{Kind: "symbol_size", Name: name,
+				A: strconv.FormatUint(sA.Size, 10), B: strconv.FormatUint(sB.Size, 10)})
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+type testElfSymbol struct {
+	name string
+	size uint64
+}
+
+// testElf returns a little endian 64 bit ELF file with a .text section, a build id and a symbol
+// table.
+func testElf(buildId string, text string, symbols ...testElfSymbol) []byte {
+	type section struct {
+		name      string
+		typ       elf.SectionType
+		data      []byte
+		link      uint32
+		info      uint32
+		entrySize uint64
+	}
+	le := binary.LittleEndian
+
+	note := &bytes.Buffer{}
+	binary.Write(note, le, []uint32{4, uint32(len(buildId)), ntGnuBuildId})
+	note.WriteString("GNU\x00" + buildId)
+	for note.Len()%4 != 0 {
+		note.WriteByte(0)
+	}
+
+	strtab := []byte{0}
+	symtab := make([]byte, 24)
+	for _, s := range symbols {
+		sym := make([]byte, 24)
+		le.PutUint32(sym[0:], uint32(len(strtab)))
+		sym[4] = byte(elf.STB_GLOBAL)<<4 | byte(elf.STT_FUNC)
+		le.PutUint16(sym[6:], 1) // .text
+		le.PutUint64(sym[16:], s.size)
+		symtab = append(symtab, sym...)
+		strtab = append(append(strtab, s.name...), 0)
+	}
+
+	sections := []section{
+		{},
+		{name: ".text", typ: elf.SHT_PROGBITS, data: []byte(text)},
+		{name: buildIdSection, typ: elf.SHT_NOTE, data: note.Bytes()},
+		{name: ".symtab", typ: elf.SHT_SYMTAB, data: symtab, link: 4, info: 1, entrySize: 24},
+		{name: ".strtab", typ: elf.SHT_STRTAB, data: strtab},
+		{name: ".shstrtab", typ: elf.SHT_STRTAB},
+	}
+	shstrtab := []byte{0}
+	nameOffsets := make([]uint32, len(sections))
+	for i, s := range sections[1:] {
+		nameOffsets[i+1] = uint32(len(shstrtab))
+		shstrtab = append(append(shstrtab, s.name...), 0)
+	}
+	sections[len(sections)-1].data = shstrtab
+
+	const headerSize, sectionHeaderSize = 64, 64
+	data := make([]byte, headerSize)
+	offsets := make([]uint64, len(sections))
+	for i, s := range sections {
+		for len(data)%8 != 0 {
+			data = append(data, 0)
+		}
+		offsets[i] = uint64(len(data))
+		data = append(data, s.data...)
+	}
+	for len(data)%8 != 0 {
+		data = append(data, 0)
+	}
+	sectionHeadersOffset := uint64(len(data))
+	for i, s := range sections {
+		sh := make([]byte, sectionHeaderSize)
+		le.PutUint32(sh[0:], nameOffsets[i])
+		le.PutUint32(sh[4:], uint32(s.typ))
+		if s.typ != elf.SHT_NULL {
+			le.PutUint64(sh[24:], offsets[i])
+			le.PutUint64(sh[32:], uint64(len(s.data)))
+		}
+		le.PutUint32(sh[40:], s.link)
+		le.PutUint32(sh[44:], s.info)
+		le.PutUint64(sh[48:], 1)
+		le.PutUint64(sh[56:], s.entrySize)
+		data = append(data, sh...)
+	}
+
+	copy(data, elf.ELFMAG)
+	data[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	data[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	data[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	le.PutUint16(data[16:], uint16(elf.ET_DYN))
+	le.PutUint16(data[18:], uint16(elf.EM_AARCH64))
+	le.PutUint32(data[20:], uint32(elf.EV_CURRENT))
+	le.PutUint64(data[40:], sectionHeadersOffset)
+	le.PutUint16(data[52:], headerSize)
+	le.PutUint16(data[58:], sectionHeaderSize)
+	le.PutUint16(data[60:], uint16(len(sections)))
+	le.PutUint16(data[62:], uint16(len(sections)-1))
+	return data
+}
+
+func TestElfComparator(t *testing.T) {
+	a := testElf("\x01\x02\x03\x04", "code", testElfSymbol{"foo", 4}, testElfSymbol{"bar", 8})
+	b := testElf("\x01\x02\x03\x05", "more code", testElfSymbol{"foo", 9}, testElfSymbol{"baz", 8})
+
+	x := newExplainer().explain("lib/libfoo.so", a, b)
+	want := &explanation{
+		Comparator: "elf",
+		Differences: []difference{
+			{Kind: "build_id", A: "01020304", B: "01020305"},
+			{Kind: "section_modified", Name: ".strtab", A: "9 bytes", B: "9 bytes"},
+			{Kind: "section_modified", Name: ".symtab", A: "72 bytes", B: "72 bytes"},
+			{Kind: "section_modified", Name: ".text", A: "4 bytes", B: "9 bytes"},
+			{Kind: "symbol_removed", Name: "bar"},
+			{Kind: "symbol_added", Name: "baz"},
+			{Kind: "symbol_size", Name: "foo", A: "4", B: "9"},
+		},
+	}
+	if !reflect.DeepEqual(x, want) {
+		t.Errorf("want explanation:\n%+v\ngot:\n%+v", want, x)
+	}
+}
+
+func TestElfComparatorSameBuildId(t *testing.T) {
+	a := testElf("\x01\x02\x03\x04", "code", testElfSymbol{"foo", 4})
+	b := testElf("\x01\x02\x03\x04", "edoc", testElfSymbol{"foo", 4})
+
+	x := newExplainer().explain("lib/libfoo.so", a, b)
+	want := &explanation{
+		Comparator: "elf",
+		Differences: []difference{
+			{Kind: "section_modified", Name: ".text", A: "4 bytes", B: "4 bytes"},
+		},
+	}
+	if !reflect.DeepEqual(x, want) {
+		t.Errorf("want explanation:\n%+v\ngot:\n%+v", want, x)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// propComparator compares the properties of build.prop files.
+type propComparator struct{}
+
+func (propComparator) Name() string { return "prop" }
+
+func (propComparator) Matches(name string, data []byte) bool {
+	base := filepath.Base(name)
+	return strings.HasSuffix(base, ".prop") || base == "prop.default"
+}
+
+func (propComparator) Compare(e *explainer, a, b []byte) ([]difference, error) {
+	propsA, err := parseProps(a)
+	if err != nil {
+		return nil, err
+	}
+	propsB, err := parseProps(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []difference
+	for _, k := range sortedUnion(propsA, propsB) {
+		valueA, inA := propsA[k]
+		valueB, inB := propsB[k]
+		switch {
+		case !inA:
+			diffs = append(diffs, difference{Kind: "property_added", Name: k, B: valueB})
+		case !inB:
+			diffs = append(diffs, difference{Kind: "property_removed", Name: k, A: valueA})
+		case valueA != valueB:
+			diffs = append(diffs, difference{Kind: "property_modified", Name: k, A: valueA, B: valueB})
+		}
+	}
+	return diffs, nil
+}
+
+// parseProps returns the properties of a build.prop file, the last value of a property that is
+// set several times overrides the others.
+func parseProps(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, _ := strings.Cut(line, "=")
+		props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return props, s.Err()
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// report is the JSON report of the differences between two zip files.  The reference zip is the
+// second argument and the primary zip the first one, a and b are their versions of a file.
+type report struct {
+	Modified []reportModifiedFile `json:"modified"`
+	Removed  []reportFile         `json:"removed"`
+	Added    []reportFile         `json:"added"`
+	// The number of differences of each kind in the explanations of the modified files,
+	// including the differences in nested archives, to classify their causes.
+	Kinds      map[string]int `json:"kinds"`
+	SizeChange int64          `json:"size_change"`
+}
+
+type reportFile struct {
+	Path string `json:"path"`
+	Size uint64 `json:"size"`
+}
+
+type reportModifiedFile struct {
+	Path        string       `json:"path"`
+	SizeA       uint64       `json:"size_a"`
+	SizeB       uint64       `json:"size_b"`
+	Explanation *explanation `json:"explanation,omitempty"`
+}
+
+func newReport(d zipDiff, explanations map[string]*explanation) *report {
+	r := &report{
+		Modified: []reportModifiedFile{},
+		Removed:  []reportFile{},
+		Added:    []reportFile{},
+		Kinds:    make(map[string]int),
+	}
+	for _, f := range d.modified {
+		x := explanations[f[0].Name]
+		r.Modified = append(r.Modified, reportModifiedFile{
+			Path:        f[0].Name,
+			SizeA:       f[0].UncompressedSize64,
+			SizeB:       f[1].UncompressedSize64,
+			Explanation: x,
+		})
+		x.kinds(r.Kinds)
+		r.SizeChange += int64(f[1].UncompressedSize64) - int64(f[0].UncompressedSize64)
+	}
+	for _, f := range d.onlyInA {
+		r.Removed = append(r.Removed, reportFile{Path: f.Name, Size: f.UncompressedSize64})
+		r.SizeChange -= int64(f.UncompressedSize64)
+	}
+	for _, f := range d.onlyInB {
+		r.Added = append(r.Added, reportFile{Path: f.Name, Size: f.UncompressedSize64})
+		r.SizeChange += int64(f.UncompressedSize64)
+	}
+	return r
+}
+
+func writeJSONReport(path string, d zipDiff, explanations map[string]*explanation) error {
+	buf, err := json.MarshalIndent(newReport(d, explanations), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0666)
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// findings_to_sarif normalizes the findings of the static analyzers run by the build (clang-tidy,
+// clippy, Error Prone and Android Lint) into a single SARIF file.  It is used both to create the
+// SARIF file of a module from the text outputs of the analyzers, and to merge the SARIF files of
+// all the modules.  With -baseline every finding is classified as new or unchanged, and with
+// -check the tool fails if there are new findings in the modules listed by -changed_modules, or
+// only if they are reported by the rules listed by -check_rules.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"android/soong/response"
+	"android/soong/sarif"
+
+	"github.com/google/blueprint/pathtools"
+)
+
+type multiString []string
+
+func (ms *multiString) String() string     { return strings.Join(*ms, ", ") }
+func (ms *multiString) Set(s string) error { *ms = append(*ms, s); return nil }
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -o <output file> [-module <name>] [-<tool> <output of tool>...] [-baseline <file>] [-check] [<sarif file>...]\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	tools := []string{sarif.ToolClangTidy, sarif.ToolClippy, sarif.ToolErrorProne}
+	inputs := make(map[string]*multiString)
+	for _, tool := range tools {
+		inputs[tool] = &multiString{}
+		flags.Var(inputs[tool], tool, "text output of "+tool+" to convert, can be repeated")
+	}
+	output := flags.String("o", "", "SARIF file to write")
+	module := flags.String("module", "", "name of the module to record in the converted findings")
+	baseline := flags.String("baseline", "", "SARIF file with the known findings")
+	check := flags.Bool("check", false, "fail if there are new findings")
+	changedModules := flags.String("changed_modules", "", "comma separated list of the modules that -check applies to, defaults to all modules")
+	checkRules := flags.String("check_rules", "", "comma separated list of the rules that -check applies to, in the format of clang-tidy -warnings-as-errors, defaults to all rules")
+
+	flags.Parse(expandedArgs)
+
+	if *output == "" {
+		fmt.Fprintf(os.Stderr, "-o argument is required\n")
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var logs []*sarif.Log
+	for _, tool := range tools {
+		run := sarif.NewRun(tool)
+		for _, input := range *inputs[tool] {
+			results, err := parseFile(input, sarif.Parsers[tool])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to parse %s output %s: %s\n", tool, input, err)
+				os.Exit(1)
+			}
+			run.AddResults(results...)
+		}
+		if len(run.Results) > 0 {
+			logs = append(logs, sarif.NewLog(run))
+		}
+	}
+	for _, input := range flags.Args() {
+		log, err := readLog(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s: %s\n", input, err)
+			os.Exit(1)
+		}
+		logs = append(logs, log)
+	}
+
+	merged := sarif.Merge(logs...)
+	if *module != "" {
+		setModule(merged, *module)
+	}
+
+	if *baseline != "" {
+		baselineLog, err := readLog(*baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read baseline %s: %s\n", *baseline, err)
+			os.Exit(1)
+		}
+		sarif.ApplyBaseline(merged, baselineLog)
+	}
+
+	// The output is only written if the check passes, so that a failed check is not considered up
+	// to date by the next build.
+	if *check {
+		var modules []string
+		if *changedModules != "" {
+			modules = strings.Split(*changedModules, ",")
+		}
+		var rules []string
+		if *checkRules != "" {
+			rules = strings.Split(*checkRules, ",")
+		}
+		if n := reportNewFindings(os.Stderr, merged, modules, rules); n > 0 {
+			fmt.Fprintf(os.Stderr, "%d new findings, fix them or update the baseline\n", n)
+			os.Remove(*output)
+			os.Exit(1)
+		}
+	}
+
+	buf := &strings.Builder{}
+	if err := merged.Write(buf); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write SARIF: %s\n", err)
+		os.Exit(1)
+	}
+	if err := pathtools.WriteFileIfChanged(*output, []byte(buf.String()), 0666); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s: %s\n", *output, err)
+		os.Exit(1)
+	}
+}
+
+// parseFile parses the text output of a tool.  A missing output means that the tool had nothing
+// to analyze, e.g. javac is not run for modules with only empty srcjars.
+func parseFile(file string, parse func(io.Reader) ([]sarif.Result, error)) ([]sarif.Result, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+func readLog(file string) (*sarif.Log, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return sarif.Read(f)
+}
+
+// setModule records the module in all the results that don't have a module yet.
+func setModule(log *sarif.Log, module string) {
+	for i := range log.Runs {
+		for j := range log.Runs[i].Results {
+			if result := &log.Runs[i].Results[j]; result.Property(sarif.ModuleProperty) == "" {
+				result.SetProperty(sarif.ModuleProperty, module)
+			}
+		}
+	}
+}
+
+// matchRule returns whether the rule is selected by the list of globs, where a glob prefixed with
+// '-' removes the rules that match it.  As in clang-tidy, later globs take precedence.
+func matchRule(rule string, globs []string) bool {
+	matched := false
+	for _, glob := range globs {
+		glob = strings.TrimSpace(glob)
+		negative := strings.HasPrefix(glob, "-")
+		if ok, _ := path.Match(strings.TrimPrefix(glob, "-"), rule); ok {
+			matched = !negative
+		}
+	}
+	return matched
+}
+
+// reportNewFindings prints the new findings of the given modules, or of all modules if modules is
+// empty, and returns how many there are.  If rules is not empty only the findings of the rules it
+// selects are reported.  Findings of modules without a baseline are all new.
+func reportNewFindings(w io.Writer, log *sarif.Log, modules []string, rules []string) int {
+	var inModules func(string) bool
+	if len(modules) == 0 {
+		inModules = func(string) bool { return true }
+	} else {
+		set := make(map[string]bool)
+		for _, module := range modules {
+			set[module] = true
+		}
+		inModules = func(module string) bool { return set[module] }
+	}
+
+	var lines []string
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if result.BaselineState == sarif.BaselineStateUnchanged || !inModules(result.Property(sarif.ModuleProperty)) {
+				continue
+			}
+			if len(rules) > 0 && !matchRule(result.RuleId, rules) {
+				continue
+			}
+			location := "<unknown>"
+			if len(result.Locations) > 0 {
+				physical := result.Locations[0].PhysicalLocation
+				location = physical.ArtifactLocation.Uri
+				if physical.Region != nil {
+					location += fmt.Sprintf(":%d", physical.Region.StartLine)
+				}
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s: %s [%s] (%s, module %s)", location, result.Level,
+				result.Message.Text, result.RuleId, run.Tool.Driver.Name, result.Property(sarif.ModuleProperty)))
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	return len(lines)
+}
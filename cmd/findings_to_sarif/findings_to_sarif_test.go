@@ -0,0 +1,104 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/sarif"
+)
+
+func TestReportNewFindings(t *testing.T) {
+	newResult := func(message, state string) sarif.Result {
+		return sarif.Result{
+			RuleId:        "check",
+			Level:         "warning",
+			Message:       sarif.Message{Text: message},
+			Locations:     []sarif.Location{sarif.NewLocation("foo/foo.cpp", 3, 1)},
+			BaselineState: state,
+		}
+	}
+
+	run := sarif.NewRun(sarif.ToolClangTidy)
+	run.AddResults(
+		newResult("new in libfoo", sarif.BaselineStateNew),
+		newResult("known in libfoo", sarif.BaselineStateUnchanged),
+		newResult("new in libbar", ""),
+		newResult("known in libbaz", sarif.BaselineStateUnchanged),
+	)
+	log := sarif.NewLog(run)
+	log.Runs[0].Results[2].SetProperty(sarif.ModuleProperty, "libbar")
+	log.Runs[0].Results[3].SetProperty(sarif.ModuleProperty, "libbaz")
+	setModule(log, "libfoo")
+
+	testCases := []struct {
+		name    string
+		modules []string
+		want    []string
+	}{
+		{
+			name: "all modules",
+			want: []string{
+				"foo/foo.cpp:3: warning: new in libbar [check] (clang-tidy, module libbar)",
+				"foo/foo.cpp:3: warning: new in libfoo [check] (clang-tidy, module libfoo)",
+			},
+		},
+		{
+			name:    "changed modules",
+			modules: []string{"libfoo"},
+			want: []string{
+				"foo/foo.cpp:3: warning: new in libfoo [check] (clang-tidy, module libfoo)",
+			},
+		},
+		{
+			name:    "unchanged modules",
+			modules: []string{"libbaz"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &strings.Builder{}
+			n := reportNewFindings(buf, log, tc.modules, nil)
+			want := strings.Join(tc.want, "\n")
+			if len(tc.want) > 0 {
+				want += "\n"
+			}
+			if n != len(tc.want) || buf.String() != want {
+				t.Errorf("expected %d findings:\n%s\ngot %d findings:\n%s", len(tc.want), want, n, buf.String())
+			}
+		})
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	testCases := []struct {
+		rule  string
+		globs []string
+		want  bool
+	}{
+		{rule: "bugprone-use-after-move", globs: []string{"bugprone-*"}, want: true},
+		{rule: "bugprone-use-after-move", globs: []string{"cert-*"}, want: false},
+		{rule: "bugprone-use-after-move", globs: []string{"bugprone-*", "-bugprone-use-after-move"}, want: false},
+		{rule: "bugprone-use-after-move", globs: []string{"-*", "bugprone-use-after-move"}, want: true},
+		{rule: "cert-err34-c", globs: []string{"*", "-bugprone-*"}, want: true},
+	}
+	for _, tc := range testCases {
+		if got := matchRule(tc.rule, tc.globs); got != tc.want {
+			t.Errorf("matchRule(%q, %q) = %v, want %v", tc.rule, tc.globs, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// abiDump is the ABI of a jar: the classes, fields and methods that can be used from outside of
+// the jar. It is written as JSON to .abi.json files, which are checked in as reference dumps.
+type abiDump struct {
+	Classes []abiClass `json:"classes"`
+}
+
+type abiClass struct {
+	// The binary name of the class with '/' replaced by '.', e.g. java.util.Map$Entry.
+	Name       string      `json:"name"`
+	Access     []string    `json:"access"`
+	Superclass string      `json:"superclass,omitempty"`
+	Interfaces []string    `json:"interfaces,omitempty"`
+	Fields     []abiMember `json:"fields,omitempty"`
+	Methods    []abiMember `json:"methods,omitempty"`
+}
+
+type abiMember struct {
+	Name string `json:"name"`
+	// The JVM type descriptor of the field or method, e.g. (ILjava/lang/String;)V.
+	Descriptor string   `json:"descriptor"`
+	Access     []string `json:"access"`
+}
+
+// key identifies a member of a class, fields and methods can be overloaded by their descriptor.
+func (m abiMember) key() string {
+	return m.Name + m.Descriptor
+}
+
+var accessFlagNames = []struct {
+	flag uint16
+	name string
+}{
+	{accPublic, "public"},
+	{accProtected, "protected"},
+	{accStatic, "static"},
+	{accFinal, "final"},
+	{accAbstract, "abstract"},
+	{accInterface, "interface"},
+	{accAnnotation, "annotation"},
+	{accEnum, "enum"},
+}
+
+// accessNames returns the names of the access flags that are part of the ABI. Flags that do not
+// affect callers, like synchronized or native, are left out.
+func accessNames(access uint16, mask uint16) []string {
+	names := []string{}
+	for _, f := range accessFlagNames {
+		if access&mask&f.flag != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+const (
+	classAccessMask  = accPublic | accProtected | accStatic | accFinal | accAbstract | accInterface | accAnnotation | accEnum
+	fieldAccessMask  = accPublic | accProtected | accStatic | accFinal
+	methodAccessMask = accPublic | accProtected | accStatic | accFinal | accAbstract
+)
+
+func javaName(binaryName string) string {
+	return strings.ReplaceAll(binaryName, "/", ".")
+}
+
+// dumpClasses returns the ABI of the classes of a jar. Only public and protected classes are
+// included, and only if the classes they are nested in are included too. Of those only the public
+// and protected members are included, except for the protected members of final classes, which
+// cannot be accessed from outside of their package. Synthetic members and bridge methods are
+// generated by the compiler and are left out.
+func dumpClasses(classes []*classFile) *abiDump {
+	byName := make(map[string]*classFile)
+	for _, c := range classes {
+		byName[c.name] = c
+	}
+
+	var visible func(c *classFile) bool
+	visible = func(c *classFile) bool {
+		if c.access&(accPublic|accProtected) == 0 || c.access&accSynthetic != 0 {
+			return false
+		}
+		if c.outerClass != "" {
+			if outer := byName[c.outerClass]; outer != nil {
+				return visible(outer)
+			}
+		}
+		return true
+	}
+
+	members := func(c *classFile, in []classMember, mask uint16, skip uint16) []abiMember {
+		var out []abiMember
+		for _, m := range in {
+			if m.access&(accPublic|accProtected) == 0 || m.access&skip != 0 {
+				continue
+			}
+			if m.access&accPublic == 0 && c.access&accFinal != 0 {
+				continue
+			}
+			out = append(out, abiMember{
+				Name:       m.name,
+				Descriptor: m.descriptor,
+				Access:     accessNames(m.access, mask),
+			})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].key() < out[j].key() })
+		return out
+	}
+
+	dump := &abiDump{Classes: []abiClass{}}
+	for _, c := range classes {
+		if !visible(c) {
+			continue
+		}
+		class := abiClass{
+			Name:    javaName(c.name),
+			Access:  accessNames(c.access, classAccessMask),
+			Fields:  members(c, c.fields, fieldAccessMask, accSynthetic),
+			Methods: members(c, c.methods, methodAccessMask, accSynthetic|accBridge),
+		}
+		if c.superclass != "" && c.superclass != "java/lang/Object" {
+			class.Superclass = javaName(c.superclass)
+		}
+		for _, iface := range c.interfaces {
+			class.Interfaces = append(class.Interfaces, javaName(iface))
+		}
+		sort.Strings(class.Interfaces)
+		dump.Classes = append(dump.Classes, class)
+	}
+	sort.Slice(dump.Classes, func(i, j int) bool { return dump.Classes[i].Name < dump.Classes[j].Name })
+	return dump
+}
+
+// dumpJar returns the ABI of the classes in a jar.
+func dumpJar(path string) (*abiDump, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var classes []*classFile
+	for _, f := range r.File {
+		// Classes in META-INF/versions are alternatives for newer runtimes of the classes at the
+		// top level of a multi-release jar, and module-info.class is not a class.
+		if !strings.HasSuffix(f.Name, ".class") || strings.HasPrefix(f.Name, "META-INF/") ||
+			strings.HasSuffix(f.Name, "module-info.class") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		c, err := parseClass(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		classes = append(classes, c)
+	}
+	return dumpClasses(classes), nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func readDump(path string) (*abiDump, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dump := &abiDump{}
+	if err := json.Unmarshal(data, dump); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return dump, nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0666)
+}
@@ -0,0 +1,297 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Access flags of classes, fields and methods, from the JVM specification.
+const (
+	accPublic     = 0x0001
+	accPrivate    = 0x0002
+	accProtected  = 0x0004
+	accStatic     = 0x0008
+	accFinal      = 0x0010
+	accBridge     = 0x0040
+	accInterface  = 0x0200
+	accAbstract   = 0x0400
+	accSynthetic  = 0x1000
+	accAnnotation = 0x2000
+	accEnum       = 0x4000
+)
+
+// Constant pool tags, from the JVM specification.
+const (
+	constantUtf8               = 1
+	constantInteger            = 3
+	constantFloat              = 4
+	constantLong               = 5
+	constantDouble             = 6
+	constantClass              = 7
+	constantString             = 8
+	constantFieldref           = 9
+	constantMethodref          = 10
+	constantInterfaceMethodref = 11
+	constantNameAndType        = 12
+	constantMethodHandle       = 15
+	constantMethodType         = 16
+	constantDynamic            = 17
+	constantInvokeDynamic      = 18
+	constantModule             = 19
+	constantPackage            = 20
+)
+
+// classFile is the part of a parsed .class file that makes up its ABI.
+type classFile struct {
+	// The binary name of the class, e.g. java/util/Map$Entry.
+	name string
+
+	// The binary name of the superclass, or "" for java/lang/Object.
+	superclass string
+
+	interfaces []string
+
+	// The access flags of the class. For nested classes these are the flags from the InnerClasses
+	// attribute, which unlike the flags of the class file record whether the class is protected,
+	// private or static.
+	access uint16
+
+	// The binary name of the class that a nested class is a member of, or "" for a top level,
+	// local or anonymous class.
+	outerClass string
+
+	fields  []classMember
+	methods []classMember
+}
+
+type classMember struct {
+	name       string
+	descriptor string
+	access     uint16
+}
+
+// classReader reads the big endian values of a .class file.
+type classReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *classReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("unexpected end of class file at offset %d", r.pos)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *classReader) u1() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *classReader) u2() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *classReader) u4() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// constantPool holds the entries of the constant pool that are needed to read the ABI of a class.
+// Only the Utf8 and Class entries are kept, other entries are skipped.
+type constantPool struct {
+	utf8    map[uint16]string
+	classes map[uint16]uint16
+}
+
+func (cp *constantPool) utf8At(index uint16) (string, error) {
+	s, ok := cp.utf8[index]
+	if !ok {
+		return "", fmt.Errorf("constant pool entry %d is not a Utf8 constant", index)
+	}
+	return s, nil
+}
+
+func (cp *constantPool) classAt(index uint16) (string, error) {
+	nameIndex, ok := cp.classes[index]
+	if !ok {
+		return "", fmt.Errorf("constant pool entry %d is not a Class constant", index)
+	}
+	return cp.utf8At(nameIndex)
+}
+
+func readConstantPool(r *classReader) (*constantPool, error) {
+	cp := &constantPool{
+		utf8:    make(map[uint16]string),
+		classes: make(map[uint16]uint16),
+	}
+	count := r.u2()
+	for i := uint16(1); i < count && r.err == nil; i++ {
+		switch tag := r.u1(); tag {
+		case constantUtf8:
+			// The modified UTF-8 of class files only differs from UTF-8 for the null character and
+			// supplementary characters, which cannot appear in the names and descriptors of members.
+			cp.utf8[i] = string(r.bytes(int(r.u2())))
+		case constantClass:
+			cp.classes[i] = r.u2()
+		case constantString, constantMethodType, constantModule, constantPackage:
+			r.bytes(2)
+		case constantMethodHandle:
+			r.bytes(3)
+		case constantInteger, constantFloat, constantFieldref, constantMethodref,
+			constantInterfaceMethodref, constantNameAndType, constantDynamic, constantInvokeDynamic:
+			r.bytes(4)
+		case constantLong, constantDouble:
+			// 8 byte constants take up two entries in the constant pool.
+			r.bytes(8)
+			i++
+		default:
+			return nil, fmt.Errorf("unknown constant pool tag %d in entry %d", tag, i)
+		}
+	}
+	return cp, r.err
+}
+
+// readAttributes reads the attributes of a class, field or method, calling f with the name and
+// the contents of each.
+func readAttributes(r *classReader, cp *constantPool, f func(name string, data []byte) error) error {
+	count := r.u2()
+	for i := uint16(0); i < count && r.err == nil; i++ {
+		name, err := cp.utf8At(r.u2())
+		if err != nil {
+			return err
+		}
+		data := r.bytes(int(r.u4()))
+		if r.err == nil && f != nil {
+			if err := f(name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return r.err
+}
+
+func readMembers(r *classReader, cp *constantPool) ([]classMember, error) {
+	count := r.u2()
+	members := make([]classMember, 0, count)
+	for i := uint16(0); i < count && r.err == nil; i++ {
+		access := r.u2()
+		name, err := cp.utf8At(r.u2())
+		if err != nil {
+			return nil, err
+		}
+		descriptor, err := cp.utf8At(r.u2())
+		if err != nil {
+			return nil, err
+		}
+		if err := readAttributes(r, cp, nil); err != nil {
+			return nil, err
+		}
+		members = append(members, classMember{name: name, descriptor: descriptor, access: access})
+	}
+	return members, r.err
+}
+
+// parseClass parses the contents of a .class file.
+func parseClass(data []byte) (*classFile, error) {
+	r := &classReader{data: data}
+	if magic := r.u4(); r.err == nil && magic != 0xCAFEBABE {
+		return nil, fmt.Errorf("not a class file, magic is %#x", magic)
+	}
+	// Skip the minor and major versions.
+	r.bytes(4)
+
+	cp, err := readConstantPool(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &classFile{}
+	c.access = r.u2()
+	thisClass := r.u2()
+	if c.name, err = cp.classAt(thisClass); err != nil {
+		return nil, err
+	}
+	if superClass := r.u2(); superClass != 0 {
+		if c.superclass, err = cp.classAt(superClass); err != nil {
+			return nil, err
+		}
+	}
+	interfaceCount := r.u2()
+	for i := uint16(0); i < interfaceCount && r.err == nil; i++ {
+		iface, err := cp.classAt(r.u2())
+		if err != nil {
+			return nil, err
+		}
+		c.interfaces = append(c.interfaces, iface)
+	}
+
+	if c.fields, err = readMembers(r, cp); err != nil {
+		return nil, err
+	}
+	if c.methods, err = readMembers(r, cp); err != nil {
+		return nil, err
+	}
+
+	err = readAttributes(r, cp, func(name string, data []byte) error {
+		if name != "InnerClasses" {
+			return nil
+		}
+		ar := &classReader{data: data}
+		count := ar.u2()
+		for i := uint16(0); i < count && ar.err == nil; i++ {
+			inner := ar.u2()
+			outer := ar.u2()
+			ar.u2() // inner_name_index
+			access := ar.u2()
+			if ar.err != nil {
+				break
+			}
+			if innerName, err := cp.classAt(inner); err != nil || innerName != c.name {
+				continue
+			}
+			c.access = access
+			if outer != 0 {
+				outerName, err := cp.classAt(outer)
+				if err != nil {
+					return err
+				}
+				c.outerClass = outerName
+			}
+		}
+		return ar.err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testClass describes a class to be written as a .class file by writeClass.
+type testClass struct {
+	name       string
+	superclass string
+	interfaces []string
+	access     uint16
+	fields     []classMember
+	methods    []classMember
+
+	// The access flags and outer class of the InnerClasses entry for the class, if innerAccess is
+	// not zero.
+	innerAccess uint16
+	outerClass  string
+}
+
+// writeClass writes a minimal .class file. The constant pool includes a Long constant and a
+// String constant that are not referenced, to test that entries of all sizes are skipped.
+func writeClass(c testClass) []byte {
+	var pool bytes.Buffer
+	count := uint16(1)
+	u2 := func(b *bytes.Buffer, v uint16) { binary.Write(b, binary.BigEndian, v) }
+	utf8s := make(map[string]uint16)
+	utf8 := func(s string) uint16 {
+		if i, ok := utf8s[s]; ok {
+			return i
+		}
+		pool.WriteByte(constantUtf8)
+		u2(&pool, uint16(len(s)))
+		pool.WriteString(s)
+		utf8s[s] = count
+		count++
+		return utf8s[s]
+	}
+	classes := make(map[string]uint16)
+	class := func(name string) uint16 {
+		if i, ok := classes[name]; ok {
+			return i
+		}
+		nameIndex := utf8(name)
+		pool.WriteByte(constantClass)
+		u2(&pool, nameIndex)
+		classes[name] = count
+		count++
+		return classes[name]
+	}
+
+	pool.WriteByte(constantLong)
+	pool.Write(make([]byte, 8))
+	count += 2
+	unused := utf8("unused")
+	pool.WriteByte(constantString)
+	u2(&pool, unused)
+	count++
+
+	var body bytes.Buffer
+	u2(&body, c.access)
+	u2(&body, class(c.name))
+	if c.superclass != "" {
+		u2(&body, class(c.superclass))
+	} else {
+		u2(&body, 0)
+	}
+	u2(&body, uint16(len(c.interfaces)))
+	for _, iface := range c.interfaces {
+		u2(&body, class(iface))
+	}
+	for _, members := range [][]classMember{c.fields, c.methods} {
+		u2(&body, uint16(len(members)))
+		for _, m := range members {
+			u2(&body, m.access)
+			u2(&body, utf8(m.name))
+			u2(&body, utf8(m.descriptor))
+			// An attribute that should be skipped.
+			u2(&body, 1)
+			u2(&body, utf8("Deprecated"))
+			binary.Write(&body, binary.BigEndian, uint32(0))
+		}
+	}
+	if c.innerAccess != 0 {
+		u2(&body, 1)
+		u2(&body, utf8("InnerClasses"))
+		binary.Write(&body, binary.BigEndian, uint32(2+8))
+		u2(&body, 1)
+		u2(&body, class(c.name))
+		if c.outerClass != "" {
+			u2(&body, class(c.outerClass))
+		} else {
+			u2(&body, 0)
+		}
+		u2(&body, 0)
+		u2(&body, c.innerAccess)
+	} else {
+		u2(&body, 0)
+	}
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, uint32(0xCAFEBABE))
+	u2(&out, 0)
+	u2(&out, 52)
+	u2(&out, count)
+	out.Write(pool.Bytes())
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func TestParseClass(t *testing.T) {
+	data := writeClass(testClass{
+		name:       "android/foo/Foo$Bar",
+		superclass: "android/foo/Base",
+		interfaces: []string{"java/lang/Runnable"},
+		access:     accPublic | accFinal,
+		fields: []classMember{
+			{name: "count", descriptor: "I", access: accPublic | accStatic},
+		},
+		methods: []classMember{
+			{name: "run", descriptor: "()V", access: accPublic},
+			{name: "helper", descriptor: "(Ljava/lang/String;J)Z", access: accPrivate},
+		},
+		innerAccess: accProtected | accStatic,
+		outerClass:  "android/foo/Foo",
+	})
+
+	got, err := parseClass(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &classFile{
+		name:       "android/foo/Foo$Bar",
+		superclass: "android/foo/Base",
+		interfaces: []string{"java/lang/Runnable"},
+		access:     accProtected | accStatic,
+		outerClass: "android/foo/Foo",
+		fields: []classMember{
+			{name: "count", descriptor: "I", access: accPublic | accStatic},
+		},
+		methods: []classMember{
+			{name: "run", descriptor: "()V", access: accPublic},
+			{name: "helper", descriptor: "(Ljava/lang/String;J)Z", access: accPrivate},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v\ngot %#v", want, got)
+	}
+}
+
+func TestParseClassErrors(t *testing.T) {
+	data := writeClass(testClass{name: "Foo", superclass: "java/lang/Object", access: accPublic})
+
+	if _, err := parseClass([]byte{0xCA, 0xFE, 0xD0, 0x0D}); err == nil || err.Error() != "not a class file, magic is 0xcafed00d" {
+		t.Errorf("expected bad magic error, got %v", err)
+	}
+	if _, err := parseClass(data[:len(data)-3]); err == nil {
+		t.Errorf("expected error for truncated class file")
+	}
+}
+
+func TestDumpJar(t *testing.T) {
+	classes := map[string]testClass{
+		"android/foo/Foo.class": {
+			name:       "android/foo/Foo",
+			superclass: "java/lang/Object",
+			interfaces: []string{"java/lang/Runnable", "java/lang/Cloneable"},
+			access:     accPublic,
+			fields: []classMember{
+				{name: "VALUE", descriptor: "I", access: accPublic | accStatic | accFinal},
+				{name: "state", descriptor: "J", access: accProtected},
+				{name: "hidden", descriptor: "J", access: accPrivate},
+				{name: "this$0", descriptor: "Landroid/foo/Foo;", access: accPublic | accSynthetic},
+			},
+			methods: []classMember{
+				{name: "run", descriptor: "()V", access: accPublic},
+				{name: "<init>", descriptor: "()V", access: accPublic},
+				{name: "onRun", descriptor: "(I)V", access: accProtected | accAbstract},
+				{name: "compareTo", descriptor: "(Ljava/lang/Object;)I", access: accPublic | accBridge | accSynthetic},
+				{name: "internal", descriptor: "()V", access: 0},
+			},
+		},
+		"android/foo/Foo$Inner.class": {
+			name:        "android/foo/Foo$Inner",
+			superclass:  "android/foo/Base",
+			access:      accPublic | accFinal,
+			innerAccess: accProtected | accStatic | accFinal,
+			outerClass:  "android/foo/Foo",
+			methods: []classMember{
+				{name: "get", descriptor: "()I", access: accPublic},
+				{name: "set", descriptor: "(I)V", access: accProtected},
+			},
+		},
+		"android/foo/Foo$Private.class": {
+			name:        "android/foo/Foo$Private",
+			superclass:  "java/lang/Object",
+			innerAccess: accPrivate | accStatic,
+			outerClass:  "android/foo/Foo",
+		},
+		"android/foo/Hidden.class": {
+			name:       "android/foo/Hidden",
+			superclass: "java/lang/Object",
+			methods: []classMember{
+				{name: "get", descriptor: "()I", access: accPublic},
+			},
+		},
+		"android/foo/Hidden$Nested.class": {
+			name:        "android/foo/Hidden$Nested",
+			superclass:  "java/lang/Object",
+			access:      accPublic,
+			innerAccess: accPublic | accStatic,
+			outerClass:  "android/foo/Hidden",
+		},
+		"META-INF/versions/11/android/foo/Foo.class": {
+			name:   "android/foo/Foo",
+			access: accPublic,
+		},
+	}
+
+	jar := filepath.Join(t.TempDir(), "test.jar")
+	f, err := os.Create(jar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, c := range classes {
+		zw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw.Write(writeClass(c))
+	}
+	if zw, err := w.Create("META-INF/MANIFEST.MF"); err != nil {
+		t.Fatal(err)
+	} else {
+		zw.Write([]byte("Manifest-Version: 1.0\n"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := dumpJar(jar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &abiDump{
+		Classes: []abiClass{
+			{
+				Name:       "android.foo.Foo",
+				Access:     []string{"public"},
+				Interfaces: []string{"java.lang.Cloneable", "java.lang.Runnable"},
+				Fields: []abiMember{
+					{Name: "VALUE", Descriptor: "I", Access: []string{"public", "static", "final"}},
+					{Name: "state", Descriptor: "J", Access: []string{"protected"}},
+				},
+				Methods: []abiMember{
+					{Name: "<init>", Descriptor: "()V", Access: []string{"public"}},
+					{Name: "onRun", Descriptor: "(I)V", Access: []string{"protected", "abstract"}},
+					{Name: "run", Descriptor: "()V", Access: []string{"public"}},
+				},
+			},
+			{
+				Name:       "android.foo.Foo$Inner",
+				Access:     []string{"protected", "static", "final"},
+				Superclass: "android.foo.Base",
+				Methods: []abiMember{
+					{Name: "get", Descriptor: "()I", Access: []string{"public"}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v\ngot %#v", want, got)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// abiDiff is the difference between a reference ABI dump and the ABI of the current source. It is
+// written as JSON to .abidiff files.
+type abiDiff struct {
+	Library        string      `json:"library"`
+	RemovedClasses []string    `json:"removed_classes"`
+	AddedClasses   []string    `json:"added_classes"`
+	ChangedClasses []classDiff `json:"changed_classes"`
+}
+
+type classDiff struct {
+	Name string `json:"name"`
+
+	// Set when the access flags of the class changed.
+	OldAccess []string `json:"old_access,omitempty"`
+	NewAccess []string `json:"new_access,omitempty"`
+
+	// Set when the superclass changed.
+	OldSuperclass *string `json:"old_superclass,omitempty"`
+	NewSuperclass *string `json:"new_superclass,omitempty"`
+
+	RemovedInterfaces []string `json:"removed_interfaces,omitempty"`
+	AddedInterfaces   []string `json:"added_interfaces,omitempty"`
+
+	RemovedFields []abiMember    `json:"removed_fields,omitempty"`
+	AddedFields   []abiMember    `json:"added_fields,omitempty"`
+	ChangedFields []memberChange `json:"changed_fields,omitempty"`
+
+	RemovedMethods []abiMember    `json:"removed_methods,omitempty"`
+	AddedMethods   []abiMember    `json:"added_methods,omitempty"`
+	ChangedMethods []memberChange `json:"changed_methods,omitempty"`
+}
+
+// memberChange is a field or method whose access flags changed.
+type memberChange struct {
+	Name       string   `json:"name"`
+	Descriptor string   `json:"descriptor"`
+	OldAccess  []string `json:"old_access"`
+	NewAccess  []string `json:"new_access"`
+}
+
+func diffDumps(library string, old, new *abiDump) *abiDiff {
+	d := &abiDiff{
+		Library:        library,
+		RemovedClasses: []string{},
+		AddedClasses:   []string{},
+		ChangedClasses: []classDiff{},
+	}
+
+	newClasses := make(map[string]abiClass)
+	for _, c := range new.Classes {
+		newClasses[c.Name] = c
+	}
+	oldClasses := make(map[string]bool)
+	for _, oldClass := range old.Classes {
+		oldClasses[oldClass.Name] = true
+		newClass, ok := newClasses[oldClass.Name]
+		if !ok {
+			d.RemovedClasses = append(d.RemovedClasses, oldClass.Name)
+			continue
+		}
+		if cd := diffClasses(oldClass, newClass); cd != nil {
+			d.ChangedClasses = append(d.ChangedClasses, *cd)
+		}
+	}
+	for _, c := range new.Classes {
+		if !oldClasses[c.Name] {
+			d.AddedClasses = append(d.AddedClasses, c.Name)
+		}
+	}
+
+	sort.Strings(d.RemovedClasses)
+	sort.Strings(d.AddedClasses)
+	sort.Slice(d.ChangedClasses, func(i, j int) bool { return d.ChangedClasses[i].Name < d.ChangedClasses[j].Name })
+	return d
+}
+
+// diffClasses returns the differences between two versions of a class, or nil if there are none.
+func diffClasses(old, new abiClass) *classDiff {
+	cd := &classDiff{Name: old.Name}
+	changed := false
+
+	if !equalStrings(old.Access, new.Access) {
+		cd.OldAccess, cd.NewAccess = old.Access, new.Access
+		changed = true
+	}
+	if old.Superclass != new.Superclass {
+		oldSuperclass, newSuperclass := old.Superclass, new.Superclass
+		cd.OldSuperclass, cd.NewSuperclass = &oldSuperclass, &newSuperclass
+		changed = true
+	}
+	cd.RemovedInterfaces, cd.AddedInterfaces = diffStrings(old.Interfaces, new.Interfaces)
+
+	cd.RemovedFields, cd.AddedFields, cd.ChangedFields = diffMembers(old.Fields, new.Fields)
+	cd.RemovedMethods, cd.AddedMethods, cd.ChangedMethods = diffMembers(old.Methods, new.Methods)
+
+	if changed || len(cd.RemovedInterfaces) > 0 || len(cd.AddedInterfaces) > 0 ||
+		len(cd.RemovedFields) > 0 || len(cd.AddedFields) > 0 || len(cd.ChangedFields) > 0 ||
+		len(cd.RemovedMethods) > 0 || len(cd.AddedMethods) > 0 || len(cd.ChangedMethods) > 0 {
+		return cd
+	}
+	return nil
+}
+
+func diffMembers(old, new []abiMember) (removed, added []abiMember, changed []memberChange) {
+	newMembers := make(map[string]abiMember)
+	for _, m := range new {
+		newMembers[m.key()] = m
+	}
+	oldMembers := make(map[string]bool)
+	for _, o := range old {
+		oldMembers[o.key()] = true
+		n, ok := newMembers[o.key()]
+		if !ok {
+			removed = append(removed, o)
+		} else if !equalStrings(o.Access, n.Access) {
+			changed = append(changed, memberChange{
+				Name:       o.Name,
+				Descriptor: o.Descriptor,
+				OldAccess:  o.Access,
+				NewAccess:  n.Access,
+			})
+		}
+	}
+	for _, n := range new {
+		if !oldMembers[n.key()] {
+			added = append(added, n)
+		}
+	}
+	return removed, added, changed
+}
+
+func diffStrings(old, new []string) (removed, added []string) {
+	for _, s := range old {
+		if !inList(s, new) {
+			removed = append(removed, s)
+		}
+	}
+	for _, s := range new {
+		if !inList(s, old) {
+			added = append(added, s)
+		}
+	}
+	return removed, added
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func inList(s string, list []string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// compatibleAccessChange returns whether code compiled against a class or member with the old
+// access flags still links against one with the new access flags: protected can become public, and
+// final or abstract can be dropped. Any other change is incompatible.
+func compatibleAccessChange(old, new []string) bool {
+	for _, flag := range old {
+		if !inList(flag, new) && flag != "protected" && flag != "final" && flag != "abstract" {
+			return false
+		}
+	}
+	for _, flag := range new {
+		if !inList(flag, old) && !(flag == "public" && inList("protected", old)) {
+			return false
+		}
+	}
+	return true
+}
+
+// problems returns the differences that make the current ABI incompatible with the reference
+// ABI. When allowExtensions is true additions to the ABI and compatible access changes are
+// allowed, otherwise every difference is a problem.
+func (d *abiDiff) problems(allowExtensions bool) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, c := range d.RemovedClasses {
+		add("removed class %s", c)
+	}
+	if !allowExtensions {
+		for _, c := range d.AddedClasses {
+			add("added class %s", c)
+		}
+	}
+
+	for _, cd := range d.ChangedClasses {
+		if cd.OldAccess != nil || cd.NewAccess != nil {
+			if !allowExtensions || !compatibleAccessChange(cd.OldAccess, cd.NewAccess) {
+				add("changed access of class %s from %q to %q", cd.Name,
+					strings.Join(cd.OldAccess, " "), strings.Join(cd.NewAccess, " "))
+			}
+		}
+		if cd.OldSuperclass != nil {
+			add("changed superclass of class %s from %q to %q", cd.Name, *cd.OldSuperclass, *cd.NewSuperclass)
+		}
+		for _, iface := range cd.RemovedInterfaces {
+			add("removed interface %s from class %s", iface, cd.Name)
+		}
+		for _, m := range cd.RemovedFields {
+			add("removed field %s.%s:%s", cd.Name, m.Name, m.Descriptor)
+		}
+		for _, m := range cd.ChangedFields {
+			if !allowExtensions || !compatibleAccessChange(m.OldAccess, m.NewAccess) {
+				add("changed access of field %s.%s:%s from %q to %q", cd.Name, m.Name, m.Descriptor,
+					strings.Join(m.OldAccess, " "), strings.Join(m.NewAccess, " "))
+			}
+		}
+		for _, m := range cd.RemovedMethods {
+			add("removed method %s.%s%s", cd.Name, m.Name, m.Descriptor)
+		}
+		for _, m := range cd.ChangedMethods {
+			if !allowExtensions || !compatibleAccessChange(m.OldAccess, m.NewAccess) {
+				add("changed access of method %s.%s%s from %q to %q", cd.Name, m.Name, m.Descriptor,
+					strings.Join(m.OldAccess, " "), strings.Join(m.NewAccess, " "))
+			}
+		}
+		if !allowExtensions {
+			for _, iface := range cd.AddedInterfaces {
+				add("added interface %s to class %s", iface, cd.Name)
+			}
+			for _, m := range cd.AddedFields {
+				add("added field %s.%s:%s", cd.Name, m.Name, m.Descriptor)
+			}
+			for _, m := range cd.AddedMethods {
+				add("added method %s.%s%s", cd.Name, m.Name, m.Descriptor)
+			}
+		}
+	}
+
+	return problems
+}
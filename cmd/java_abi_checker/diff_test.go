@@ -0,0 +1,198 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var referenceDump = &abiDump{
+	Classes: []abiClass{
+		{
+			Name:   "android.foo.Foo",
+			Access: []string{"public"},
+			Fields: []abiMember{
+				{Name: "VALUE", Descriptor: "I", Access: []string{"public", "static", "final"}},
+			},
+			Methods: []abiMember{
+				{Name: "get", Descriptor: "()I", Access: []string{"public"}},
+				{Name: "onGet", Descriptor: "()V", Access: []string{"protected"}},
+				{Name: "set", Descriptor: "(I)V", Access: []string{"public", "final"}},
+			},
+		},
+		{
+			Name:   "android.foo.Removed",
+			Access: []string{"public"},
+		},
+	},
+}
+
+func TestDiffDumps(t *testing.T) {
+	testCases := []struct {
+		name          string
+		new           *abiDump
+		want          *abiDiff
+		wantProblems  []string
+		wantExtension []string
+	}{
+		{
+			name: "same",
+			new:  referenceDump,
+			want: &abiDiff{
+				Library:        "libfoo",
+				RemovedClasses: []string{},
+				AddedClasses:   []string{},
+				ChangedClasses: []classDiff{},
+			},
+		},
+		{
+			name: "extensions",
+			new: &abiDump{
+				Classes: []abiClass{
+					{
+						Name:       "android.foo.Added",
+						Access:     []string{"public"},
+						Superclass: "android.foo.Foo",
+					},
+					{
+						Name:       "android.foo.Foo",
+						Access:     []string{"public"},
+						Interfaces: []string{"java.lang.Runnable"},
+						Fields: []abiMember{
+							{Name: "VALUE", Descriptor: "I", Access: []string{"public", "static", "final"}},
+						},
+						Methods: []abiMember{
+							{Name: "get", Descriptor: "()I", Access: []string{"public"}},
+							{Name: "onGet", Descriptor: "()V", Access: []string{"public"}},
+							{Name: "run", Descriptor: "()V", Access: []string{"public"}},
+							{Name: "set", Descriptor: "(I)V", Access: []string{"public"}},
+						},
+					},
+					{
+						Name:   "android.foo.Removed",
+						Access: []string{"public"},
+					},
+				},
+			},
+			want: &abiDiff{
+				Library:        "libfoo",
+				RemovedClasses: []string{},
+				AddedClasses:   []string{"android.foo.Added"},
+				ChangedClasses: []classDiff{
+					{
+						Name:            "android.foo.Foo",
+						AddedInterfaces: []string{"java.lang.Runnable"},
+						AddedMethods: []abiMember{
+							{Name: "run", Descriptor: "()V", Access: []string{"public"}},
+						},
+						ChangedMethods: []memberChange{
+							{Name: "onGet", Descriptor: "()V", OldAccess: []string{"protected"}, NewAccess: []string{"public"}},
+							{Name: "set", Descriptor: "(I)V", OldAccess: []string{"public", "final"}, NewAccess: []string{"public"}},
+						},
+					},
+				},
+			},
+			wantProblems: []string{
+				"added class android.foo.Added",
+				`changed access of method android.foo.Foo.onGet()V from "protected" to "public"`,
+				`changed access of method android.foo.Foo.set(I)V from "public final" to "public"`,
+				"added interface java.lang.Runnable to class android.foo.Foo",
+				"added method android.foo.Foo.run()V",
+			},
+		},
+		{
+			name: "incompatible",
+			new: &abiDump{
+				Classes: []abiClass{
+					{
+						Name:       "android.foo.Foo",
+						Access:     []string{"public", "final"},
+						Superclass: "android.foo.Base",
+						Fields: []abiMember{
+							{Name: "VALUE", Descriptor: "J", Access: []string{"public", "static", "final"}},
+						},
+						Methods: []abiMember{
+							{Name: "get", Descriptor: "()I", Access: []string{"public", "static"}},
+							{Name: "set", Descriptor: "(I)V", Access: []string{"public", "final"}},
+						},
+					},
+				},
+			},
+			want: &abiDiff{
+				Library:        "libfoo",
+				RemovedClasses: []string{"android.foo.Removed"},
+				AddedClasses:   []string{},
+				ChangedClasses: []classDiff{
+					{
+						Name:          "android.foo.Foo",
+						OldAccess:     []string{"public"},
+						NewAccess:     []string{"public", "final"},
+						OldSuperclass: stringPtr(""),
+						NewSuperclass: stringPtr("android.foo.Base"),
+						RemovedFields: []abiMember{
+							{Name: "VALUE", Descriptor: "I", Access: []string{"public", "static", "final"}},
+						},
+						AddedFields: []abiMember{
+							{Name: "VALUE", Descriptor: "J", Access: []string{"public", "static", "final"}},
+						},
+						RemovedMethods: []abiMember{
+							{Name: "onGet", Descriptor: "()V", Access: []string{"protected"}},
+						},
+						ChangedMethods: []memberChange{
+							{Name: "get", Descriptor: "()I", OldAccess: []string{"public"}, NewAccess: []string{"public", "static"}},
+						},
+					},
+				},
+			},
+			wantProblems: []string{
+				"removed class android.foo.Removed",
+				`changed access of class android.foo.Foo from "public" to "public final"`,
+				`changed superclass of class android.foo.Foo from "" to "android.foo.Base"`,
+				"removed field android.foo.Foo.VALUE:I",
+				"removed method android.foo.Foo.onGet()V",
+				`changed access of method android.foo.Foo.get()I from "public" to "public static"`,
+				"added field android.foo.Foo.VALUE:J",
+			},
+			wantExtension: []string{
+				"removed class android.foo.Removed",
+				`changed access of class android.foo.Foo from "public" to "public final"`,
+				`changed superclass of class android.foo.Foo from "" to "android.foo.Base"`,
+				"removed field android.foo.Foo.VALUE:I",
+				"removed method android.foo.Foo.onGet()V",
+				`changed access of method android.foo.Foo.get()I from "public" to "public static"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := diffDumps("libfoo", referenceDump, tc.new)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected diff %#v\ngot %#v", tc.want, got)
+			}
+			if problems := got.problems(false); !reflect.DeepEqual(problems, tc.wantProblems) {
+				t.Errorf("expected problems %q\ngot %q", tc.wantProblems, problems)
+			}
+			if problems := got.problems(true); !reflect.DeepEqual(problems, tc.wantExtension) {
+				t.Errorf("expected problems with extensions allowed %q\ngot %q", tc.wantExtension, problems)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// java_abi_checker dumps the ABI of the classes in a jar and compares it against a reference
+// dump, like header-abi-dumper and header-abi-diff do for the headers of native libraries.
+//
+//	java_abi_checker dump -o <out.abi.json> <in.jar>
+//	java_abi_checker diff [-allow-extensions] [-lib <name>] -old <ref.abi.json> -o <out.abidiff> <new.abi.json>
+//
+// The diff command always writes the differences to the output file, and exits with an error if
+// they make the new ABI incompatible with the reference one.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: java_abi_checker dump -o <out.abi.json> <in.jar>")
+	fmt.Fprintln(os.Stderr, "       java_abi_checker diff [-allow-extensions] [-lib <name>] -old <ref.abi.json> -o <out.abidiff> <new.abi.json>")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "dump":
+		dumpMain(os.Args[2:])
+	case "diff":
+		diffMain(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func dumpMain(args []string) {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	out := flags.String("o", "", "file to write the ABI dump to")
+	flags.Parse(args)
+
+	if *out == "" || flags.NArg() != 1 {
+		usage()
+	}
+
+	dump, err := dumpJar(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+	if err := writeJSON(*out, dump); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
+
+func diffMain(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	out := flags.String("o", "", "file to write the ABI differences to")
+	old := flags.String("old", "", "the reference ABI dump")
+	lib := flags.String("lib", "", "the name of the library, for messages")
+	allowExtensions := flags.Bool("allow-extensions", false,
+		"allow additions to the ABI and access changes that do not break callers")
+	flags.Parse(args)
+
+	if *out == "" || *old == "" || flags.NArg() != 1 {
+		usage()
+	}
+
+	oldDump, err := readDump(*old)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *old, err)
+		os.Exit(1)
+	}
+	newDump, err := readDump(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", flags.Arg(0), err)
+		os.Exit(1)
+	}
+
+	diff := diffDumps(*lib, oldDump, newDump)
+	if err := writeJSON(*out, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+
+	if problems := diff.problems(*allowExtensions); len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "ABI of %s is not compatible with %s:\n", *lib, *old)
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "  %s\n", p)
+		}
+		os.Exit(1)
+	}
+}
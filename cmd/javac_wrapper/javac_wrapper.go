@@ -18,6 +18,10 @@
 // It also hides the unhelpful and unhideable "warning there is a warning"
 // messages.
 //
+// With --log <file> before the javac command line the unmodified output of
+// javac is also written to the file, e.g. for converting the findings of
+// Error Prone to SARIF.
+//
 // Each javac build statement has an order-only dependency on the
 // soong_javac_wrapper tool, which means the javac command will not be rerun
 // if soong_javac_wrapper changes.  That means that soong_javac_wrapper must
@@ -61,8 +65,19 @@ func main() {
 }
 
 func Main(out io.Writer, name string, args []string) (int, error) {
+	var log io.Writer
+	if len(args) >= 2 && args[0] == "--log" {
+		f, err := os.Create(args[1])
+		if err != nil {
+			return 1, fmt.Errorf("creating log: %s", err)
+		}
+		defer f.Close()
+		log = f
+		args = args[2:]
+	}
+
 	if len(args) < 1 {
-		return 1, fmt.Errorf("usage: %s javac ...", name)
+		return 1, fmt.Errorf("usage: %s [--log file] javac ...", name)
 	}
 
 	pr, pw, err := os.Pipe()
@@ -81,7 +96,7 @@ func Main(out io.Writer, name string, args []string) (int, error) {
 
 	pw.Close()
 
-	proc := processor{}
+	proc := processor{log: log}
 	// Process subprocess stdout asynchronously
 	errCh := make(chan error)
 	go func() {
@@ -121,6 +136,7 @@ func Main(out io.Writer, name string, args []string) (int, error) {
 
 type processor struct {
 	silencedWarnings int
+	log              io.Writer
 }
 
 func (proc *processor) process(r io.Reader, w io.Writer) error {
@@ -130,6 +146,9 @@ func (proc *processor) process(r io.Reader, w io.Writer) error {
 	// buffer size to 2MB.
 	scanner.Buffer(nil, 2*1024*1024)
 	for scanner.Scan() {
+		if proc.log != nil {
+			fmt.Fprintln(proc.log, scanner.Text())
+		}
 		proc.processLine(w, scanner.Text())
 	}
 	err := scanner.Err()
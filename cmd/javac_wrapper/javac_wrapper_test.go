@@ -17,6 +17,8 @@ package main
 import (
 	"bytes"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 )
@@ -140,4 +142,26 @@ func TestSubprocess(t *testing.T) {
 		}
 	})
 
+	t.Run("log", func(t *testing.T) {
+		log := filepath.Join(t.TempDir(), "javac.log")
+		buf := new(bytes.Buffer)
+		exitCode, err := Main(buf, "test", []string{"--log", log, "echo", "Foo.java:1: warning: [Check] foo"})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exitCode != 0 {
+			t.Fatal("expected exit code 0, got", exitCode)
+		}
+		data, err := os.ReadFile(log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Foo.java:1: warning: [Check] foo\n"; string(data) != want {
+			t.Errorf("expected log %q, got %q", want, string(data))
+		}
+		if buf.String() == string(data) {
+			t.Errorf("expected colorized output, got %q", buf.String())
+		}
+	})
+
 }
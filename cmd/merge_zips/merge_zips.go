@@ -33,6 +33,7 @@ import (
 
 	"android/soong/jar"
 	"android/soong/third_party/zip"
+	soongzip "android/soong/zip"
 )
 
 // Input zip: we can open it, close it, and obtain an array of entries
@@ -62,6 +63,11 @@ type ZipEntryFromZip struct {
 	isDir    bool
 	crc32    uint32
 	size     uint64
+	// The method to recompress the entry with if it is compressed with another method, or
+	// zip.Store to keep the method of the entry.
+	method uint16
+	// Whether to canonicalize the header of the entry, see zip.FileHeader.Canonicalize.
+	canonicalize bool
 }
 
 func NewZipEntryFromZip(inputZip InputZip, entryIndex int) *ZipEntryFromZip {
@@ -96,7 +102,17 @@ func (ze ZipEntryFromZip) WriteToZip(dest string, zw *zip.Writer) error {
 	if err := ze.inputZip.Open(); err != nil {
 		return err
 	}
-	return zw.CopyFrom(ze.inputZip.Entries()[ze.index], dest)
+	entry := ze.inputZip.Entries()[ze.index]
+	if ze.canonicalize {
+		// Copy the entry so that the header of the input zip is not modified.
+		canonicalEntry := *entry
+		canonicalEntry.FileHeader.Canonicalize(jar.DefaultTime)
+		entry = &canonicalEntry
+	}
+	if ze.method != zip.Store && entry.Method != zip.Store {
+		return zw.CopyFromWithMethod(entry, dest, ze.method)
+	}
+	return zw.CopyFrom(entry, dest)
 }
 
 // a ZipEntryFromBuffer is a ZipEntryContents that pulls its content from a []byte
@@ -147,6 +163,10 @@ type OutputZip struct {
 	excludeDirs      []string
 	excludeFiles     []string
 	sourceByDest     map[string]ZipEntryContents
+	// The method to recompress the compressed entries with, or zip.Store to keep their methods.
+	compressionMethod uint16
+	// Whether to canonicalize the headers of the entries copied from the input zips.
+	reproducible bool
 }
 
 func NewOutputZip(outputWriter *zip.Writer, sortEntries, emulateJar, stripDirEntries, ignoreDuplicates bool) *OutputZip {
@@ -171,6 +191,14 @@ func (oz *OutputZip) setExcludeFiles(excludeFiles []string) {
 	oz.excludeFiles = excludeFiles
 }
 
+func (oz *OutputZip) setCompressionMethod(method uint16) {
+	oz.compressionMethod = method
+}
+
+func (oz *OutputZip) setReproducible(reproducible bool) {
+	oz.reproducible = reproducible
+}
+
 // Adds an entry with given name whose source is given ZipEntryContents. Returns old ZipEntryContents
 // if entry with given name already exists.
 func (oz *OutputZip) addZipEntry(name string, source ZipEntryContents) (ZipEntryContents, error) {
@@ -275,6 +303,8 @@ func (oz *OutputZip) isEntryExcluded(name string) bool {
 // Creates a zip entry whose contents is an entry from the given input zip.
 func (oz *OutputZip) copyEntry(inputZip InputZip, index int) error {
 	entry := NewZipEntryFromZip(inputZip, index)
+	entry.method = oz.compressionMethod
+	entry.canonicalize = oz.reproducible
 	if oz.stripDirEntries && entry.IsDir() {
 		return nil
 	}
@@ -534,11 +564,29 @@ func (miz *ManagedInputZip) Entries() []*zip.File {
 // Actual processing.
 func mergeZips(inputZips []InputZip, writer *zip.Writer, manifest, pyMain string,
 	sortEntries, emulateJar, emulatePar, stripDirEntries, ignoreDuplicates bool,
-	excludeFiles, excludeDirs []string, zipsToNotStrip map[string]bool) error {
+	excludeFiles, excludeDirs []string, zipsToNotStrip map[string]bool, compressionMethod uint16,
+	reproducible bool) error {
+
+	if emulateJar {
+		// Jars are read by javac and d8, which only support deflate.
+		if compressionMethod == zip.Zstd {
+			return errors.New("zstd compression can't be used with -j, jars must be readable by javac and d8")
+		}
+		compressionMethod = zip.Deflate
+	}
+
+	if reproducible {
+		// The entries that merge_zips creates itself already have canonical headers, those copied
+		// from the input zips are canonicalized, and the order of the entries must not depend on
+		// the order of the input zips.
+		sortEntries = true
+	}
 
 	out := NewOutputZip(writer, sortEntries, emulateJar, stripDirEntries, ignoreDuplicates)
 	out.setExcludeFiles(excludeFiles)
 	out.setExcludeDirs(excludeDirs)
+	out.setCompressionMethod(compressionMethod)
+	out.setReproducible(reproducible)
 	if manifest != "" {
 		if err := out.addManifest(manifest); err != nil {
 			return err
@@ -648,6 +696,9 @@ var (
 	pyMain           = flag.String("pm", "", "__main__.py file to insert in par")
 	prefix           = flag.String("prefix", "", "A file to prefix to the zip file")
 	ignoreDuplicates = flag.Bool("ignore-duplicates", false, "take each entry from the first zip it exists in and don't warn")
+	method           = flag.String("method", "", "recompress the compressed entries with deflate or zstd (defaults to keeping their compression)")
+	reproducible     = flag.Bool("reproducible", false, "sort the entries and canonicalize their timestamps, permissions and extra fields")
+	verify           = flag.String("verify", "", "compare the output zip file with a reference zip file and fail if any entry differs")
 )
 
 func init() {
@@ -698,7 +749,7 @@ func (fiz *FileInputZip) Open() error {
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintln(os.Stderr, "usage: merge_zips [-jpsD] [-m manifest] [--prefix script] [-pm __main__.py] OutputZip [inputs...]")
+		fmt.Fprintln(os.Stderr, "usage: merge_zips [-jpsD] [-m manifest] [--prefix script] [-pm __main__.py] [-method deflate|zstd] [-reproducible] [-verify reference.zip] OutputZip [inputs...]")
 		flag.PrintDefaults()
 	}
 
@@ -751,12 +802,6 @@ func main() {
 	}
 
 	writer := zip.NewWriter(outputZip)
-	defer func() {
-		err := writer.Close()
-		if err != nil {
-			log.Fatal(err)
-		}
-	}()
 	writer.SetOffset(offset)
 
 	if *manifest != "" && !*emulateJar {
@@ -767,6 +812,14 @@ func main() {
 		log.Fatal(errors.New("must specify -p when specifying a Python __main__.py via -pm"))
 	}
 
+	compressionMethod := zip.Store
+	if *method != "" {
+		compressionMethod, err = zip.ParseCompressionMethod(*method)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// do merge
 	inputZipsManager := NewInputZipsManager(len(inputs), 1000)
 	inputZips := make([]InputZip, len(inputs))
@@ -775,8 +828,20 @@ func main() {
 	}
 	err = mergeZips(inputZips, writer, *manifest, *pyMain, *sortEntries, *emulateJar, *emulatePar,
 		*stripDirEntries, *ignoreDuplicates, []string(excludeFiles), []string(excludeDirs),
-		map[string]bool(zipsToNotStrip))
+		map[string]bool(zipsToNotStrip), compressionMethod, *reproducible)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err := writer.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := outputZip.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	if *verify != "" {
+		if err := soongzip.VerifyArchive(outputPath, *verify); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
@@ -58,6 +58,12 @@ var (
 	moduleInfoFile = testZipEntry{jar.ModuleInfoClass, 0755, []byte("module-info"), zip.Deflate}
 )
 
+var (
+	aZstd   = testZipEntry{"a", 0755, []byte("foo"), zip.Zstd}
+	bcZstd  = testZipEntry{"b/c", 0755, []byte("bar"), zip.Zstd}
+	bcStore = testZipEntry{"b/c", 0755, []byte("bar"), zip.Store}
+)
+
 type testInputZip struct {
 	name    string
 	entries []testZipEntry
@@ -102,6 +108,7 @@ func TestMergeZips(t *testing.T) {
 		ignoreDuplicates bool
 		stripDirEntries  bool
 		zipsToNotStrip   map[string]bool
+		method           uint16
 
 		out []testZipEntry
 		err string
@@ -252,6 +259,46 @@ func TestMergeZips(t *testing.T) {
 			jar: true,
 			out: []testZipEntry{service1combined, service2},
 		},
+		{
+			name: "keep compression methods",
+			in: [][]testZipEntry{
+				{aZstd, bc},
+			},
+			out: []testZipEntry{aZstd, bc},
+		},
+		{
+			name: "recompress with deflate",
+			in: [][]testZipEntry{
+				{aZstd, bcStore},
+			},
+			method: zip.Deflate,
+			out:    []testZipEntry{a, bcStore},
+		},
+		{
+			name: "recompress with zstd",
+			in: [][]testZipEntry{
+				{a, bc},
+			},
+			method: zip.Zstd,
+			out:    []testZipEntry{aZstd, bcZstd},
+		},
+		{
+			name: "jars are deflated",
+			in: [][]testZipEntry{
+				{aZstd},
+			},
+			jar: true,
+			out: []testZipEntry{a},
+		},
+		{
+			name: "zstd jar error",
+			in: [][]testZipEntry{
+				{a},
+			},
+			jar:    true,
+			method: zip.Zstd,
+			err:    "zstd compression can't be used with -j",
+		},
 	}
 
 	for _, test := range testCases {
@@ -268,7 +315,7 @@ func TestMergeZips(t *testing.T) {
 
 			err := mergeZips(inputZips, writer, "", "",
 				test.sort, test.jar, false, test.stripDirEntries, test.ignoreDuplicates,
-				test.stripFiles, test.stripDirs, test.zipsToNotStrip)
+				test.stripFiles, test.stripDirs, test.zipsToNotStrip, test.method, false)
 
 			closeErr := writer.Close()
 			if closeErr != nil {
@@ -297,6 +344,58 @@ func TestMergeZips(t *testing.T) {
 	}
 }
 
+func TestMergeZipsReproducible(t *testing.T) {
+	bf := testZipEntry{"b/f", 0600, []byte("qux"), zip.Deflate}
+
+	merge := func(in ...[]testZipEntry) []byte {
+		inputZips := make([]InputZip, len(in))
+		for i, entries := range in {
+			inputZips[i] = &testInputZip{name: "in" + strconv.Itoa(i), entries: entries}
+		}
+		out := &bytes.Buffer{}
+		writer := zip.NewWriter(out)
+		err := mergeZips(inputZips, writer, "", "", false, false, false, false, false,
+			nil, nil, nil, zip.Store, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return out.Bytes()
+	}
+
+	got := merge([]testZipEntry{bf, bd}, []testZipEntry{bDir, a})
+	if other := merge([]testZipEntry{bDir, a}, []testZipEntry{bd, bf}); !bytes.Equal(got, other) {
+		t.Errorf("the output depends on the order of the inputs:\n%s\nand:\n%s", dumpZip(got), dumpZip(other))
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(got), int64(len(got)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		name string
+		mode os.FileMode
+	}{
+		{"a", 0755},
+		{"b/", os.ModeDir | 0755},
+		{"b/d", 0755},
+		{"b/f", 0644},
+	}
+	if len(zr.File) != len(want) {
+		t.Fatalf("want %d entries, got:\n%s", len(want), dumpZip(got))
+	}
+	for i, f := range zr.File {
+		if f.Name != want[i].name || f.Mode() != want[i].mode {
+			t.Errorf("want entry %d to be %s %v, got %s %v", i, want[i].name, want[i].mode, f.Name, f.Mode())
+		}
+		if !f.ModTime().Equal(jar.DefaultTime) {
+			t.Errorf("want %s to be modified at %v, got %v", f.Name, jar.DefaultTime, f.ModTime())
+		}
+	}
+}
+
 func testZipEntriesToBuf(entries []testZipEntry) []byte {
 	b := &bytes.Buffer{}
 	zw := zip.NewWriter(b)
@@ -0,0 +1,543 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file implements a local content-addressed cache of the outputs of sandboxed commands.
+//
+// The cache directory contains two subdirectories and two files:
+//   cas/<xx>/<sha256>   the contents of output files, keyed by the hash of their contents
+//   ac/<xx>/<sha256>    action entries, keyed by the hash of the command and all of its inputs,
+//                       listing the output files and the console output of the command
+//   size                the total size of the files in cas and ac
+//   lock                locked while size is updated and while the cache is trimmed
+//
+// The size file is a running total of the bytes stored in the cache, so that the cache is only
+// walked when the total crosses its maximum size, to evict the least recently used files, or when
+// the size file is missing.  The total may overestimate the size of the cache, e.g. when two
+// processes store the same output, which only makes it walk the cache and correct the total
+// earlier.
+//
+// A command is only cacheable if its inputs are sandboxed, i.e. it runs in the sandbox directory
+// and all the tools and inputs it uses are copied in by copy_before or rsp_files, as otherwise
+// the key of the action cannot include all of its inputs.  Commands that write a depfile are not
+// cached either, as the depfile would refer to the inputs of the original run.
+//
+// Multiple sbox processes may use the cache concurrently.  All files are written to a temporary
+// file and renamed into place, and a missing output file in the cache is treated as a miss, so
+// the worst case of a race with eviction is rerunning the command.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"android/soong/cmd/sbox/sbox_proto"
+	"android/soong/response"
+)
+
+// The version of the cache format, included in every action key so that changes to the format
+// or to the way keys are computed don't reuse stale entries.
+const actionCacheVersion = "sbox-action-cache-v1"
+
+// When the cache grows past its maximum size it is trimmed to this fraction of the maximum size
+// so that every following store doesn't have to trim it again.
+const actionCacheTrimRatio = 0.8
+
+const (
+	actionCacheSizeFile = "size"
+	actionCacheLockFile = "lock"
+)
+
+type actionCache struct {
+	dir     string
+	maxSize int64
+}
+
+// actionCacheOutput describes an output file of a cached action.
+type actionCacheOutput struct {
+	// The path of the output file relative to the sandbox directory, i.e. the from field of the
+	// copy_after rule.
+	Path string `json:"path"`
+
+	// The sha256 hash of the contents of the output file.
+	Hash string `json:"hash"`
+
+	// Whether the output file was executable.
+	Executable bool `json:"executable,omitempty"`
+}
+
+// actionCacheEntry is the entry stored in the cache for an action.
+type actionCacheEntry struct {
+	Outputs []actionCacheOutput `json:"outputs"`
+
+	// The combined stdout and stderr of the command.
+	Console []byte `json:"console,omitempty"`
+}
+
+// actionCacheStat is appended to the stats file for every command run by an sbox process with
+// the cache enabled.
+type actionCacheStat struct {
+	Key string `json:"key,omitempty"`
+
+	// One of "hit", "miss" or "uncacheable".
+	Result string `json:"result"`
+
+	// The number of bytes of output files restored on a hit, or stored on a miss.
+	Bytes int64 `json:"bytes,omitempty"`
+
+	// The number of bytes evicted from the cache after storing the outputs of the command.
+	EvictedBytes int64 `json:"evicted_bytes,omitempty"`
+
+	// The time it took to run the command, or to restore its outputs on a hit, in milliseconds.
+	TimeMs int64 `json:"time_ms"`
+}
+
+const (
+	actionCacheHit         = "hit"
+	actionCacheMiss        = "miss"
+	actionCacheUncacheable = "uncacheable"
+)
+
+func newActionCache(dir string, maxSize int64) *actionCache {
+	return &actionCache{dir: dir, maxSize: maxSize}
+}
+
+func (c *actionCache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "cas", hash[:2], hash)
+}
+
+func (c *actionCache) entryPath(key string) string {
+	return filepath.Join(c.dir, "ac", key[:2], key)
+}
+
+// isCacheable returns whether the outputs of a command can be cached.
+func isCacheable(command *sbox_proto.Command) bool {
+	return command.GetChdir() && !strings.Contains(command.GetCommand(), depFilePlaceholder)
+}
+
+// hashFile returns the hex encoded sha256 hash of the contents of a file and its size.
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// actionKey computes the key of a command in the cache from the command line, the layout of the
+// sandbox, and the contents of every file copied into the sandbox, including the tools.
+func actionKey(command *sbox_proto.Command) (string, error) {
+	h := sha256.New()
+	field := func(name, value string) {
+		fmt.Fprintf(h, "%s %d %s\n", name, len(value), value)
+	}
+
+	field("version", actionCacheVersion)
+	field("command", command.GetCommand())
+
+	inputHash := func(from, to string, executable bool) error {
+		hash, _, err := hashFile(from)
+		if err != nil {
+			return fmt.Errorf("failed to hash input %q: %w", from, err)
+		}
+		field("input", to)
+		field("executable", fmt.Sprint(executable))
+		field("hash", hash)
+		return nil
+	}
+
+	for _, copyPair := range command.CopyBefore {
+		if err := inputHash(copyPair.GetFrom(), copyPair.GetTo(), copyPair.GetExecutable()); err != nil {
+			return "", err
+		}
+	}
+
+	for _, rspFile := range command.RspFiles {
+		in, err := os.Open(rspFile.GetFile())
+		if err != nil {
+			return "", err
+		}
+		files, err := response.ReadRspFile(in)
+		in.Close()
+		if err != nil {
+			return "", err
+		}
+
+		field("rsp_file", applyPathMappings(rspFile.PathMappings, rspFile.GetFile()))
+		for _, from := range files {
+			if err := inputHash(from, applyPathMappings(rspFile.PathMappings, from), false); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, copyPair := range command.CopyAfter {
+		field("output", copyPair.GetFrom())
+		field("executable", fmt.Sprint(copyPair.GetExecutable()))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFileAtomically writes a file in the cache through a temporary file so that concurrent
+// readers never see a partially written file.
+func writeFileAtomically(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	// ioutil.TempFile creates files that are only readable by the owner, the permissions of the
+	// blobs are copied to the restored outputs.
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// lookup returns the cache entry for the key, or nil if there is no complete entry in the cache.
+func (c *actionCache) lookup(key string) *actionCacheEntry {
+	data, err := ioutil.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil
+	}
+	entry := &actionCacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil
+	}
+	for _, output := range entry.Outputs {
+		if _, err := os.Stat(c.blobPath(output.Hash)); err != nil {
+			return nil
+		}
+	}
+	return entry
+}
+
+// restore copies the outputs of a cached action to the paths specified by the copy_after rules
+// of the command, returning the number of bytes restored.
+func (c *actionCache) restore(key string, entry *actionCacheEntry, copies []*sbox_proto.Copy,
+	write writeType) (int64, error) {
+
+	outputs := make(map[string]actionCacheOutput, len(entry.Outputs))
+	for _, output := range entry.Outputs {
+		outputs[output.Path] = output
+	}
+
+	now := time.Now()
+	var bytes int64
+	for _, copyPair := range copies {
+		output, ok := outputs[copyPair.GetFrom()]
+		if !ok {
+			return 0, fmt.Errorf("cache entry %s has no output %q", key, copyPair.GetFrom())
+		}
+		blob := c.blobPath(output.Hash)
+		err := copyOneFile(blob, copyPair.GetTo(), output.Executable, requireFromExists, write)
+		if err != nil {
+			return 0, err
+		}
+		if stat, err := os.Stat(blob); err == nil {
+			bytes += stat.Size()
+		}
+		// Mark the blob as recently used so that it is not evicted.
+		os.Chtimes(blob, now, now)
+	}
+	os.Chtimes(c.entryPath(key), now, now)
+
+	return bytes, nil
+}
+
+// store copies the outputs of a command from the sandbox directory into the cache, returning the
+// number of bytes stored.
+func (c *actionCache) store(key string, copies []*sbox_proto.Copy, sandboxDir string, console []byte) (int64, error) {
+	entry := actionCacheEntry{Console: console}
+	var bytes int64
+
+	for _, copyPair := range copies {
+		from := joinPath(sandboxDir, copyPair.GetFrom())
+		stat, err := os.Stat(from)
+		if err != nil {
+			return 0, err
+		}
+		hash, size, err := hashFile(from)
+		if err != nil {
+			return 0, err
+		}
+
+		blob := c.blobPath(hash)
+		if _, err := os.Stat(blob); os.IsNotExist(err) {
+			err := writeFileAtomically(blob, func(w io.Writer) error {
+				in, err := os.Open(from)
+				if err != nil {
+					return err
+				}
+				defer in.Close()
+				_, err = io.Copy(w, in)
+				return err
+			})
+			if err != nil {
+				return 0, err
+			}
+			bytes += size
+		}
+
+		entry.Outputs = append(entry.Outputs, actionCacheOutput{
+			Path:       copyPair.GetFrom(),
+			Hash:       hash,
+			Executable: copyPair.GetExecutable() || stat.Mode()&0100 != 0,
+		})
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	err = writeFileAtomically(c.entryPath(key), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return bytes + int64(len(data)), nil
+}
+
+type actionCacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// files returns the files in the cache and their total size.
+func (c *actionCache) files() ([]actionCacheFile, int64, error) {
+	var files []actionCacheFile
+	var total int64
+	for _, subdir := range []string{"ac", "cas"} {
+		err := filepath.Walk(filepath.Join(c.dir, subdir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, actionCacheFile{path, info.Size(), info.ModTime()})
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return files, total, nil
+}
+
+// trim evicts the least recently used files from the cache until it is smaller than
+// actionCacheTrimRatio of its maximum size, if it is larger than its maximum size.  It returns
+// the number of bytes evicted and the size of the cache afterwards.
+func (c *actionCache) trim() (evicted, remaining int64, err error) {
+	files, total, err := c.files()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if total <= c.maxSize {
+		return 0, total, nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	target := int64(float64(c.maxSize) * actionCacheTrimRatio)
+	for _, file := range files {
+		if total-evicted <= target {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return evicted, total - evicted, err
+		}
+		evicted += file.size
+	}
+
+	return evicted, total - evicted, nil
+}
+
+// lock takes the lock of the cache, waiting for other sbox processes to release it, and returns a
+// function that releases it.
+func (c *actionCache) lock() (func(), error) {
+	if err := os.MkdirAll(c.dir, 0777); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(c.dir, actionCacheLockFile), os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// addSize adds the number of bytes stored by a command to the size file of the cache, and trims
+// the cache if the total is larger than its maximum size.  It returns the number of bytes
+// evicted.
+func (c *actionCache) addSize(bytes int64) (int64, error) {
+	if c.maxSize <= 0 {
+		return 0, nil
+	}
+
+	unlock, err := c.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	sizeFile := filepath.Join(c.dir, actionCacheSizeFile)
+	total := int64(-1)
+	if data, err := ioutil.ReadFile(sizeFile); err == nil {
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			total = n
+		}
+	}
+
+	if total < 0 {
+		// The size file is missing or corrupt, the size of the cache, including the bytes
+		// that were just stored, has to be computed from its files.
+		if _, total, err = c.files(); err != nil {
+			return 0, err
+		}
+	} else {
+		total += bytes
+	}
+
+	var evicted int64
+	if total > c.maxSize {
+		if evicted, total, err = c.trim(); err != nil {
+			// Let the next store recompute the size of the cache.
+			os.Remove(sizeFile)
+			return evicted, err
+		}
+	}
+
+	err = writeFileAtomically(sizeFile, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "%d\n", total)
+		return err
+	})
+	return evicted, err
+}
+
+// writeActionCacheStat appends a stat to the stats file.  Each stat is written with a single
+// write to a file opened with O_APPEND so that concurrent sbox processes don't interleave lines.
+func writeActionCacheStat(file string, stat actionCacheStat) error {
+	if file == "" {
+		return nil
+	}
+	data, err := json.Marshal(stat)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// restoreFromCache restores the outputs of a command from the cache if it has an entry for the
+// key, and writes the console output of the original run to stdout.  It returns whether there
+// was an entry in the cache, and whether the outputs were restored successfully.
+func restoreFromCache(command *sbox_proto.Command, key string, startTime time.Time) (attempted, ok bool) {
+	entry := cache.lookup(key)
+	if entry == nil {
+		return false, false
+	}
+
+	bytes, err := cache.restore(key, entry, command.CopyAfter, writeType(writeIfChanged))
+	if err != nil {
+		return true, false
+	}
+
+	os.Stdout.Write(entry.Console)
+
+	writeActionCacheStat(cacheStatsFile, actionCacheStat{
+		Key:    key,
+		Result: actionCacheHit,
+		Bytes:  bytes,
+		TimeMs: time.Since(startTime).Milliseconds(),
+	})
+	return true, true
+}
+
+// storeInCache stores the outputs of a command that ran successfully in the cache, and evicts old
+// entries if the cache has grown too large.  Failing to store the outputs doesn't fail the
+// command, the cache is only an optimization.
+func storeInCache(command *sbox_proto.Command, key, sandboxDir string, console []byte, startTime time.Time) {
+	stat := actionCacheStat{
+		Key:    key,
+		Result: actionCacheMiss,
+		TimeMs: time.Since(startTime).Milliseconds(),
+	}
+
+	if bytes, err := cache.store(key, command.CopyAfter, sandboxDir, console); err == nil {
+		stat.Bytes = bytes
+		stat.EvictedBytes, _ = cache.addSize(bytes)
+	}
+
+	writeActionCacheStat(cacheStatsFile, stat)
+}
@@ -0,0 +1,223 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"android/soong/cmd/sbox/sbox_proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestActionKey(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	writeTestFile(t, input, "foo")
+
+	command := func(cmd, to string) *sbox_proto.Command {
+		return &sbox_proto.Command{
+			Command: proto.String(cmd),
+			Chdir:   proto.Bool(true),
+			CopyBefore: []*sbox_proto.Copy{
+				{From: proto.String(input), To: proto.String(to)},
+			},
+			CopyAfter: []*sbox_proto.Copy{
+				{From: proto.String("out/output"), To: proto.String(filepath.Join(dir, "output"))},
+			},
+		}
+	}
+
+	key := func(c *sbox_proto.Command) string {
+		t.Helper()
+		k, err := actionKey(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	base := key(command("cp input out/output", "input"))
+	if k := key(command("cp input out/output", "input")); k != base {
+		t.Errorf("key is not stable: %s != %s", k, base)
+	}
+	if k := key(command("cat input > out/output", "input")); k == base {
+		t.Errorf("key did not change when the command changed")
+	}
+	if k := key(command("cp input out/output", "other")); k == base {
+		t.Errorf("key did not change when the sandbox layout changed")
+	}
+
+	writeTestFile(t, input, "bar")
+	if k := key(command("cp input out/output", "input")); k == base {
+		t.Errorf("key did not change when the contents of an input changed")
+	}
+}
+
+func TestActionCacheStoreAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	cache := newActionCache(filepath.Join(dir, "cache"), 0)
+	sandboxDir := filepath.Join(dir, "sandbox")
+	writeTestFile(t, filepath.Join(sandboxDir, "out/a"), "aaa")
+	writeTestFile(t, filepath.Join(sandboxDir, "out/b"), "bbb")
+
+	copies := []*sbox_proto.Copy{
+		{From: proto.String("out/a"), To: proto.String(filepath.Join(dir, "outputs/a"))},
+		{From: proto.String("out/b"), To: proto.String(filepath.Join(dir, "outputs/b")), Executable: proto.Bool(true)},
+	}
+
+	const key = "0123456789abcdef"
+	if cache.lookup(key) != nil {
+		t.Fatal("unexpected cache entry before store")
+	}
+
+	if _, err := cache.store(key, copies, sandboxDir, []byte("warning: foo\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := cache.lookup(key)
+	if entry == nil {
+		t.Fatal("missing cache entry after store")
+	}
+	if string(entry.Console) != "warning: foo\n" {
+		t.Errorf("unexpected console output %q", entry.Console)
+	}
+
+	if _, err := cache.restore(key, entry, copies, alwaysWrite); err != nil {
+		t.Fatal(err)
+	}
+	for _, copyPair := range copies {
+		data, err := os.ReadFile(copyPair.GetTo())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Base(copyPair.GetTo()); string(data) != want+want+want {
+			t.Errorf("unexpected contents of %s: %q", copyPair.GetTo(), data)
+		}
+	}
+	if stat, err := os.Stat(filepath.Join(dir, "outputs/b")); err != nil || stat.Mode()&0100 == 0 {
+		t.Errorf("expected outputs/b to be executable")
+	}
+
+	// A missing output file makes the entry incomplete.
+	os.Remove(cache.blobPath(entry.Outputs[0].Hash))
+	if cache.lookup(key) != nil {
+		t.Errorf("expected no cache entry after removing an output")
+	}
+}
+
+func TestActionCacheTrim(t *testing.T) {
+	dir := t.TempDir()
+	cache := newActionCache(dir, 100)
+
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"aa0", "aa1", "aa2", "aa3"} {
+		path := cache.blobPath(name)
+		writeTestFile(t, path, string(make([]byte, 40)))
+		modTime := old.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	evicted, remaining, err := cache.trim()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evicted != 80 {
+		t.Errorf("expected 80 bytes to be evicted, got %d", evicted)
+	}
+	if remaining != 80 {
+		t.Errorf("expected 80 bytes to remain, got %d", remaining)
+	}
+	for i, name := range []string{"aa0", "aa1", "aa2", "aa3"} {
+		_, err := os.Stat(cache.blobPath(name))
+		if exists := err == nil; exists != (i >= 2) {
+			t.Errorf("unexpected existence of %s: %v", name, exists)
+		}
+	}
+}
+
+func TestActionCacheAddSize(t *testing.T) {
+	dir := t.TempDir()
+	cache := newActionCache(dir, 100)
+	sizeFile := filepath.Join(dir, actionCacheSizeFile)
+
+	readSize := func() string {
+		t.Helper()
+		data, err := os.ReadFile(sizeFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Without a size file the size is computed from the files in the cache.
+	writeTestFile(t, cache.blobPath("aa0"), string(make([]byte, 40)))
+	if evicted, err := cache.addSize(40); err != nil || evicted != 0 {
+		t.Fatalf("unexpected eviction of %d bytes: %v", evicted, err)
+	}
+	if got := readSize(); got != "40\n" {
+		t.Errorf("expected a size of 40, got %q", got)
+	}
+
+	// Below the maximum size the bytes are added to the size file without walking the cache,
+	// which doesn't see a file removed behind its back.
+	writeTestFile(t, cache.blobPath("aa1"), string(make([]byte, 40)))
+	os.Remove(cache.blobPath("aa0"))
+	if evicted, err := cache.addSize(40); err != nil || evicted != 0 {
+		t.Fatalf("unexpected eviction of %d bytes: %v", evicted, err)
+	}
+	if got := readSize(); got != "80\n" {
+		t.Errorf("expected a size of 80, got %q", got)
+	}
+
+	// Crossing the maximum size walks the cache, which corrects the size.
+	writeTestFile(t, cache.blobPath("aa2"), string(make([]byte, 40)))
+	if evicted, err := cache.addSize(40); err != nil || evicted != 0 {
+		t.Fatalf("unexpected eviction of %d bytes: %v", evicted, err)
+	}
+	if got := readSize(); got != "80\n" {
+		t.Errorf("expected a size of 80, got %q", got)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.blobPath("aa1"), old, old)
+	writeTestFile(t, cache.blobPath("aa3"), string(make([]byte, 40)))
+	evicted, err := cache.addSize(40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evicted != 40 {
+		t.Errorf("expected 40 bytes to be evicted, got %d", evicted)
+	}
+	if got := readSize(); got != "80\n" {
+		t.Errorf("expected a size of 80, got %q", got)
+	}
+	if _, err := os.Stat(cache.blobPath("aa1")); !os.IsNotExist(err) {
+		t.Errorf("expected aa1 to be evicted")
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// This file implements running sandboxed commands in Linux user, mount and network namespaces
+// using nsjail.  Copying the inputs into the sandbox directory doesn't stop a command from reading
+// files through absolute paths, or through paths relative to the top of the source tree when it
+// doesn't change directory.  Inside the namespaces only the sandbox directory, the directories on
+// PATH and the tools they link to, and a minimal read-only root are visible, so reads of
+// undeclared inputs fail.
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The directories of the host that are mounted read-only in the namespace sandbox so that
+// commands can use the system shell and libraries.
+var namespaceSandboxRootDirs = []string{
+	"/bin",
+	"/etc",
+	"/lib",
+	"/lib64",
+	"/sbin",
+	"/usr",
+}
+
+// The device nodes that are mounted in the namespace sandbox.
+var namespaceSandboxDevices = []string{
+	"/dev/null",
+	"/dev/random",
+	"/dev/urandom",
+	"/dev/zero",
+}
+
+// The maximum number of symlinks followed to find the target of a tool on PATH, as in Linux.
+const maxSymlinkHops = 40
+
+// namespaceSandboxMounts returns the directories that are mounted read-only in the namespace
+// sandbox, which are the minimal root, the absolute directories on PATH, and the directories of
+// the targets of the symlinks in the directories on PATH.  The directories on PATH set by soong_ui
+// (out/.path) only contain symlinks to tools in prebuilts/ and out/, which would be dangling in
+// the sandbox without the directories of their targets.
+func namespaceSandboxMounts(pathEnv string) []string {
+	var mounts []string
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if seen[dir] || !filepath.IsAbs(dir) {
+			return
+		}
+		seen[dir] = true
+		if _, err := os.Stat(dir); err == nil {
+			mounts = append(mounts, dir)
+		}
+	}
+
+	for _, dir := range namespaceSandboxRootDirs {
+		add(dir)
+	}
+	var pathDirs []string
+	for _, dir := range filepath.SplitList(pathEnv) {
+		dir = filepath.Clean(dir)
+		add(dir)
+		pathDirs = append(pathDirs, dir)
+	}
+	for _, dir := range pathDirs {
+		if !filepath.IsAbs(dir) {
+			continue
+		}
+		for _, targetDir := range symlinkTargetDirs(dir) {
+			if !isUnderAnyDir(targetDir, mounts) {
+				add(targetDir)
+			}
+		}
+	}
+	return mounts
+}
+
+// symlinkTargetDirs returns the directories that contain the targets of the symlinks in dir,
+// including the intermediate targets of chains of symlinks.
+func symlinkTargetDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		link := filepath.Join(dir, entry.Name())
+		for i := 0; i < maxSymlinkHops; i++ {
+			if stat, err := os.Lstat(link); err != nil || stat.Mode()&os.ModeSymlink == 0 {
+				break
+			}
+			target, err := os.Readlink(link)
+			if err != nil {
+				break
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(link), target)
+			}
+			link = filepath.Clean(target)
+			dirs = append(dirs, filepath.Dir(link))
+		}
+		// The lexical targets differ from the real ones when a target is relative to a directory
+		// that is itself a symlink.
+		if real, err := filepath.EvalSymlinks(filepath.Join(dir, entry.Name())); err == nil {
+			dirs = append(dirs, filepath.Dir(real))
+		}
+	}
+	return dirs
+}
+
+// wrapInNamespaces modifies cmd to run inside nsjail with only the sandbox directory writable and
+// a minimal root read-only.  The network namespace is left without any interfaces.
+func wrapInNamespaces(cmd *exec.Cmd, nsjailPath, sandboxDir string) error {
+	absSandboxDir, err := filepath.Abs(sandboxDir)
+	if err != nil {
+		return err
+	}
+	absNsjailPath, err := filepath.Abs(nsjailPath)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-q",
+		"-H", "android-build",
+		"-t", "0",
+		// The environment has already been filtered by soong_ui.
+		"-e",
+		"--disable_clone_newcgroup",
+		"--rlimit_as", "soft",
+		"--rlimit_core", "soft",
+		"--rlimit_cpu", "soft",
+		"--rlimit_fsize", "soft",
+		"--rlimit_nofile", "soft",
+		"--cwd", absSandboxDir,
+	}
+
+	for _, dir := range namespaceSandboxMounts(os.Getenv("PATH")) {
+		args = append(args, "-R", dir)
+	}
+	for _, dev := range namespaceSandboxDevices {
+		if _, err := os.Stat(dev); err == nil {
+			args = append(args, "-R", dev)
+		}
+	}
+	args = append(args,
+		"-T", "/tmp",
+		"-B", absSandboxDir,
+		"--",
+		"/bin/bash")
+	args = append(args, cmd.Args[1:]...)
+
+	cmd.Path = absNsjailPath
+	cmd.Args = append([]string{absNsjailPath}, args...)
+	return nil
+}
+
+var pathLikeRegexp = regexp.MustCompile(`[A-Za-z0-9_.+@/-]*/[A-Za-z0-9_.+@/-]+`)
+
+// undeclaredPaths looks through the output of a command that failed in the namespace sandbox for
+// paths of files that exist outside the sandbox but are not visible inside it, which are most
+// likely inputs of the command that were not declared.  Absolute paths are checked on the host,
+// relative paths are checked relative to the top of the source tree, and are only reported if
+// they don't exist in the sandbox directory.
+func undeclaredPaths(output []byte, sandboxDir string, mounts []string) []string {
+	const maxPaths = 10
+
+	absSandboxDir, _ := filepath.Abs(sandboxDir)
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, match := range pathLikeRegexp.FindAll(output, -1) {
+		path := strings.TrimRight(string(match), ".")
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if filepath.IsAbs(path) {
+			if isUnderAnyDir(path, append([]string{absSandboxDir}, mounts...)) {
+				continue
+			}
+		} else if _, err := os.Stat(filepath.Join(sandboxDir, path)); err == nil {
+			continue
+		}
+
+		if stat, err := os.Stat(path); err != nil || stat.IsDir() {
+			continue
+		}
+
+		paths = append(paths, path)
+		if len(paths) == maxPaths {
+			break
+		}
+	}
+	return paths
+}
+
+func isUnderAnyDir(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// undeclaredPathsMessage returns an error message listing the undeclared inputs that a command
+// that failed in the namespace sandbox most likely tried to read, or an empty string if none were
+// found.
+func undeclaredPathsMessage(output []byte, sandboxDir string) string {
+	paths := undeclaredPaths(output, sandboxDir, namespaceSandboxMounts(os.Getenv("PATH")))
+	if len(paths) == 0 {
+		return ""
+	}
+
+	msg := "The command was run in a namespace sandbox and tried to access files that are not\n" +
+		"inputs or tools of the rule, add them to the inputs or tools of the rule:\n"
+	for _, path := range paths {
+		msg += fmt.Sprintf("  %s\n", path)
+	}
+	return msg
+}
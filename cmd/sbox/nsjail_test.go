@@ -0,0 +1,120 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrapInNamespaces(t *testing.T) {
+	cmd := exec.Command("bash", "sbox_command.0.bash")
+	if err := wrapInNamespaces(cmd, "prebuilts/nsjail", "out/sandbox"); err != nil {
+		t.Fatal(err)
+	}
+
+	absNsjail, _ := filepath.Abs("prebuilts/nsjail")
+	absSandbox, _ := filepath.Abs("out/sandbox")
+
+	if cmd.Path != absNsjail {
+		t.Errorf("want path %q, got %q", absNsjail, cmd.Path)
+	}
+
+	args := strings.Join(cmd.Args, " ")
+	for _, want := range []string{
+		"--cwd " + absSandbox,
+		"-B " + absSandbox,
+		"-T /tmp",
+		"-- /bin/bash sbox_command.0.bash",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("want %q in args, got %q", want, args)
+		}
+	}
+	if strings.Contains(args, " -N") {
+		t.Errorf("network must not be enabled, got %q", args)
+	}
+}
+
+func TestNamespaceSandboxMounts(t *testing.T) {
+	dir := t.TempDir()
+	pathDir := filepath.Join(dir, "out/.path")
+	writeTestFile(t, filepath.Join(dir, "prebuilts/build-tools/bin/toybox"), "")
+	writeTestFile(t, filepath.Join(dir, "out/host/bin/soong_zip"), "")
+	writeTestFile(t, filepath.Join(pathDir, "bash"), "")
+	for link, target := range map[string]string{
+		"cat":       "../../prebuilts/build-tools/bin/toybox",
+		"soong_zip": filepath.Join(dir, "out/host/bin/soong_zip"),
+	} {
+		if err := os.Symlink(target, filepath.Join(pathDir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mounts := namespaceSandboxMounts(pathDir + ":relative/bin")
+	for _, want := range []string{
+		pathDir,
+		filepath.Join(dir, "prebuilts/build-tools/bin"),
+		filepath.Join(dir, "out/host/bin"),
+	} {
+		if !isUnderAnyDir(want, mounts) {
+			t.Errorf("want %q to be mounted, got %q", want, mounts)
+		}
+	}
+	for _, mount := range mounts {
+		if mount == "relative/bin" || mount == dir || mount == filepath.Join(dir, "out") {
+			t.Errorf("want %q not to be mounted, got %q", mount, mounts)
+		}
+	}
+}
+
+func TestUndeclaredPaths(t *testing.T) {
+	dir := t.TempDir()
+	sandboxDir := filepath.Join(dir, "sandbox")
+	mountDir := filepath.Join(dir, "usr")
+	writeTestFile(t, filepath.Join(sandboxDir, "declared.h"), "")
+	writeTestFile(t, filepath.Join(dir, "src/undeclared.h"), "")
+	writeTestFile(t, filepath.Join(mountDir, "include/stdio.h"), "")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	output := strings.Join([]string{
+		"foo.c:1:10: fatal error: src/undeclared.h: No such file or directory",
+		"cat: " + filepath.Join(dir, "src/undeclared.h") + ": No such file or directory",
+		"note: " + filepath.Join(mountDir, "include/stdio.h") + " included here",
+		"note: " + filepath.Join(sandboxDir, "declared.h") + " included here",
+		"warning: src/missing.h: No such file or directory",
+	}, "\n")
+
+	got := undeclaredPaths([]byte(output), "sandbox", []string{mountDir})
+	want := []string{
+		"src/undeclared.h",
+		filepath.Join(dir, "src/undeclared.h"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
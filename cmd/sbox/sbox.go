@@ -43,6 +43,12 @@ var (
 	manifestFile   string
 	keepOutDir     bool
 	writeIfChanged bool
+	cacheDir       string
+	cacheMaxSize   int64
+	cacheStatsFile string
+	nsjailPath     string
+
+	cache *actionCache
 )
 
 const (
@@ -61,6 +67,14 @@ func init() {
 		"whether to keep the sandbox directory when done")
 	flag.BoolVar(&writeIfChanged, "write-if-changed", false,
 		"only write the output files if they have changed")
+	flag.StringVar(&cacheDir, "cache-dir", "",
+		"directory of a local cache of the outputs of sandboxed commands")
+	flag.Int64Var(&cacheMaxSize, "cache-max-size", 0,
+		"maximum size of the cache in bytes, the least recently used entries are evicted past it")
+	flag.StringVar(&cacheStatsFile, "cache-stats", "",
+		"file to append cache hit and miss statistics to")
+	flag.StringVar(&nsjailPath, "nsjail", "",
+		"path to nsjail, run commands that change to the sandbox directory in Linux namespaces with it")
 }
 
 func usageViolation(violation string) {
@@ -124,6 +138,10 @@ func run() error {
 		return fmt.Errorf("at least one commands entry is required in %q", manifestFile)
 	}
 
+	if cacheDir != "" {
+		cache = newActionCache(cacheDir, cacheMaxSize)
+	}
+
 	// setup sandbox directory
 	err = os.MkdirAll(sandboxesRoot, 0777)
 	if err != nil {
@@ -248,6 +266,28 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 		return "", err
 	}
 
+	startTime := time.Now()
+	var cacheKey string
+	if cache != nil {
+		if !isCacheable(command) {
+			writeActionCacheStat(cacheStatsFile, actionCacheStat{Result: actionCacheUncacheable})
+		} else {
+			cacheKey, err = actionKey(command)
+			if err != nil {
+				return "", err
+			}
+			if attempted, ok := restoreFromCache(command, cacheKey, startTime); ok {
+				return "", nil
+			} else if attempted {
+				// A partial restore may have left files behind, clear them before running the command.
+				err = clearOutputDirectory(command.CopyAfter, outputDir, writeType(writeIfChanged))
+				if err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
 	pathToTempDirInSbox := tempDir
 	if command.GetChdir() {
 		pathToTempDirInSbox = "."
@@ -309,6 +349,15 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 			return "", fmt.Errorf("Failed to update PATH: %w", err)
 		}
 	}
+
+	inNamespaces := nsjailPath != "" && command.GetChdir()
+	if inNamespaces {
+		err = wrapInNamespaces(cmd, nsjailPath, tempDir)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	err = cmd.Run()
 
 	if err != nil {
@@ -334,6 +383,11 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 	os.Stdout.Write(buf.Bytes())
 
 	if err != nil {
+		// If the command failed in the namespace sandbox point out any files that it tried to
+		// access which are not visible in it, after the command's output so that it is not missed.
+		if inNamespaces {
+			fmt.Fprint(os.Stderr, undeclaredPathsMessage(buf.Bytes(), tempDir))
+		}
 		return "", err
 	}
 
@@ -342,6 +396,10 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 		return "", err
 	}
 
+	if cacheKey != "" {
+		storeInCache(command, cacheKey, tempDir, buf.Bytes(), startTime)
+	}
+
 	// the created files match the declared files; now move them
 	err = moveFiles(command.CopyAfter, tempDir, "", writeType(writeIfChanged))
 	if err != nil {
@@ -71,6 +71,7 @@ func init() {
 	// Flags representing various modes soong_build can run in
 	flag.StringVar(&cmdlineArgs.ModuleGraphFile, "module_graph_file", "", "JSON module graph file to output")
 	flag.StringVar(&cmdlineArgs.ModuleActionsFile, "module_actions_file", "", "JSON file to output inputs/outputs of actions of modules")
+	flag.StringVar(&cmdlineArgs.QueryGraphFile, "query_graph_file", "", "JSON module graph file to output for soong_ui --query")
 	flag.StringVar(&cmdlineArgs.DocFile, "soong_docs", "", "build documentation file to output")
 	flag.StringVar(&cmdlineArgs.BazelQueryViewDir, "bazel_queryview_dir", "", "path to the bazel queryview directory relative to --top")
 	flag.StringVar(&cmdlineArgs.BazelApiBp2buildDir, "bazel_api_bp2build_dir", "", "path to the bazel api_bp2build directory relative to --top")
@@ -327,7 +328,7 @@ func runSoongOnlyBuild(ctx *android.Context, extraNinjaDeps []string) string {
 
 	var stopBefore bootstrap.StopBefore
 	switch ctx.Config().BuildMode {
-	case android.GenerateModuleGraph:
+	case android.GenerateModuleGraph, android.GenerateQueryGraph:
 		stopBefore = bootstrap.StopBeforeWriteNinja
 	case android.GenerateQueryView, android.GenerateDocFile:
 		stopBefore = bootstrap.StopBeforePrepareBuildActions
@@ -352,6 +353,10 @@ func runSoongOnlyBuild(ctx *android.Context, extraNinjaDeps []string) string {
 		writeJsonModuleGraphAndActions(ctx, cmdlineArgs)
 		writeDepFile(cmdlineArgs.ModuleGraphFile, ctx.EventHandler, ninjaDeps)
 		return cmdlineArgs.ModuleGraphFile
+	case android.GenerateQueryGraph:
+		// The graph was written by the query_graph singleton in the RunBlueprint() call above
+		writeDepFile(cmdlineArgs.QueryGraphFile, ctx.EventHandler, ninjaDeps)
+		return cmdlineArgs.QueryGraphFile
 	case android.GenerateDocFile:
 		// TODO: we could make writeDocs() return the list of documentation files
 		// written and add them to the .d file. Then soong_docs would be re-run
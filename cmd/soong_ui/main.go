@@ -100,6 +100,37 @@ var commands = []command{
 		// However, this invocation marks the true "end of the build", and thus we
 		// need to update the total runtime of the build to include this upload step.
 		run: updateTotalRealTime,
+	}, {
+		flag:         "--perf-report",
+		description:  "compare the last build against the median of previous builds and list the largest regressions",
+		simpleOutput: true,
+		logsPrefix:   "perf-report-",
+		config:       perfReportConfig,
+		stdio:        stdio,
+		run:          perfReport,
+	}, {
+		flag:        "--finder-daemon",
+		description: "watch the source tree and serve it to the source finder of later builds until interrupted",
+		logsPrefix:  "finder-daemon-",
+		config:      finderDaemonConfig,
+		stdio:       stdio,
+		run:         finderDaemon,
+	}, {
+		flag:         "--query",
+		description:  "run a query against the Soong module graph, e.g. rdeps(libfoo), and print the result to stdout",
+		simpleOutput: true,
+		logsPrefix:   "query-",
+		config:       queryConfig,
+		stdio:        customStdio,
+		run:          runQuery,
+	}, {
+		flag:         "--why-installed",
+		description:  "explain why a file or a module is in a filesystem image, e.g. --why-installed system_image libfoo",
+		simpleOutput: true,
+		logsPrefix:   "query-",
+		config:       queryConfig,
+		stdio:        customStdio,
+		run:          whyInstalled,
 	},
 }
 
@@ -221,15 +252,21 @@ func main() {
 
 	trace.SetOutput(filepath.Join(logsDir, c.logsPrefix+"build.trace"))
 
+	// Failed builds stop early, so their performance is not comparable to the previous builds.
+	buildSucceeded := false
 	defer func() {
 		stat.Finish()
 		criticalPath.WriteToMetrics(met)
+		if buildSucceeded {
+			build.RecordBuildPerf(buildCtx, config)
+		}
 		met.Dump(soongMetricsFile)
 		if !config.SkipMetricsUpload() {
 			build.UploadMetrics(buildCtx, config, c.simpleOutput, buildStarted, bazelProfileFile, bazelMetricsFile, metricsFiles...)
 		}
 	}()
 	c.run(buildCtx, config, args)
+	buildSucceeded = true
 
 }
 
@@ -260,6 +297,9 @@ func logAndSymlinkSetup(buildCtx build.Context, config build.Config) {
 	stat.AddOutput(status.NewProtoErrorLog(log, buildErrorFile))
 	stat.AddOutput(status.NewCriticalPathLogger(log, buildCtx.CriticalPath))
 	stat.AddOutput(status.NewBuildProgressLog(log, filepath.Join(logsDir, logsPrefix+"build_progress.pb")))
+	if config.ActionTrace() {
+		stat.AddOutput(status.NewActionTraceLog(log, filepath.Join(logsDir, logsPrefix+"action.trace"), buildCtx.CriticalPath))
+	}
 
 	buildCtx.Verbosef("Detected %.3v GB total RAM", float32(config.TotalRAM())/(1024*1024*1024))
 	buildCtx.Verbosef("Parallelism (local/remote/highmem): %v/%v/%v",
@@ -436,6 +476,104 @@ func dumpVars(ctx build.Context, config build.Config, args []string) {
 	}
 }
 
+func perfReport(ctx build.Context, config build.Config, args []string) {
+	flags := flag.NewFlagSet("perf-report", flag.ExitOnError)
+	flags.SetOutput(ctx.Writer)
+
+	flags.Usage = func() {
+		fmt.Fprintf(ctx.Writer, "usage: %s --perf-report [--builds=N] [--top=N]\n\n", os.Args[0])
+		fmt.Fprintln(ctx.Writer, "In perf-report mode, compare the last build against the median of the")
+		fmt.Fprintln(ctx.Writer, "previous builds recorded in the build performance history in OUT_DIR, and")
+		fmt.Fprintln(ctx.Writer, "list the phases, modules and actions that regressed the most.")
+		fmt.Fprintln(ctx.Writer, "")
+		flags.PrintDefaults()
+	}
+
+	builds := flags.Int("builds", 10, "Number of previous builds to compute the median from")
+	top := flags.Int("top", 20, "Maximum number of regressions to list per category")
+
+	flags.Parse(args)
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		ctx.Fatalf("Invalid usage")
+	}
+
+	build.PerfReport(ctx, config, *builds, *top)
+}
+
+func finderDaemon(ctx build.Context, config build.Config, args []string) {
+	if len(args) != 0 {
+		fmt.Fprintf(ctx.Writer, "usage: %s --finder-daemon\n", os.Args[0])
+		ctx.Fatalf("Invalid usage")
+	}
+	build.RunFinderDaemon(ctx, config)
+}
+
+func runQuery(ctx build.Context, config build.Config, args []string) {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	flags.SetOutput(ctx.Writer)
+
+	flags.Usage = func() {
+		fmt.Fprintf(ctx.Writer, "usage: %s --query [--output=text|json|dot] [--cached] <query>\n\n", os.Args[0])
+		fmt.Fprintln(ctx.Writer, "In query mode, run a query against the module graph of Soong, with every variant")
+		fmt.Fprintln(ctx.Writer, "of every module, their dependencies with their tags, install paths and outputs.")
+		fmt.Fprintln(ctx.Writer, "Queries combine module patterns (libfoo, libfoo@android_arm64*, //dir:libfoo,")
+		fmt.Fprintln(ctx.Writer, "//dir/...) with the functions deps(x[, depth]), rdeps(x[, depth]), somepath(x, y),")
+		fmt.Fprintln(ctx.Writer, "allpaths(x, y) and filter(field, regexp, x), and the operators +, - and ^.")
+		fmt.Fprintln(ctx.Writer, "")
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("output", "text", "Output format of the result: text, json or dot")
+	cached := flags.Bool("cached", false, "Use the module graph of the last query without running Soong")
+
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		ctx.Fatalf("Invalid usage")
+	}
+
+	if !*cached {
+		logAndSymlinkSetup(ctx, config)
+		build.GenerateQueryGraph(ctx, config)
+	}
+	build.RunQuery(ctx, config, os.Stdout, strings.Join(flags.Args(), " "), *output)
+}
+
+func whyInstalled(ctx build.Context, config build.Config, args []string) {
+	flags := flag.NewFlagSet("why-installed", flag.ExitOnError)
+	flags.SetOutput(ctx.Writer)
+
+	flags.Usage = func() {
+		fmt.Fprintf(ctx.Writer, "usage: %s --why-installed [--output=text|json] [--cached] <package> <path|module>\n\n", os.Args[0])
+		fmt.Fprintln(ctx.Writer, "In why-installed mode, print the shortest chains of dependencies through which a")
+		fmt.Fprintln(ctx.Writer, "package, e.g. a filesystem module, contains a file or a module, with the properties")
+		fmt.Fprintln(ctx.Writer, "that added every dependency.  The package is a module pattern as in --query, the")
+		fmt.Fprintln(ctx.Writer, "file is a path in the package, e.g. lib64/libfoo.so, or on the device, e.g.")
+		fmt.Fprintln(ctx.Writer, "/system/lib64/libfoo.so.")
+		fmt.Fprintln(ctx.Writer, "")
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("output", "text", "Output format of the result: text or json")
+	cached := flags.Bool("cached", false, "Use the module graph of the last query without running Soong")
+
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		ctx.Fatalf("Invalid usage")
+	}
+
+	if !*cached {
+		logAndSymlinkSetup(ctx, config)
+		build.GenerateQueryGraph(ctx, config)
+	}
+	build.RunWhyInstalled(ctx, config, os.Stdout, flags.Arg(0), flags.Arg(1), *output)
+}
+
 func stdio() terminal.StdioInterface {
 	return terminal.StdioImpl{}
 }
@@ -459,6 +597,24 @@ func uploadOnlyConfig(ctx build.Context, args ...string) build.Config {
 	return build.UploadOnlyConfig(ctx)
 }
 
+// perfReportConfig does not require any arguments to be parsed by the NewConfig, and never
+// uploads metrics.
+func perfReportConfig(ctx build.Context, args ...string) build.Config {
+	return build.NewConfig(ctx, "--skip-metrics-upload")
+}
+
+// finderDaemonConfig does not require any arguments to be parsed by the NewConfig, and never
+// uploads metrics.
+func finderDaemonConfig(ctx build.Context, args ...string) build.Config {
+	return build.NewConfig(ctx, "--skip-metrics-upload")
+}
+
+// queryConfig does not require any arguments to be parsed by the NewConfig, and never uploads
+// metrics.
+func queryConfig(ctx build.Context, args ...string) build.Config {
+	return build.NewConfig(ctx, "--skip-metrics-upload")
+}
+
 func buildActionConfig(ctx build.Context, args ...string) build.Config {
 	flags := flag.NewFlagSet("build-mode", flag.ContinueOnError)
 	flags.SetOutput(ctx.Writer)
@@ -29,6 +29,7 @@ import (
 
 	"android/soong/jar"
 	"android/soong/third_party/zip"
+	soongzip "android/soong/zip"
 )
 
 var (
@@ -37,6 +38,10 @@ var (
 	sortGlobs = flag.Bool("s", false, "sort matches from each glob (defaults to the order from the input zip file)")
 	sortJava  = flag.Bool("j", false, "sort using jar ordering within each glob (META-INF/MANIFEST.MF first)")
 	setTime   = flag.Bool("t", false, "set timestamps to 2009-01-01 00:00:00")
+	method    = flag.String("method", "", "recompress the compressed files with deflate or zstd (defaults to keeping their compression)")
+
+	reproducible = flag.Bool("reproducible", false, "sort all the output files and canonicalize their timestamps, permissions and extra fields")
+	verify       = flag.String("verify", "", "compare the output file with a reference zip file and fail if any entry differs")
 
 	staticTime = time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC)
 
@@ -53,7 +58,7 @@ func init() {
 
 func main() {
 	flag.Usage = func() {
-		fmt.Fprintln(os.Stderr, "usage: zip2zip -i zipfile -o zipfile [-s|-j] [-t] [filespec]...")
+		fmt.Fprintln(os.Stderr, "usage: zip2zip -i zipfile -o zipfile [-s|-j] [-t] [-method deflate|zstd] [-reproducible] [-verify reference.zip] [filespec]...")
 		flag.PrintDefaults()
 		fmt.Fprintln(os.Stderr, "  filespec:")
 		fmt.Fprintln(os.Stderr, "    <name>")
@@ -63,9 +68,13 @@ func main() {
 		fmt.Fprintln(os.Stderr, "<glob> uses the rules at https://godoc.org/github.com/google/blueprint/pathtools/#Match")
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "Files will be copied with their existing compression from the input zipfile to")
-		fmt.Fprintln(os.Stderr, "the output zipfile, in the order of filespec arguments.")
+		fmt.Fprintln(os.Stderr, "the output zipfile, in the order of filespec arguments, unless they are converted")
+		fmt.Fprintln(os.Stderr, "to uncompressed with -0 or recompressed with -method.")
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "If no filepsec is provided all files and directories are copied.")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "With -reproducible the output files are sorted by name, or using jar ordering with -j,")
+		fmt.Fprintln(os.Stderr, "instead of in the order of filespec arguments.")
 	}
 
 	flag.Parse()
@@ -77,6 +86,15 @@ func main() {
 
 	log.SetFlags(log.Lshortfile)
 
+	compressionMethod := zip.Store
+	if *method != "" {
+		var err error
+		compressionMethod, err = zip.ParseCompressionMethod(*method)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	reader, err := zip.OpenReader(*input)
 	if err != nil {
 		log.Fatal(err)
@@ -90,18 +108,24 @@ func main() {
 	defer output.Close()
 
 	writer := zip.NewWriter(output)
-	defer func() {
-		err := writer.Close()
-		if err != nil {
-			log.Fatal(err)
-		}
-	}()
 
 	if err := zip2zip(&reader.Reader, writer, *sortGlobs, *sortJava, *setTime,
-		flag.Args(), excludes, includes, uncompress); err != nil {
+		flag.Args(), excludes, includes, uncompress, compressionMethod, *reproducible); err != nil {
 
 		log.Fatal(err)
 	}
+	if err := writer.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := output.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	if *verify != "" {
+		if err := soongzip.VerifyArchive(output.Name(), *verify); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
 type pair struct {
@@ -110,8 +134,12 @@ type pair struct {
 	uncompress bool
 }
 
+// zip2zip copies the matching files from reader to writer.  Compressed files that are not converted
+// to uncompressed are recompressed with method if it is not zip.Store.  If reproducible is true all
+// the files are sorted and their headers are canonicalized, see zip.FileHeader.Canonicalize.
 func zip2zip(reader *zip.Reader, writer *zip.Writer, sortOutput, sortJava, setTime bool,
-	args []string, excludes, includes multiFlag, uncompresses []string) error {
+	args []string, excludes, includes multiFlag, uncompresses []string, method uint16,
+	reproducible bool) error {
 
 	matches := []pair{}
 
@@ -213,8 +241,20 @@ func zip2zip(reader *zip.Reader, writer *zip.Writer, sortOutput, sortJava, setTi
 		matchesAfterExcludes = append(matchesAfterExcludes, match)
 	}
 
+	if reproducible {
+		// Sort all the files instead of the matches of each filespec.
+		sortOutput = true
+		sortMatches(matchesAfterExcludes)
+	}
+
 	for _, match := range matchesAfterExcludes {
-		if setTime {
+		if reproducible {
+			modTime := jar.DefaultTime
+			if setTime {
+				modTime = staticTime
+			}
+			match.File.FileHeader.Canonicalize(modTime)
+		} else if setTime {
 			match.File.SetModTime(staticTime)
 		}
 		if match.uncompress && match.File.FileHeader.Method != zip.Store {
@@ -238,6 +278,11 @@ func zip2zip(reader *zip.Reader, writer *zip.Writer, sortOutput, sortJava, setTi
 			if err != nil {
 				return err
 			}
+		} else if method != zip.Store && match.File.FileHeader.Method != zip.Store {
+			err := writer.CopyFromWithMethod(match.File, match.newName, method)
+			if err != nil {
+				return err
+			}
 		} else {
 			err := writer.CopyFrom(match.File, match.newName)
 			if err != nil {
@@ -20,6 +20,7 @@ import (
 	"reflect"
 	"testing"
 
+	"android/soong/jar"
 	"android/soong/third_party/zip"
 )
 
@@ -33,9 +34,12 @@ var testCases = []struct {
 	excludes     []string
 	includes     []string
 	uncompresses []string
+	method       uint16
+	reproducible bool
 
 	outputFiles []string
 	storedFiles []string
+	zstdFiles   []string
 	err         error
 }{
 	{
@@ -406,6 +410,42 @@ var testCases = []struct {
 			"b/a/b",
 		},
 	},
+	{
+		name: "recompress with zstd",
+
+		inputFiles: []string{
+			"a",
+			"b",
+		},
+		uncompresses: []string{"b"},
+		method:       zip.Zstd,
+		outputFiles: []string{
+			"a",
+			"b",
+		},
+		storedFiles: []string{
+			"b",
+		},
+		zstdFiles: []string{
+			"a",
+		},
+	},
+	{
+		name: "reproducible",
+
+		inputFiles: []string{
+			"a",
+			"b",
+			"c/d",
+		},
+		args:         []string{"c/*", "b", "a"},
+		reproducible: true,
+		outputFiles: []string{
+			"a",
+			"b",
+			"c/d",
+		},
+	},
 }
 
 func errorString(e error) string {
@@ -438,7 +478,8 @@ func TestZip2Zip(t *testing.T) {
 
 			outputWriter := zip.NewWriter(outputBuf)
 			err = zip2zip(inputReader, outputWriter, testCase.sortGlobs, testCase.sortJava, false,
-				testCase.args, testCase.excludes, testCase.includes, testCase.uncompresses)
+				testCase.args, testCase.excludes, testCase.includes, testCase.uncompresses, testCase.method,
+				testCase.reproducible)
 			if errorString(testCase.err) != errorString(err) {
 				t.Fatalf("Unexpected error:\n got: %q\nwant: %q", errorString(err), errorString(testCase.err))
 			}
@@ -451,12 +492,18 @@ func TestZip2Zip(t *testing.T) {
 			}
 			var outputFiles []string
 			var storedFiles []string
+			var zstdFiles []string
 			if len(outputReader.File) > 0 {
 				outputFiles = make([]string, len(outputReader.File))
 				for i, file := range outputReader.File {
 					outputFiles[i] = file.Name
+					if testCase.reproducible && !file.ModTime().Equal(jar.DefaultTime) {
+						t.Errorf("want %s to be modified at %v, got %v", file.Name, jar.DefaultTime, file.ModTime())
+					}
 					if file.Method == zip.Store {
 						storedFiles = append(storedFiles, file.Name)
+					} else if file.Method == zip.Zstd {
+						zstdFiles = append(zstdFiles, file.Name)
 					}
 				}
 			}
@@ -467,6 +514,9 @@ func TestZip2Zip(t *testing.T) {
 			if !reflect.DeepEqual(testCase.storedFiles, storedFiles) {
 				t.Fatalf("Stored file list does not match:\nwant: %v\n got: %v", testCase.storedFiles, storedFiles)
 			}
+			if !reflect.DeepEqual(testCase.zstdFiles, zstdFiles) {
+				t.Fatalf("Zstd file list does not match:\nwant: %v\n got: %v", testCase.zstdFiles, zstdFiles)
+			}
 		})
 	}
 }
@@ -22,9 +22,11 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"runtime/pprof"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"android/soong/finder"
@@ -42,6 +44,8 @@ var (
 	verbose       bool
 	dbPath        string
 	numIterations int
+	daemon        bool
+	queryDaemon   bool
 )
 
 func init() {
@@ -49,6 +53,10 @@ func init() {
 		"filepath of profile file to write (optional)")
 	flag.BoolVar(&verbose, "v", false, "log additional information")
 	flag.StringVar(&dbPath, "db", "", "filepath of cache db")
+	flag.BoolVar(&daemon, "daemon", false,
+		"watch the search directories and serve them to later runs with the same params until interrupted")
+	flag.BoolVar(&queryDaemon, "query-daemon", false,
+		"ask the daemon for the files named -names instead of loading the whole cache")
 
 	flag.StringVar(&excludeDirs, "exclude-dirs", "",
 		"comma-separated list of directory names to exclude from search")
@@ -128,8 +136,18 @@ func run() error {
 		return errors.New("Param 'db' must be nonempty")
 	}
 
+	if daemon {
+		return runDaemon(params, logger)
+	}
+
 	matches := []string{}
-	for i := 0; i < numIterations; i++ {
+	if queryDaemon {
+		matches, err = runQueryDaemon(params)
+		if err != nil {
+			return err
+		}
+	}
+	for i := 0; i < numIterations && !queryDaemon; i++ {
 		matches, err = runFind(params, logger)
 		if err != nil {
 			return err
@@ -154,3 +172,34 @@ func runFind(params finder.CacheParams, logger *log.Logger) (paths []string, err
 	defer service.Shutdown()
 	return service.FindAll(), nil
 }
+
+func runDaemon(params finder.CacheParams, logger *log.Logger) error {
+	d, err := finder.NewDaemon(params, logger, dbPath)
+	if err != nil {
+		return err
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		d.Close()
+	}()
+	if err := d.Serve(); err != nil {
+		d.Close()
+		return err
+	}
+	// wait for the daemon to save the db
+	return d.Close()
+}
+
+func runQueryDaemon(params finder.CacheParams) (paths []string, err error) {
+	client := finder.NewDaemonClient(params, dbPath)
+	for _, name := range params.IncludeFiles {
+		found, err := client.FindNamedAt("/", name)
+		if err != nil {
+			return []string{}, err
+		}
+		paths = append(paths, found...)
+	}
+	return paths, nil
+}
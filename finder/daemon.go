@@ -0,0 +1,618 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"android/soong/finder/fs"
+)
+
+// This file provides a Daemon that keeps the tree of a Finder in memory and in sync with the
+// filesystem, by watching every directory of the tree with inotify, and serves it to other
+// processes over a unix socket next to the cache db.
+//
+// Most of the time of loading a Finder from its cache db is spent calling Stat on every directory
+// to find the ones that changed. The daemon instead learns about changes from inotify, so a
+// Finder created by New with the same CacheParams and db only needs to load a snapshot of the
+// tree from the daemon, and answers all of its queries (including FindMatching, whose WalkFunc
+// cannot be sent to the daemon) from the snapshot.
+// If there is no daemon, it serves different CacheParams or it cannot confirm that it is in sync
+// with the filesystem, New loads the Finder from the cache db as usual.
+//
+// To confirm that it is in sync, the daemon creates a file in a directory that it watches before
+// answering a query, and waits until it receives the inotify event for it. As inotify reports
+// the events of an instance in order, every change made before the query has been applied to the
+// tree by then.
+
+const (
+	// daemonOpSnapshot requests the whole tree, serialized like the cache db
+	daemonOpSnapshot = "snapshot"
+	// daemonOpFindNamedAt requests the results of FindNamedAt
+	daemonOpFindNamedAt = "find-named-at"
+	// daemonOpFindFirstNamedAt requests the results of FindFirstNamedAt
+	daemonOpFindFirstNamedAt = "find-first-named-at"
+
+	// daemonTimeout is how long a connection to the daemon may take
+	daemonTimeout = time.Minute
+	// daemonSyncTimeout is how long the daemon waits to confirm that it is in sync
+	daemonSyncTimeout = 10 * time.Second
+)
+
+// DaemonSocketPath returns the path of the unix socket of the finder daemon for the cache db at
+// <dbPath>
+func DaemonSocketPath(dbPath string) string {
+	return dbPath + ".sock"
+}
+
+// a daemonRequest is sent as a line of json to the daemon
+type daemonRequest struct {
+	Op string
+
+	// The metadata of the Finder sending the request, which must match the daemon's
+	Metadata cacheMetadata
+
+	// The arguments of daemonOpFindNamedAt and daemonOpFindFirstNamedAt
+	RootPath string
+	FileName string
+}
+
+// a daemonResponse is sent as a line of json by the daemon. The response to daemonOpSnapshot is
+// followed by Size bytes of the serialized tree.
+type daemonResponse struct {
+	Error   string
+	Results []string
+	Size    int
+}
+
+// queryDaemon sends <request> to the finder daemon for <dbPath>, and passes the bytes following
+// the response to <readBody>, if any
+func queryDaemon(dbPath string, request daemonRequest, readBody func([]byte) error) (daemonResponse, error) {
+	conn, err := net.DialTimeout("unix", DaemonSocketPath(dbPath), time.Second)
+	if err != nil {
+		return daemonResponse{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(daemonTimeout))
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return daemonResponse{}, err
+	}
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes(lineSeparator)
+	if err != nil {
+		return daemonResponse{}, fmt.Errorf("failed to read response of finder daemon: %v", err)
+	}
+	var response daemonResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return daemonResponse{}, fmt.Errorf("failed to parse response of finder daemon: %v", err)
+	}
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+	if readBody != nil {
+		body := make([]byte, response.Size)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return response, fmt.Errorf("failed to read snapshot of finder daemon: %v", err)
+		}
+		err = readBody(body)
+	}
+	return response, err
+}
+
+// newFromDaemon creates a Finder from the snapshot of the tree of the finder daemon for <dbPath>
+func newFromDaemon(cacheParams CacheParams, filesystem fs.FileSystem,
+	logger Logger, dbPath string, numThreads int) (f *Finder, err error) {
+	startTime := time.Now()
+	f = newUnloadedFinder(cacheParams, filesystem, logger, dbPath, numThreads)
+
+	request := daemonRequest{Op: daemonOpSnapshot, Metadata: f.cacheMetadata}
+	_, err = queryDaemon(dbPath, request, f.loadSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.checkLoaded()
+	if err != nil {
+		return nil, err
+	}
+	f.verbosef("Loaded snapshot from finder daemon in %v\n", time.Since(startTime))
+	return f, nil
+}
+
+// loadSnapshot loads the tree from <data>, which is serialized like the cache db, without
+// comparing it to the filesystem
+func (f *Finder) loadSnapshot(data []byte) error {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	if !f.validateCacheHeader(reader) {
+		return errors.New("snapshot of finder daemon does not match")
+	}
+	for {
+		block, err := f.readLine(reader)
+		if len(bytes.TrimSpace(block)) > 0 {
+			dirs, parseErr := f.parseCacheEntry(block)
+			if parseErr != nil {
+				return parseErr
+			}
+			for _, dir := range dirs {
+				node := f.nodes.GetNode(dir.Path, true)
+				node.mapNode = mapNode{statResponse: dir.statResponse, FileNames: dir.FileNames}
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	f.nodes.UpdateNumDescendentsRecursive()
+	return nil
+}
+
+// a DaemonClient sends queries to a finder daemon without loading the tree
+type DaemonClient struct {
+	metadata cacheMetadata
+	dbPath   string
+}
+
+// NewDaemonClient creates a DaemonClient for the finder daemon serving <cacheParams> for <dbPath>
+func NewDaemonClient(cacheParams CacheParams, dbPath string) *DaemonClient {
+	return &DaemonClient{
+		metadata: newCacheMetadata(cacheParams, fs.OsFs),
+		dbPath:   dbPath,
+	}
+}
+
+// FindNamedAt is like Finder.FindNamedAt, but is answered by the daemon
+func (c *DaemonClient) FindNamedAt(rootPath string, fileName string) ([]string, error) {
+	return c.find(daemonOpFindNamedAt, rootPath, fileName)
+}
+
+// FindFirstNamedAt is like Finder.FindFirstNamedAt, but is answered by the daemon
+func (c *DaemonClient) FindFirstNamedAt(rootPath string, fileName string) ([]string, error) {
+	return c.find(daemonOpFindFirstNamedAt, rootPath, fileName)
+}
+
+func (c *DaemonClient) find(op string, rootPath string, fileName string) ([]string, error) {
+	request := daemonRequest{Op: op, Metadata: c.metadata, RootPath: rootPath, FileName: fileName}
+	response, err := queryDaemon(c.dbPath, request, nil)
+	return response.Results, err
+}
+
+// a watchEvent is the relevant portion of an inotify event
+type watchEvent struct {
+	wd   int
+	name string
+
+	// the directory itself, or its entry <name> if <name> is not empty, changed
+	changed bool
+	// the entry <name> is a directory
+	isDir bool
+	// the file <name> was created
+	created bool
+	// the watch was removed, because the directory was deleted
+	ignored bool
+	// events were lost, because the event queue overflowed
+	overflow bool
+}
+
+// a Daemon keeps the tree of a Finder in sync with the filesystem and serves it over a unix socket
+type Daemon struct {
+	finder   *Finder
+	watcher  *watcher
+	listener net.Listener
+
+	// The following fields are guarded by the lock of the Finder.
+	// the watched directories, by watch descriptor
+	watchedPaths map[int]string
+	// err is set if the daemon failed to keep the tree in sync
+	err error
+
+	// the directory in which the files to confirm that the daemon is in sync are created
+	syncDir     string
+	syncWd      int
+	syncMutex   sync.Mutex
+	syncCounter int
+	syncWaiters map[string]chan bool
+
+	closeOnce sync.Once
+	closed    chan bool
+}
+
+// NewDaemon loads the Finder for <cacheParams> and <dbPath> from the source tree, starts watching
+// every directory of the tree, and listens on DaemonSocketPath(dbPath). Serve must be called to
+// answer queries.
+func NewDaemon(cacheParams CacheParams, logger Logger, dbPath string) (d *Daemon, err error) {
+	f, err := newImpl(cacheParams, fs.OsFs, logger, dbPath, defaultNumThreads)
+	if err != nil {
+		return nil, err
+	}
+	f.WaitForDbDump()
+
+	w, err := newWatcher()
+	if err != nil {
+		return nil, err
+	}
+	d = &Daemon{
+		finder:       f,
+		watcher:      w,
+		watchedPaths: make(map[int]string),
+		syncDir:      dbPath + ".sync",
+		syncWaiters:  make(map[string]chan bool),
+		closed:       make(chan bool),
+	}
+	defer func() {
+		if err != nil {
+			d.watcher.close()
+		}
+	}()
+
+	if err := os.MkdirAll(d.syncDir, 0777); err != nil {
+		return nil, err
+	}
+	d.syncWd, err = w.add(d.syncDir)
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	f.lock()
+	d.watchTree(&f.nodes, true)
+	err = d.err
+	f.unlock()
+	if err != nil {
+		return nil, err
+	}
+	f.verbosef("Watched %v directories in %v\n", len(d.watchedPaths), time.Since(startTime))
+
+	socketPath := DaemonSocketPath(dbPath)
+	os.Remove(socketPath)
+	d.listener, err = net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Serve applies the changes to the tree and answers queries until Close is called
+func (d *Daemon) Serve() error {
+	go d.watchLoop()
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			select {
+			case <-d.closed:
+				return nil
+			default:
+				return err
+			}
+		}
+		go d.serveConn(conn)
+	}
+}
+
+// Close stops the daemon, and saves the tree to the cache db so that Finders loaded without the
+// daemon start from an up-to-date db
+func (d *Daemon) Close() error {
+	var err error
+	d.closeOnce.Do(func() {
+		close(d.closed)
+		d.listener.Close()
+		d.watcher.close()
+		os.RemoveAll(d.syncDir)
+
+		f := d.finder
+		f.lock()
+		defer f.unlock()
+		err = d.err
+		if err == nil {
+			err = f.dumpDb()
+		}
+	})
+	return err
+}
+
+// watch watches the directory at <path>, and returns whether it was not watched yet
+func (d *Daemon) watch(path string) bool {
+	wd, err := d.watcher.add(path)
+	if err != nil {
+		// A directory may be deleted before it can be watched, which its parent reports, and
+		// like the Finder the daemon ignores directories that it cannot read
+		if !os.IsNotExist(err) && !os.IsPermission(err) {
+			d.fail(fmt.Errorf("failed to watch %v: %v", path, err))
+		}
+		return false
+	}
+	// inotify returns the same watch descriptor for a directory that was moved
+	if d.watchedPaths[wd] == path {
+		return false
+	}
+	d.watchedPaths[wd] = path
+	return true
+}
+
+func (d *Daemon) fail(err error) {
+	if d.err == nil {
+		d.finder.verbosef("Finder daemon is out of sync: %v\n", err)
+		d.err = err
+	}
+}
+
+// watchTree watches every directory under <root>, and rescans the directories that changed since
+// they were listed, which may have happened before they were watched. If <all> is false, only
+// the directories that were not watched yet are checked, as inotify reports the changes to the
+// others.
+func (d *Daemon) watchTree(root *pathMap, all bool) {
+	var nodes []*pathMap
+	var collect func(node *pathMap)
+	collect = func(node *pathMap) {
+		nodes = append(nodes, node)
+		for _, child := range node.children {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	var changed []*pathMap
+	for _, node := range nodes {
+		if node.ModTime == 0 {
+			// the root of the tree, or one of its ancestors, which are not listed
+			continue
+		}
+		if d.watch(node.path) || all {
+			if !d.finder.isInfoUpToDate(node.statResponse, d.finder.statDirSync(node.path)) {
+				changed = append(changed, node)
+			}
+		}
+	}
+	for _, node := range changed {
+		d.rescan(node)
+	}
+}
+
+// rescan lists the directory of <node> again, and loads and watches its new subdirectories
+func (d *Daemon) rescan(node *pathMap) {
+	f := d.finder
+	if f.nodes.GetNode(node.path, false) != node {
+		// the node was removed from the tree by the rescan of an ancestor
+		return
+	}
+
+	// listDirSync adds the new children to the existing map
+	oldChildren := make(map[string]*pathMap, len(node.children))
+	for name, child := range node.children {
+		oldChildren[name] = child
+	}
+	f.threadPool = newThreadPool(f.numDbLoadingThreads)
+	node.statResponse = f.statDirSync(node.path)
+	if node.ModTime != 0 {
+		f.listDirSync(node)
+	} else {
+		node.FileNames = nil
+		node.children = make(map[string]*pathMap)
+	}
+	f.threadPool.Wait()
+	f.threadPool = nil
+	// Errors are only reported while loading the Finder, like for deleted directories
+	f.fsErrs = nil
+	node.UpdateNumDescendentsRecursive()
+
+	for name, child := range node.children {
+		if oldChildren[name] != child {
+			d.watchTree(child, false)
+		}
+	}
+}
+
+// applyEvents updates the tree for a batch of inotify events
+func (d *Daemon) applyEvents(events []watchEvent) {
+	f := d.finder
+	f.lock()
+	defer f.unlock()
+
+	var syncs []string
+	overflow := false
+	dirty := make(map[string]*pathMap)
+	for _, event := range events {
+		if event.overflow {
+			overflow = true
+			continue
+		}
+		if event.wd == d.syncWd && event.created {
+			syncs = append(syncs, event.name)
+		}
+		path, found := d.watchedPaths[event.wd]
+		if !found {
+			continue
+		}
+		if event.ignored {
+			delete(d.watchedPaths, event.wd)
+			continue
+		}
+		if !event.changed {
+			continue
+		}
+		node := f.nodes.GetNode(path, false)
+		if node == nil || node.ModTime == 0 {
+			// the directory is no longer part of the tree, e.g. it was moved out of it
+			if event.wd != d.syncWd {
+				d.watcher.remove(event.wd)
+				delete(d.watchedPaths, event.wd)
+			}
+			continue
+		}
+		if event.isDir {
+			// Forget a subdirectory that was created, deleted or replaced, so that the rescan
+			// of its parent loads it again
+			delete(node.children, event.name)
+		}
+		dirty[path] = node
+	}
+
+	if overflow {
+		// The events since the last batch are lost, so check every directory
+		f.verbosef("Finder daemon event queue overflowed, checking every directory\n")
+		d.watchTree(&f.nodes, true)
+	} else {
+		paths := make([]string, 0, len(dirty))
+		for path := range dirty {
+			paths = append(paths, path)
+		}
+		// rescan parents before their children, which the rescan may remove
+		sort.Strings(paths)
+		for _, path := range paths {
+			d.rescan(dirty[path])
+		}
+	}
+
+	d.syncMutex.Lock()
+	for _, name := range syncs {
+		if waiter, ok := d.syncWaiters[name]; ok {
+			close(waiter)
+			delete(d.syncWaiters, name)
+		}
+	}
+	d.syncMutex.Unlock()
+}
+
+// watchLoop applies the inotify events to the tree until the daemon is closed
+func (d *Daemon) watchLoop() {
+	for {
+		events, err := d.watcher.read()
+		if err != nil {
+			select {
+			case <-d.closed:
+			default:
+				d.finder.lock()
+				d.fail(fmt.Errorf("failed to read inotify events: %v", err))
+				d.finder.unlock()
+			}
+			return
+		}
+		d.applyEvents(events)
+	}
+}
+
+// sync returns once every change made to the filesystem before it was called was applied to the
+// tree
+func (d *Daemon) sync() error {
+	d.syncMutex.Lock()
+	d.syncCounter++
+	name := fmt.Sprintf("sync-%d", d.syncCounter)
+	waiter := make(chan bool)
+	d.syncWaiters[name] = waiter
+	d.syncMutex.Unlock()
+
+	path := filepath.Join(d.syncDir, name)
+	err := os.WriteFile(path, nil, 0666)
+	if err == nil {
+		err = os.Remove(path)
+	}
+	if err == nil {
+		select {
+		case <-waiter:
+		case <-time.After(daemonSyncTimeout):
+			err = errors.New("timed out waiting for inotify events")
+		}
+	}
+
+	d.syncMutex.Lock()
+	delete(d.syncWaiters, name)
+	d.syncMutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+	d.finder.lock()
+	defer d.finder.unlock()
+	return d.err
+}
+
+func (d *Daemon) serveConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(daemonTimeout))
+
+	line, err := bufio.NewReader(conn).ReadBytes(lineSeparator)
+	if err != nil {
+		return
+	}
+	var response daemonResponse
+	var body []byte
+	var request daemonRequest
+	if err := json.Unmarshal(line, &request); err != nil {
+		response.Error = fmt.Sprintf("failed to parse request: %v", err)
+	} else {
+		response, body = d.answer(request)
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	conn.Write(append(responseBytes, lineSeparator))
+	conn.Write(body)
+}
+
+// answer returns the response to <request>, and the bytes that follow it
+func (d *Daemon) answer(request daemonRequest) (daemonResponse, []byte) {
+	f := d.finder
+	errorResponse := func(err error) (daemonResponse, []byte) {
+		return daemonResponse{Error: fmt.Sprintf("finder daemon: %v", err)}, nil
+	}
+
+	requestConfig, err := request.Metadata.Config.Dump()
+	if err != nil {
+		return errorResponse(err)
+	}
+	config, err := f.cacheMetadata.Config.Dump()
+	if err != nil {
+		return errorResponse(err)
+	}
+	if request.Metadata.Version != f.cacheMetadata.Version || !bytes.Equal(requestConfig, config) {
+		return errorResponse(errors.New("serving different parameters"))
+	}
+
+	if err := d.sync(); err != nil {
+		return errorResponse(err)
+	}
+
+	switch request.Op {
+	case daemonOpSnapshot:
+		f.lock()
+		data, err := f.serializeDb()
+		f.unlock()
+		if err != nil {
+			return errorResponse(err)
+		}
+		return daemonResponse{Size: len(data)}, data
+	case daemonOpFindNamedAt:
+		return daemonResponse{Results: f.FindNamedAt(request.RootPath, request.FileName)}, nil
+	case daemonOpFindFirstNamedAt:
+		return daemonResponse{Results: f.FindFirstNamedAt(request.RootPath, request.FileName)}, nil
+	default:
+		return errorResponse(fmt.Errorf("unknown operation %q", request.Op))
+	}
+}
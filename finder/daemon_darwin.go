@@ -0,0 +1,38 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"errors"
+)
+
+// The finder daemon requires inotify, so New always loads the Finder from the cache db on darwin.
+type watcher struct{}
+
+func newWatcher() (*watcher, error) {
+	return nil, errors.New("the finder daemon is only supported on linux")
+}
+
+func (w *watcher) add(path string) (int, error) {
+	return -1, errors.New("the finder daemon is only supported on linux")
+}
+
+func (w *watcher) remove(wd int) {}
+
+func (w *watcher) read() ([]watchEvent, error) {
+	return nil, errors.New("the finder daemon is only supported on linux")
+}
+
+func (w *watcher) close() {}
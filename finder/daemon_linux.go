@@ -0,0 +1,106 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// the events that can change the entries of a directory that the Finder records
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// a watcher reports changes to directories using inotify
+type watcher struct {
+	fd   int
+	file *os.File
+	buf  []byte
+}
+
+func newWatcher() (*watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// As the file descriptor is non-blocking, reads from the file wait in the runtime poller,
+	// and are interrupted by closing the file.
+	return &watcher{
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		buf:  make([]byte, 64*1024),
+	}, nil
+}
+
+// add watches the directory at <path> and returns the watch descriptor
+func (w *watcher) add(path string) (int, error) {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, watchMask)
+	if err != nil {
+		if err == syscall.ENOSPC {
+			return -1, &os.PathError{Op: "inotify_add_watch", Path: path,
+				Err: errors.New("out of inotify watches, increase fs.inotify.max_user_watches")}
+		}
+		return -1, &os.PathError{Op: "inotify_add_watch", Path: path, Err: err}
+	}
+	return wd, nil
+}
+
+// remove stops watching the directory of <wd>
+func (w *watcher) remove(wd int) {
+	syscall.InotifyRmWatch(w.fd, uint32(wd))
+}
+
+// read waits for and returns the next events
+func (w *watcher) read() ([]watchEvent, error) {
+	n, err := w.file.Read(w.buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []watchEvent
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&w.buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(raw.Len)
+		name := string(bytes.TrimRight(w.buf[nameStart:nameEnd], "\x00"))
+		offset = nameEnd
+
+		mask := raw.Mask
+		isDir := mask&syscall.IN_ISDIR != 0
+		entryChanged := mask&(syscall.IN_CREATE|syscall.IN_DELETE|syscall.IN_MOVED_FROM|syscall.IN_MOVED_TO) != 0
+		// Attribute changes of files don't change the entries of the directory, but those of
+		// the directory or its subdirectories may change whether they can be read.
+		attribChanged := mask&syscall.IN_ATTRIB != 0 && (name == "" || isDir)
+		selfChanged := mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0
+
+		events = append(events, watchEvent{
+			wd:       int(raw.Wd),
+			name:     name,
+			changed:  entryChanged || attribChanged || selfChanged,
+			isDir:    isDir && name != "",
+			created:  mask&syscall.IN_CREATE != 0,
+			ignored:  mask&syscall.IN_IGNORED != 0,
+			overflow: mask&syscall.IN_Q_OVERFLOW != 0,
+		})
+	}
+	return events, nil
+}
+
+func (w *watcher) close() {
+	w.file.Close()
+}
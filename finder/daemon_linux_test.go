@@ -0,0 +1,164 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package finder
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"android/soong/finder/fs"
+)
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startTestDaemon(t *testing.T, params CacheParams, dbPath string) *Daemon {
+	t.Helper()
+	d, err := NewDaemon(params, log.New(ioutil.Discard, "", 0), dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- d.Serve() }()
+	t.Cleanup(func() {
+		d.Close()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return d
+}
+
+// findFromDaemon creates a Finder with New and checks that it was loaded from the daemon
+func findFromDaemon(t *testing.T, params CacheParams, dbPath string, fileName string) []string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	f, err := New(params, fs.OsFs, log.New(buf, "", 0), dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Shutdown()
+	if strings.Contains(buf.String(), "Not using finder daemon") {
+		t.Fatalf("expected the Finder to be loaded from the daemon:\n%s", buf.String())
+	}
+	return f.FindNamedAt(".", fileName)
+}
+
+func TestDaemon(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(t.TempDir(), "files.db")
+	writeTestFile(t, filepath.Join(root, "a/Android.bp"))
+	writeTestFile(t, filepath.Join(root, "a/b/Android.bp"))
+	writeTestFile(t, filepath.Join(root, "c/Android.bp"))
+	writeTestFile(t, filepath.Join(root, "c/other.txt"))
+
+	params := CacheParams{
+		WorkingDirectory: root,
+		RootDirs:         []string{"."},
+		PruneFiles:       []string{".out-dir"},
+		IncludeFiles:     []string{"Android.bp"},
+	}
+	startTestDaemon(t, params, dbPath)
+
+	fs.AssertSameResponse(t, findFromDaemon(t, params, dbPath, "Android.bp"),
+		[]string{"a/Android.bp", "a/b/Android.bp", "c/Android.bp"})
+
+	// new files and directories
+	writeTestFile(t, filepath.Join(root, "a/b/c/d/Android.bp"))
+	writeTestFile(t, filepath.Join(root, "e/Android.bp"))
+	fs.AssertSameResponse(t, findFromDaemon(t, params, dbPath, "Android.bp"),
+		[]string{"a/Android.bp", "a/b/Android.bp", "a/b/c/d/Android.bp", "c/Android.bp", "e/Android.bp"})
+
+	// deleted and moved directories
+	if err := os.RemoveAll(filepath.Join(root, "e")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(root, "a/b"), filepath.Join(root, "c/b")); err != nil {
+		t.Fatal(err)
+	}
+	fs.AssertSameResponse(t, findFromDaemon(t, params, dbPath, "Android.bp"),
+		[]string{"a/Android.bp", "c/Android.bp", "c/b/Android.bp", "c/b/c/d/Android.bp"})
+
+	// files in the moved directory are still watched
+	writeTestFile(t, filepath.Join(root, "c/b/c/Android.bp"))
+	fs.AssertSameResponse(t, findFromDaemon(t, params, dbPath, "Android.bp"),
+		[]string{"a/Android.bp", "c/Android.bp", "c/b/Android.bp", "c/b/c/Android.bp", "c/b/c/d/Android.bp"})
+
+	// pruned directories
+	writeTestFile(t, filepath.Join(root, "c/.out-dir"))
+	fs.AssertSameResponse(t, findFromDaemon(t, params, dbPath, "Android.bp"),
+		[]string{"a/Android.bp"})
+
+	client := NewDaemonClient(params, dbPath)
+	found, err := client.FindNamedAt("a", "Android.bp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.AssertSameResponse(t, found, []string{"a/Android.bp"})
+}
+
+func TestDaemonFallback(t *testing.T) {
+	root := t.TempDir()
+	dbPath := filepath.Join(t.TempDir(), "files.db")
+	writeTestFile(t, filepath.Join(root, "a/Android.bp"))
+
+	params := CacheParams{
+		WorkingDirectory: root,
+		RootDirs:         []string{"."},
+		IncludeFiles:     []string{"Android.bp"},
+	}
+	d := startTestDaemon(t, params, dbPath)
+
+	// A Finder with different parameters doesn't use the daemon
+	otherParams := params
+	otherParams.IncludeFiles = []string{"Android.bp", "Android.mk"}
+	buf := &bytes.Buffer{}
+	f, err := New(otherParams, fs.OsFs, log.New(buf, "", 0), dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Shutdown()
+	if !strings.Contains(buf.String(), "serving different parameters") {
+		t.Errorf("expected the Finder not to use the daemon:\n%s", buf.String())
+	}
+
+	// Without the daemon the Finder is loaded from the db that the daemon saved
+	writeTestFile(t, filepath.Join(root, "b/Android.bp"))
+	findFromDaemon(t, params, dbPath, "Android.bp")
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err = New(params, fs.OsFs, log.New(ioutil.Discard, "", 0), dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Shutdown()
+	fs.AssertSameResponse(t, f.FindNamedAt(".", "Android.bp"), []string{"a/Android.bp", "b/Android.bp"})
+	if f.wasModified() {
+		t.Errorf("expected the db saved by the daemon to be up to date")
+	}
+}
@@ -169,29 +169,44 @@ type Finder struct {
 var defaultNumThreads = runtime.NumCPU() * 2
 
 // New creates a new Finder for use
+// If a finder daemon (see Daemon) is serving the same CacheParams for dbPath, the Finder loads
+// the tree from the daemon instead of checking the cache db against the filesystem.
 func New(cacheParams CacheParams, filesystem fs.FileSystem,
 	logger Logger, dbPath string) (f *Finder, err error) {
+	if filesystem == fs.OsFs {
+		f, err = newFromDaemon(cacheParams, filesystem, logger, dbPath, defaultNumThreads)
+		if err == nil {
+			return f, nil
+		}
+		logger.Output(2, fmt.Sprintf("Not using finder daemon: %v\n", err))
+	}
 	return newImpl(cacheParams, filesystem, logger, dbPath, defaultNumThreads)
 }
 
 // newImpl is like New but accepts more params
 func newImpl(cacheParams CacheParams, filesystem fs.FileSystem,
 	logger Logger, dbPath string, numThreads int) (f *Finder, err error) {
-	numDbLoadingThreads := numThreads
-	numSearchingThreads := numThreads
+	f = newUnloadedFinder(cacheParams, filesystem, logger, dbPath, numThreads)
 
-	metadata := cacheMetadata{
-		Version: versionString,
-		Config: cacheConfig{
-			CacheParams:    cacheParams,
-			FilesystemView: filesystem.ViewId(),
-		},
+	f.loadFromFilesystem()
+
+	err = f.checkLoaded()
+	if err != nil {
+		return nil, err
 	}
+	return f, nil
+}
 
-	f = &Finder{
+// newUnloadedFinder creates a Finder that has not loaded anything yet
+func newUnloadedFinder(cacheParams CacheParams, filesystem fs.FileSystem,
+	logger Logger, dbPath string, numThreads int) *Finder {
+	numDbLoadingThreads := numThreads
+	numSearchingThreads := numThreads
+
+	return &Finder{
 		numDbLoadingThreads: numDbLoadingThreads,
 		numSearchingThreads: numSearchingThreads,
-		cacheMetadata:       metadata,
+		cacheMetadata:       newCacheMetadata(cacheParams, filesystem),
 		logger:              logger,
 		filesystem:          filesystem,
 
@@ -200,27 +215,39 @@ func newImpl(cacheParams CacheParams, filesystem fs.FileSystem,
 
 		shutdownWaitgroup: sync.WaitGroup{},
 	}
+}
 
-	f.loadFromFilesystem()
+// newCacheMetadata returns the metadata of a Finder for <cacheParams> in <filesystem>
+func newCacheMetadata(cacheParams CacheParams, filesystem fs.FileSystem) cacheMetadata {
+	return cacheMetadata{
+		Version: versionString,
+		Config: cacheConfig{
+			CacheParams:    cacheParams,
+			FilesystemView: filesystem.ViewId(),
+		},
+	}
+}
 
+// checkLoaded returns an error if loading the Finder failed
+func (f *Finder) checkLoaded() error {
 	// check for any filesystem errors
-	err = f.getErr()
+	err := f.getErr()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// confirm that every path mentioned in the CacheConfig exists
-	for _, path := range cacheParams.RootDirs {
+	for _, path := range f.cacheMetadata.Config.RootDirs {
 		if !filepath.IsAbs(path) {
 			path = filepath.Join(f.cacheMetadata.Config.WorkingDirectory, path)
 		}
 		node := f.nodes.GetNode(filepath.Clean(path), false)
 		if node == nil || node.ModTime == 0 {
-			return nil, fmt.Errorf("path %v was specified to be included in the cache but does not exist\n", path)
+			return fmt.Errorf("path %v was specified to be included in the cache but does not exist\n", path)
 		}
 	}
 
-	return f, nil
+	return nil
 }
 
 // FindNamed searches for every cached file
@@ -0,0 +1,317 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ide
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/rust"
+)
+
+// This singleton writes a ready to open VS Code workspace, using clangd for C/C++ and
+// rust-analyzer for Rust, for the modules named in SOONG_IDE_WORKSPACE_MODULES and the modules
+// they depend on.  For example:
+//
+//   $ SOONG_IDE_WORKSPACE_MODULES=libfoo,libfoo_test m nothing
+//   $ code out/soong/development/ide/workspace/libfoo/libfoo.code-workspace
+//
+// The workspace is written to ${OUT_DIR}/soong/development/ide/workspace/<name>, where <name> is
+// SOONG_IDE_WORKSPACE_NAME, or the first module if it is not set.  It contains:
+//
+//   <name>.code-workspace: the VS Code workspace, with a folder for the directory of each of the
+//       named modules, the settings for clangd, rust-analyzer and the Java extension, and launch
+//       configurations that debug the host variants of the cc and rust tests with CodeLLDB.
+//   compile_commands.json: the compilation database of the C/C++ modules, which clangd is pointed
+//       at by the clangd.arguments setting of the workspace.
+//   rust-project.json: the rust-analyzer project of the crates.
+//
+// Only host tests get launch configurations.  Device tests have to be pushed to a device and run
+// under lldb-server, use lldbclient.py to debug them.
+//
+// The workspace is a view of the model of the modules collected by android.CollectIdeModuleInfos.
+
+func init() {
+	android.RegisterSingletonType("ide_workspace", ideWorkspaceSingletonFactory)
+}
+
+const (
+	// Environment variables used to control the behavior of this singleton.
+	envVariableWorkspaceModules = "SOONG_IDE_WORKSPACE_MODULES"
+	envVariableWorkspaceName    = "SOONG_IDE_WORKSPACE_NAME"
+
+	workspaceOutputDirectory = "development/ide/workspace"
+	compdbFileName           = "compile_commands.json"
+	rustProjectFileName      = "rust-project.json"
+)
+
+func ideWorkspaceSingletonFactory() android.Singleton {
+	return &ideWorkspaceSingleton{}
+}
+
+type ideWorkspaceSingleton struct{}
+
+// workspace describes the workspace for a set of modules.
+type workspace struct {
+	name string
+	// The absolute paths of the workspace directory and the top of the source tree.
+	dir string
+	top string
+	// The modules named by the user, and all the modules they depend on including themselves.
+	modules   []string
+	reachable []string
+}
+
+func (s *ideWorkspaceSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	modules := strings.FieldsFunc(ctx.Config().Getenv(envVariableWorkspaceModules), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(modules) == 0 {
+		return
+	}
+
+	infos := android.CollectIdeModuleInfos(ctx)
+	for _, module := range modules {
+		if infos[module] == nil {
+			ctx.Errorf("%s: unknown module %q, or a module without sources", envVariableWorkspaceModules, module)
+		}
+	}
+	if ctx.Failed() {
+		return
+	}
+
+	name := ctx.Config().Getenv(envVariableWorkspaceName)
+	if name == "" {
+		name = modules[0]
+	}
+	dir := android.PathForOutput(ctx, workspaceOutputDirectory, name)
+	w := workspace{
+		name:      name,
+		top:       android.AbsSrcDirForExistingUseCases(),
+		modules:   modules,
+		reachable: reachableModules(infos, modules),
+	}
+	w.dir = w.abs(dir.String())
+
+	write := func(file string, data []byte, err error) {
+		if err == nil {
+			err = android.WriteFileToOutputDir(dir.Join(ctx, file), data, 0666)
+		}
+		if err != nil {
+			ctx.Errorf("Failed to write %s: %s", file, err)
+		}
+	}
+
+	// The source roots depend on the packages declared in the Java and Kotlin sources, so the
+	// workspace is regenerated when they change.
+	var javaSrcs []string
+	readPackage := func(path string) string {
+		javaSrcs = append(javaSrcs, path)
+		return readJavaPackage(path)
+	}
+	vscode := newVscodeWorkspace(infos, w, readPackage)
+	ctx.AddNinjaFileDeps(android.SortedUniqueStrings(javaSrcs)...)
+	data, err := json.MarshalIndent(vscode, "", "  ")
+	write(name+".code-workspace", data, err)
+
+	data, err = cc.CompdbJsonForIdeModules(infos, w.reachable)
+	write(compdbFileName, data, err)
+
+	data, err = rust.ProjectJson(infos, w.modules)
+	write(rustProjectFileName, data, err)
+}
+
+// reachableModules returns the given modules and the modules they depend on, transitively.
+func reachableModules(infos android.IdeModuleInfos, modules []string) []string {
+	seen := make(map[string]bool)
+	queue := append([]string(nil), modules...)
+	for len(queue) > 0 {
+		module := queue[0]
+		queue = queue[1:]
+		if seen[module] || infos[module] == nil {
+			continue
+		}
+		seen[module] = true
+		queue = append(queue, infos[module].Deps...)
+	}
+	return android.SortedKeys(seen)
+}
+
+type vscodeWorkspace struct {
+	Folders  []vscodeFolder         `json:"folders"`
+	Settings map[string]interface{} `json:"settings"`
+	Launch   *vscodeLaunch          `json:"launch,omitempty"`
+}
+
+type vscodeFolder struct {
+	Path string `json:"path"`
+}
+
+type vscodeLaunch struct {
+	Version        string                      `json:"version"`
+	Configurations []vscodeLaunchConfiguration `json:"configurations"`
+}
+
+type vscodeLaunchConfiguration struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Request string   `json:"request"`
+	Program string   `json:"program"`
+	Args    []string `json:"args"`
+	Cwd     string   `json:"cwd"`
+}
+
+func (w workspace) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(w.top, path)
+}
+
+// newVscodeWorkspace returns the VS Code workspace.  readPackage returns the package declared by
+// a Java or Kotlin source file, and is used to find the source roots.
+func newVscodeWorkspace(infos android.IdeModuleInfos, w workspace,
+	readPackage func(path string) string) vscodeWorkspace {
+
+	vscode := vscodeWorkspace{
+		Folders:  []vscodeFolder{},
+		Settings: make(map[string]interface{}),
+	}
+
+	var folders []string
+	for _, module := range w.modules {
+		for _, dir := range infos[module].Dirs {
+			folders = append(folders, w.abs(dir))
+		}
+	}
+	for _, folder := range android.FirstUniqueStrings(folders) {
+		vscode.Folders = append(vscode.Folders, vscodeFolder{Path: folder})
+	}
+
+	named := make(map[string]bool)
+	for _, module := range w.modules {
+		named[module] = true
+	}
+
+	var queryDrivers, crates, sourcePaths, libraries []string
+	for _, module := range w.reachable {
+		info := infos[module]
+		for _, ccInfo := range info.Cc {
+			queryDrivers = append(queryDrivers, filepath.Join(filepath.Dir(w.abs(ccInfo.C_compiler)), "*"))
+		}
+		if info.Rust != nil && info.Rust.Crate_root != "" {
+			crates = append(crates, module)
+		}
+		if info.Java != nil {
+			if named[module] {
+				for _, src := range info.Java.Srcs {
+					sourcePaths = append(sourcePaths, w.abs(javaSourceRoot(src, readPackage(w.abs(src)))))
+				}
+			} else {
+				for _, jar := range info.Java.Installed_paths {
+					libraries = append(libraries, w.abs(jar))
+				}
+			}
+			for _, jar := range info.Java.Jars {
+				libraries = append(libraries, w.abs(jar))
+			}
+		}
+	}
+
+	if len(queryDrivers) > 0 {
+		vscode.Settings["clangd.arguments"] = []string{
+			"--compile-commands-dir=" + w.dir,
+			"--query-driver=" + strings.Join(android.SortedUniqueStrings(queryDrivers), ","),
+		}
+	}
+	if len(crates) > 0 {
+		vscode.Settings["rust-analyzer.linkedProjects"] = []string{filepath.Join(w.dir, rustProjectFileName)}
+	}
+	if len(sourcePaths) > 0 {
+		vscode.Settings["java.project.sourcePaths"] = android.SortedUniqueStrings(sourcePaths)
+	}
+	if len(libraries) > 0 {
+		vscode.Settings["java.project.referencedLibraries"] = android.SortedUniqueStrings(libraries)
+	}
+
+	// Only the host variants of the tests can be launched directly, see the comment at the top of
+	// this file.
+	var configurations []vscodeLaunchConfiguration
+	for _, module := range w.modules {
+		for _, binary := range infos[module].Host_test_binaries {
+			configurations = append(configurations, vscodeLaunchConfiguration{
+				Name:    fmt.Sprintf("%s (%s)", module, filepath.Base(filepath.Dir(binary))),
+				Type:    "lldb",
+				Request: "launch",
+				Program: w.abs(binary),
+				Args:    []string{},
+				Cwd:     w.top,
+			})
+		}
+	}
+	if len(configurations) > 0 {
+		sort.Slice(configurations, func(i, j int) bool {
+			return configurations[i].Name < configurations[j].Name
+		})
+		vscode.Launch = &vscodeLaunch{Version: "0.2.0", Configurations: configurations}
+	}
+
+	return vscode
+}
+
+var javaPackageRe = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`)
+
+// readJavaPackage returns the package declared by a Java or Kotlin source file, or an empty string
+// if it can't be read.
+func readJavaPackage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	// The package declaration comes before any other declaration.
+	buf := make([]byte, 16*1024)
+	n, _ := io.ReadFull(f, buf)
+	if match := javaPackageRe.FindSubmatch(buf[:n]); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// javaSourceRoot returns the source root of a Java or Kotlin source file, which is its directory
+// with the directories of its package removed if they match.
+func javaSourceRoot(src, pkg string) string {
+	dir := filepath.Dir(src)
+	if pkg == "" {
+		return dir
+	}
+	pkgDir := strings.ReplaceAll(pkg, ".", "/")
+	if dir == pkgDir {
+		return "."
+	}
+	if strings.HasSuffix(dir, "/"+pkgDir) {
+		return strings.TrimSuffix(dir, "/"+pkgDir)
+	}
+	return dir
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ide
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func testIdeModuleInfos() android.IdeModuleInfos {
+	return android.IdeModuleInfos{
+		"libfoo": {
+			Name: "libfoo",
+			Dirs: []string{"foo"},
+			Deps: []string{"libbar"},
+			Cc: []*android.IdeCcInfo{{
+				C_compiler:          "prebuilts/clang/host/linux-x86/clang-r1/bin/clang",
+				Global_common_flags: []string{"-O2", "--sysroot", "prebuilts/sysroot"},
+			}},
+		},
+		"libfoo_test": {
+			Name:               "libfoo_test",
+			Dirs:               []string{"foo/tests"},
+			Deps:               []string{"libfoo", "libunknown"},
+			Host_test_binaries: []string{"out/soong/.intermediates/foo/tests/libfoo_test/linux_glibc_x86_64/unstripped/libfoo_test"},
+			Cc: []*android.IdeCcInfo{{
+				C_compiler: "prebuilts/clang/host/linux-x86/clang-r1/bin/clang",
+			}},
+		},
+		"libbar": {
+			Name: "libbar",
+			Dirs: []string{"bar"},
+			Deps: []string{"libfoo"},
+			Rust: &android.IdeRustInfo{
+				Crate_name: "bar",
+				Crate_root: "bar/src/lib.rs",
+			},
+		},
+		"baz": {
+			Name: "baz",
+			Dirs: []string{"baz"},
+			Deps: []string{"baz-lib"},
+			Java: &android.IdeInfo{
+				Srcs: []string{"baz/src/com/android/baz/Baz.java", "baz/Other.java"},
+				Jars: []string{"out/soong/.intermediates/baz/baz/android_common/combined/baz.jar"},
+			},
+		},
+		"baz-lib": {
+			Name: "baz-lib",
+			Dirs: []string{"baz/lib"},
+			Java: &android.IdeInfo{
+				Installed_paths: []string{"out/target/product/generic/system/framework/baz-lib.jar"},
+			},
+		},
+	}
+}
+
+func TestReachableModules(t *testing.T) {
+	infos := testIdeModuleInfos()
+
+	testCases := []struct {
+		name    string
+		modules []string
+		want    []string
+	}{
+		{
+			name:    "leaf",
+			modules: []string{"baz-lib"},
+			want:    []string{"baz-lib"},
+		},
+		{
+			name:    "cycle",
+			modules: []string{"libfoo"},
+			want:    []string{"libbar", "libfoo"},
+		},
+		{
+			name:    "unknown deps are skipped",
+			modules: []string{"libfoo_test"},
+			want:    []string{"libbar", "libfoo", "libfoo_test"},
+		},
+		{
+			name:    "multiple modules",
+			modules: []string{"baz", "libfoo"},
+			want:    []string{"baz", "baz-lib", "libbar", "libfoo"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := reachableModules(infos, tc.modules); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestVscodeWorkspace(t *testing.T) {
+	infos := testIdeModuleInfos()
+	modules := []string{"libfoo_test", "baz"}
+	w := workspace{
+		name:      "foo",
+		dir:       "/src/out/soong/development/ide/workspace/foo",
+		top:       "/src",
+		modules:   modules,
+		reachable: reachableModules(infos, modules),
+	}
+	readPackage := func(path string) string {
+		if strings.HasSuffix(path, "Baz.java") {
+			return "com.android.baz"
+		}
+		return ""
+	}
+
+	got := newVscodeWorkspace(infos, w, readPackage)
+
+	wantFolders := []vscodeFolder{{Path: "/src/foo/tests"}, {Path: "/src/baz"}}
+	if !reflect.DeepEqual(got.Folders, wantFolders) {
+		t.Errorf("expected folders %q, got %q", wantFolders, got.Folders)
+	}
+
+	wantSettings := map[string]interface{}{
+		"clangd.arguments": []string{
+			"--compile-commands-dir=/src/out/soong/development/ide/workspace/foo",
+			"--query-driver=/src/prebuilts/clang/host/linux-x86/clang-r1/bin/*",
+		},
+		"rust-analyzer.linkedProjects": []string{
+			"/src/out/soong/development/ide/workspace/foo/rust-project.json",
+		},
+		"java.project.sourcePaths": []string{"/src/baz", "/src/baz/src"},
+		"java.project.referencedLibraries": []string{
+			"/src/out/soong/.intermediates/baz/baz/android_common/combined/baz.jar",
+			"/src/out/target/product/generic/system/framework/baz-lib.jar",
+		},
+	}
+	if !reflect.DeepEqual(got.Settings, wantSettings) {
+		t.Errorf("expected settings %q, got %q", wantSettings, got.Settings)
+	}
+
+	wantLaunch := &vscodeLaunch{
+		Version: "0.2.0",
+		Configurations: []vscodeLaunchConfiguration{
+			{
+				Name:    "libfoo_test (unstripped)",
+				Type:    "lldb",
+				Request: "launch",
+				Program: "/src/out/soong/.intermediates/foo/tests/libfoo_test/linux_glibc_x86_64/unstripped/libfoo_test",
+				Args:    []string{},
+				Cwd:     "/src",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got.Launch, wantLaunch) {
+		t.Errorf("expected launch %#v, got %#v", wantLaunch, got.Launch)
+	}
+}
+
+func TestJavaSourceRoot(t *testing.T) {
+	testCases := []struct {
+		src, pkg, want string
+	}{
+		{src: "a/src/com/android/Foo.java", pkg: "com.android", want: "a/src"},
+		{src: "com/android/Foo.java", pkg: "com.android", want: "."},
+		{src: "a/src/Foo.java", pkg: "com.android", want: "a/src"},
+		{src: "a/src/Foo.java", pkg: "", want: "a/src"},
+		{src: "a/src/notcom/android/Foo.kt", pkg: "com.android", want: "a/src/notcom/android"},
+	}
+
+	for _, tc := range testCases {
+		if got := javaSourceRoot(tc.src, tc.pkg); got != tc.want {
+			t.Errorf("javaSourceRoot(%q, %q): expected %q, got %q", tc.src, tc.pkg, tc.want, got)
+		}
+	}
+}
@@ -271,6 +271,19 @@ type DeviceProperties struct {
 	// public stubs library.
 	SyspropPublicStub string `blueprint:"mutated"`
 
+	// Properties for the Java ABI checker, which compares the public and protected classes,
+	// fields and methods of the implementation jar of the APEX variants of a library against
+	// reference ABI dumps.
+	Java_abi_checker struct {
+		// Enable the Java ABI checker. Defaults to true for java_sdk_library modules and to false
+		// for other libraries.
+		Enabled *bool
+
+		// Directories, relative to the module directory, that contain reference ABI dumps of the
+		// library to check against in addition to the ones in prebuilts/abi-dumps/java.
+		Ref_dump_dirs []string
+	}
+
 	HiddenAPIPackageProperties
 	HiddenAPIFlagFileProperties
 }
@@ -469,6 +482,9 @@ type Module struct {
 	// will be used by android.IDEInfo struct
 	expandIDEInfoCompiledSrcs []string
 
+	// Kotlin specific information for the IDE model, set if the module compiles Kotlin sources.
+	ideKotlinInfo *android.IdeKotlinInfo
+
 	// expanded Jarjar_rules
 	expandJarjarRules android.Path
 
@@ -484,6 +500,9 @@ type Module struct {
 	// list of the xref extraction files
 	kytheFiles android.Paths
 
+	// The outputs of javac running Error Prone.
+	errorProneLogs android.Paths
+
 	// Collect the module directory for IDE info in java/jdeps.go.
 	modulePaths []string
 
@@ -1159,6 +1178,10 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 
 		// Collect common .kt files for AIDEGen
 		j.expandIDEInfoCompiledSrcs = append(j.expandIDEInfoCompiledSrcs, kotlinCommonSrcFiles.Strings()...)
+		j.ideKotlinInfo = &android.IdeKotlinInfo{
+			Common_srcs: kotlinCommonSrcFiles.Strings(),
+			Flags:       kotlincFlags,
+		}
 
 		flags.classpath = append(flags.classpath, deps.kotlinStdlib...)
 		flags.classpath = append(flags.classpath, deps.kotlinAnnotations...)
@@ -1251,6 +1274,7 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 
 			transformJavaToClasses(ctx, errorprone, -1, uniqueJavaFiles, srcJars, errorproneFlags, nil,
 				"errorprone", "errorprone")
+			j.errorProneLogs = append(j.errorProneLogs, errorProneLogPath(ctx, errorprone))
 
 			extraJarDeps = append(extraJarDeps, errorprone)
 		}
@@ -1612,6 +1636,11 @@ func (j *Module) compile(ctx android.ModuleContext, aaptSrcJar android.Path) {
 
 	ctx.CheckbuildFile(outputFile)
 
+	android.SetAnalysisFindingsProvider(ctx, android.AnalysisFindingsInfo{
+		ErrorProne: j.errorProneLogs,
+		Sarif:      android.PathsIfNonNil(j.linter.outputs.sarif),
+	})
+
 	ctx.SetProvider(JavaInfoProvider, JavaInfo{
 		HeaderJars:                     android.PathsIfNonNil(j.headerJarFile),
 		TransitiveLibsHeaderJars:       j.transitiveLibsHeaderJars,
@@ -1639,6 +1668,7 @@ func (j *Module) useCompose() bool {
 // Returns a copy of the supplied flags, but with all the errorprone-related
 // fields copied to the regular build's fields.
 func enableErrorproneFlags(flags javaBuilderFlags) javaBuilderFlags {
+	flags.errorProne = true
 	flags.processorPath = append(flags.errorProneProcessorPath, flags.processorPath...)
 
 	if len(flags.errorProneExtraJavacFlags) > 0 {
@@ -1662,6 +1692,9 @@ func (j *Module) compileJavaClasses(ctx android.ModuleContext, jarName string, i
 
 	classes := android.PathForModuleOut(ctx, "javac", jarName).OutputPath
 	TransformJavaToClasses(ctx, classes, idx, srcFiles, srcJars, flags, extraJarDeps)
+	if flags.errorProne {
+		j.errorProneLogs = append(j.errorProneLogs, errorProneLogPath(ctx, classes))
+	}
 
 	if ctx.Config().EmitXrefRules() {
 		extractionFile := android.PathForModuleOut(ctx, kzipName)
@@ -1850,6 +1883,12 @@ func (j *Module) IDEInfo(dpInfo *android.IdeInfo) {
 	dpInfo.Libs = append(dpInfo.Libs, j.properties.Libs...)
 }
 
+// IdeKotlinInfo returns the Kotlin specific information for the IDE model, or nil if the module
+// doesn't compile Kotlin sources.
+func (j *Module) IdeKotlinInfo() *android.IdeKotlinInfo {
+	return j.ideKotlinInfo
+}
+
 func (j *Module) CompilerDeps() []string {
 	jdeps := []string{}
 	jdeps = append(jdeps, j.properties.Libs...)
@@ -45,7 +45,7 @@ var (
 			Command: `rm -rf "$outDir" "$annoDir" "$srcJarDir" "$out" && mkdir -p "$outDir" "$annoDir" "$srcJarDir" && ` +
 				`${config.ZipSyncCmd} -d $srcJarDir -l $srcJarDir/list -f "*.java" $srcJars && ` +
 				`(if [ -s $srcJarDir/list ] || [ -s $out.rsp ] ; then ` +
-				`${config.SoongJavacWrapper} $javaTemplate${config.JavacCmd} ` +
+				`${config.SoongJavacWrapper} $javacWrapperFlags $javaTemplate${config.JavacCmd} ` +
 				`${config.JavacHeapFlags} ${config.JavacVmFlags} ${config.CommonJdkFlags} ` +
 				`$processorpath $processor $javacFlags $bootClasspath $classpath ` +
 				`-source $javaVersion -target $javaVersion ` +
@@ -74,7 +74,7 @@ var (
 				Platform:     map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
 			},
 		}, []string{"javacFlags", "bootClasspath", "classpath", "processorpath", "processor", "srcJars", "srcJarDir",
-			"outDir", "annoDir", "javaVersion"}, nil)
+			"outDir", "annoDir", "javaVersion", "javacWrapperFlags"}, nil)
 
 	_ = pctx.VariableFunc("kytheCorpus",
 		func(ctx android.PackageVarContext) string { return ctx.Config().XrefCorpusName() })
@@ -289,6 +289,8 @@ type javaBuilderFlags struct {
 
 	errorProneExtraJavacFlags string
 	errorProneProcessorPath   classpath
+	// errorProne is true if javac runs Error Prone, see enableErrorproneFlags.
+	errorProne bool
 
 	kotlincFlags     string
 	kotlincClasspath classpath
@@ -476,27 +478,42 @@ func transformJavaToClasses(ctx android.ModuleContext, outputFile android.Writab
 	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_JAVAC") {
 		rule = javacRE
 	}
+	// Save the output of javac running Error Prone so that its findings can be converted to SARIF.
+	var implicitOutputs android.WritablePaths
+	javacWrapperFlags := ""
+	if flags.errorProne {
+		errorProneLog := errorProneLogPath(ctx, outputFile)
+		implicitOutputs = append(implicitOutputs, errorProneLog)
+		javacWrapperFlags = "--log " + errorProneLog.String()
+	}
 	ctx.Build(pctx, android.BuildParams{
-		Rule:        rule,
-		Description: desc,
-		Output:      outputFile,
-		Inputs:      srcFiles,
-		Implicits:   deps,
+		Rule:            rule,
+		Description:     desc,
+		Output:          outputFile,
+		ImplicitOutputs: implicitOutputs,
+		Inputs:          srcFiles,
+		Implicits:       deps,
 		Args: map[string]string{
-			"javacFlags":    flags.javacFlags,
-			"bootClasspath": bootClasspath,
-			"classpath":     classpath.FormJavaClassPath("-classpath"),
-			"processorpath": flags.processorPath.FormJavaClassPath("-processorpath"),
-			"processor":     processor,
-			"srcJars":       strings.Join(srcJars.Strings(), " "),
-			"srcJarDir":     android.PathForModuleOut(ctx, intermediatesDir, srcJarDir).String(),
-			"outDir":        android.PathForModuleOut(ctx, intermediatesDir, outDir).String(),
-			"annoDir":       android.PathForModuleOut(ctx, intermediatesDir, annoDir).String(),
-			"javaVersion":   flags.javaVersion.String(),
+			"javacFlags":        flags.javacFlags,
+			"bootClasspath":     bootClasspath,
+			"classpath":         classpath.FormJavaClassPath("-classpath"),
+			"processorpath":     flags.processorPath.FormJavaClassPath("-processorpath"),
+			"processor":         processor,
+			"srcJars":           strings.Join(srcJars.Strings(), " "),
+			"srcJarDir":         android.PathForModuleOut(ctx, intermediatesDir, srcJarDir).String(),
+			"outDir":            android.PathForModuleOut(ctx, intermediatesDir, outDir).String(),
+			"annoDir":           android.PathForModuleOut(ctx, intermediatesDir, annoDir).String(),
+			"javaVersion":       flags.javaVersion.String(),
+			"javacWrapperFlags": javacWrapperFlags,
 		},
 	})
 }
 
+// errorProneLogPath returns the file that the output of javac running Error Prone is saved to.
+func errorProneLogPath(ctx android.PathContext, outputFile android.WritablePath) android.WritablePath {
+	return outputFile.ReplaceExtension(ctx, "errorprone.log")
+}
+
 func TransformResourcesToJar(ctx android.ModuleContext, outputFile android.WritablePath,
 	jarArgs []string, deps android.Paths) {
 
@@ -166,6 +166,7 @@ func init() {
 	pctx.HostBinToolVariable("ResourceShrinkerCmd", "resourceshrinker")
 	pctx.HostBinToolVariable("HiddenAPICmd", "hiddenapi")
 	pctx.HostBinToolVariable("ExtractApksCmd", "extract_apks")
+	pctx.HostBinToolVariable("JavaAbiCheckerCmd", "java_abi_checker")
 	pctx.VariableFunc("TurbineJar", func(ctx android.PackageVarContext) string {
 		turbine := "turbine.jar"
 		if ctx.Config().AlwaysUsePrebuiltSdks() {
@@ -611,6 +611,9 @@ type Library struct {
 
 	exportedProguardFlagFiles android.Paths
 
+	// The dump of the ABI of the implementation jar, if the Java ABI checker is enabled.
+	javaAbiDumpFile android.Path
+
 	InstallMixin func(ctx android.ModuleContext, installPath android.Path) (extraInstallDeps android.Paths)
 }
 
@@ -687,6 +690,7 @@ func (j *Library) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		j.classLoaderContexts = j.usesLibrary.classLoaderContextForUsesLibDeps(ctx)
 	}
 	j.compile(ctx, nil)
+	j.checkJavaAbi(ctx)
 
 	// Collect the module directory for IDE info in java/jdeps.go.
 	j.modulePaths = append(j.modulePaths, ctx.ModuleDir())
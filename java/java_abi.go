@@ -0,0 +1,251 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// The Java ABI checker is the Java counterpart of the header ABI checker of cc libraries. Libraries
+// in an APEX can be updated independently of the code that uses them, so the APEX variants of
+// java_sdk_library modules, and of java_library modules that set java_abi_checker.enabled, dump
+// the public and protected classes, fields and methods of their implementation jar to an
+// .abi.json file. The dump is compared against the reference dumps that are checked in to:
+//   - prebuilts/abi-dumps/java/<previous SDK version>/<module>.abi.json, allowing compatible
+//     extensions of the ABI, like the cross-version check of cc libraries.
+//   - prebuilts/abi-dumps/java/current/<module>.abi.json, or the directory of the current SDK
+//     version once it is final, which must match exactly.
+//   - <ref_dump_dir>/<module>.abi.json for each of the java_abi_checker.ref_dump_dirs of the
+//     module, which must match exactly.
+//
+// The differences are written to an .abidiff file, which is copied to $DIST_DIR/abidiffs when the
+// check fails. The checks are skipped when SKIP_ABI_CHECKS=true, like the header ABI checks.
+//
+// The dumps of all the libraries are built by `m java_abi_dumps` and listed in the
+// JAVA_ABI_DUMP_PATHS make variable, in the <tag>:<path> format of the LSDUMP_PATHS variable of
+// the header ABI checker with the JAVA tag. create_reference_dumps.py only updates the reference
+// dumps of cc libraries, build/soong/scripts/update_java_abi_dumps.py reads JAVA_ABI_DUMP_PATHS
+// and updates the reference dumps of the Java libraries:
+//
+//	m java_abi_dumps
+//	build/soong/scripts/update_java_abi_dumps.py \
+//	    --dump-paths "$(get_build_var JAVA_ABI_DUMP_PATHS)" [--version <version>] [<module>...]
+
+var (
+	javaAbiDump = pctx.AndroidStaticRule("javaAbiDump",
+		blueprint.RuleParams{
+			Command:     "${config.JavaAbiCheckerCmd} dump -o $out $in",
+			CommandDeps: []string{"${config.JavaAbiCheckerCmd}"},
+		})
+
+	javaAbiDiff = pctx.AndroidStaticRule("javaAbiDiff",
+		blueprint.RuleParams{
+			Command: "(${config.JavaAbiCheckerCmd} diff ${extraFlags} -lib ${libName} -o ${out} -old ${referenceDump} ${in})" +
+				" || (echo '${errorMessage}'" +
+				" && (mkdir -p $$DIST_DIR/abidiffs && cp ${out} $$DIST_DIR/abidiffs/)" +
+				" && exit 1)",
+			CommandDeps: []string{"${config.JavaAbiCheckerCmd}"},
+		},
+		"extraFlags", "referenceDump", "libName", "errorMessage")
+)
+
+const javaAbiDumpDir = "prebuilts/abi-dumps/java"
+
+// javaAbiDumpTag is the tag of the dumps in JAVA_ABI_DUMP_PATHS, it must match JAVA_ABI_DUMP_TAG
+// in scripts/update_java_abi_dumps.py.
+const javaAbiDumpTag = "JAVA"
+
+func init() {
+	registerJavaAbiBuildComponents(android.InitRegistrationContext)
+}
+
+func registerJavaAbiBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterSingletonType("java_abi_dumps", javaAbiDumpsSingletonFactory)
+}
+
+var prepareForTestWithJavaAbiDumps = android.FixtureRegisterWithContext(registerJavaAbiBuildComponents)
+
+// javaAbiCheckerEnabled returns whether the ABI of the library should be dumped and checked.
+func (j *Library) javaAbiCheckerEnabled(ctx android.ModuleContext) bool {
+	if ctx.Config().IsEnvTrue("SKIP_ABI_CHECKS") || !ctx.Device() || j.implementationJarFile == nil {
+		return false
+	}
+	if apexInfo := ctx.Provider(android.ApexInfoProvider).(android.ApexInfo); apexInfo.IsForPlatform() {
+		return false
+	}
+	return proptools.BoolDefault(j.deviceProperties.Java_abi_checker.Enabled, j.deviceProperties.IsSDKLibrary)
+}
+
+// checkJavaAbi dumps the ABI of the implementation jar of the library and compares it against the
+// reference dumps that exist. The comparisons are added as validations of the dex jar, which is
+// what the APEX packages.
+func (j *Library) checkJavaAbi(ctx android.ModuleContext) {
+	if !j.javaAbiCheckerEnabled(ctx) {
+		return
+	}
+
+	name := ctx.ModuleName()
+	fileName := name + ".abi.json"
+	dumpFile := android.PathForModuleOut(ctx, "abi", fileName)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        javaAbiDump,
+		Description: "java abi dump " + name,
+		Output:      dumpFile,
+		Input:       j.implementationJarFile,
+	})
+	j.javaAbiDumpFile = dumpFile
+
+	var diffs android.Paths
+
+	// Check against the previous version, allowing extensions.
+	prevVersion := strconv.Itoa(prevJavaAbiDumpVersion(ctx))
+	if prevDump := android.ExistentPathForSource(ctx, javaAbiDumpDir, prevVersion, fileName); prevDump.Valid() {
+		errorMessage := "error: The ABI of " + name + " is not compatible with the ABI of version " + prevVersion +
+			" in " + prevDump.String() + ". Please fix the incompatible changes listed above."
+		diffs = append(diffs, j.javaAbiDiff(ctx, prevDump.Path(), prevVersion, true, errorMessage))
+	}
+
+	// Check against the current version, which must match exactly.
+	currDir := filepath.Join(javaAbiDumpDir, currJavaAbiDumpVersion(ctx))
+	if currDump := android.ExistentPathForSource(ctx, currDir, fileName); currDump.Valid() {
+		diffs = append(diffs, j.javaAbiDiff(ctx, currDump.Path(), "", false,
+			javaAbiUpdateMessage(j.javaAbiDumpFile, currDump.Path())))
+	}
+
+	// Check against the opt-in reference dumps, which are not versioned.
+	for i, refDumpDir := range j.deviceProperties.Java_abi_checker.Ref_dump_dirs {
+		refDumpDirPath := android.PathForModuleSrc(ctx, refDumpDir)
+		refDump := android.ExistentPathForSource(ctx, refDumpDirPath.String(), fileName)
+		if !refDump.Valid() {
+			continue
+		}
+		diffs = append(diffs, j.javaAbiDiff(ctx, refDump.Path(), "opt"+strconv.Itoa(i), false,
+			javaAbiUpdateMessage(j.javaAbiDumpFile, refDump.Path())))
+	}
+
+	if len(diffs) > 0 && j.dexJarFile.Valid() {
+		j.dexJarFile = makeDexJarPathFromPath(android.AttachValidationActions(ctx, j.dexJarFile.Path(), diffs))
+	}
+}
+
+// javaAbiDiff registers a build statement to compare the ABI dump of the library against a
+// reference dump.
+func (j *Library) javaAbiDiff(ctx android.ModuleContext, referenceDump android.Path, nameExt string,
+	allowExtensions bool, errorMessage string) android.Path {
+
+	name := ctx.ModuleName()
+	var outputFile android.ModuleOutPath
+	if nameExt != "" {
+		outputFile = android.PathForModuleOut(ctx, "abi", name+"."+nameExt+".abidiff")
+	} else {
+		outputFile = android.PathForModuleOut(ctx, "abi", name+".abidiff")
+	}
+
+	var extraFlags []string
+	if allowExtensions {
+		extraFlags = append(extraFlags, "-allow-extensions")
+	}
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        javaAbiDiff,
+		Description: "java abi diff " + outputFile.Base(),
+		Output:      outputFile,
+		Input:       j.javaAbiDumpFile,
+		Implicit:    referenceDump,
+		Args: map[string]string{
+			"extraFlags":    strings.Join(extraFlags, " "),
+			"referenceDump": referenceDump.String(),
+			"libName":       name,
+			"errorMessage":  errorMessage,
+		},
+	})
+	return outputFile
+}
+
+func javaAbiUpdateMessage(dump, referenceDump android.Path) string {
+	return "error: Please update the ABI reference with: cp " + dump.String() + " " + referenceDump.String()
+}
+
+// prevJavaAbiDumpVersion returns the SDK version of the previous reference dumps, following the
+// same rules as the header ABI checker.
+func prevJavaAbiDumpVersion(ctx android.ModuleContext) int {
+	sdkVersionInt := ctx.Config().PlatformSdkVersion().FinalInt()
+	if ctx.Config().PlatformSdkFinal() {
+		return sdkVersionInt - 1
+	}
+	// The platform SDK version can be upgraded before finalization, before the dumps of the
+	// corresponding version have been generated.
+	if android.ExistentPathForSource(ctx, javaAbiDumpDir, strconv.Itoa(sdkVersionInt)).Valid() {
+		return sdkVersionInt
+	}
+	return sdkVersionInt - 1
+}
+
+// currJavaAbiDumpVersion returns the version of the reference dumps that must match the source.
+func currJavaAbiDumpVersion(ctx android.ModuleContext) string {
+	if ctx.Config().PlatformSdkFinal() {
+		return ctx.Config().PlatformSdkVersion().String()
+	}
+	return "current"
+}
+
+func javaAbiDumpsSingletonFactory() android.Singleton {
+	return &javaAbiDumpsSingleton{}
+}
+
+// javaAbiDumper is implemented by the modules that dump their ABI.
+type javaAbiDumper interface {
+	javaAbiDump() android.Path
+}
+
+var _ javaAbiDumper = (*Library)(nil)
+
+func (j *Library) javaAbiDump() android.Path {
+	return j.javaAbiDumpFile
+}
+
+// javaAbiDumpsSingleton collects the ABI dumps of all the libraries for the java_abi_dumps phony
+// target and the JAVA_ABI_DUMP_PATHS make variable, which are used by update_java_abi_dumps.py to
+// update the reference dumps.
+type javaAbiDumpsSingleton struct {
+	dumpPaths []string
+}
+
+func (s *javaAbiDumpsSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var dumps android.Paths
+	ctx.VisitAllModules(func(module android.Module) {
+		if dumper, ok := module.(javaAbiDumper); ok && dumper.javaAbiDump() != nil {
+			dumps = append(dumps, dumper.javaAbiDump())
+			s.dumpPaths = append(s.dumpPaths, javaAbiDumpTag+":"+dumper.javaAbiDump().String())
+		}
+	})
+	if len(dumps) > 0 {
+		ctx.Phony("java_abi_dumps", dumps...)
+	}
+	s.dumpPaths = android.SortedUniqueStrings(s.dumpPaths)
+}
+
+func (s *javaAbiDumpsSingleton) MakeVars(ctx android.MakeVarsContext) {
+	ctx.Strict("JAVA_ABI_DUMP_PATHS", strings.Join(s.dumpPaths, " "))
+}
+
+var _ android.SingletonMakeVarsProvider = (*javaAbiDumpsSingleton)(nil)
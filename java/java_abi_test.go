@@ -0,0 +1,134 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+var javaAbiCheckerBp = `
+	droiddoc_exported_dir {
+		name: "droiddoc-templates-sdk",
+		path: ".",
+	}
+
+	java_sdk_library {
+		name: "foo",
+		srcs: ["a.java"],
+		api_packages: ["foo"],
+		apex_available: ["com.android.foo"],
+	}
+
+	java_library {
+		name: "bar",
+		srcs: ["b.java"],
+		apex_available: ["com.android.foo"],
+		java_abi_checker: {
+			enabled: true,
+			ref_dump_dirs: ["abi"],
+		},
+	}
+
+	java_library {
+		name: "baz",
+		srcs: ["c.java"],
+		apex_available: ["com.android.foo"],
+	}
+`
+
+var prepareForJavaAbiCheckerTest = android.GroupFixturePreparers(
+	prepareForJavaTest,
+	PrepareForTestWithJavaSdkLibraryFiles,
+	PrepareForTestWithFakeApexMutator,
+	prepareForTestWithJavaAbiDumps,
+	FixtureWithLastReleaseApis("foo"),
+	android.FixtureMergeMockFs(android.MockFS{
+		"prebuilts/abi-dumps/java/29/foo.abi.json":      nil,
+		"prebuilts/abi-dumps/java/current/foo.abi.json": nil,
+		"prebuilts/abi-dumps/java/current/bar.abi.json": nil,
+		"abi/bar.abi.json": nil,
+	}),
+)
+
+func TestJavaAbiChecker(t *testing.T) {
+	result := prepareForJavaAbiCheckerTest.RunTestWithBp(t, javaAbiCheckerBp)
+
+	foo := result.ModuleForTests("foo", "android_common_apex1000")
+	fooLibrary := foo.Module().(*SdkLibrary)
+	dump := foo.Rule("javaAbiDump")
+	android.AssertPathRelativeToTopEquals(t, "foo dump input",
+		android.PathRelativeToTop(fooLibrary.implementationJarFile), dump.Input)
+	android.AssertPathRelativeToTopEquals(t, "foo dump output",
+		"out/soong/.intermediates/foo/android_common_apex1000/abi/foo.abi.json", dump.Output)
+
+	prevDiff := foo.Output("abi/foo.29.abidiff")
+	android.AssertStringEquals(t, "foo previous version reference dump",
+		"prebuilts/abi-dumps/java/29/foo.abi.json", prevDiff.Args["referenceDump"])
+	android.AssertStringEquals(t, "foo previous version flags", "-allow-extensions", prevDiff.Args["extraFlags"])
+
+	currDiff := foo.Output("abi/foo.abidiff")
+	android.AssertStringEquals(t, "foo current reference dump",
+		"prebuilts/abi-dumps/java/current/foo.abi.json", currDiff.Args["referenceDump"])
+	android.AssertStringEquals(t, "foo current flags", "", currDiff.Args["extraFlags"])
+	android.AssertStringDoesContain(t, "foo current error message", currDiff.Args["errorMessage"],
+		"prebuilts/abi-dumps/java/current/foo.abi.json")
+
+	// The diffs are validations of the dex jar that is packaged in the APEX.
+	validated := foo.Output("validated/foo.jar")
+	android.AssertPathRelativeToTopEquals(t, "foo dex jar",
+		android.PathRelativeToTop(validated.Output), fooLibrary.DexJarBuildPath().Path())
+	android.AssertPathsRelativeToTopEquals(t, "foo validations", []string{
+		"out/soong/.intermediates/foo/android_common_apex1000/abi/foo.29.abidiff",
+		"out/soong/.intermediates/foo/android_common_apex1000/abi/foo.abidiff",
+	}, validated.Validations)
+
+	bar := result.ModuleForTests("bar", "android_common_apex1000")
+	bar.Output("abi/bar.abi.json")
+	bar.Output("abi/bar.abidiff")
+	optDiff := bar.Output("abi/bar.opt0.abidiff")
+	android.AssertStringEquals(t, "bar opt-in reference dump", "abi/bar.abi.json", optDiff.Args["referenceDump"])
+
+	// The platform variants and libraries that do not enable the checker are not checked.
+	for _, m := range []struct{ name, variant string }{
+		{"foo", "android_common"},
+		{"bar", "android_common"},
+		{"baz", "android_common_apex1000"},
+	} {
+		if result.ModuleForTests(m.name, m.variant).MaybeRule("javaAbiDump").Rule != nil {
+			t.Errorf("unexpected ABI dump for %s %s", m.name, m.variant)
+		}
+	}
+
+	android.AssertDeepEquals(t, "JAVA_ABI_DUMP_PATHS", []string{
+		"JAVA:out/soong/.intermediates/bar/android_common_apex1000/abi/bar.abi.json",
+		"JAVA:out/soong/.intermediates/foo/android_common_apex1000/abi/foo.abi.json",
+	}, android.StringsRelativeToTop(result.Config,
+		result.SingletonForTests("java_abi_dumps").Singleton().(*javaAbiDumpsSingleton).dumpPaths))
+}
+
+func TestJavaAbiCheckerSkipped(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForJavaAbiCheckerTest,
+		android.FixtureMergeEnv(map[string]string{"SKIP_ABI_CHECKS": "true"}),
+	).RunTestWithBp(t, javaAbiCheckerBp)
+
+	for _, name := range []string{"foo", "bar"} {
+		if result.ModuleForTests(name, "android_common_apex1000").MaybeRule("javaAbiDump").Rule != nil {
+			t.Errorf("unexpected ABI dump for %s with SKIP_ABI_CHECKS=true", name)
+		}
+	}
+}
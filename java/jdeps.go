@@ -23,10 +23,12 @@ import (
 
 // This singleton generates android java dependency into to a json file. It does so for each
 // blueprint Android.bp resulting in a java.Module when either make, mm, mma, mmm or mmma is
-// called. Dependency info file is generated in $OUT/module_bp_java_depend.json.
+// called. Dependency info file is generated in $OUT/module_bp_java_depend.json.  It is a view of
+// the Java section of the model of the modules collected by android.CollectIdeModuleInfos.
 
 func init() {
 	android.RegisterSingletonType("jdeps_generator", jDepsGeneratorSingleton)
+	android.RegisterIdeInfoContributor(javaIdeInfoContributor)
 }
 
 func jDepsGeneratorSingleton() android.Singleton {
@@ -43,47 +45,29 @@ const (
 	jdepsJsonFileName = "module_bp_java_deps.json"
 )
 
-func (j *jdepsGeneratorSingleton) GenerateBuildActions(ctx android.SingletonContext) {
-	// (b/204397180) Generate module_bp_java_deps.json by default.
-	moduleInfos := make(map[string]android.IdeInfo)
-
-	ctx.VisitAllModules(func(module android.Module) {
-		if !module.Enabled() {
-			return
-		}
-
-		// Prevent including both prebuilts and matching source modules when one replaces the other.
-		if !android.IsModulePreferred(module) {
-			return
-		}
-
-		ideInfoProvider, ok := module.(android.IDEInfo)
-		if !ok {
-			return
-		}
-		name := ideInfoProvider.BaseModuleName()
-		ideModuleNameProvider, ok := module.(android.IDECustomizedModuleName)
-		if ok {
-			name = ideModuleNameProvider.IDECustomizedModuleName()
-		}
+// javaIdeInfoContributor fills in the model of the modules that implement android.IDEInfo.
+func javaIdeInfoContributor(ctx android.IdeInfoContext, module android.Module, info *android.IdeModuleInfo) bool {
+	ideInfoProvider, ok := module.(android.IDEInfo)
+	if !ok {
+		return false
+	}
 
-		dpInfo := moduleInfos[name]
-		ideInfoProvider.IDEInfo(&dpInfo)
-		dpInfo.Deps = android.FirstUniqueStrings(dpInfo.Deps)
-		dpInfo.Srcs = android.FirstUniqueStrings(dpInfo.Srcs)
-		dpInfo.Aidl_include_dirs = android.FirstUniqueStrings(dpInfo.Aidl_include_dirs)
-		dpInfo.Jarjar_rules = android.FirstUniqueStrings(dpInfo.Jarjar_rules)
-		dpInfo.Jars = android.FirstUniqueStrings(dpInfo.Jars)
-		dpInfo.SrcJars = android.FirstUniqueStrings(dpInfo.SrcJars)
-		dpInfo.Paths = android.FirstUniqueStrings(dpInfo.Paths)
-		dpInfo.Static_libs = android.FirstUniqueStrings(dpInfo.Static_libs)
-		dpInfo.Libs = android.FirstUniqueStrings(dpInfo.Libs)
-		moduleInfos[name] = dpInfo
-
-		mkProvider, ok := module.(android.AndroidMkDataProvider)
-		if !ok {
-			return
-		}
+	if info.Java == nil {
+		info.Java = &android.IdeInfo{}
+	}
+	dpInfo := info.Java
+	ideInfoProvider.IDEInfo(dpInfo)
+	dpInfo.Deps = android.FirstUniqueStrings(dpInfo.Deps)
+	dpInfo.Srcs = android.FirstUniqueStrings(dpInfo.Srcs)
+	dpInfo.Aidl_include_dirs = android.FirstUniqueStrings(dpInfo.Aidl_include_dirs)
+	dpInfo.Jarjar_rules = android.FirstUniqueStrings(dpInfo.Jarjar_rules)
+	dpInfo.Jars = android.FirstUniqueStrings(dpInfo.Jars)
+	dpInfo.SrcJars = android.FirstUniqueStrings(dpInfo.SrcJars)
+	dpInfo.Paths = android.FirstUniqueStrings(dpInfo.Paths)
+	dpInfo.Static_libs = android.FirstUniqueStrings(dpInfo.Static_libs)
+	dpInfo.Libs = android.FirstUniqueStrings(dpInfo.Libs)
+
+	if mkProvider, ok := module.(android.AndroidMkDataProvider); ok {
 		data := mkProvider.AndroidMk()
 		if data.Class != "" {
 			dpInfo.Classes = append(dpInfo.Classes, data.Class)
@@ -95,8 +79,42 @@ func (j *jdepsGeneratorSingleton) GenerateBuildActions(ctx android.SingletonCont
 		}
 		dpInfo.Classes = android.FirstUniqueStrings(dpInfo.Classes)
 		dpInfo.Installed_paths = android.FirstUniqueStrings(dpInfo.Installed_paths)
-		moduleInfos[name] = dpInfo
-	})
+	}
+
+	info.Srcs = append(info.Srcs, dpInfo.Srcs...)
+	info.Generated_srcs = append(info.Generated_srcs, dpInfo.SrcJars...)
+	info.Include_dirs = append(info.Include_dirs, dpInfo.Aidl_include_dirs...)
+	info.Class_path = append(info.Class_path, dpInfo.Jars...)
+	info.Deps = append(info.Deps, dpInfo.Deps...)
+	info.Outputs = append(info.Outputs, dpInfo.Installed_paths...)
+
+	if j, ok := module.(ideKotlinInfoProvider); ok {
+		info.Languages = append(info.Languages, "java")
+		if kotlinInfo := j.IdeKotlinInfo(); kotlinInfo != nil {
+			info.Languages = append(info.Languages, "kotlin")
+			if info.Kotlin == nil {
+				info.Kotlin = &android.IdeKotlinInfo{}
+			}
+			info.Kotlin.Common_srcs = android.FirstUniqueStrings(append(info.Kotlin.Common_srcs, kotlinInfo.Common_srcs...))
+			info.Kotlin.Flags = android.FirstUniqueStrings(append(info.Kotlin.Flags, kotlinInfo.Flags...))
+		}
+	}
+	return true
+}
+
+// ideKotlinInfoProvider is implemented by the java modules that compile sources.
+type ideKotlinInfoProvider interface {
+	IdeKotlinInfo() *android.IdeKotlinInfo
+}
+
+func (j *jdepsGeneratorSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	// (b/204397180) Generate module_bp_java_deps.json by default.
+	moduleInfos := make(map[string]android.IdeInfo)
+	for name, info := range android.CollectIdeModuleInfosWith(ctx, javaIdeInfoContributor).ByModuleName() {
+		if info.Java != nil {
+			moduleInfos[name] = *info.Java
+		}
+	}
 
 	jfpath := android.PathForOutput(ctx, jdepsJsonFileName)
 	err := createJsonFile(moduleInfos, jfpath)
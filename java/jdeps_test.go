@@ -85,3 +85,32 @@ func TestCollectJavaLibraryPropertiesAddJarjarRules(t *testing.T) {
 		t.Errorf("Library.IDEInfo() Jarjar_rules = %v, want %v", dpInfo.Jarjar_rules[0], expected)
 	}
 }
+
+func TestCollectJavaLibraryKotlinIdeInfo(t *testing.T) {
+	ctx, _ := testJava(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java", "b.kt"],
+			common_srcs: ["c.kt"],
+			kotlincflags: ["-Xjvm-default=all"],
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["a.java"],
+		}
+	`)
+
+	foo := ctx.ModuleForTests("foo", "android_common").Module().(*Library)
+	kotlinInfo := foo.IdeKotlinInfo()
+	if kotlinInfo == nil {
+		t.Fatalf("expected Kotlin IDE info for foo")
+	}
+	android.AssertDeepEquals(t, "common srcs", []string{"c.kt"}, kotlinInfo.Common_srcs)
+	android.AssertStringListContains(t, "flags", kotlinInfo.Flags, "-Xjvm-default=all")
+
+	bar := ctx.ModuleForTests("bar", "android_common").Module().(*Library)
+	if bar.IdeKotlinInfo() != nil {
+		t.Errorf("expected no Kotlin IDE info for bar, got %+v", bar.IdeKotlinInfo())
+	}
+}
@@ -99,6 +99,7 @@ type lintOutputs struct {
 	html              android.Path
 	text              android.Path
 	xml               android.Path
+	sarif             android.Path
 	referenceBaseline android.Path
 
 	depSets LintDepSets
@@ -451,6 +452,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 	html := android.PathForModuleOut(ctx, "lint", "lint-report.html")
 	text := android.PathForModuleOut(ctx, "lint", "lint-report.txt")
 	xml := android.PathForModuleOut(ctx, "lint", "lint-report.xml")
+	sarif := android.PathForModuleOut(ctx, "lint", "lint-report.sarif")
 	referenceBaseline := android.PathForModuleOut(ctx, "lint", "lint-baseline.xml")
 
 	depSetsBuilder := NewLintDepSetBuilder().Direct(html, text, xml)
@@ -463,7 +465,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 
 	rule.Command().Text("rm -rf").Flag(lintPaths.cacheDir.String()).Flag(lintPaths.homeDir.String())
 	rule.Command().Text("mkdir -p").Flag(lintPaths.cacheDir.String()).Flag(lintPaths.homeDir.String())
-	rule.Command().Text("rm -f").Output(html).Output(text).Output(xml)
+	rule.Command().Text("rm -f").Output(html).Output(text).Output(xml).Output(sarif)
 
 	files, ok := allLintDatabasefiles[l.compileSdkKind]
 	if !ok {
@@ -492,6 +494,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 		FlagWithOutput("--html ", html).
 		FlagWithOutput("--text ", text).
 		FlagWithOutput("--xml ", xml).
+		FlagWithOutput("--sarif ", sarif).
 		FlagWithArg("--compile-sdk-version ", strconv.Itoa(l.compileSdkVersion)).
 		FlagWithArg("--java-language-level ", l.javaLanguageLevel).
 		FlagWithArg("--kotlin-language-level ", l.kotlinLanguageLevel).
@@ -542,6 +545,7 @@ func (l *linter) lint(ctx android.ModuleContext) {
 		html:              html,
 		text:              text,
 		xml:               xml,
+		sarif:             sarif,
 		referenceBaseline: referenceBaseline,
 
 		depSets: depSetsBuilder.Build(),
@@ -0,0 +1,67 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package python
+
+import (
+	"android/soong/android"
+)
+
+func init() {
+	android.RegisterIdeInfoContributor(pythonIdeInfoContributor)
+}
+
+type pythonIdeModule interface {
+	pythonDependency
+	basePropertiesProvider
+}
+
+// pythonIdeInfoContributor fills in the IDE model of python modules.
+func pythonIdeInfoContributor(ctx android.IdeInfoContext, module android.Module, info *android.IdeModuleInfo) bool {
+	p, ok := module.(pythonIdeModule)
+	if !ok {
+		return false
+	}
+
+	info.Languages = append(info.Languages, "python")
+
+	for _, mapping := range p.getSrcsPathMappings() {
+		if _, generated := mapping.src.(android.WritablePath); generated {
+			info.Generated_srcs = append(info.Generated_srcs, mapping.src.String())
+		} else {
+			info.Srcs = append(info.Srcs, mapping.src.String())
+		}
+	}
+
+	ctx.VisitDirectDeps(module, func(dep android.Module) {
+		if _, ok := dep.(pythonDependency); ok {
+			info.Deps = append(info.Deps, ctx.IdeModuleName(dep))
+		}
+	})
+
+	if srcsZip := p.getSrcsZip(); srcsZip != nil {
+		info.Outputs = append(info.Outputs, srcsZip.String())
+	}
+
+	if info.Python == nil {
+		info.Python = &android.IdePythonInfo{
+			Pkg_path: String(p.getBaseProperties().Pkg_path),
+		}
+	}
+	for _, mapping := range p.getDataPathMappings() {
+		info.Python.Data = append(info.Python.Data, mapping.src.String())
+	}
+	info.Python.Data = android.FirstUniqueStrings(info.Python.Data)
+	return true
+}
@@ -0,0 +1,277 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// set is a set of modules, by id.
+type set map[int]bool
+
+// Result is the result of a query.
+type Result struct {
+	Graph *Graph
+	// The modules in the result.  They are in the order of the path for somepath queries, and
+	// sorted by label and variant otherwise.
+	Modules []*Module
+	// Whether Modules is a path, where each module depends on the next one.
+	Path bool
+}
+
+// Evaluate evaluates a query expression against the module graph.
+func Evaluate(g *Graph, e Expr) (*Result, error) {
+	if p, ok := e.(*pathsExpr); ok && !p.all {
+		ids, err := p.somePath(g)
+		if err != nil {
+			return nil, err
+		}
+		r := &Result{Graph: g, Path: true}
+		for _, id := range ids {
+			r.Modules = append(r.Modules, g.Modules[id])
+		}
+		return r, nil
+	}
+
+	s, err := e.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{Graph: g}
+	for id := range s {
+		r.Modules = append(r.Modules, g.Modules[id])
+	}
+	sort.Slice(r.Modules, func(i, j int) bool {
+		a, b := r.Modules[i], r.Modules[j]
+		if a.Label() != b.Label() {
+			return a.Label() < b.Label()
+		}
+		if a.Variant != b.Variant {
+			return a.Variant < b.Variant
+		}
+		return a.Id < b.Id
+	})
+	return r, nil
+}
+
+func (e *patternExpr) match(m *Module) bool {
+	if ok, _ := path.Match(e.name, m.Name); !ok {
+		return false
+	}
+	if e.variant != "" {
+		if ok, _ := path.Match(e.variant, m.Variant); !ok {
+			return false
+		}
+	}
+	switch {
+	case e.anyDir:
+		return true
+	case e.recursive:
+		return e.dir == "" || m.Dir == e.dir || strings.HasPrefix(m.Dir, e.dir+"/")
+	default:
+		ok, _ := path.Match(e.dir, m.Dir)
+		return ok
+	}
+}
+
+func (e *patternExpr) eval(g *Graph) (set, error) {
+	s := make(set)
+	for _, m := range g.Modules {
+		if e.match(m) {
+			s[m.Id] = true
+		}
+	}
+	if len(s) == 0 {
+		return nil, fmt.Errorf("no modules match %q", e.pattern)
+	}
+	return s, nil
+}
+
+// edges returns the modules that the module depends on, or the modules that depend on it.
+func (g *Graph) edges(id int, reverse bool) []Dep {
+	if reverse {
+		return g.reverseDeps(id)
+	}
+	return g.Modules[id].Deps
+}
+
+// reachable returns the modules reachable from the modules in s up to the given depth, or without
+// limit if depth is negative.
+func (g *Graph) reachable(s set, reverse bool, depth int) set {
+	result := make(set)
+	var queue []int
+	for id := range s {
+		result[id] = true
+		queue = append(queue, id)
+	}
+	for d := 0; len(queue) > 0 && (depth < 0 || d < depth); d++ {
+		var next []int
+		for _, id := range queue {
+			for _, dep := range g.edges(id, reverse) {
+				if !result[dep.Module] {
+					result[dep.Module] = true
+					next = append(next, dep.Module)
+				}
+			}
+		}
+		queue = next
+	}
+	return result
+}
+
+func (e *depsExpr) eval(g *Graph) (set, error) {
+	s, err := e.x.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	return g.reachable(s, e.reverse, e.depth), nil
+}
+
+func (e *pathsExpr) eval(g *Graph) (set, error) {
+	if !e.all {
+		ids, err := e.somePath(g)
+		if err != nil {
+			return nil, err
+		}
+		s := make(set)
+		for _, id := range ids {
+			s[id] = true
+		}
+		return s, nil
+	}
+
+	from, err := e.from.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.to.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	// The modules on any path are those that are both dependencies of from and reverse
+	// dependencies of to.
+	forward := g.reachable(from, false, -1)
+	backward := g.reachable(to, true, -1)
+	s := make(set)
+	for id := range forward {
+		if backward[id] {
+			s[id] = true
+		}
+	}
+	return s, nil
+}
+
+// somePath returns the modules on a shortest path from a module in from to a module in to, or an
+// empty path if there is none.
+func (e *pathsExpr) somePath(g *Graph) ([]int, error) {
+	from, err := e.from.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	to, err := e.to.eval(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search breadth first from the modules of from in id order so that the result is stable.
+	parents := make(map[int]int)
+	var queue []int
+	for _, id := range sortedIds(from) {
+		parents[id] = -1
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if to[id] {
+			var path []int
+			for ; id >= 0; id = parents[id] {
+				path = append([]int{id}, path...)
+			}
+			return path, nil
+		}
+		for _, dep := range g.Modules[id].Deps {
+			if _, seen := parents[dep.Module]; !seen {
+				parents[dep.Module] = id
+				queue = append(queue, dep.Module)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (e *filterExpr) eval(g *Graph) (set, error) {
+	s, err := e.x.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	field := filterFields[e.field]
+	result := make(set)
+	for id := range s {
+		for _, value := range field(g.Modules[id]) {
+			if e.re.MatchString(value) {
+				result[id] = true
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (e *setExpr) eval(g *Graph) (set, error) {
+	left, err := e.left.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(g)
+	if err != nil {
+		return nil, err
+	}
+	result := make(set)
+	switch e.op {
+	case "+":
+		for id := range left {
+			result[id] = true
+		}
+		for id := range right {
+			result[id] = true
+		}
+	case "-":
+		for id := range left {
+			if !right[id] {
+				result[id] = true
+			}
+		}
+	case "^":
+		for id := range left {
+			if right[id] {
+				result[id] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+func sortedIds(s set) []int {
+	ids := make([]int, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package query answers questions about the module graph of a Soong build.  The graph is
+// serialized by soong_build into a JSON file that lists every variant of every module with its
+// type, directory, dependencies and their tags, install paths and outputs.  Queries are written in
+// a small expression language, see Parse, and their results can be printed as text, JSON or
+// Graphviz.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// GraphSchemaVersion is the version of the schema of the serialized graph.  It is incremented
+// whenever a change is made that is not backwards compatible.
+const GraphSchemaVersion = 1
+
+// Graph is the module graph of a Soong build.
+type Graph struct {
+	Version int       `json:"version"`
+	Modules []*Module `json:"modules"`
+
+	// The reverse dependencies of each module, computed when the graph is read.
+	rdeps [][]Dep
+}
+
+// Module is a variant of a module.
+type Module struct {
+	// The index of the module in Graph.Modules.
+	Id      int    `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Variant string `json:"variant,omitempty"`
+	Dir     string `json:"dir"`
+	// Disabled modules are in the graph so that the modules that depend on them can be found.
+	Disabled      bool     `json:"disabled,omitempty"`
+	Deps          []Dep    `json:"deps,omitempty"`
+	Install_paths []string `json:"install_paths,omitempty"`
+	Outputs       []string `json:"outputs,omitempty"`
+	// The files that the module itself places in packages, e.g. filesystem images.
+	Packaging_specs []PackagingSpec `json:"packaging_specs,omitempty"`
+}
+
+// PackagingSpec is a file that a module places in the packages that depend on it.
+type PackagingSpec struct {
+	// The path of the file relative to the root of the package.
+	Path      string `json:"path"`
+	Partition string `json:"partition,omitempty"`
+}
+
+// Dep is a dependency between two modules.  In Module.Deps it points to the dependency, in the
+// reverse dependencies of a module it points to the module that depends on it.
+type Dep struct {
+	Module int    `json:"module"`
+	Tag    string `json:"tag,omitempty"`
+	// The property that added the dependency, e.g. shared_libs, if it is known.
+	Property string `json:"property,omitempty"`
+	// Whether the packaging specs of the dependency are packaged with those of the module.
+	Packaging bool `json:"packaging,omitempty"`
+}
+
+// Label returns the label of the module, //<dir>:<name>.
+func (m *Module) Label() string {
+	return "//" + m.Dir + ":" + m.Name
+}
+
+// String returns the label of the module followed by its variant.
+func (m *Module) String() string {
+	if m.Variant == "" {
+		return m.Label()
+	}
+	return m.Label() + " " + m.Variant
+}
+
+// ReadGraph reads a serialized module graph.
+func ReadGraph(r io.Reader) (*Graph, error) {
+	g := &Graph{}
+	if err := json.NewDecoder(r).Decode(g); err != nil {
+		return nil, fmt.Errorf("failed to parse module graph: %s", err)
+	}
+	if g.Version != GraphSchemaVersion {
+		return nil, fmt.Errorf("unsupported module graph version %d, expected %d", g.Version, GraphSchemaVersion)
+	}
+	if err := g.index(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Write serializes the module graph.
+func (g *Graph) Write(w io.Writer) error {
+	g.Version = GraphSchemaVersion
+	buf, err := json.MarshalIndent(g, "", " ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+// index checks the ids of the modules and computes the reverse dependencies.
+func (g *Graph) index() error {
+	g.rdeps = make([][]Dep, len(g.Modules))
+	for i, m := range g.Modules {
+		if m.Id != i {
+			return fmt.Errorf("module %s has id %d at index %d", m, m.Id, i)
+		}
+		for _, dep := range m.Deps {
+			if dep.Module < 0 || dep.Module >= len(g.Modules) {
+				return fmt.Errorf("module %s depends on unknown module %d", m, dep.Module)
+			}
+			rdep := dep
+			rdep.Module = i
+			g.rdeps[dep.Module] = append(g.rdeps[dep.Module], rdep)
+		}
+	}
+	return nil
+}
+
+// reverseDeps returns the modules that depend on the module with the given id.
+func (g *Graph) reverseDeps(id int) []Dep {
+	if g.rdeps == nil {
+		g.index()
+	}
+	return g.rdeps[id]
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Formats are the output formats of query results, by name.
+var Formats = map[string]func(w io.Writer, r *Result) error{
+	"text": WriteText,
+	"json": WriteJSON,
+	"dot":  WriteDot,
+}
+
+// edgesWithin returns the dependencies of the module on the other modules of the result.
+func (r *Result) edgesWithin(m *Module) []Dep {
+	in := make(set)
+	for _, other := range r.Modules {
+		in[other.Id] = true
+	}
+	var deps []Dep
+	for _, dep := range m.Deps {
+		if in[dep.Module] {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// WriteText writes a line for every module of the result with its label and variant.  The
+// modules of a path are separated by lines with the properties or the tags of the dependencies
+// between them.
+func WriteText(w io.Writer, r *Result) error {
+	for i, m := range r.Modules {
+		if r.Path && i > 0 {
+			prev := r.Modules[i-1]
+			for _, dep := range prev.Deps {
+				if dep.Module == m.Id {
+					if _, err := fmt.Fprintf(w, "  depends on (%s)\n", dep.label()); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if _, err := fmt.Fprintln(w, m.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the modules of the result as a JSON array.  The dependencies of each module
+// are limited to the other modules of the result.
+func WriteJSON(w io.Writer, r *Result) error {
+	modules := make([]Module, 0, len(r.Modules))
+	for _, m := range r.Modules {
+		copy := *m
+		copy.Deps = r.edgesWithin(m)
+		modules = append(modules, copy)
+	}
+	buf, err := json.MarshalIndent(modules, "", " ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+// WriteDot writes the modules of the result and the dependencies between them as a Graphviz
+// graph, with the dependencies labeled with their properties or tags.
+func WriteDot(w io.Writer, r *Result) error {
+	lines := []string{"digraph {"}
+	for _, m := range r.Modules {
+		label := m.Label()
+		if m.Variant != "" {
+			label += "\n" + m.Variant
+		}
+		lines = append(lines, fmt.Sprintf("  m%d [label=%s];", m.Id, strconv.Quote(label)))
+	}
+	for _, m := range r.Modules {
+		for _, dep := range r.edgesWithin(m) {
+			if dep.Tag == "" && dep.Property == "" {
+				lines = append(lines, fmt.Sprintf("  m%d -> m%d;", m.Id, dep.Module))
+			} else {
+				lines = append(lines, fmt.Sprintf("  m%d -> m%d [label=%s];", m.Id, dep.Module, strconv.Quote(dep.label())))
+			}
+		}
+	}
+	lines = append(lines, "}")
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label returns the property that added the dependency if it is known, or its tag otherwise.
+func (d Dep) label() string {
+	switch {
+	case d.Property != "":
+		return d.Property
+	case d.Tag != "":
+		return d.Tag
+	default:
+		return "<none>"
+	}
+}
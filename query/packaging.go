@@ -0,0 +1,211 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A package, e.g. a filesystem module, contains the packaging specs of the modules it depends on
+// through packaging dependencies, and transitively those of the modules that they depend on through
+// packaging dependencies, e.g. the shared libraries of binaries.  PackagingChains explains why a
+// file or a module is in a package by listing the chains of packaging dependencies that lead to it.
+
+// Chain is a chain of packaging dependencies from a package to a module in it.
+type Chain struct {
+	Modules []*Module
+	// Deps[i] is the dependency of Modules[i] on Modules[i+1].
+	Deps []Dep
+}
+
+// PackagingChains returns the shortest chains of packaging dependencies from each of the packages
+// matched by the pattern pkg to the modules that place the file with the given path in them, or to
+// the modules with the given name if target is not a path.  At most limit chains are returned if
+// limit is positive, with a boolean that is true if there were more.
+func PackagingChains(g *Graph, pkg string, target string, limit int) ([]Chain, bool, error) {
+	e, err := Parse(pkg)
+	if err != nil {
+		return nil, false, err
+	}
+	roots, err := e.eval(g)
+	if err != nil {
+		return nil, false, err
+	}
+
+	matches := packagingTargetMatcher(target)
+	found := false
+	for _, m := range g.Modules {
+		if matches(m) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		if strings.Contains(target, "/") {
+			return nil, false, fmt.Errorf("no module places %q in packages", target)
+		}
+		return nil, false, fmt.Errorf("no modules match %q", target)
+	}
+
+	// The chains are the shortest ones of each package, a module that is in several packages
+	// is explained for each of them.
+	var chains []Chain
+	truncated := false
+	for _, root := range sortedIds(roots) {
+		dist, preds := packagingDistances(g, root)
+
+		// walk builds the chains backwards from the target.
+		var walk func(id int, modules []*Module, deps []Dep)
+		walk = func(id int, modules []*Module, deps []Dep) {
+			if limit > 0 && len(chains) >= limit {
+				truncated = true
+				return
+			}
+			modules = append([]*Module{g.Modules[id]}, modules...)
+			if id == root {
+				chains = append(chains, Chain{Modules: modules, Deps: deps})
+				return
+			}
+			for _, pred := range preds[id] {
+				dep := pred
+				dep.Module = id
+				walk(pred.Module, modules, append([]Dep{dep}, deps...))
+			}
+		}
+		for _, m := range g.Modules {
+			if _, ok := dist[m.Id]; ok && m.Id != root && matches(m) {
+				walk(m.Id, nil, nil)
+			}
+		}
+	}
+	if len(chains) == 0 && !truncated {
+		return nil, false, fmt.Errorf("%q is not in %s", target, e)
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return chains[i].String() < chains[j].String()
+	})
+	return chains, truncated, nil
+}
+
+// packagingDistances returns the distance of every module in the package root from it, and the
+// dependencies on each module that are on the shortest chains to it, pointing to the modules that
+// depend on it.
+func packagingDistances(g *Graph, root int) (map[int]int, map[int][]Dep) {
+	dist := map[int]int{root: 0}
+	preds := make(map[int][]Dep)
+	queue := []int{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, dep := range g.Modules[id].Deps {
+			if !dep.Packaging {
+				continue
+			}
+			if d, seen := dist[dep.Module]; !seen {
+				dist[dep.Module] = dist[id] + 1
+				queue = append(queue, dep.Module)
+			} else if d != dist[id]+1 {
+				continue
+			}
+			pred := dep
+			pred.Module = id
+			preds[dep.Module] = append(preds[dep.Module], pred)
+		}
+	}
+	return dist, preds
+}
+
+// packagingTargetMatcher returns a function that matches the modules that place the file at
+// path in packages, or the modules named target if it is not a path.  The path can be relative to
+// the root of the package, or to the root of the device, e.g. /system/lib64/libc.so.
+func packagingTargetMatcher(target string) func(m *Module) bool {
+	if !strings.Contains(target, "/") {
+		return func(m *Module) bool { return m.Name == target }
+	}
+	path := strings.TrimPrefix(target, "/")
+	return func(m *Module) bool {
+		for _, ps := range m.Packaging_specs {
+			if ps.Path == path || (ps.Partition != "" && ps.Partition+"/"+ps.Path == path) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// String returns the chain on a single line.
+func (c Chain) String() string {
+	s := c.Modules[0].String()
+	for i, dep := range c.Deps {
+		s += " -[" + dep.label() + "]-> " + c.Modules[i+1].String()
+	}
+	return s
+}
+
+// WriteChainsText writes every chain with a line for each module, preceded by the property or the
+// tag of the dependency on it, and a blank line between chains.
+func WriteChainsText(w io.Writer, chains []Chain) error {
+	var lines []string
+	for i, c := range chains {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, c.Modules[0].String())
+		for j, dep := range c.Deps {
+			lines = append(lines, fmt.Sprintf("%s%s -> %s", strings.Repeat("  ", j+1), dep.label(), c.Modules[j+1]))
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainStepJson is an element of a chain in the JSON output.
+type chainStepJson struct {
+	Label   string `json:"label"`
+	Variant string `json:"variant,omitempty"`
+	// The property or the tag of the dependency on the module, empty for the package.
+	Dependency string `json:"dependency,omitempty"`
+}
+
+// WriteChainsJSON writes the chains as a JSON array of arrays of modules.
+func WriteChainsJSON(w io.Writer, chains []Chain) error {
+	out := make([][]chainStepJson, 0, len(chains))
+	for _, c := range chains {
+		var steps []chainStepJson
+		for i, m := range c.Modules {
+			step := chainStepJson{Label: m.Label(), Variant: m.Variant}
+			if i > 0 {
+				step.Dependency = c.Deps[i-1].label()
+			}
+			steps = append(steps, step)
+		}
+		out = append(out, steps)
+	}
+	buf, err := json.MarshalIndent(out, "", " ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
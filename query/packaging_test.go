@@ -0,0 +1,217 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPackagingGraph returns the graph:
+//
+//	myfs -[deps]-> bin1 -[shared_libs]-> libfoo -[shared_libs]-> libc
+//	myfs -[deps]-> bin2 -[shared_libs]-> libbar -[shared_libs]-> libc
+//	myfs -[deps]-> bin2 -[static_libs]-> libstatic, which is not packaged
+//	otherfs -[deps]-> libfoo
+//	yourfs -[deps]-> bin1 and libc, so the chains through bin1 to libc aren't the shortest
+func testPackagingGraph(t *testing.T) *Graph {
+	packagingDep := func(module int, property string) Dep {
+		return Dep{Module: module, Tag: "tag", Property: property, Packaging: true}
+	}
+	spec := func(path string) []PackagingSpec {
+		return []PackagingSpec{{Path: path, Partition: "system"}}
+	}
+	g := &Graph{
+		Modules: []*Module{
+			{Id: 0, Name: "myfs", Type: "filesystem", Dir: "fs",
+				Deps: []Dep{packagingDep(1, "deps"), packagingDep(2, "deps")}},
+			{Id: 1, Name: "bin1", Type: "cc_binary", Dir: "a",
+				Deps:            []Dep{packagingDep(3, "shared_libs")},
+				Packaging_specs: spec("bin/bin1")},
+			{Id: 2, Name: "bin2", Type: "cc_binary", Dir: "a",
+				Deps:            []Dep{packagingDep(4, "shared_libs"), {Module: 6, Property: "static_libs"}},
+				Packaging_specs: spec("bin/bin2")},
+			{Id: 3, Name: "libfoo", Type: "cc_library", Dir: "b",
+				Deps:            []Dep{packagingDep(5, "shared_libs")},
+				Packaging_specs: spec("lib64/libfoo.so")},
+			{Id: 4, Name: "libbar", Type: "cc_library", Dir: "b",
+				Deps:            []Dep{packagingDep(5, "shared_libs")},
+				Packaging_specs: spec("lib64/libbar.so")},
+			{Id: 5, Name: "libc", Type: "cc_library", Dir: "c",
+				Packaging_specs: spec("lib64/libc.so")},
+			{Id: 6, Name: "libstatic", Type: "cc_library_static", Dir: "b"},
+			{Id: 7, Name: "otherfs", Type: "filesystem", Dir: "fs",
+				Deps: []Dep{packagingDep(3, "deps")}},
+			{Id: 8, Name: "yourfs", Type: "filesystem", Dir: "fs",
+				Deps: []Dep{packagingDep(1, "deps"), packagingDep(5, "deps")}},
+		},
+	}
+	if err := g.index(); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestPackagingChains(t *testing.T) {
+	g := testPackagingGraph(t)
+	testCases := []struct {
+		name   string
+		pkg    string
+		target string
+		want   []string
+	}{
+		{
+			name:   "all shortest chains",
+			pkg:    "myfs",
+			target: "/system/lib64/libc.so",
+			want: []string{
+				"//fs:myfs -[deps]-> //a:bin1 -[shared_libs]-> //b:libfoo -[shared_libs]-> //c:libc",
+				"//fs:myfs -[deps]-> //a:bin2 -[shared_libs]-> //b:libbar -[shared_libs]-> //c:libc",
+			},
+		},
+		{
+			name:   "path relative to the package",
+			pkg:    "otherfs",
+			target: "lib64/libc.so",
+			want: []string{
+				"//fs:otherfs -[deps]-> //b:libfoo -[shared_libs]-> //c:libc",
+			},
+		},
+		{
+			name:   "only the shortest chains",
+			pkg:    "yourfs",
+			target: "libc",
+			want: []string{
+				"//fs:yourfs -[deps]-> //c:libc",
+			},
+		},
+		{
+			name:   "several packages",
+			pkg:    "//fs/...",
+			target: "libfoo",
+			want: []string{
+				"//fs:myfs -[deps]-> //a:bin1 -[shared_libs]-> //b:libfoo",
+				"//fs:otherfs -[deps]-> //b:libfoo",
+				"//fs:yourfs -[deps]-> //a:bin1 -[shared_libs]-> //b:libfoo",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chains, truncated, err := PackagingChains(g, tc.pkg, tc.target, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if truncated {
+				t.Errorf("expected all the chains")
+			}
+			var got []string
+			for _, c := range chains {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected chains:\n%s\ngot:\n%s", strings.Join(tc.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestPackagingChainsLimit(t *testing.T) {
+	chains, truncated, err := PackagingChains(testPackagingGraph(t), "myfs", "libc", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) != 1 || !truncated {
+		t.Errorf("expected a single chain or more, got %d chains, truncated %v", len(chains), truncated)
+	}
+}
+
+func TestPackagingChainsErrors(t *testing.T) {
+	testCases := []struct {
+		pkg    string
+		target string
+		err    string
+	}{
+		{pkg: "myfs", target: "libstatic", err: `"libstatic" is not in myfs`},
+		{pkg: "otherfs", target: "bin/bin1", err: `"bin/bin1" is not in otherfs`},
+		{pkg: "myfs", target: "/system/lib64/libmissing.so", err: `no module places "/system/lib64/libmissing.so" in packages`},
+		{pkg: "myfs", target: "libmissing", err: `no modules match "libmissing"`},
+		{pkg: "missingfs", target: "libc", err: `no modules match "missingfs"`},
+	}
+	g := testPackagingGraph(t)
+	for _, tc := range testCases {
+		_, _, err := PackagingChains(g, tc.pkg, tc.target, 0)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("%s in %s: expected error %q, got %v", tc.target, tc.pkg, tc.err, err)
+		}
+	}
+}
+
+func TestWriteChains(t *testing.T) {
+	chains, _, err := PackagingChains(testPackagingGraph(t), "myfs", "libc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteChainsText(buf, chains); err != nil {
+		t.Fatal(err)
+	}
+	wantText := strings.Join([]string{
+		"//fs:myfs",
+		"  deps -> //a:bin1",
+		"    shared_libs -> //b:libfoo",
+		"      shared_libs -> //c:libc",
+		"",
+		"//fs:myfs",
+		"  deps -> //a:bin2",
+		"    shared_libs -> //b:libbar",
+		"      shared_libs -> //c:libc",
+		"",
+	}, "\n")
+	if buf.String() != wantText {
+		t.Errorf("expected text output:\n%s\ngot:\n%s", wantText, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteChainsJSON(buf, chains[:1]); err != nil {
+		t.Fatal(err)
+	}
+	wantJSON := `[
+ [
+  {
+   "label": "//fs:myfs"
+  },
+  {
+   "label": "//a:bin1",
+   "dependency": "deps"
+  },
+  {
+   "label": "//b:libfoo",
+   "dependency": "shared_libs"
+  },
+  {
+   "label": "//c:libc",
+   "dependency": "shared_libs"
+  }
+ ]
+]
+`
+	if buf.String() != wantJSON {
+		t.Errorf("expected JSON output:\n%s\ngot:\n%s", wantJSON, buf.String())
+	}
+}
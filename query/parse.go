@@ -0,0 +1,410 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The query language is modeled on bazel query.  An expression evaluates to a set of modules:
+//
+//	libfoo                    all the variants of the modules named libfoo
+//	libfoo@android_arm64*     the variants of libfoo that match the variant glob
+//	//dir:libfoo              the modules named libfoo defined in dir
+//	//dir/...                 all the modules defined in dir and its subdirectories
+//	//...                     all the modules
+//	deps(x[, depth])          x and the modules it depends on, transitively or up to depth
+//	rdeps(x[, depth])         x and the modules that depend on it
+//	somepath(x, y)            the modules on one path from a module in x to a module in y
+//	allpaths(x, y)            the modules on any path from a module in x to a module in y
+//	filter(field, regexp, x)  the modules in x with a field that matches the regular expression,
+//	                          the fields are name, type, variant, dir, label, install, output
+//	                          and packaged
+//	x + y, x union y          the modules in x or y
+//	x - y, x except y         the modules in x but not in y
+//	x ^ y, x intersect y      the modules in both x and y
+//
+// Names and variants in patterns can contain the * and ? globs.  Words that contain whitespace,
+// parentheses or commas, e.g. regular expressions, can be quoted with " or '.
+
+// Expr is a parsed query expression.
+type Expr interface {
+	// eval returns the set of modules that the expression evaluates to.
+	eval(g *Graph) (set, error)
+	String() string
+}
+
+// patternExpr matches modules by name, directory and variant.
+type patternExpr struct {
+	pattern string
+
+	// Whether the modules are matched in any directory, or only in dir.
+	anyDir bool
+	dir    string
+	// Whether the modules in the subdirectories of dir are matched too.
+	recursive bool
+	name      string
+	variant   string
+}
+
+// depsExpr is deps(x) or rdeps(x).
+type depsExpr struct {
+	x       Expr
+	reverse bool
+	// The maximum depth of the dependencies, or -1 for no limit.
+	depth int
+}
+
+// pathsExpr is somepath(from, to) or allpaths(from, to).
+type pathsExpr struct {
+	from, to Expr
+	all      bool
+}
+
+// filterExpr is filter(field, regexp, x).
+type filterExpr struct {
+	field string
+	re    *regexp.Regexp
+	x     Expr
+}
+
+// setExpr is x + y, x - y or x ^ y.
+type setExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e *patternExpr) String() string { return e.pattern }
+
+func (e *depsExpr) String() string {
+	f := "deps"
+	if e.reverse {
+		f = "rdeps"
+	}
+	if e.depth >= 0 {
+		return fmt.Sprintf("%s(%s, %d)", f, e.x, e.depth)
+	}
+	return fmt.Sprintf("%s(%s)", f, e.x)
+}
+
+func (e *pathsExpr) String() string {
+	f := "somepath"
+	if e.all {
+		f = "allpaths"
+	}
+	return fmt.Sprintf("%s(%s, %s)", f, e.from, e.to)
+}
+
+func (e *filterExpr) String() string {
+	return fmt.Sprintf("filter(%s, %q, %s)", e.field, e.re.String(), e.x)
+}
+
+func (e *setExpr) String() string {
+	return fmt.Sprintf("(%s %s %s)", e.left, e.op, e.right)
+}
+
+// The fields of a module that filter can match.
+var filterFields = map[string]func(m *Module) []string{
+	"name":    func(m *Module) []string { return []string{m.Name} },
+	"type":    func(m *Module) []string { return []string{m.Type} },
+	"variant": func(m *Module) []string { return []string{m.Variant} },
+	"dir":     func(m *Module) []string { return []string{m.Dir} },
+	"label":   func(m *Module) []string { return []string{m.Label()} },
+	"install": func(m *Module) []string { return m.Install_paths },
+	"output":  func(m *Module) []string { return m.Outputs },
+	"packaged": func(m *Module) []string {
+		var paths []string
+		for _, ps := range m.Packaging_specs {
+			paths = append(paths, ps.Path)
+		}
+		return paths
+	},
+}
+
+var setOperators = map[string]string{
+	"+":         "+",
+	"union":     "+",
+	"-":         "-",
+	"except":    "-",
+	"^":         "^",
+	"intersect": "^",
+}
+
+type token struct {
+	// One of "(", ")", "," or "" for words.
+	kind   string
+	word   string
+	quoted bool
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == "" {
+		return strconv.Quote(t.word)
+	}
+	return "'" + t.kind + "'"
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{kind: string(c), offset: i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("offset %d: unterminated quoted word", i)
+			}
+			tokens = append(tokens, token{word: s[i+1 : i+1+end], quoted: true, offset: i})
+			i += end + 2
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r(),\"'", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{word: s[start:i], offset: start})
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	end    int
+}
+
+// Parse parses a query expression.
+func Parse(query string) (Expr, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, end: len(query)}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("offset %d: unexpected %s", t.offset, t)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next(what string) (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return token{}, fmt.Errorf("offset %d: expected %s, got end of query", p.end, what)
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) expect(kind string) error {
+	t, err := p.next("'" + kind + "'")
+	if err != nil {
+		return err
+	}
+	if t.kind != kind {
+		return fmt.Errorf("offset %d: expected '%s', got %s", t.offset, kind, t)
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != "" || t.quoted || setOperators[t.word] == "" {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &setExpr{op: setOperators[t.word], left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (Expr, error) {
+	t, err := p.next("an expression")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case t.kind == "(":
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case t.kind != "":
+		return nil, fmt.Errorf("offset %d: expected an expression, got %s", t.offset, t)
+	}
+
+	if next, ok := p.peek(); ok && next.kind == "(" && !t.quoted {
+		p.pos++
+		return p.parseFunction(t)
+	}
+	return parsePattern(t)
+}
+
+func (p *parser) parseFunction(f token) (Expr, error) {
+	var e Expr
+	var err error
+	switch f.word {
+	case "deps", "rdeps":
+		e, err = p.parseDeps(f.word == "rdeps")
+	case "somepath", "allpaths":
+		e, err = p.parsePaths(f.word == "allpaths")
+	case "filter":
+		e, err = p.parseFilter()
+	default:
+		return nil, fmt.Errorf("offset %d: unknown function %q", f.offset, f.word)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, p.expect(")")
+}
+
+func (p *parser) parseDeps(reverse bool) (Expr, error) {
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	e := &depsExpr{x: x, reverse: reverse, depth: -1}
+	if t, ok := p.peek(); ok && t.kind == "," {
+		p.pos++
+		t, err := p.next("a depth")
+		if err != nil {
+			return nil, err
+		}
+		depth, err := strconv.Atoi(t.word)
+		if t.kind != "" || err != nil || depth < 0 {
+			return nil, fmt.Errorf("offset %d: expected a depth, got %s", t.offset, t)
+		}
+		e.depth = depth
+	}
+	return e, nil
+}
+
+func (p *parser) parsePaths(all bool) (Expr, error) {
+	from, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	to, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &pathsExpr{from: from, to: to, all: all}, nil
+}
+
+func (p *parser) parseFilter() (Expr, error) {
+	field, err := p.next("a field")
+	if err != nil {
+		return nil, err
+	}
+	if field.kind != "" || filterFields[field.word] == nil {
+		return nil, fmt.Errorf("offset %d: expected one of the fields %s, got %s", field.offset,
+			strings.Join(sortedFilterFields(), ", "), field)
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	pattern, err := p.next("a regular expression")
+	if err != nil {
+		return nil, err
+	}
+	if pattern.kind != "" {
+		return nil, fmt.Errorf("offset %d: expected a regular expression, got %s", pattern.offset, pattern)
+	}
+	re, err := regexp.Compile(pattern.word)
+	if err != nil {
+		return nil, fmt.Errorf("offset %d: %s", pattern.offset, err)
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return &filterExpr{field: field.word, re: re, x: x}, nil
+}
+
+func sortedFilterFields() []string {
+	var fields []string
+	for field := range filterFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// parsePattern parses [//<dir>:]<name>[@<variant>] or //<dir>/...[@<variant>].
+func parsePattern(t token) (Expr, error) {
+	e := &patternExpr{pattern: t.word, name: t.word, anyDir: true}
+	if i := strings.LastIndexByte(e.name, '@'); i >= 0 {
+		e.name, e.variant = e.name[:i], e.name[i+1:]
+	}
+	if strings.HasPrefix(e.name, "//") {
+		e.anyDir = false
+		label := strings.TrimPrefix(e.name, "//")
+		if label == "..." || strings.HasSuffix(label, "/...") {
+			e.dir = strings.TrimSuffix(strings.TrimSuffix(label, "..."), "/")
+			e.recursive = true
+			e.name = "*"
+		} else if i := strings.IndexByte(label, ':'); i >= 0 {
+			e.dir, e.name = label[:i], label[i+1:]
+		} else {
+			return nil, fmt.Errorf("offset %d: expected //<dir>:<name> or //<dir>/..., got %s", t.offset, t)
+		}
+	}
+	if e.name == "" {
+		return nil, fmt.Errorf("offset %d: missing module name in %s", t.offset, t)
+	}
+	for _, glob := range []string{e.dir, e.name, e.variant} {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("offset %d: invalid glob in %s: %s", t.offset, t, err)
+		}
+	}
+	return e, nil
+}
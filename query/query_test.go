@@ -0,0 +1,267 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGraph returns the graph:
+//
+//	bin (a) -> libfoo (a/foo) -> libbar (b)
+//	bin (a) -> libbaz (b)     -> libbar (b)
+//	libbar has an arm64 and an x86_64 variant
+//	libunused (c) depends on nothing
+func testGraph(t *testing.T) *Graph {
+	g := &Graph{
+		Modules: []*Module{
+			{Id: 0, Name: "bin", Type: "cc_binary", Variant: "android_arm64", Dir: "a",
+				Deps:          []Dep{{Module: 1, Tag: "shared"}, {Module: 3, Tag: "static"}},
+				Install_paths: []string{"out/target/product/x/system/bin/bin"}},
+			{Id: 1, Name: "libfoo", Type: "cc_library", Variant: "android_arm64", Dir: "a/foo",
+				Deps:          []Dep{{Module: 2, Tag: "shared"}},
+				Install_paths: []string{"out/target/product/x/system/lib64/libfoo.so"}},
+			{Id: 2, Name: "libbar", Type: "cc_library", Variant: "android_arm64", Dir: "b",
+				Outputs: []string{"out/soong/.intermediates/b/libbar/android_arm64/libbar.so"}},
+			{Id: 3, Name: "libbaz", Type: "cc_library_static", Variant: "android_arm64", Dir: "b",
+				Deps: []Dep{{Module: 2, Tag: "header"}}},
+			{Id: 4, Name: "libbar", Type: "cc_library", Variant: "linux_glibc_x86_64", Dir: "b"},
+			{Id: 5, Name: "libunused", Type: "cc_library", Dir: "c"},
+		},
+	}
+	buf := &bytes.Buffer{}
+	if err := g.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	g, err := ReadGraph(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func runQuery(t *testing.T, g *Graph, query string) *Result {
+	t.Helper()
+	e, err := Parse(query)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %s", query, err)
+	}
+	r, err := Evaluate(g, e)
+	if err != nil {
+		t.Fatalf("failed to evaluate %q: %s", query, err)
+	}
+	return r
+}
+
+func resultStrings(r *Result) []string {
+	var strs []string
+	for _, m := range r.Modules {
+		strs = append(strs, m.String())
+	}
+	return strs
+}
+
+func TestEvaluate(t *testing.T) {
+	g := testGraph(t)
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "libbar",
+			want:  []string{"//b:libbar android_arm64", "//b:libbar linux_glibc_x86_64"},
+		},
+		{
+			query: "libbar@linux*",
+			want:  []string{"//b:libbar linux_glibc_x86_64"},
+		},
+		{
+			query: "//a/...",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64"},
+		},
+		{
+			query: "//b:lib*",
+			want:  []string{"//b:libbar android_arm64", "//b:libbar linux_glibc_x86_64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "deps(bin)",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64", "//b:libbar android_arm64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "deps(bin, 1)",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "rdeps(libbar@android_arm64)",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64", "//b:libbar android_arm64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "rdeps(libbar) - libbar",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "allpaths(bin, libbar)",
+			want:  []string{"//a/foo:libfoo android_arm64", "//a:bin android_arm64", "//b:libbar android_arm64", "//b:libbaz android_arm64"},
+		},
+		{
+			query: "allpaths(libfoo, libbaz)",
+			want:  nil,
+		},
+		{
+			query: "filter(install, '/system/lib64/libfoo\\.so$', //...)",
+			want:  []string{"//a/foo:libfoo android_arm64"},
+		},
+		{
+			query: "filter(type, static, //...) union libunused",
+			want:  []string{"//b:libbaz android_arm64", "//c:libunused"},
+		},
+		{
+			query: "deps(bin) ^ (libfoo + libunused)",
+			want:  []string{"//a/foo:libfoo android_arm64"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			if got := resultStrings(runQuery(t, g, tc.query)); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSomePath(t *testing.T) {
+	g := testGraph(t)
+	r := runQuery(t, g, "somepath(bin, libbar)")
+	if !r.Path {
+		t.Errorf("expected a path")
+	}
+	want := []string{"//a:bin android_arm64", "//a/foo:libfoo android_arm64", "//b:libbar android_arm64"}
+	if got := resultStrings(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if r := runQuery(t, g, "somepath(libbar, bin)"); len(r.Modules) != 0 {
+		t.Errorf("expected no path, got %q", resultStrings(r))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		query string
+		err   string
+	}{
+		{query: "deps(libfoo", err: "offset 11: expected ')', got end of query"},
+		{query: "deps(libfoo, x)", err: `offset 13: expected a depth, got "x"`},
+		{query: "foo(libfoo)", err: `offset 0: unknown function "foo"`},
+		{query: "filter(size, x, libfoo)", err: `offset 7: expected one of the fields dir, install, label, name, output, packaged, type, variant, got "size"`},
+		{query: "filter(name, '(', libfoo)", err: "offset 13: error parsing regexp"},
+		{query: "libfoo libbar", err: `offset 7: unexpected "libbar"`},
+		{query: "//a/b", err: `offset 0: expected //<dir>:<name> or //<dir>/..., got "//a/b"`},
+		{query: "'libfoo", err: "offset 0: unterminated quoted word"},
+		{query: "libfoo +", err: "offset 8: expected an expression, got end of query"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := Parse(tc.query)
+			if err == nil {
+				t.Fatalf("expected error %q", tc.err)
+			}
+			if !strings.HasPrefix(err.Error(), tc.err) {
+				t.Errorf("expected error %q, got %q", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestUnmatchedPattern(t *testing.T) {
+	e, err := Parse("deps(libmissing)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Evaluate(testGraph(t), e); err == nil || err.Error() != `no modules match "libmissing"` {
+		t.Errorf("expected no modules to match, got %v", err)
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	g := testGraph(t)
+
+	buf := &bytes.Buffer{}
+	if err := WriteText(buf, runQuery(t, g, "somepath(bin, libbar)")); err != nil {
+		t.Fatal(err)
+	}
+	wantText := strings.Join([]string{
+		"//a:bin android_arm64",
+		"  depends on (shared)",
+		"//a/foo:libfoo android_arm64",
+		"  depends on (shared)",
+		"//b:libbar android_arm64",
+		"",
+	}, "\n")
+	if buf.String() != wantText {
+		t.Errorf("expected text output:\n%s\ngot:\n%s", wantText, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteDot(buf, runQuery(t, g, "deps(libfoo)")); err != nil {
+		t.Fatal(err)
+	}
+	wantDot := strings.Join([]string{
+		"digraph {",
+		`  m1 [label="//a/foo:libfoo\nandroid_arm64"];`,
+		`  m2 [label="//b:libbar\nandroid_arm64"];`,
+		`  m1 -> m2 [label="shared"];`,
+		"}",
+		"",
+	}, "\n")
+	if buf.String() != wantDot {
+		t.Errorf("expected dot output:\n%s\ngot:\n%s", wantDot, buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJSON(buf, runQuery(t, g, "libfoo + libunused")); err != nil {
+		t.Fatal(err)
+	}
+	var modules []Module
+	if err := json.Unmarshal(buf.Bytes(), &modules); err != nil {
+		t.Fatal(err)
+	}
+	// The modules keep their ids in the graph, and only the dependencies within the result.
+	if len(modules) != 2 || modules[0].Id != 1 || modules[1].Id != 5 || len(modules[0].Deps) != 0 {
+		t.Errorf("expected libfoo and libunused without dependencies, got:\n%s", buf.String())
+	}
+}
+
+func TestReadGraphErrors(t *testing.T) {
+	testCases := []struct {
+		json string
+		err  string
+	}{
+		{json: `{"version": 2}`, err: "unsupported module graph version 2, expected 1"},
+		{json: `{"version": 1, "modules": [{"id": 0, "name": "a", "dir": "a", "deps": [{"module": 1}]}]}`,
+			err: "module //a:a depends on unknown module 1"},
+		{json: `{`, err: "failed to parse module graph: unexpected EOF"},
+	}
+	for _, tc := range testCases {
+		_, err := ReadGraph(strings.NewReader(tc.json))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+}
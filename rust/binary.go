@@ -150,7 +150,10 @@ func (binary *binaryDecorator) compile(ctx ModuleContext, flags Flags, deps Path
 	}
 	binary.baseCompiler.unstrippedOutputFile = outputFile
 
-	ret.kytheFile = TransformSrcToBinary(ctx, srcPath, deps, flags, outputFile).kytheFile
+	crateOutput := TransformSrcToBinary(ctx, srcPath, deps, flags, outputFile)
+	ret.kytheFile = crateOutput.kytheFile
+	ret.clippyLog = crateOutput.clippyLog
+	ret.rustcCommand = crateOutput.rustcCommand
 	return ret
 }
 
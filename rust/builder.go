@@ -61,11 +61,12 @@ var (
 	_            = pctx.SourcePathVariable("clippyCmd", "${config.RustBin}/clippy-driver")
 	clippyDriver = pctx.AndroidStaticRule("clippy",
 		blueprint.RuleParams{
-			Command: "$envVars $clippyCmd " +
+			// The diagnostics are also saved to $out.log so that they can be converted to SARIF.
+			Command: "($envVars $clippyCmd " +
 				// Because clippy-driver uses rustc as backend, we need to have some output even during the linting.
 				// Use the metadata output as it has the smallest footprint.
 				"--emit metadata -o $out --emit dep-info=$out.d.raw $in ${libFlags} " +
-				"$rustcFlags $clippyFlags" +
+				"$rustcFlags $clippyFlags 2>$out.log; EXITCODE=$$?; cat $out.log >&2; exit $$EXITCODE)" +
 				" && grep \"^$out:\" $out.d.raw > $out.d",
 			CommandDeps: []string{"$clippyCmd"},
 			Deps:        blueprint.DepsGCC,
@@ -116,8 +117,10 @@ var (
 )
 
 type buildOutput struct {
-	outputFile android.Path
-	kytheFile  android.Path
+	outputFile   android.Path
+	kytheFile    android.Path
+	clippyLog    android.Path
+	rustcCommand rustcCommand
 }
 
 func init() {
@@ -334,12 +337,14 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 
 	if flags.Clippy {
 		clippyFile := android.PathForModuleOut(ctx, outputFile.Base()+".clippy")
+		clippyLog := android.PathForModuleOut(ctx, outputFile.Base()+".clippy.log")
 		ctx.Build(pctx, android.BuildParams{
-			Rule:        clippyDriver,
-			Description: "clippy " + main.Rel(),
-			Output:      clippyFile,
-			Inputs:      inputs,
-			Implicits:   implicits,
+			Rule:           clippyDriver,
+			Description:    "clippy " + main.Rel(),
+			Output:         clippyFile,
+			ImplicitOutput: clippyLog,
+			Inputs:         inputs,
+			Implicits:      implicits,
 			Args: map[string]string{
 				"rustcFlags":  strings.Join(rustcFlags, " "),
 				"libFlags":    strings.Join(libFlags, " "),
@@ -349,6 +354,7 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 		})
 		// Declare the clippy build as an implicit dependency of the original crate.
 		implicits = append(implicits, clippyFile)
+		output.clippyLog = clippyLog
 	}
 
 	rustcOutputFile := outputFile
@@ -402,6 +408,12 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 		})
 		output.kytheFile = kytheFile
 	}
+
+	output.rustcCommand = rustcCommand{
+		crateRoot:  main,
+		rustcFlags: append(append([]string(nil), rustcFlags...), libFlags...),
+		output:     rustcOutputFile,
+	}
 	return output
 }
 
@@ -0,0 +1,103 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"android/soong/android"
+	"android/soong/cc"
+)
+
+func init() {
+	android.RegisterIdeInfoContributor(rustIdeInfoContributor)
+}
+
+// rustcCommand records the rustc command that compiles the crate of a module.
+type rustcCommand struct {
+	crateRoot  android.Path
+	rustcFlags []string
+	output     android.Path
+}
+
+// rustIdeInfoContributor fills in the IDE model of rust modules.
+func rustIdeInfoContributor(ctx android.IdeInfoContext, module android.Module, info *android.IdeModuleInfo) bool {
+	rModule, comp, ok := isModuleSupported(module)
+	if !ok {
+		return false
+	}
+
+	info.Languages = append(info.Languages, "rust")
+
+	ctx.VisitDirectDeps(module, func(dep android.Module) {
+		// Skip intra-module dependencies (i.e., generated-source library depending on the source variant).
+		if dep.Name() == module.Name() {
+			return
+		}
+		if _, _, ok := isModuleSupported(dep); ok {
+			info.Deps = append(info.Deps, ctx.IdeModuleName(dep))
+		}
+	})
+
+	if rModule.outputFile.Valid() {
+		info.Outputs = append(info.Outputs, rModule.outputFile.String())
+	}
+	if _, ok := rModule.compiler.(*testDecorator); ok && rModule.Host() && rModule.UnstrippedOutputFile() != nil {
+		info.Host_test_binaries = append(info.Host_test_binaries, rModule.UnstrippedOutputFile().String())
+	}
+
+	crateRoot := rModule.rustcCommand.crateRoot
+	if crateRoot == nil {
+		return true
+	}
+	if _, generated := crateRoot.(android.WritablePath); generated {
+		info.Generated_srcs = append(info.Generated_srcs, crateRoot.String())
+	} else {
+		info.Srcs = append(info.Srcs, crateRoot.String())
+	}
+
+	// The crate roots of source provider libraries are generated for each variant.  Use the variant
+	// of the primary device target, or of the build OS for host modules, and the first variant that
+	// compiles the crate otherwise.
+	preferredTarget := ctx.Config().AndroidFirstDeviceTarget.String()
+	switch rModule.hod {
+	case android.HostSupported, android.HostSupportedNoCross:
+		preferredTarget = ctx.Config().BuildOSTarget.String()
+	}
+	if info.Rust != nil && (info.Rust.Target == preferredTarget || rModule.Target().String() != preferredTarget) {
+		return true
+	}
+
+	_, procMacro := rModule.compiler.(*procMacroDecorator)
+	rustInfo := &android.IdeRustInfo{
+		Target:      rModule.Target().String(),
+		Crate_name:  rModule.CrateName(),
+		Crate_root:  crateRoot.String(),
+		Edition:     comp.edition(),
+		Features:    comp.Properties.Features,
+		Proc_macro:  procMacro,
+		Rustc:       "/bin/false",
+		Rustc_flags: cc.EvalAndSplitArgs(ctx, pctx, rModule.rustcCommand.rustcFlags),
+	}
+	if comp.CargoOutDir().Valid() {
+		rustInfo.Out_dir = comp.CargoOutDir().String()
+	}
+	if rustBin, err := ctx.Eval(pctx, "${config.RustBin}"); err == nil {
+		rustInfo.Rustc = rustBin + "/rustc"
+	}
+	if rModule.rustcCommand.output != nil {
+		rustInfo.Output = rModule.rustcCommand.output.String()
+	}
+	info.Rust = rustInfo
+	return true
+}
@@ -17,7 +17,6 @@ package rust
 import (
 	"encoding/json"
 	"fmt"
-	"path"
 
 	"android/soong/android"
 )
@@ -29,6 +28,9 @@ import (
 // For example,
 //
 //   $ SOONG_GEN_RUST_PROJECT=1 m nothing
+//
+// The project is a view of the Rust section of the model of the modules collected by
+// android.CollectIdeModuleInfos.
 
 const (
 	// Environment variables used to control the behavior of this singleton.
@@ -58,16 +60,7 @@ type rustProjectJson struct {
 	Crates []rustProjectCrate `json:"crates"`
 }
 
-// crateInfo is used during the processing to keep track of the known crates.
-type crateInfo struct {
-	Idx  int            // Index of the crate in rustProjectJson.Crates slice.
-	Deps map[string]int // The keys are the module names and not the crate names.
-}
-
-type projectGeneratorSingleton struct {
-	project     rustProjectJson
-	knownCrates map[string]crateInfo // Keys are module names.
-}
+type projectGeneratorSingleton struct{}
 
 func rustProjectGeneratorSingleton() android.Singleton {
 	return &projectGeneratorSingleton{}
@@ -77,123 +70,9 @@ func init() {
 	android.RegisterSingletonType("rust_project_generator", rustProjectGeneratorSingleton)
 }
 
-// sourceProviderVariantSource returns the path to the source file if this
-// module variant should be used as a priority.
-//
-// SourceProvider modules may have multiple variants considered as source
-// (e.g., x86_64 and armv8). For a module available on device, use the source
-// generated for the target. For a host-only module, use the source generated
-// for the host.
-func sourceProviderVariantSource(ctx android.SingletonContext, rModule *Module) (string, bool) {
-	rustLib, ok := rModule.compiler.(*libraryDecorator)
-	if !ok {
-		return "", false
-	}
-	if rustLib.source() {
-		switch rModule.hod {
-		case android.HostSupported, android.HostSupportedNoCross:
-			if rModule.Target().String() == ctx.Config().BuildOSTarget.String() {
-				src := rustLib.sourceProvider.Srcs()[0]
-				return src.String(), true
-			}
-		default:
-			if rModule.Target().String() == ctx.Config().AndroidFirstDeviceTarget.String() {
-				src := rustLib.sourceProvider.Srcs()[0]
-				return src.String(), true
-			}
-		}
-	}
-	return "", false
-}
-
-// sourceProviderSource finds the main source file of a source-provider crate.
-func sourceProviderSource(ctx android.SingletonContext, rModule *Module) (string, bool) {
-	rustLib, ok := rModule.compiler.(*libraryDecorator)
-	if !ok {
-		return "", false
-	}
-	if rustLib.source() {
-		// This is a source-variant, check if we are the right variant
-		// depending on the module configuration.
-		if src, ok := sourceProviderVariantSource(ctx, rModule); ok {
-			return src, true
-		}
-	}
-	foundSource := false
-	sourceSrc := ""
-	// Find the variant with the source and return its.
-	ctx.VisitAllModuleVariants(rModule, func(variant android.Module) {
-		if foundSource {
-			return
-		}
-		// All variants of a source provider library are libraries.
-		rVariant, _ := variant.(*Module)
-		variantLib, _ := rVariant.compiler.(*libraryDecorator)
-		if variantLib.source() {
-			sourceSrc, ok = sourceProviderVariantSource(ctx, rVariant)
-			if ok {
-				foundSource = true
-			}
-		}
-	})
-	if !foundSource {
-		ctx.Errorf("No valid source for source provider found: %v\n", rModule)
-	}
-	return sourceSrc, foundSource
-}
-
-// crateSource finds the main source file (.rs) for a crate.
-func crateSource(ctx android.SingletonContext, rModule *Module, comp *baseCompiler) (string, bool) {
-	// Basic libraries, executables and tests.
-	srcs := comp.Properties.Srcs
-	if len(srcs) != 0 {
-		return path.Join(ctx.ModuleDir(rModule), srcs[0]), true
-	}
-	// SourceProvider libraries.
-	if rModule.sourceProvider != nil {
-		return sourceProviderSource(ctx, rModule)
-	}
-	return "", false
-}
-
-// mergeDependencies visits all the dependencies for module and updates crate and deps
-// with any new dependency.
-func (singleton *projectGeneratorSingleton) mergeDependencies(ctx android.SingletonContext,
-	module *Module, crate *rustProjectCrate, deps map[string]int) {
-
-	ctx.VisitDirectDeps(module, func(child android.Module) {
-		// Skip intra-module dependencies (i.e., generated-source library depending on the source variant).
-		if module.Name() == child.Name() {
-			return
-		}
-		// Skip unsupported modules.
-		rChild, compChild, ok := isModuleSupported(ctx, child)
-		if !ok {
-			return
-		}
-		// For unknown dependency, add it first.
-		var childId int
-		cInfo, known := singleton.knownCrates[rChild.Name()]
-		if !known {
-			childId, ok = singleton.addCrate(ctx, rChild, compChild)
-			if !ok {
-				return
-			}
-		} else {
-			childId = cInfo.Idx
-		}
-		// Is this dependency known already?
-		if _, ok = deps[child.Name()]; ok {
-			return
-		}
-		crate.Deps = append(crate.Deps, rustProjectDep{Crate: childId, Name: rChild.CrateName()})
-		deps[child.Name()] = childId
-	})
-}
-
 // isModuleSupported returns the RustModule and baseCompiler if the module
 // should be considered for inclusion in rust-project.json.
-func isModuleSupported(ctx android.SingletonContext, module android.Module) (*Module, *baseCompiler, bool) {
+func isModuleSupported(module android.Module) (*Module, *baseCompiler, bool) {
 	rModule, ok := module.(*Module)
 	if !ok {
 		return nil, nil, false
@@ -219,88 +98,81 @@ func isModuleSupported(ctx android.SingletonContext, module android.Module) (*Mo
 	return rModule, comp, true
 }
 
-// addCrate adds a crate to singleton.project.Crates ensuring that required
-// dependencies are also added. It returns the index of the new crate in
-// singleton.project.Crates
-func (singleton *projectGeneratorSingleton) addCrate(ctx android.SingletonContext, rModule *Module, comp *baseCompiler) (int, bool) {
-	rootModule, ok := crateSource(ctx, rModule, comp)
-	if !ok {
-		ctx.Errorf("Unable to find source for valid module: %v", rModule)
-		return 0, false
+func (singleton *projectGeneratorSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	if !ctx.Config().IsEnvTrue(envVariableCollectRustDeps) {
+		return
 	}
 
-	_, procMacro := rModule.compiler.(*procMacroDecorator)
-
-	crate := rustProjectCrate{
-		DisplayName: rModule.Name(),
-		RootModule:  rootModule,
-		Edition:     comp.edition(),
-		Deps:        make([]rustProjectDep, 0),
-		Cfg:         make([]string, 0),
-		Env:         make(map[string]string),
-		ProcMacro:   procMacro,
+	infos := android.CollectIdeModuleInfos(ctx)
+	var modules []string
+	for _, name := range android.SortedStringKeys(infos) {
+		if rustInfo := infos[name].Rust; rustInfo != nil && rustInfo.Crate_root != "" {
+			modules = append(modules, name)
+		}
 	}
 
-	if comp.CargoOutDir().Valid() {
-		crate.Env["OUT_DIR"] = comp.CargoOutDir().String()
+	path := android.PathForOutput(ctx, rustProjectJsonFileName)
+	buf, err := ProjectJson(infos, modules)
+	if err == nil {
+		err = android.WriteFileToOutputDir(path, buf, 0666)
 	}
-
-	for _, feature := range comp.Properties.Features {
-		crate.Cfg = append(crate.Cfg, "feature=\""+feature+"\"")
+	if err != nil {
+		ctx.Errorf("Writing rust-project to %s failed: %s", path.String(), err)
 	}
-
-	deps := make(map[string]int)
-	singleton.mergeDependencies(ctx, rModule, &crate, deps)
-
-	idx := len(singleton.project.Crates)
-	singleton.knownCrates[rModule.Name()] = crateInfo{Idx: idx, Deps: deps}
-	singleton.project.Crates = append(singleton.project.Crates, crate)
-	return idx, true
 }
 
-// appendCrateAndDependencies creates a rustProjectCrate for the module argument and appends it to singleton.project.
-// It visits the dependencies of the module depth-first so the dependency ID can be added to the current module. If the
-// current module is already in singleton.knownCrates, its dependencies are merged.
-func (singleton *projectGeneratorSingleton) appendCrateAndDependencies(ctx android.SingletonContext, module android.Module) {
-	rModule, comp, ok := isModuleSupported(ctx, module)
-	if !ok {
-		return
-	}
-	// If we have seen this crate already; merge any new dependencies.
-	if cInfo, ok := singleton.knownCrates[module.Name()]; ok {
-		crate := singleton.project.Crates[cInfo.Idx]
-		singleton.mergeDependencies(ctx, rModule, &crate, cInfo.Deps)
-		singleton.project.Crates[cInfo.Idx] = crate
-		return
-	}
-	singleton.addCrate(ctx, rModule, comp)
-}
+// ProjectJson returns the contents of a rust-project.json with the crates of the given modules of
+// the IDE model, and the crates they depend on.  The crates come after the crates they depend on.
+func ProjectJson(infos android.IdeModuleInfos, modules []string) ([]byte, error) {
+	project := rustProjectJson{Crates: []rustProjectCrate{}}
+	crateIdx := make(map[string]int)
+	visiting := make(map[string]bool)
 
-func (singleton *projectGeneratorSingleton) GenerateBuildActions(ctx android.SingletonContext) {
-	if !ctx.Config().IsEnvTrue(envVariableCollectRustDeps) {
-		return
-	}
+	var addCrate func(name string) (int, bool)
+	addCrate = func(name string) (int, bool) {
+		if idx, ok := crateIdx[name]; ok {
+			return idx, true
+		}
+		info := infos[name]
+		if info == nil || info.Rust == nil || info.Rust.Crate_root == "" || visiting[name] {
+			return 0, false
+		}
+		visiting[name] = true
+
+		crate := rustProjectCrate{
+			DisplayName: info.Module_name,
+			RootModule:  info.Rust.Crate_root,
+			Edition:     info.Rust.Edition,
+			Deps:        make([]rustProjectDep, 0),
+			Cfg:         make([]string, 0),
+			Env:         make(map[string]string),
+			ProcMacro:   info.Rust.Proc_macro,
+		}
+		if info.Rust.Out_dir != "" {
+			crate.Env["OUT_DIR"] = info.Rust.Out_dir
+		}
+		for _, feature := range info.Rust.Features {
+			crate.Cfg = append(crate.Cfg, "feature=\""+feature+"\"")
+		}
+		for _, dep := range info.Deps {
+			if depIdx, ok := addCrate(dep); ok {
+				crate.Deps = append(crate.Deps, rustProjectDep{Crate: depIdx, Name: infos[dep].Rust.Crate_name})
+			}
+		}
 
-	singleton.knownCrates = make(map[string]crateInfo)
-	ctx.VisitAllModules(func(module android.Module) {
-		singleton.appendCrateAndDependencies(ctx, module)
-	})
+		idx := len(project.Crates)
+		crateIdx[name] = idx
+		project.Crates = append(project.Crates, crate)
+		return idx, true
+	}
 
-	path := android.PathForOutput(ctx, rustProjectJsonFileName)
-	err := createJsonFile(singleton.project, path)
-	if err != nil {
-		ctx.Errorf(err.Error())
+	for _, module := range modules {
+		addCrate(module)
 	}
-}
 
-func createJsonFile(project rustProjectJson, rustProjectPath android.WritablePath) error {
 	buf, err := json.MarshalIndent(project, "", "  ")
 	if err != nil {
-		return fmt.Errorf("JSON marshal of rustProjectJson failed: %s", err)
-	}
-	err = android.WriteFileToOutputDir(rustProjectPath, buf, 0666)
-	if err != nil {
-		return fmt.Errorf("Writing rust-project to %s failed: %s", rustProjectPath.String(), err)
+		return nil, fmt.Errorf("JSON marshal of rustProjectJson failed: %s", err)
 	}
-	return nil
+	return buf, nil
 }
@@ -349,3 +349,60 @@ func TestProjectJsonMultiVersion(t *testing.T) {
 	}
 	t.Errorf("libb crate has not been found: %v", crates)
 }
+
+func TestProjectJsonForIdeModules(t *testing.T) {
+	infos := android.IdeModuleInfos{
+		"libfoo": {
+			Name:        "libfoo",
+			Module_name: "libfoo",
+			Deps:        []string{"//vendor/bar:libbar", "libcc"},
+			Rust:        &android.IdeRustInfo{Crate_name: "foo", Crate_root: "foo/lib.rs", Edition: "2021"},
+		},
+		"//vendor/bar:libbar": {
+			Name:        "//vendor/bar:libbar",
+			Module_name: "libbar",
+			Deps:        []string{"libfoo"},
+			Rust:        &android.IdeRustInfo{Crate_name: "bar", Crate_root: "bar/lib.rs", Features: []string{"std"}},
+		},
+		"libcc": {
+			Name: "libcc",
+			Cc:   []*android.IdeCcInfo{{}},
+		},
+		"libunrelated": {
+			Name: "libunrelated",
+			Rust: &android.IdeRustInfo{Crate_name: "unrelated", Crate_root: "unrelated/lib.rs"},
+		},
+	}
+
+	content, err := ProjectJson(infos, []string{"libfoo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crates := validateJsonCrates(t, content)
+	if len(crates) != 2 {
+		t.Fatalf("expected 2 crates, got %d: %s", len(crates), content)
+	}
+
+	// The dependencies come before the crates that depend on them.
+	bar := validateCrate(t, crates[0])
+	if bar["display_name"] != "libbar" || bar["root_module"] != "bar/lib.rs" {
+		t.Errorf("unexpected first crate %v", bar)
+	}
+	if cfg := bar["cfg"].([]interface{}); len(cfg) != 1 || cfg[0] != "feature=\"std\"" {
+		t.Errorf("unexpected cfg for libbar %v", cfg)
+	}
+	if deps := validateDependencies(t, bar); len(deps) != 0 {
+		t.Errorf("expected no dependencies for libbar, the cycle to libfoo is dropped, got %v", deps)
+	}
+
+	foo := validateCrate(t, crates[1])
+	if foo["display_name"] != "libfoo" || foo["edition"] != "2021" {
+		t.Errorf("unexpected second crate %v", foo)
+	}
+	if deps := validateDependencies(t, foo); len(deps) != 1 || deps[0] != "bar" {
+		t.Errorf("unexpected dependencies for libfoo %v", deps)
+	}
+	if dep := foo["deps"].([]interface{})[0].(map[string]interface{}); dep["crate"] != float64(0) {
+		t.Errorf("expected libfoo to depend on crate 0, got %v", dep["crate"])
+	}
+}
@@ -161,6 +161,9 @@ type Module struct {
 
 	docTimestampFile android.OptionalPath
 
+	// The crate root and flags of the rustc command, for the IDE model.
+	rustcCommand rustcCommand
+
 	hideApexVariantFromMake bool
 
 	// For apex variants, this is set as apex.min_sdk_version
@@ -968,6 +971,10 @@ func (mod *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		if buildOutput.kytheFile != nil {
 			mod.kytheFiles = append(mod.kytheFiles, buildOutput.kytheFile)
 		}
+		mod.rustcCommand = buildOutput.rustcCommand
+		android.SetAnalysisFindingsProvider(ctx, android.AnalysisFindingsInfo{
+			Clippy: android.PathsIfNonNil(buildOutput.clippyLog),
+		})
 		bloaty.MeasureSizeForPaths(ctx, mod.compiler.strippedOutputFilePath(), android.OptionalPathForPath(mod.compiler.unstrippedOutputFilePath()))
 
 		mod.docTimestampFile = mod.compiler.rustdoc(ctx, flags, deps)
@@ -1005,6 +1012,11 @@ func (mod *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 			}
 		}
 
+		// Attribute the sizes of the installed executables and shared libraries to their symbols.
+		if (mod.Binary() || mod.Shared() || mod.Dylib()) && cc.InstalledVariant(mod, apexInfo) {
+			bloaty.MeasureSymbolSizesForPaths(ctx, android.OptionalPathForPath(mod.compiler.unstrippedOutputFilePath()))
+		}
+
 		ctx.Phony("rust", ctx.RustModule().OutputFile().Path())
 	}
 }
@@ -480,6 +480,36 @@ func TestLibrarySizes(t *testing.T) {
 	m.Output("libwaldo.dylib.so.bloaty.csv")
 }
 
+// Test that the symbol sizes of binaries and shared libraries are measured from their unstripped
+// outputs.
+func TestSymbolSizes(t *testing.T) {
+	ctx := testRust(t, `
+		rust_library_dylib {
+			name: "libwaldo",
+			srcs: ["foo.rs"],
+			crate_name: "waldo",
+		}
+		rust_library_rlib {
+			name: "libplugh",
+			srcs: ["foo.rs"],
+			crate_name: "plugh",
+		}`)
+
+	m := ctx.SingletonForTests("file_metrics")
+	symbols := m.Output("unstripped/libwaldo.dylib.so.bloaty.symbols.csv")
+	android.AssertStringDoesContain(t, "symbols input", symbols.Input.String(), "unstripped/libwaldo.dylib.so")
+	if m.MaybeOutput("libwaldo.dylib.so.bloaty.symbols.csv").Rule != nil {
+		t.Errorf("unexpected symbol sizes of the stripped output")
+	}
+
+	manifest := android.ContentFromFileRuleForTests(t, m.Output("binary_symbol_sizes.lst"))
+	android.AssertStringDoesContain(t, "manifest", manifest, "\tlibwaldo\t.\tsystem\t\n")
+	android.AssertStringDoesNotContain(t, "manifest", manifest, "libplugh")
+
+	merger := m.Output("binary_symbol_sizes.pb.gz")
+	android.AssertPathRelativeToTopEquals(t, "merger input", "out/soong/binary_symbol_sizes.lst", merger.Input)
+}
+
 func assertString(t *testing.T, got, expected string) {
 	t.Helper()
 	if got != expected {
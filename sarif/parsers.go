@@ -0,0 +1,163 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The names of the tools whose text output can be converted to SARIF.
+const (
+	ToolClangTidy  = "clang-tidy"
+	ToolClippy     = "clippy"
+	ToolErrorProne = "errorprone"
+)
+
+// Parsers maps the name of a tool to the function that parses its text output into results.
+var Parsers = map[string]func(r io.Reader) ([]Result, error){
+	ToolClangTidy:  ParseClangTidy,
+	ToolClippy:     ParseClippy,
+	ToolErrorProne: ParseErrorProne,
+}
+
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 2*1024*1024)
+	return scanner
+}
+
+func newResult(ruleId, level, message, file string, line, column int) Result {
+	return Result{
+		RuleId:    ruleId,
+		Level:     level,
+		Message:   Message{message},
+		Locations: []Location{NewLocation(file, line, column)},
+	}
+}
+
+// clang-tidy reports diagnostics like clang, with the name of the check at the end:
+//
+//	system/foo/foo.cpp:12:5: warning: use nullptr [modernize-use-nullptr]
+//
+// Checks that are treated as errors have ",-warnings-as-errors" appended to the name.
+var clangTidyDiagRe = regexp.MustCompile(`^(.+?):(\d+):(\d+): (warning|error): (.*) \[([^\]]+)\]$`)
+
+// ParseClangTidy parses the output of clang-tidy.
+func ParseClangTidy(r io.Reader) ([]Result, error) {
+	var results []Result
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		match := clangTidyDiagRe.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		check := strings.Split(match[6], ",")[0]
+		results = append(results, newResult(check, match[4], match[5], match[1], line, column))
+	}
+	return results, scanner.Err()
+}
+
+// rustc and clippy report diagnostics over multiple lines:
+//
+//	warning: this `if` has identical blocks
+//	  --> system/foo/src/lib.rs:10:5
+//	   |
+//	...
+//	   = note: `#[warn(clippy::if_same_then_else)]` on by default
+//	   = help: for further information visit https://rust-lang.github.io/rust-clippy/master/index.html#if_same_then_else
+var (
+	rustDiagRe     = regexp.MustCompile(`^(warning|error)(?:\[(\w+)\])?: (.*)$`)
+	rustLocationRe = regexp.MustCompile(`^\s*--> (.+?):(\d+):(\d+)$`)
+	rustLintRe     = regexp.MustCompile("`#\\[(?:warn|deny|forbid)\\(([\\w:]+)\\)\\]`|`-[DW] ([\\w:-]+)`|index\\.html#(\\w+)")
+)
+
+// ParseClippy parses the human readable output of clippy-driver, which also includes the warnings
+// and errors of rustc.
+func ParseClippy(r io.Reader) ([]Result, error) {
+	var results []Result
+	var current *Result
+	flush := func() {
+		if current != nil && len(current.Locations) > 0 {
+			results = append(results, *current)
+		}
+		current = nil
+	}
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if match := rustDiagRe.FindStringSubmatch(text); match != nil {
+			flush()
+			current = &Result{RuleId: match[2], Level: match[1], Message: Message{match[3]}}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := rustLocationRe.FindStringSubmatch(text); match != nil {
+			if len(current.Locations) == 0 {
+				line, _ := strconv.Atoi(match[2])
+				column, _ := strconv.Atoi(match[3])
+				current.Locations = []Location{NewLocation(match[1], line, column)}
+			}
+			continue
+		}
+		if current.RuleId == "" {
+			if match := rustLintRe.FindStringSubmatch(text); match != nil {
+				lint := match[1] + match[2]
+				if match[3] != "" {
+					lint = "clippy::" + match[3]
+				}
+				current.RuleId = strings.ReplaceAll(lint, "-", "_")
+			}
+		}
+	}
+	flush()
+
+	// Diagnostics without a lint or error code, e.g. from #[deprecated], are reported as rustc
+	// warnings.
+	for i := range results {
+		if results[i].RuleId == "" {
+			results[i].RuleId = "rustc"
+		}
+	}
+	return results, scanner.Err()
+}
+
+// javac reports Error Prone findings, and the javac lints, with the name of the check in brackets:
+//
+//	frameworks/foo/Foo.java:12: warning: [MissingOverride] bar implements method in Baz
+var javacDiagRe = regexp.MustCompile(`^(.+\.java):(\d+): (warning|error): \[([\w-]+)\] (.*)$`)
+
+// ParseErrorProne parses the output of javac running Error Prone.
+func ParseErrorProne(r io.Reader) ([]Result, error) {
+	var results []Result
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		match := javacDiagRe.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		results = append(results, newResult(match[4], match[3], match[5], match[1], line, 0))
+	}
+	return results, scanner.Err()
+}
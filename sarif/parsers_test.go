@@ -0,0 +1,105 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsers(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tool   string
+		output string
+		want   []Result
+	}{
+		{
+			name: "clang-tidy",
+			tool: ToolClangTidy,
+			output: `system/foo/foo.cpp:12:5: warning: use nullptr [modernize-use-nullptr]
+  int *p = 0;
+           ^
+           nullptr
+system/foo/foo.h:3:1: error: do not use 'else' after 'return' [readability-else-after-return,-warnings-as-errors]
+system/foo/foo.h:2:1: note: some note
+2 warnings generated.
+`,
+			want: []Result{
+				newResult("modernize-use-nullptr", "warning", "use nullptr", "system/foo/foo.cpp", 12, 5),
+				newResult("readability-else-after-return", "error", "do not use 'else' after 'return'", "system/foo/foo.h", 3, 1),
+			},
+		},
+		{
+			name: "clippy",
+			tool: ToolClippy,
+			output: "warning: this `if` has identical blocks\n" +
+				"  --> system/foo/src/lib.rs:10:5\n" +
+				"   |\n" +
+				"10 |     if x { 1 } else { 1 }\n" +
+				"   = note: `#[warn(clippy::if_same_then_else)]` on by default\n" +
+				"   = help: for further information visit https://rust-lang.github.io/rust-clippy/master/index.html#if_same_then_else\n" +
+				"\n" +
+				"error: unneeded `return` statement\n" +
+				"  --> system/foo/src/lib.rs:20:9\n" +
+				"   = note: `-D clippy::needless-return` implied by `-D warnings`\n" +
+				"\n" +
+				"error[E0308]: mismatched types\n" +
+				" --> system/foo/src/main.rs:3:18\n" +
+				"  |\n" +
+				"\n" +
+				"warning: use of deprecated function `foo`\n" +
+				" --> system/foo/src/main.rs:5:1\n" +
+				"\n" +
+				"warning: 1 warning emitted\n",
+			want: []Result{
+				newResult("clippy::if_same_then_else", "warning", "this `if` has identical blocks", "system/foo/src/lib.rs", 10, 5),
+				newResult("clippy::needless_return", "error", "unneeded `return` statement", "system/foo/src/lib.rs", 20, 9),
+				newResult("E0308", "error", "mismatched types", "system/foo/src/main.rs", 3, 18),
+				newResult("rustc", "warning", "use of deprecated function `foo`", "system/foo/src/main.rs", 5, 1),
+			},
+		},
+		{
+			name: "errorprone",
+			tool: ToolErrorProne,
+			output: `frameworks/foo/Foo.java:12: warning: [MissingOverride] bar implements method in Baz
+  public void bar() {}
+              ^
+    (see https://errorprone.info/bugpattern/MissingOverride)
+frameworks/foo/Foo.java:20: error: [DeadException] Exception created but not thrown
+frameworks/foo/Foo.java:30: error: cannot find symbol
+1 error
+1 warning
+`,
+			want: []Result{
+				newResult("MissingOverride", "warning", "bar implements method in Baz", "frameworks/foo/Foo.java", 12, 0),
+				newResult("DeadException", "error", "Exception created but not thrown", "frameworks/foo/Foo.java", 20, 0),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parsers[tc.tool](strings.NewReader(tc.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected:\n%#v\ngot:\n%#v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sarif implements the subset of the Static Analysis Results Interchange Format (SARIF)
+// 2.1.0 that is used to report the findings of the build, along with a baseline mechanism that
+// classifies findings as new or already known.
+package sarif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// FingerprintKey is the key of the fingerprint computed by Fingerprint in the
+	// partialFingerprints of a result.
+	FingerprintKey = "soongFinding/v1"
+
+	// The values of the baselineState of a result after ApplyBaseline.
+	BaselineStateNew       = "new"
+	BaselineStateUnchanged = "unchanged"
+
+	// ModuleProperty is the key of the property of a result that holds the name of the module
+	// the result was reported for.
+	ModuleProperty = "module"
+)
+
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+type Rule struct {
+	Id               string  `json:"id"`
+	ShortDescription Message `json:"shortDescription"`
+	FullDescription  Message `json:"fullDescription"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleId              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             Message                `json:"message"`
+	Locations           []Location             `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	Uri       string `json:"uri"`
+	UriBaseId string `json:"uriBaseId,omitempty"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// NewLog returns a log with the given runs.
+func NewLog(runs ...Run) *Log {
+	if runs == nil {
+		runs = []Run{}
+	}
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs:    runs,
+	}
+}
+
+// NewRun returns an empty run for the named tool.
+func NewRun(tool string) Run {
+	return Run{
+		Tool:    Tool{Driver: Driver{Name: tool, Rules: []Rule{}}},
+		Results: []Result{},
+	}
+}
+
+// NewLocation returns the location of a line and column of a file.  A line or column of 0 means
+// that it is unknown.
+func NewLocation(file string, line, column int) Location {
+	location := Location{PhysicalLocation{ArtifactLocation: ArtifactLocation{Uri: file}}}
+	if line > 0 {
+		location.PhysicalLocation.Region = &Region{StartLine: line, StartColumn: column}
+	}
+	return location
+}
+
+// Read reads a log.
+func Read(r io.Reader) (*Log, error) {
+	var log Log
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// Write writes a log, indented so that it can be diffed.
+func (log *Log) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// AddRule adds a rule to the run if there is no rule with the same id yet.
+func (run *Run) AddRule(rule Rule) {
+	for _, r := range run.Tool.Driver.Rules {
+		if r.Id == rule.Id {
+			return
+		}
+	}
+	run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+}
+
+// AddResults adds results to the run, adding a rule for any rule id that the run doesn't have a
+// rule for yet.
+func (run *Run) AddResults(results ...Result) {
+	for _, result := range results {
+		run.AddRule(Rule{
+			Id:               result.RuleId,
+			ShortDescription: Message{result.RuleId},
+			FullDescription:  Message{result.RuleId},
+		})
+		run.Results = append(run.Results, result)
+	}
+}
+
+// Property returns the value of a string property of the result, or an empty string.
+func (result *Result) Property(key string) string {
+	s, _ := result.Properties[key].(string)
+	return s
+}
+
+// SetProperty sets a property of the result.
+func (result *Result) SetProperty(key, value string) {
+	if result.Properties == nil {
+		result.Properties = make(map[string]interface{})
+	}
+	result.Properties[key] = value
+}
+
+func (result *Result) uri() string {
+	if len(result.Locations) == 0 {
+		return ""
+	}
+	return result.Locations[0].PhysicalLocation.ArtifactLocation.Uri
+}
+
+func (result *Result) region() Region {
+	if len(result.Locations) == 0 || result.Locations[0].PhysicalLocation.Region == nil {
+		return Region{}
+	}
+	return *result.Locations[0].PhysicalLocation.Region
+}
+
+// Fingerprint returns a fingerprint of a result of a tool that stays the same when unrelated
+// changes move the result to another line.  It is computed from the tool, the rule, the file and
+// the message of the result, like the baselines of Android Lint.
+func Fingerprint(tool string, result Result) string {
+	message := strings.Join(strings.Fields(result.Message.Text), " ")
+	h := sha256.New()
+	for _, s := range []string{tool, result.RuleId, result.uri(), message} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// fingerprint returns the fingerprint of the result, computing it if the result doesn't have one.
+func (result *Result) fingerprint(tool string) string {
+	if fp := result.PartialFingerprints[FingerprintKey]; fp != "" {
+		return fp
+	}
+	if result.PartialFingerprints == nil {
+		result.PartialFingerprints = make(map[string]string)
+	}
+	fp := Fingerprint(tool, *result)
+	result.PartialFingerprints[FingerprintKey] = fp
+	return fp
+}
+
+// Merge merges logs into a single log with one run per tool, sorted by tool name.  Duplicate
+// results, e.g. the same finding in a header reported for multiple source files or multiple
+// variants of a module, are only kept once.
+func Merge(logs ...*Log) *Log {
+	runs := make(map[string]*Run)
+	seen := make(map[string]map[string]bool)
+	for _, log := range logs {
+		for _, run := range log.Runs {
+			tool := run.Tool.Driver.Name
+			merged := runs[tool]
+			if merged == nil {
+				r := NewRun(tool)
+				merged = &r
+				runs[tool] = merged
+				seen[tool] = make(map[string]bool)
+			}
+			for _, rule := range run.Tool.Driver.Rules {
+				merged.AddRule(rule)
+			}
+			for _, result := range run.Results {
+				region := result.region()
+				key := fmt.Sprintf("%s %s %d %d", result.fingerprint(tool), result.Property(ModuleProperty),
+					region.StartLine, region.StartColumn)
+				if seen[tool][key] {
+					continue
+				}
+				seen[tool][key] = true
+				merged.Results = append(merged.Results, result)
+			}
+		}
+	}
+
+	merged := NewLog()
+	tools := make([]string, 0, len(runs))
+	for tool := range runs {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		merged.Runs = append(merged.Runs, *runs[tool])
+	}
+	return merged
+}
+
+// ApplyBaseline sets the baselineState of every result of the log to BaselineStateUnchanged if
+// the baseline has a result with the same fingerprint, and to BaselineStateNew otherwise.  Each
+// result of the baseline matches at most one result of the log, so that adding another instance
+// of a known finding to a file is reported as new.
+func ApplyBaseline(log, baseline *Log) {
+	known := make(map[string]int)
+	for i := range baseline.Runs {
+		run := &baseline.Runs[i]
+		for j := range run.Results {
+			known[run.Results[j].fingerprint(run.Tool.Driver.Name)]++
+		}
+	}
+
+	for i := range log.Runs {
+		run := &log.Runs[i]
+		for j := range run.Results {
+			result := &run.Results[j]
+			fp := result.fingerprint(run.Tool.Driver.Name)
+			if known[fp] > 0 {
+				known[fp]--
+				result.BaselineState = BaselineStateUnchanged
+			} else {
+				result.BaselineState = BaselineStateNew
+			}
+		}
+	}
+}
+
+// NewResults returns the results of the log whose baselineState is BaselineStateNew.
+func NewResults(log *Log) []Result {
+	var results []Result
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			if result.BaselineState == BaselineStateNew {
+				results = append(results, result)
+			}
+		}
+	}
+	return results
+}
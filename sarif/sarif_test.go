@@ -0,0 +1,114 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testLog(tool string, results ...Result) *Log {
+	run := NewRun(tool)
+	run.AddResults(results...)
+	return NewLog(run)
+}
+
+func TestFingerprint(t *testing.T) {
+	r := newResult("check", "warning", "use  nullptr", "foo.cpp", 12, 5)
+	moved := newResult("check", "warning", "use nullptr", "foo.cpp", 40, 1)
+	if Fingerprint(ToolClangTidy, r) != Fingerprint(ToolClangTidy, moved) {
+		t.Errorf("expected the fingerprint to ignore the line, column and whitespace")
+	}
+
+	for _, other := range []Result{
+		newResult("other-check", "warning", "use nullptr", "foo.cpp", 12, 5),
+		newResult("check", "warning", "use NULL", "foo.cpp", 12, 5),
+		newResult("check", "warning", "use nullptr", "bar.cpp", 12, 5),
+	} {
+		if Fingerprint(ToolClangTidy, r) == Fingerprint(ToolClangTidy, other) {
+			t.Errorf("expected different fingerprints for %#v and %#v", r, other)
+		}
+	}
+	if Fingerprint(ToolClangTidy, r) == Fingerprint(ToolClippy, r) {
+		t.Errorf("expected different fingerprints for different tools")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	header := newResult("check", "warning", "in header", "foo.h", 1, 1)
+	a := testLog(ToolClangTidy, header, newResult("check", "warning", "in a", "a.cpp", 1, 1))
+	b := testLog(ToolClangTidy, header, newResult("other", "warning", "in b", "b.cpp", 1, 1))
+	c := testLog(ToolClippy, newResult("clippy::foo", "warning", "in c", "c.rs", 1, 1))
+
+	merged := Merge(c, a, b)
+
+	if len(merged.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(merged.Runs))
+	}
+	tidy := merged.Runs[0]
+	if tidy.Tool.Driver.Name != ToolClangTidy || merged.Runs[1].Tool.Driver.Name != ToolClippy {
+		t.Errorf("expected runs sorted by tool, got %q and %q", tidy.Tool.Driver.Name, merged.Runs[1].Tool.Driver.Name)
+	}
+	var messages []string
+	for _, result := range tidy.Results {
+		messages = append(messages, result.Message.Text)
+	}
+	if want := []string{"in header", "in a", "in b"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("expected results %q, got %q", want, messages)
+	}
+	var rules []string
+	for _, rule := range tidy.Tool.Driver.Rules {
+		rules = append(rules, rule.Id)
+	}
+	if want := []string{"check", "other"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected rules %q, got %q", want, rules)
+	}
+}
+
+func TestApplyBaseline(t *testing.T) {
+	known := newResult("check", "warning", "known", "foo.cpp", 10, 1)
+	baseline := testLog(ToolClangTidy, known)
+
+	// Round trip the baseline through JSON as it would be read from a file.
+	buf := &bytes.Buffer{}
+	if err := baseline.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	movedKnown := newResult("check", "warning", "known", "foo.cpp", 20, 1)
+	secondKnown := newResult("check", "warning", "known", "foo.cpp", 30, 1)
+	fresh := newResult("check", "warning", "fresh", "foo.cpp", 40, 1)
+	log := testLog(ToolClangTidy, movedKnown, secondKnown, fresh)
+
+	ApplyBaseline(log, baseline)
+
+	var states []string
+	for _, result := range log.Runs[0].Results {
+		states = append(states, result.BaselineState)
+	}
+	want := []string{BaselineStateUnchanged, BaselineStateNew, BaselineStateNew}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("expected baseline states %q, got %q", want, states)
+	}
+
+	if got := NewResults(log); len(got) != 2 || got[1].Message.Text != "fresh" {
+		t.Errorf("expected 2 new results, got %#v", got)
+	}
+}
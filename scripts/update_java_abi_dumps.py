@@ -0,0 +1,103 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+"""Updates the reference dumps of the Java ABI checker.
+
+The Java counterpart of create_reference_dumps.py.  The dumps are listed in the
+JAVA_ABI_DUMP_PATHS make variable in the same <tag>:<path> format as the
+LSDUMP_PATHS variable read by create_reference_dumps.py, and are built by
+`m java_abi_dumps`:
+
+  m java_abi_dumps
+  build/soong/scripts/update_java_abi_dumps.py \
+      --dump-paths "$(get_build_var JAVA_ABI_DUMP_PATHS)" [--version 34] [libfoo ...]
+
+Each dump <module>.abi.json is copied to
+prebuilts/abi-dumps/java/<version>/<module>.abi.json.
+"""
+
+import argparse
+import os
+import shutil
+import sys
+
+JAVA_ABI_DUMP_TAG = 'JAVA'
+JAVA_ABI_DUMP_DIR = os.path.join('prebuilts', 'abi-dumps', 'java')
+JAVA_ABI_DUMP_EXT = '.abi.json'
+
+
+def parse_dump_paths(dump_paths):
+  """Returns a dict of the paths of the dumps in dump_paths by module."""
+  dumps = {}
+  for entry in dump_paths.split():
+    tag, sep, path = entry.partition(':')
+    if not sep or tag != JAVA_ABI_DUMP_TAG:
+      raise ValueError('invalid entry %r, expected %s:<path>' %
+                       (entry, JAVA_ABI_DUMP_TAG))
+    name = os.path.basename(path)
+    if not name.endswith(JAVA_ABI_DUMP_EXT):
+      raise ValueError('invalid dump %r, expected <module>%s' %
+                       (path, JAVA_ABI_DUMP_EXT))
+    dumps[name[:-len(JAVA_ABI_DUMP_EXT)]] = path
+  return dumps
+
+
+def update_reference_dumps(top, dumps, version, modules):
+  """Copies the dumps of the modules, or all of them if modules is empty, to
+  the reference dumps of version.  Returns the paths of the reference dumps."""
+  if modules:
+    missing = [m for m in modules if m not in dumps]
+    if missing:
+      raise ValueError('no ABI dump for %s' % ', '.join(missing))
+  else:
+    modules = sorted(dumps)
+
+  ref_dir = os.path.join(top, JAVA_ABI_DUMP_DIR, version)
+  updated = []
+  for module in modules:
+    src = os.path.join(top, dumps[module])
+    if not os.path.exists(src):
+      raise ValueError('%s does not exist, run `m java_abi_dumps` first' % src)
+    dst = os.path.join(ref_dir, module + JAVA_ABI_DUMP_EXT)
+    os.makedirs(ref_dir, exist_ok=True)
+    shutil.copyfile(src, dst)
+    updated.append(dst)
+  return updated
+
+
+def main():
+  parser = argparse.ArgumentParser(description=__doc__,
+                                   formatter_class=argparse.RawDescriptionHelpFormatter)
+  parser.add_argument('--dump-paths', required=True,
+                      help='the value of the JAVA_ABI_DUMP_PATHS make variable')
+  parser.add_argument('--version', default='current',
+                      help='the version of the reference dumps to update')
+  parser.add_argument('--top', default=os.environ.get('ANDROID_BUILD_TOP', '.'),
+                      help='the top of the source tree')
+  parser.add_argument('modules', nargs='*',
+                      help='the modules to update, defaults to all the modules')
+  args = parser.parse_args()
+
+  try:
+    dumps = parse_dump_paths(args.dump_paths)
+    for path in update_reference_dumps(args.top, dumps, args.version, args.modules):
+      print('Updated', path)
+  except ValueError as e:
+    print('error:', e, file=sys.stderr)
+    sys.exit(1)
+
+
+if __name__ == '__main__':
+  main()
@@ -0,0 +1,67 @@
+#!/usr/bin/env python3
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Unit tests for update_java_abi_dumps.py."""
+
+import os
+import tempfile
+import unittest
+
+import update_java_abi_dumps
+
+FOO_DUMP = 'out/soong/.intermediates/foo/android_common_apex1000/abi/foo.abi.json'
+BAR_DUMP = 'out/soong/.intermediates/bar/android_common_apex1000/abi/bar.abi.json'
+
+
+class UpdateJavaAbiDumpsTest(unittest.TestCase):
+  """Unit tests for update_java_abi_dumps."""
+
+  def test_parse_dump_paths(self):
+    dumps = update_java_abi_dumps.parse_dump_paths(
+        'JAVA:%s JAVA:%s' % (BAR_DUMP, FOO_DUMP))
+    self.assertEqual(dumps, {'bar': BAR_DUMP, 'foo': FOO_DUMP})
+
+  def test_parse_dump_paths_errors(self):
+    for dump_paths in ['foo:' + FOO_DUMP, FOO_DUMP, 'JAVA:foo.lsdump']:
+      with self.assertRaises(ValueError):
+        update_java_abi_dumps.parse_dump_paths(dump_paths)
+
+  def test_update_reference_dumps(self):
+    with tempfile.TemporaryDirectory() as top:
+      for path, contents in [(FOO_DUMP, 'foo'), (BAR_DUMP, 'bar')]:
+        os.makedirs(os.path.join(top, os.path.dirname(path)))
+        with open(os.path.join(top, path), 'w') as f:
+          f.write(contents)
+      dumps = {'foo': FOO_DUMP, 'bar': BAR_DUMP}
+
+      updated = update_java_abi_dumps.update_reference_dumps(top, dumps, '34', ['foo'])
+      ref = os.path.join(top, 'prebuilts/abi-dumps/java/34/foo.abi.json')
+      self.assertEqual(updated, [ref])
+      with open(ref) as f:
+        self.assertEqual(f.read(), 'foo')
+
+      updated = update_java_abi_dumps.update_reference_dumps(top, dumps, 'current', [])
+      self.assertEqual(updated, [
+          os.path.join(top, 'prebuilts/abi-dumps/java/current/bar.abi.json'),
+          os.path.join(top, 'prebuilts/abi-dumps/java/current/foo.abi.json'),
+      ])
+
+      with self.assertRaises(ValueError):
+        update_java_abi_dumps.update_reference_dumps(top, dumps, 'current', ['baz'])
+
+
+if __name__ == '__main__':
+  unittest.main(verbosity=2)
@@ -16,12 +16,31 @@ package zip
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"time"
 )
 
 const DataDescriptorFlag = 0x8
 const ExtendedTimeStampTag = 0x5455
 
+// CompressionMethods maps the names of the compression methods that soong_zip, merge_zips and
+// zip2zip can write to their ids.  Zstd is only meant for intermediates of the build, anything that
+// is installed on the device or read by javac or d8 must use deflate.
+var CompressionMethods = map[string]uint16{
+	"deflate": Deflate,
+	"zstd":    Zstd,
+}
+
+// ParseCompressionMethod returns the id of the compression method with the given name.
+func ParseCompressionMethod(name string) (uint16, error) {
+	if method, ok := CompressionMethods[name]; ok {
+		return method, nil
+	}
+	return 0, fmt.Errorf("unknown compression method %q, expected deflate or zstd", name)
+}
+
 func (w *Writer) CopyFrom(orig *File, newName string) error {
 	if w.last != nil && !w.last.closed {
 		if err := w.last.close(); err != nil {
@@ -95,6 +114,11 @@ func (w *Writer) CopyFrom(orig *File, newName string) error {
 // Extended-Timestamp extra(LFH): <tag-size-flag-modtime-actime-changetime>
 // Extended-Timestamp extra(CDH): <tag-size-flag-modtime>
 func stripExtras(input []byte) []byte {
+	return stripExtraIDs(input, zip64ExtraId, ExtendedTimeStampTag)
+}
+
+// stripExtraIDs removes the extra fields with the given header IDs.
+func stripExtraIDs(input []byte, ids ...uint16) []byte {
 	ret := []byte{}
 
 	for len(input) >= 4 {
@@ -104,7 +128,7 @@ func stripExtras(input []byte) []byte {
 		if int(size) > len(r) {
 			break
 		}
-		if tag != zip64ExtraId && tag != ExtendedTimeStampTag {
+		if !containsID(ids, tag) {
 			ret = append(ret, input[:4+size]...)
 		}
 		input = input[4+size:]
@@ -116,6 +140,70 @@ func stripExtras(input []byte) []byte {
 	return ret
 }
 
+func containsID(ids []uint16, id uint16) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Header IDs of the extra fields that record metadata of the file system that a file was zipped
+// from.
+const (
+	ntfsExtraID        = 0x000a // NTFS timestamps
+	unixExtraID        = 0x000d // PKWARE Unix timestamps and owner
+	infoZipUnixExtraID = 0x5855 // Info-ZIP Unix timestamps and owner
+	unixOwnerExtraID   = 0x7875 // Info-ZIP Unix owner
+)
+
+// Canonicalize replaces the metadata of the entry that depends on the file system that it was
+// zipped from, or on the tool that zipped it, with canonical values, so that the header only
+// depends on the name, the contents and the compression of the entry.  The modification time is
+// set to modTime, directories and files executable by their owner get 0755 permissions, like
+// soong_zip gives them, symlinks 0777 and other files 0644, and the comment and the extra fields
+// that record timestamps or owners are removed.
+func (fh *FileHeader) Canonicalize(modTime time.Time) {
+	mode := fh.Mode()
+	switch {
+	case mode.IsDir():
+		mode = os.ModeDir | 0755
+	case mode&os.ModeSymlink != 0:
+		mode = os.ModeSymlink | 0777
+	case mode&0100 != 0:
+		mode = 0755
+	default:
+		mode = 0644
+	}
+	fh.CreatorVersion = zipVersion20
+	fh.SetMode(mode)
+	fh.SetModTime(modTime)
+
+	fh.ReaderVersion = readerVersion(fh.Method)
+	if fh.isZip64() && fh.ReaderVersion < zipVersion45 {
+		fh.ReaderVersion = zipVersion45
+	}
+
+	fh.Extra = stripExtraIDs(fh.Extra, ExtendedTimeStampTag, ntfsExtraID, unixExtraID,
+		infoZipUnixExtraID, unixOwnerExtraID)
+	fh.Comment = ""
+}
+
+// LocalHeader returns the raw local file header of the entry, including its name and extra
+// fields.
+func (f *File) LocalHeader() ([]byte, error) {
+	bodyOffset, err := f.findBodyOffset()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, bodyOffset)
+	if _, err := f.zipr.ReadAt(buf, f.headerOffset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // CreateCompressedHeader adds a file to the zip file using the provied
 // FileHeader for the file metadata.
 // It returns a Writer to which the already compressed file contents
@@ -142,7 +230,7 @@ func (w *Writer) CreateCompressedHeader(fh *FileHeader) (io.WriteCloser, error)
 	fh.Flags |= DataDescriptorFlag // we will write a data descriptor
 
 	fh.CreatorVersion = fh.CreatorVersion&0xff00 | zipVersion20 // preserve compatibility byte
-	fh.ReaderVersion = zipVersion20
+	fh.ReaderVersion = readerVersion(fh.Method)
 
 	fw := &compressedFileWriter{
 		fileWriter{
@@ -201,7 +289,9 @@ func (w *compressedFileWriter) Close() error {
 	if fh.isZip64() {
 		fh.CompressedSize = uint32max
 		fh.UncompressedSize = uint32max
-		fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+		if fh.ReaderVersion < zipVersion45 {
+			fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+		}
 	} else {
 		fh.CompressedSize = uint32(fh.CompressedSize64)
 		fh.UncompressedSize = uint32(fh.UncompressedSize64)
@@ -231,3 +321,45 @@ func (w *compressedFileWriter) Close() error {
 	_, err := w.zipw.Write(buf)
 	return err
 }
+
+// readerVersion returns the version of the zip specification needed to extract an entry
+// compressed with the given method.
+func readerVersion(method uint16) uint16 {
+	if method == Zstd {
+		return zipVersion63
+	}
+	return zipVersion20
+}
+
+// CopyFromWithMethod is like CopyFrom, but decompresses and recompresses the entry if it is not
+// compressed with the given method.
+func (w *Writer) CopyFromWithMethod(orig *File, newName string, method uint16) error {
+	if orig.Method == method || orig.FileInfo().IsDir() {
+		return w.CopyFrom(orig, newName)
+	}
+
+	fh := orig.FileHeader
+	fh.Name = newName
+	fh.Method = method
+	fh.Extra = stripExtras(fh.Extra)
+
+	r, err := orig.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var zw io.Writer
+	if method == Store {
+		// Stored entries don't need a data descriptor, the sizes and crc are already known.
+		fh.CompressedSize64 = fh.UncompressedSize64
+		zw, err = w.CreateHeaderAndroid(&fh)
+	} else {
+		zw, err = w.CreateHeader(&fh)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(zw, r)
+	return err
+}
@@ -16,7 +16,11 @@ package zip
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"os"
 	"testing"
+	"time"
 )
 
 var stripZip64Testcases = []struct {
@@ -220,3 +224,142 @@ func TestZip64P7ZipRecords(t *testing.T) {
 		t.Errorf("wanted directoryOffset > %d, got %d", w, g)
 	}
 }
+
+func TestZstd(t *testing.T) {
+	contents := bytes.Repeat([]byte("zstd compressed contents\n"), 1000)
+
+	fromZipBytes := &bytes.Buffer{}
+	fromZip := NewWriter(fromZipBytes)
+	w, err := fromZip.CreateHeader(&FileHeader{
+		Name:   "a",
+		Method: Zstd,
+	})
+	if err != nil {
+		t.Fatalf("CreateHeader: %v", err)
+	}
+	if _, err := w.Write(contents); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := fromZip.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fromZipReader, err := NewReader(bytes.NewReader(fromZipBytes.Bytes()), int64(fromZipBytes.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	zstdFile := fromZipReader.File[0]
+	if g, w := zstdFile.ReaderVersion, uint16(zipVersion63); g != w {
+		t.Errorf("Expected ReaderVersion %d, got %d", w, g)
+	}
+	if zstdFile.CompressedSize64 >= zstdFile.UncompressedSize64 {
+		t.Errorf("Expected CompressedSize64 %d to be less than UncompressedSize64 %d",
+			zstdFile.CompressedSize64, zstdFile.UncompressedSize64)
+	}
+
+	toZipBytes := &bytes.Buffer{}
+	toZip := NewWriter(toZipBytes)
+	for _, method := range []uint16{Zstd, Deflate, Store} {
+		if err := toZip.CopyFromWithMethod(zstdFile, fmt.Sprintf("%d", method), method); err != nil {
+			t.Fatalf("CopyFromWithMethod: %v", err)
+		}
+	}
+	if err := toZip.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	toZipReader, err := NewReader(bytes.NewReader(toZipBytes.Bytes()), int64(toZipBytes.Len()))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	for _, f := range toZipReader.File {
+		if g, w := fmt.Sprintf("%d", f.Method), f.Name; g != w {
+			t.Errorf("Expected method %s, got %s", w, g)
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open %s: %v", f.Name, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll %s: %v", f.Name, err)
+		}
+		if !bytes.Equal(got, contents) {
+			t.Errorf("Unexpected contents of entry with method %s", f.Name)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	modTime := time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name  string
+		mode  os.FileMode
+		extra []byte
+		want  os.FileMode
+		// The extra fields after canonicalization.
+		wantExtra []byte
+	}{
+		{
+			name: "file",
+			mode: 0600,
+			want: 0644,
+		},
+		{
+			name: "executable",
+			mode: 0700,
+			want: 0755,
+		},
+		{
+			name: "executable by others",
+			mode: 0611,
+			want: 0644,
+		},
+		{
+			name: "directory",
+			mode: os.ModeDir | 0700,
+			want: os.ModeDir | 0755,
+		},
+		{
+			name: "symlink",
+			mode: os.ModeSymlink | 0755,
+			want: os.ModeSymlink | 0777,
+		},
+		{
+			name: "extras",
+			mode: 0644,
+			// An extended timestamp, a uid/gid and a jar directory extra field.
+			extra:     []byte{0x55, 0x54, 5, 0, 1, 1, 2, 3, 4, 0x75, 0x78, 1, 0, 1, 0xfe, 0xca, 0, 0},
+			want:      0644,
+			wantExtra: []byte{0xfe, 0xca, 0, 0},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fh := &FileHeader{
+				Name:    tc.name,
+				Method:  Deflate,
+				Extra:   tc.extra,
+				Comment: "comment",
+			}
+			fh.SetMode(tc.mode)
+			fh.SetModTime(time.Now())
+
+			fh.Canonicalize(modTime)
+
+			if g, w := fh.Mode(), tc.want; g != w {
+				t.Errorf("Expected mode %v, got %v", w, g)
+			}
+			if g, w := fh.ModTime(), modTime; !g.Equal(w) {
+				t.Errorf("Expected modification time %v, got %v", w, g)
+			}
+			if g, w := fh.Extra, tc.wantExtra; !bytes.Equal(g, w) {
+				t.Errorf("Expected extra %v, got %v", w, g)
+			}
+			if fh.Comment != "" {
+				t.Errorf("Expected no comment, got %q", fh.Comment)
+			}
+		})
+	}
+}
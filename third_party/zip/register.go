@@ -108,16 +108,22 @@ var (
 	compressors = map[uint16]Compressor{
 		Store:   func(w io.Writer) (io.WriteCloser, error) { return &nopCloser{w}, nil },
 		Deflate: func(w io.Writer) (io.WriteCloser, error) { return newFlateWriter(w), nil },
+		// BEGIN ANDROID CHANGE add zstd compression
+		Zstd: newZstdWriter,
+		// END ANDROID CHANGE
 	}
 
 	decompressors = map[uint16]Decompressor{
 		Store:   ioutil.NopCloser,
 		Deflate: newFlateReader,
+		// BEGIN ANDROID CHANGE add zstd compression
+		Zstd: newZstdReader,
+		// END ANDROID CHANGE
 	}
 )
 
 // RegisterDecompressor allows custom decompressors for a specified method ID.
-// The common methods Store and Deflate are built in.
+// The common methods Store and Deflate, and Zstd, are built in.
 func RegisterDecompressor(method uint16, dcomp Decompressor) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -129,7 +135,7 @@ func RegisterDecompressor(method uint16, dcomp Decompressor) {
 }
 
 // RegisterCompressor registers custom compressors for a specified method ID.
-// The common methods Store and Deflate are built in.
+// The common methods Store and Deflate, and Zstd, are built in.
 func RegisterCompressor(method uint16, comp Compressor) {
 	mu.Lock()
 	defer mu.Unlock()
@@ -29,6 +29,9 @@ import (
 const (
 	Store   uint16 = 0
 	Deflate uint16 = 8
+	// BEGIN ANDROID CHANGE add zstd compression
+	Zstd uint16 = 93
+	// END ANDROID CHANGE
 )
 
 const (
@@ -56,6 +59,9 @@ const (
 	// version numbers
 	zipVersion20 = 20 // 2.0
 	zipVersion45 = 45 // 4.5 (reads and writes zip64 archives)
+	// BEGIN ANDROID CHANGE add zstd compression
+	zipVersion63 = 63 // 6.3 (zstd compression)
+	// END ANDROID CHANGE
 
 	// limits for non zip64 files
 	uint16max = (1 << 16) - 1
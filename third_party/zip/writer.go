@@ -240,7 +240,9 @@ func (w *Writer) createHeaderImpl(fh *FileHeader) (io.Writer, error) {
 	// fh.Flags |= 0x8 // we will write a data descriptor
 	// END ANDROID CHANGE
 	fh.CreatorVersion = fh.CreatorVersion&0xff00 | zipVersion20 // preserve compatibility byte
-	fh.ReaderVersion = zipVersion20
+	// BEGIN ANDROID CHANGE zstd requires a later version
+	fh.ReaderVersion = readerVersion(fh.Method)
+	// END ANDROID CHANGE
 
 	fw := &fileWriter{
 		zipw:      w.cw,
@@ -388,7 +390,11 @@ func (w *fileWriter) close() (err error) {
 	if fh.isZip64() {
 		fh.CompressedSize = uint32max
 		fh.UncompressedSize = uint32max
-		fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+		// BEGIN ANDROID CHANGE zstd requires a later version
+		if fh.ReaderVersion < zipVersion45 {
+			fh.ReaderVersion = zipVersion45 // requires 4.5 - File uses ZIP64 format extensions
+		}
+		// END ANDROID CHANGE
 	} else {
 		fh.CompressedSize = uint32(fh.CompressedSize64)
 		fh.UncompressedSize = uint32(fh.UncompressedSize64)
@@ -0,0 +1,118 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zip
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"android/soong/third_party/zstd"
+)
+
+// Zstd entries are only meant for zip files that are produced and consumed by the build, the
+// tools that read zip files on device or in the JDK don't support them.
+
+var zstdWriterPool sync.Pool
+
+func newZstdWriter(w io.Writer) (io.WriteCloser, error) {
+	zw, ok := zstdWriterPool.Get().(*zstd.Encoder)
+	if ok {
+		zw.Reset(w)
+	} else {
+		var err error
+		zw, err = zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &pooledZstdWriter{zw: zw}, nil
+}
+
+type pooledZstdWriter struct {
+	mu sync.Mutex // guards Close and Write
+	zw *zstd.Encoder
+}
+
+func (w *pooledZstdWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.zw == nil {
+		return 0, errors.New("Write after Close")
+	}
+	return w.zw.Write(p)
+}
+
+func (w *pooledZstdWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if w.zw != nil {
+		err = w.zw.Close()
+		zstdWriterPool.Put(w.zw)
+		w.zw = nil
+	}
+	return err
+}
+
+var zstdReaderPool sync.Pool
+
+func newZstdReader(r io.Reader) io.ReadCloser {
+	zr, ok := zstdReaderPool.Get().(*zstd.Decoder)
+	if ok {
+		if err := zr.Reset(r); err != nil {
+			return &pooledZstdReader{err: err}
+		}
+	} else {
+		// A decoder without concurrency decodes synchronously, so it can be reused after Reset
+		// without leaking goroutines.
+		var err error
+		zr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return &pooledZstdReader{err: err}
+		}
+	}
+	return &pooledZstdReader{zr: zr}
+}
+
+type pooledZstdReader struct {
+	mu  sync.Mutex // guards Close and Read
+	zr  *zstd.Decoder
+	err error
+}
+
+func (r *pooledZstdReader) Read(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.zr == nil {
+		return 0, errors.New("Read after Close")
+	}
+	return r.zr.Read(p)
+}
+
+func (r *pooledZstdReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.zr != nil {
+		// Release the reference to the underlying reader before putting the decoder in the pool.
+		r.zr.Reset(nil)
+		zstdReaderPool.Put(r.zr)
+		r.zr = nil
+	}
+	return nil
+}
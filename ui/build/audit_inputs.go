@@ -0,0 +1,569 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+// This file implements the input audit mode of soong_ui (--audit-inputs).  Ninja is run under
+// strace, which records every file opened by every process.  The processes are attributed to
+// the ninja actions that started them, and the files each action read are compared against the
+// inputs declared for it in the ninja graph and the dependencies ninja recorded from its depfile.
+// The result is a report of the source files each action read without declaring them, which
+// cause flaky incremental builds, and of the declared inputs each action never read.
+//
+// Only the actions that run during the audited build are traced, so a full audit requires a
+// clean build.  Tracing every system call slows the build down considerably.
+//
+// strace is run from prebuilts/build-tools like ninja, inside the same sandbox that soong_ui
+// normally runs ninja in.  It only traces its own children, which doesn't need any privileges,
+// and writes the trace to the output directory, which is writable in the sandbox.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"android/soong/ui/status"
+)
+
+const (
+	auditInputsTraceDir   = "audit_inputs_trace"
+	auditInputsReportJson = "audit_inputs.json"
+	auditInputsReportText = "audit_inputs.txt"
+)
+
+// Source directories whose files are read by toolchains without being declared as inputs, for
+// example the headers and libraries of the compilers.  Reads from them are not reported.
+var auditInputsIgnoredSourceDirs = []string{
+	"prebuilts/",
+}
+
+// auditAction is a ninja action recorded from the status of the build.
+type auditAction struct {
+	command string
+	inputs  []string
+	outputs []string
+
+	// The files read by the processes of the action, relative to the top of the source tree
+	// when they are inside it.
+	reads map[string]bool
+	// Whether any process of the action was found in the trace.
+	traced bool
+}
+
+// auditActionRecorder is a status.StatusOutput that records the actions started by ninja, so
+// that they can be matched with the commands found in the trace.
+type auditActionRecorder struct {
+	lock    sync.Mutex
+	actions map[string]*auditAction
+}
+
+var _ status.StatusOutput = (*auditActionRecorder)(nil)
+
+func newAuditActionRecorder() *auditActionRecorder {
+	return &auditActionRecorder{actions: make(map[string]*auditAction)}
+}
+
+func (r *auditActionRecorder) StartAction(action *status.Action, counts status.Counts) {
+	if action.Command == "" {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.actions[action.Command] = &auditAction{
+		command: action.Command,
+		inputs:  action.Inputs,
+		outputs: action.Outputs,
+		reads:   make(map[string]bool),
+	}
+}
+
+func (r *auditActionRecorder) FinishAction(result status.ActionResult, counts status.Counts) {}
+func (r *auditActionRecorder) Message(level status.MsgLevel, msg string)                     {}
+func (r *auditActionRecorder) Flush()                                                        {}
+func (r *auditActionRecorder) Write(p []byte) (int, error)                                   { return len(p), nil }
+
+// inputAudit holds the state of an audit while ninja runs.
+type inputAudit struct {
+	traceDir string
+	recorder *auditActionRecorder
+}
+
+// startInputAudit wraps the ninja command in strace and starts recording the actions that ninja
+// runs.
+func startInputAudit(ctx Context, config Config, cmd *Cmd) *inputAudit {
+	strace := config.PrebuiltBuildTool("strace")
+	if _, err := os.Stat(strace); err != nil {
+		ctx.Fatalf("--audit-inputs requires the prebuilt strace: %s", err)
+	}
+
+	traceDir := filepath.Join(config.OutDir(), auditInputsTraceDir)
+	if err := os.RemoveAll(traceDir); err != nil {
+		ctx.Fatalf("Failed to remove %s: %s", traceDir, err)
+	}
+	if err := os.MkdirAll(traceDir, 0777); err != nil {
+		ctx.Fatalf("Failed to create %s: %s", traceDir, err)
+	}
+
+	straceArgs := []string{
+		strace,
+		// Follow all children, writing the trace of each process to a separate file so that the
+		// lines of concurrent processes are not interleaved.
+		"-f", "-ff",
+		"-qq",
+		// Print strings in full and hex encoded so that they can be decoded unambiguously.
+		"-xx", "-s", "1048576",
+		"-e", "trace=execve,open,openat,chdir,clone,clone3,fork,vfork",
+		"-o", filepath.Join(traceDir, "trace"),
+	}
+	cmd.Args = append(straceArgs, cmd.Args...)
+	cmd.Path = strace
+
+	audit := &inputAudit{
+		traceDir: traceDir,
+		recorder: newAuditActionRecorder(),
+	}
+	ctx.Status.AddOutput(audit.recorder)
+	return audit
+}
+
+// finish analyzes the trace and writes the audit report.
+func (a *inputAudit) finish(ctx Context, config Config) {
+	defer os.RemoveAll(a.traceDir)
+
+	ctx.Status.Status("Analyzing file access trace...")
+
+	top, err := os.Getwd()
+	if err != nil {
+		ctx.Fatalf("Failed to get working directory: %s", err)
+	}
+
+	procs, err := readStraceDir(a.traceDir)
+	if err != nil {
+		ctx.Fatalf("Failed to read trace: %s", err)
+	}
+
+	a.recorder.lock.Lock()
+	actions := a.recorder.actions
+	a.recorder.lock.Unlock()
+
+	attributeTraceToActions(procs, actions, top)
+
+	deps := ninjaDepsForActions(ctx, config, actions)
+
+	report := auditInputs(actions, deps, config.OutDir(), fileExists, newBlueprintFileChecker())
+
+	writeAuditReport(ctx, report, filepath.Join(config.LogsDir(), auditInputsReportJson),
+		filepath.Join(config.LogsDir(), auditInputsReportText))
+}
+
+func fileExists(path string) bool {
+	stat, err := os.Stat(path)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// traceEvent is a system call of a traced process.
+type traceEvent struct {
+	call string
+	// The path argument of the call, or for clone calls the pid of the child.
+	path  string
+	child int
+	// The argv of an execve call.
+	argv []string
+	// Whether an open call opened the file for writing.
+	write bool
+}
+
+type traceProc struct {
+	pid    int
+	events []traceEvent
+}
+
+var (
+	straceLineRe    = regexp.MustCompile(`^(\w+)\((.*)\)\s+=\s+(-?\d+)`)
+	straceStringRe  = regexp.MustCompile(`"((?:\\x[0-9a-f]{2})*)"`)
+	straceWriteOpen = regexp.MustCompile(`O_WRONLY|O_RDWR|O_CREAT|O_TRUNC`)
+)
+
+func decodeStraceString(s string) string {
+	data, err := hex.DecodeString(strings.ReplaceAll(s, `\x`, ""))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// parseStraceLine parses a line of strace output produced with -xx, returning false for
+// failed calls and calls that are not of interest.
+func parseStraceLine(line string) (traceEvent, bool) {
+	match := straceLineRe.FindStringSubmatch(line)
+	if match == nil {
+		return traceEvent{}, false
+	}
+	call, args := match[1], match[2]
+	ret, err := strconv.Atoi(match[3])
+	if err != nil || ret < 0 {
+		return traceEvent{}, false
+	}
+
+	var strs []string
+	for _, s := range straceStringRe.FindAllStringSubmatch(args, -1) {
+		strs = append(strs, decodeStraceString(s[1]))
+	}
+
+	switch call {
+	case "clone", "clone3", "fork", "vfork":
+		return traceEvent{call: "clone", child: ret}, true
+	case "chdir":
+		if len(strs) < 1 {
+			return traceEvent{}, false
+		}
+		return traceEvent{call: call, path: strs[0]}, true
+	case "execve":
+		if len(strs) < 1 {
+			return traceEvent{}, false
+		}
+		return traceEvent{call: call, path: strs[0], argv: strs[1:]}, true
+	case "open", "openat":
+		if len(strs) < 1 {
+			return traceEvent{}, false
+		}
+		if call == "openat" && !strings.HasPrefix(args, "AT_FDCWD,") {
+			// Paths relative to other directories can't be resolved without tracking file
+			// descriptors.
+			return traceEvent{}, false
+		}
+		if strings.Contains(args, "O_DIRECTORY") {
+			return traceEvent{}, false
+		}
+		flags := args[strings.LastIndex(args, `"`)+1:]
+		return traceEvent{call: "open", path: strs[0], write: straceWriteOpen.MatchString(flags)}, true
+	}
+	return traceEvent{}, false
+}
+
+func parseStrace(pid int, r io.Reader) (*traceProc, error) {
+	proc := &traceProc{pid: pid}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if event, ok := parseStraceLine(scanner.Text()); ok {
+			proc.events = append(proc.events, event)
+		}
+	}
+	return proc, scanner.Err()
+}
+
+// readStraceDir reads the trace.<pid> files written by strace -ff.
+func readStraceDir(dir string) (map[int]*traceProc, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "trace.*"))
+	if err != nil {
+		return nil, err
+	}
+
+	procs := make(map[int]*traceProc)
+	for _, file := range files {
+		pid, err := strconv.Atoi(strings.TrimPrefix(filepath.Ext(file), "."))
+		if err != nil {
+			continue
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		proc, err := parseStrace(pid, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		procs[pid] = proc
+	}
+	return procs, nil
+}
+
+// attributeTraceToActions walks the process tree from the root processes, assigning every
+// process started by the shell that ninja runs for an action to that action, and recording the
+// files they read.  Paths are resolved against the working directory of each process and made
+// relative to top if they are inside it.
+func attributeTraceToActions(procs map[int]*traceProc, actions map[string]*auditAction, top string) {
+	isChild := make(map[int]bool)
+	for _, proc := range procs {
+		for _, event := range proc.events {
+			if event.call == "clone" {
+				isChild[event.child] = true
+			}
+		}
+	}
+
+	relToTop := func(path string) string {
+		if rel, err := filepath.Rel(top, path); err == nil && !strings.HasPrefix(rel, "../") && rel != ".." {
+			return rel
+		}
+		return path
+	}
+
+	var walk func(proc *traceProc, cwd string, action *auditAction)
+	walk = func(proc *traceProc, cwd string, action *auditAction) {
+		resolve := func(path string) string {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(cwd, path)
+			}
+			return filepath.Clean(path)
+		}
+
+		for _, event := range proc.events {
+			switch event.call {
+			case "chdir":
+				cwd = resolve(event.path)
+			case "execve":
+				if action == nil && len(event.argv) == 3 && event.argv[1] == "-c" {
+					action = actions[event.argv[2]]
+				}
+				if action != nil {
+					action.traced = true
+					action.reads[relToTop(resolve(event.path))] = true
+				}
+			case "open":
+				if action != nil && !event.write {
+					action.reads[relToTop(resolve(event.path))] = true
+				}
+			case "clone":
+				if child, ok := procs[event.child]; ok {
+					walk(child, cwd, action)
+				}
+			}
+		}
+	}
+
+	for pid, proc := range procs {
+		if !isChild[pid] {
+			walk(proc, top, nil)
+		}
+	}
+}
+
+var ninjaDepsTargetRe = regexp.MustCompile(`^(.+): #deps \d+`)
+
+// parseNinjaDeps parses the output of ninja -t deps.
+func parseNinjaDeps(r io.Reader) map[string][]string {
+	deps := make(map[string][]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	var target string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := ninjaDepsTargetRe.FindStringSubmatch(line); match != nil {
+			target = match[1]
+		} else if strings.HasPrefix(line, "    ") && target != "" {
+			deps[target] = append(deps[target], strings.TrimSpace(line))
+		} else {
+			target = ""
+		}
+	}
+	return deps
+}
+
+// ninjaDepsForActions returns the dependencies that ninja discovered from the depfiles of the
+// traced actions, keyed by output.
+func ninjaDepsForActions(ctx Context, config Config, actions map[string]*auditAction) map[string][]string {
+	var outputs []string
+	for _, action := range actions {
+		if action.traced && len(action.outputs) > 0 {
+			outputs = append(outputs, action.outputs[0])
+		}
+	}
+	sort.Strings(outputs)
+
+	deps := make(map[string][]string)
+	const batchSize = 1000
+	for len(outputs) > 0 {
+		batch := outputs
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		outputs = outputs[len(batch):]
+
+		args := append([]string{"-f", config.CombinedNinjaFile(), "-t", "deps"}, batch...)
+		cmd := Command(ctx, config, "ninja deps", config.PrebuiltBuildTool("ninja"), args...)
+		data, err := cmd.Output()
+		if err != nil {
+			ctx.Verbosef("Failed to read ninja deps: %s", err)
+			continue
+		}
+		for target, targetDeps := range parseNinjaDeps(bytes.NewReader(data)) {
+			deps[target] = targetDeps
+		}
+	}
+	return deps
+}
+
+type auditActionReport struct {
+	Output     string   `json:"output"`
+	Undeclared []string `json:"undeclared_reads,omitempty"`
+	Unused     []string `json:"unused_inputs,omitempty"`
+}
+
+type auditModuleReport struct {
+	Module  string              `json:"module"`
+	Actions []auditActionReport `json:"actions"`
+}
+
+type auditReport struct {
+	TracedActions   int                 `json:"traced_actions"`
+	UntracedActions int                 `json:"untraced_actions"`
+	Modules         []auditModuleReport `json:"modules"`
+}
+
+// auditInputs compares the files read by each traced action against its declared inputs and
+// the dependencies ninja recorded for it.  Only reads of source files are reported as
+// undeclared, as reads of intermediate files that are not declared would already be caught by
+// ninja's missing dependency checks.
+func auditInputs(actions map[string]*auditAction, deps map[string][]string, outDir string,
+	fileExists func(string) bool, hasBlueprintFile func(string) bool) auditReport {
+
+	outPrefix := filepath.Clean(outDir) + "/"
+	isSource := func(path string) bool {
+		if filepath.IsAbs(path) || strings.HasPrefix(path, outPrefix) {
+			return false
+		}
+		for _, dir := range auditInputsIgnoredSourceDirs {
+			if strings.HasPrefix(path, dir) {
+				return false
+			}
+		}
+		return true
+	}
+
+	report := auditReport{}
+	modules := make(map[string]*auditModuleReport)
+
+	commands := make([]string, 0, len(actions))
+	for command := range actions {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	for _, command := range commands {
+		action := actions[command]
+		if !action.traced || len(action.outputs) == 0 {
+			report.UntracedActions++
+			continue
+		}
+		report.TracedActions++
+
+		declared := make(map[string]bool)
+		for _, list := range [][]string{action.inputs, action.outputs, deps[action.outputs[0]]} {
+			for _, path := range list {
+				declared[filepath.Clean(path)] = true
+			}
+		}
+
+		var undeclared, unused []string
+		for path := range action.reads {
+			if !declared[path] && isSource(path) && fileExists(path) {
+				undeclared = append(undeclared, path)
+			}
+		}
+		for _, input := range action.inputs {
+			input = filepath.Clean(input)
+			if !action.reads[input] && fileExists(input) {
+				unused = append(unused, input)
+			}
+		}
+		if len(undeclared) == 0 && len(unused) == 0 {
+			continue
+		}
+		sort.Strings(undeclared)
+		sort.Strings(unused)
+
+		module := moduleForOutput(action.outputs[0], hasBlueprintFile)
+		if module == "" {
+			module = "<unknown>"
+		}
+		if modules[module] == nil {
+			modules[module] = &auditModuleReport{Module: module}
+		}
+		modules[module].Actions = append(modules[module].Actions, auditActionReport{
+			Output:     action.outputs[0],
+			Undeclared: undeclared,
+			Unused:     unused,
+		})
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Modules = append(report.Modules, *modules[name])
+	}
+	return report
+}
+
+func writeAuditReportText(w io.Writer, report auditReport) {
+	fmt.Fprintf(w, "Traced %d actions, %d actions could not be found in the trace.\n",
+		report.TracedActions, report.UntracedActions)
+	for _, module := range report.Modules {
+		fmt.Fprintf(w, "\n%s:\n", module.Module)
+		for _, action := range module.Actions {
+			fmt.Fprintf(w, "  %s\n", action.Output)
+			for _, path := range action.Undeclared {
+				fmt.Fprintf(w, "    undeclared read: %s\n", path)
+			}
+			for _, path := range action.Unused {
+				fmt.Fprintf(w, "    unused input:    %s\n", path)
+			}
+		}
+	}
+}
+
+func writeAuditReport(ctx Context, report auditReport, jsonFile, textFile string) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		ctx.Fatalf("Failed to marshal input audit report: %s", err)
+	}
+	if err := os.WriteFile(jsonFile, data, 0666); err != nil {
+		ctx.Fatalf("Failed to write %s: %s", jsonFile, err)
+	}
+
+	buf := &bytes.Buffer{}
+	writeAuditReportText(buf, report)
+	if err := os.WriteFile(textFile, buf.Bytes(), 0666); err != nil {
+		ctx.Fatalf("Failed to write %s: %s", textFile, err)
+	}
+
+	undeclared, unused := 0, 0
+	for _, module := range report.Modules {
+		for _, action := range module.Actions {
+			if len(action.Undeclared) > 0 {
+				undeclared++
+			}
+			if len(action.Unused) > 0 {
+				unused++
+			}
+		}
+	}
+	ctx.Printf("Input audit: %d actions read undeclared source files, %d actions have unused inputs, see %s",
+		undeclared, unused, textFile)
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// straceString encodes a string the way strace -xx prints it.
+func straceString(s string) string {
+	encoded := hex.EncodeToString([]byte(s))
+	var b strings.Builder
+	b.WriteString(`"`)
+	for i := 0; i < len(encoded); i += 2 {
+		b.WriteString(`\x` + encoded[i:i+2])
+	}
+	b.WriteString(`"`)
+	return b.String()
+}
+
+func TestParseStraceLine(t *testing.T) {
+	testCases := []struct {
+		line  string
+		want  traceEvent
+		valid bool
+	}{
+		{
+			line:  `openat(AT_FDCWD, ` + straceString("foo/bar.h") + `, O_RDONLY|O_CLOEXEC) = 3`,
+			want:  traceEvent{call: "open", path: "foo/bar.h"},
+			valid: true,
+		},
+		{
+			line:  `openat(AT_FDCWD, ` + straceString("out/foo.o") + `, O_WRONLY|O_CREAT|O_TRUNC, 0666) = 4`,
+			want:  traceEvent{call: "open", path: "out/foo.o", write: true},
+			valid: true,
+		},
+		{
+			line: `openat(AT_FDCWD, ` + straceString("missing.h") + `, O_RDONLY) = -1 ENOENT (No such file or directory)`,
+		},
+		{
+			line: `openat(AT_FDCWD, ` + straceString("dir") + `, O_RDONLY|O_DIRECTORY) = 3`,
+		},
+		{
+			line: `openat(5, ` + straceString("relative") + `, O_RDONLY) = 3`,
+		},
+		{
+			line: `execve(` + straceString("/bin/sh") + `, [` + straceString("/bin/sh") + `, ` +
+				straceString("-c") + `, ` + straceString("cp a b") + `], 0x7ffd /* 12 vars */) = 0`,
+			want:  traceEvent{call: "execve", path: "/bin/sh", argv: []string{"/bin/sh", "-c", "cp a b"}},
+			valid: true,
+		},
+		{
+			line:  `clone(child_stack=NULL, flags=CLONE_CHILD_CLEARTID|CLONE_CHILD_SETTID|SIGCHLD, child_tidptr=0x7f) = 1234`,
+			want:  traceEvent{call: "clone", child: 1234},
+			valid: true,
+		},
+		{
+			line:  `chdir(` + straceString("sub") + `) = 0`,
+			want:  traceEvent{call: "chdir", path: "sub"},
+			valid: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, valid := parseStraceLine(tc.line)
+		if valid != tc.valid || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: want %+v (%v), got %+v (%v)", tc.line, tc.want, tc.valid, got, valid)
+		}
+	}
+}
+
+func TestParseNinjaDeps(t *testing.T) {
+	output := `out/foo.o: #deps 2, deps mtime 1234 (VALID)
+    foo.h
+    bar.h
+
+out/bar.o: #deps 1, deps mtime 1234 (STALE)
+    baz.h
+
+`
+	want := map[string][]string{
+		"out/foo.o": {"foo.h", "bar.h"},
+		"out/bar.o": {"baz.h"},
+	}
+	if got := parseNinjaDeps(strings.NewReader(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestAuditInputs(t *testing.T) {
+	actions := map[string]*auditAction{
+		"cc foo.c": {
+			command: "cc foo.c",
+			inputs:  []string{"foo/foo.c", "foo/unused.txt"},
+			outputs: []string{"out/soong/.intermediates/foo/libfoo/android_arm64/foo.o"},
+			reads:   make(map[string]bool),
+		},
+		"cp bar": {
+			command: "cp bar",
+			inputs:  []string{"bar/bar.txt"},
+			outputs: []string{"out/soong/.intermediates/bar/bar/gen/bar.txt"},
+			reads:   make(map[string]bool),
+		},
+		"not run": {
+			command: "not run",
+			inputs:  []string{"baz/baz.txt"},
+			outputs: []string{"out/soong/baz.txt"},
+			reads:   make(map[string]bool),
+		},
+	}
+
+	procs := map[int]*traceProc{
+		1: {pid: 1, events: []traceEvent{
+			{call: "clone", child: 2},
+			{call: "clone", child: 3},
+		}},
+		2: {pid: 2, events: []traceEvent{
+			{call: "execve", path: "/bin/sh", argv: []string{"/bin/sh", "-c", "cc foo.c"}},
+			{call: "clone", child: 4},
+		}},
+		3: {pid: 3, events: []traceEvent{
+			{call: "execve", path: "/bin/sh", argv: []string{"/bin/sh", "-c", "cp bar"}},
+			{call: "open", path: "/top/bar/bar.txt"},
+			{call: "open", path: "/top/out/soong/.intermediates/bar/bar/gen/bar.txt", write: true},
+		}},
+		4: {pid: 4, events: []traceEvent{
+			{call: "execve", path: "/top/prebuilts/clang/bin/clang", argv: []string{"clang", "foo.c"}},
+			{call: "chdir", path: "foo"},
+			{call: "open", path: "foo.c"},
+			{call: "open", path: "foo.h"},
+			{call: "open", path: "undeclared.h"},
+			{call: "open", path: "/usr/include/stdio.h"},
+		}},
+	}
+
+	attributeTraceToActions(procs, actions, "/top")
+
+	deps := map[string][]string{
+		"out/soong/.intermediates/foo/libfoo/android_arm64/foo.o": {"foo/foo.h"},
+	}
+	exists := func(path string) bool { return path != "foo/missing.txt" }
+	hasBlueprintFile := func(dir string) bool { return dir == "foo" || dir == "bar" }
+
+	got := auditInputs(actions, deps, "out", exists, hasBlueprintFile)
+	want := auditReport{
+		TracedActions:   2,
+		UntracedActions: 1,
+		Modules: []auditModuleReport{
+			{
+				Module: "//foo:libfoo",
+				Actions: []auditActionReport{
+					{
+						Output:     "out/soong/.intermediates/foo/libfoo/android_arm64/foo.o",
+						Undeclared: []string{"foo/undeclared.h"},
+						Unused:     []string{"foo/unused.txt"},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -330,6 +330,7 @@ func Build(ctx Context, config Config) {
 			installCleanIfNecessary(ctx, config)
 		}
 		runNinjaForBuild(ctx, config)
+		logSboxCacheStats(ctx, config)
 	}
 
 	if what&RunDistActions != 0 {
@@ -73,6 +73,7 @@ type configImpl struct {
 	checkbuild        bool
 	dist              bool
 	jsonModuleGraph   bool
+	queryGraph        bool // Generate the module graph for queries, set by soong_ui --query.
 	apiBp2build       bool // Generate BUILD files for Soong modules that contribute APIs
 	bp2build          bool
 	queryview         bool
@@ -87,8 +88,11 @@ type configImpl struct {
 	skipSoongTests    bool
 	searchApiDir      bool // Scan the Android.bp files generated in out/api_surfaces
 	skipMetricsUpload bool
+	actionTrace       bool  // Write a trace of every action with its resource usage.
 	buildStartedTime  int64 // For metrics-upload-only - manually specify a build-started time
 	buildFromTextStub bool
+	sboxNamespaces    bool // Run sbox commands with sandboxed inputs in Linux namespaces.
+	auditInputs       bool // Trace the files read by ninja actions and compare them to their inputs.
 
 	// From the product config
 	katiArgs        []string
@@ -530,6 +534,12 @@ func NewConfig(ctx Context, args ...string) Config {
 		ret.environ.Set("UNSAFE_DISABLE_HIDDENAPI_FLAGS", "true")
 	}
 
+	if ret.SboxNamespaces() {
+		// Picked up by soong_build, which passes --nsjail to sbox for RuleBuilder rules with
+		// sandboxed inputs.
+		ret.environ.Set("SOONG_SBOX_NAMESPACES", "true")
+	}
+
 	bpd := ret.BazelMetricsDir()
 	if err := os.RemoveAll(bpd); err != nil {
 		ctx.Fatalf("Unable to remove bazel profile directory %q: %v", bpd, err)
@@ -835,6 +845,12 @@ func (c *configImpl) parseArgs(ctx Context, args []string) {
 			c.skipSoongTests = true
 		} else if arg == "--skip-metrics-upload" {
 			c.skipMetricsUpload = true
+		} else if arg == "--action-trace" {
+			c.actionTrace = true
+		} else if arg == "--audit-inputs" {
+			c.auditInputs = true
+		} else if arg == "--sbox-namespaces" {
+			c.sboxNamespaces = true
 		} else if arg == "--mk-metrics" {
 			c.reportMkMetrics = true
 		} else if arg == "--multitree-build" {
@@ -1026,7 +1042,7 @@ func (c *configImpl) SoongBuildInvocationNeeded() bool {
 		return true
 	}
 
-	if !c.JsonModuleGraph() && !c.Bp2Build() && !c.Queryview() && !c.SoongDocs() && !c.ApiBp2build() {
+	if !c.JsonModuleGraph() && !c.QueryGraph() && !c.Bp2Build() && !c.Queryview() && !c.SoongDocs() && !c.ApiBp2build() {
 		// Command line was empty, the default Ninja target is built
 		return true
 	}
@@ -1133,10 +1149,20 @@ func (c *configImpl) ModuleActionsFile() string {
 	return shared.JoinPath(c.SoongOutDir(), "module-actions.json")
 }
 
+func (c *configImpl) QueryGraphFile() string {
+	return shared.JoinPath(c.SoongOutDir(), "module-query-graph.json")
+}
+
 func (c *configImpl) TempDir() string {
 	return shared.TempDirForOutDir(c.SoongOutDir())
 }
 
+// PerfHistoryFile returns the path to the file that keeps a record of the
+// performance of recent builds, used by soong_ui --perf-report.
+func (c *configImpl) PerfHistoryFile() string {
+	return filepath.Join(c.OutDir(), "build_perf_history.jsonl")
+}
+
 func (c *configImpl) FileListDir() string {
 	return filepath.Join(c.OutDir(), ".module_paths")
 }
@@ -1162,6 +1188,11 @@ func (c *configImpl) JsonModuleGraph() bool {
 	return c.jsonModuleGraph
 }
 
+// QueryGraph returns whether the module graph for soong_ui --query is generated.
+func (c *configImpl) QueryGraph() bool {
+	return c.queryGraph
+}
+
 func (c *configImpl) Bp2Build() bool {
 	return c.bp2build
 }
@@ -1716,6 +1747,25 @@ func (c *configImpl) SkipMetricsUpload() bool {
 	return c.skipMetricsUpload
 }
 
+// ActionTrace returns true if a trace of every action, including its resource usage
+// statistics and the critical path, should be written to <logs dir>/action.trace.gz.
+func (c *configImpl) ActionTrace() bool {
+	return c.actionTrace
+}
+
+// SboxNamespaces returns true if sbox commands with sandboxed inputs should be run in Linux
+// user, mount and network namespaces so that undeclared inputs are detected.
+func (c *configImpl) SboxNamespaces() bool {
+	return c.sboxNamespaces
+}
+
+// AuditInputs returns true if ninja should be run under strace to find the source files that
+// actions read without declaring them, and the declared inputs they never read.  Ninja then runs
+// without the sandbox.
+func (c *configImpl) AuditInputs() bool {
+	return c.auditInputs
+}
+
 // Returns a Time object if one was passed via a command-line flag.
 // Otherwise returns the passed default.
 func (c *configImpl) BuildStartedTimeOrDefault(defaultTime time.Time) time.Time {
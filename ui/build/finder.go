@@ -39,6 +39,42 @@ func NewSourceFinder(ctx Context, config Config) (f *finder.Finder) {
 	ctx.BeginTrace(metrics.RunSetupTool, "find modules")
 	defer ctx.EndTrace()
 
+	cacheParams, dbPath := sourceFinderParams(ctx, config)
+	f, err := finder.New(cacheParams, fs.OsFs, logger.New(ioutil.Discard), dbPath)
+	if err != nil {
+		ctx.Fatalf("Could not create module-finder: %v", err)
+	}
+	return f
+}
+
+// RunFinderDaemon runs a finder daemon for the Finder of NewSourceFinder until soong_ui is
+// interrupted, so that NewSourceFinder loads the tree of the daemon instead of checking the
+// cache db against the whole source tree.
+func RunFinderDaemon(ctx Context, config Config) {
+	cacheParams, dbPath := sourceFinderParams(ctx, config)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0777); err != nil {
+		ctx.Fatalf("Failed to create %s: %v", filepath.Dir(dbPath), err)
+	}
+	d, err := finder.NewDaemon(cacheParams, logger.New(ioutil.Discard), dbPath)
+	if err != nil {
+		ctx.Fatalf("Could not start finder daemon: %v", err)
+	}
+	ctx.Printf("Finder daemon listening on %s", finder.DaemonSocketPath(dbPath))
+
+	done := make(chan error, 1)
+	go func() { done <- d.Serve() }()
+	select {
+	case <-ctx.Done():
+	case err := <-done:
+		ctx.Fatalf("Finder daemon failed: %v", err)
+	}
+	if err := d.Close(); err != nil {
+		ctx.Fatalf("Failed to stop finder daemon: %v", err)
+	}
+}
+
+// sourceFinderParams returns the parameters and the cache db of the Finder for source files.
+func sourceFinderParams(ctx Context, config Config) (finder.CacheParams, string) {
 	// Set up the working directory for the Finder.
 	dir, err := os.Getwd()
 	if err != nil {
@@ -94,12 +130,7 @@ func NewSourceFinder(ctx Context, config Config) (f *finder.Finder) {
 		IncludeSuffixes: []string{".bzl", ".mk"},
 	}
 	dumpDir := config.FileListDir()
-	f, err = finder.New(cacheParams, filesystem, logger.New(ioutil.Discard),
-		filepath.Join(dumpDir, "files.db"))
-	if err != nil {
-		ctx.Fatalf("Could not create module-finder: %v", err)
-	}
-	return f
+	return cacheParams, filepath.Join(dumpDir, "files.db")
 }
 
 func androidBpSearchDirs(config Config) []string {
@@ -84,6 +84,16 @@ func runNinjaForBuild(ctx Context, config Config) {
 	ctx.BeginTrace(metrics.PrimaryNinja, "ninja")
 	defer ctx.EndTrace()
 
+	// An input audit can only be analyzed once the ninja reader below has seen every action, so
+	// the analysis is deferred before the reader is closed.
+	var audit *inputAudit
+	ninjaSucceeded := false
+	defer func() {
+		if audit != nil && ninjaSucceeded {
+			audit.finish(ctx, config)
+		}
+	}()
+
 	// Sets up the FIFO status updater that reads the Ninja protobuf output, and
 	// translates it to the soong_ui status output, displaying real-time
 	// progress of the build.
@@ -286,8 +296,13 @@ func runNinjaForBuild(ctx Context, config Config) {
 		}
 	}()
 
+	if config.AuditInputs() {
+		audit = startInputAudit(ctx, config, cmd)
+	}
+
 	ctx.Status.Status("Starting ninja...")
 	cmd.RunAndStreamOrFatal()
+	ninjaSucceeded = true
 }
 
 // A simple struct for checking if Ninja gets stuck, using timestamps.
@@ -0,0 +1,340 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+// This file contains the functionality to keep a local history of the
+// performance of previous builds in OUT_DIR, and to compare the latest build
+// against the rolling median of that history.
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// The maximum number of builds kept in the history file.
+	perfHistoryMaxRecords = 20
+
+	// Actions that took less than this are not recorded individually in the
+	// history, but still count towards the time of their module.
+	perfHistoryMinActionTime = time.Second
+)
+
+// perfRecord is the compact record of a single build stored in the history
+// file. All durations are in milliseconds.
+type perfRecord struct {
+	// The time the record was written, in seconds since the epoch.
+	Time int64 `json:"time"`
+
+	// The targets passed to the build.
+	Targets []string `json:"targets,omitempty"`
+
+	// The real time of each build phase (setup, soong, kati, ninja, total),
+	// keyed by phase name.
+	Phases map[string]int64 `json:"phases,omitempty"`
+
+	// The duration of each action that took at least perfHistoryMinActionTime,
+	// keyed by the first output of the action.
+	Actions map[string]int64 `json:"actions,omitempty"`
+
+	// The summed duration of all actions of each module, keyed by a
+	// "//dir:name" label for Soong modules or the module name for Make modules.
+	Modules map[string]int64 `json:"modules,omitempty"`
+
+	// The first output of each action on the critical path.
+	CriticalPath []string `json:"critical_path,omitempty"`
+}
+
+const soongIntermediates = "soong/.intermediates/"
+
+var makeIntermediatesRe = regexp.MustCompile(`/obj(?:32|_[^/]+)?/[A-Z_]+/([^/]+)_intermediates/`)
+
+// moduleForOutput returns the name of the module that produced output, or an
+// empty string if it could not be determined. Soong modules are placed in
+// .intermediates/<module dir>/<module name>/<variant>, so the module dir is the
+// longest prefix of the path that contains an Android.bp file.
+func moduleForOutput(output string, hasBlueprintFile func(dir string) bool) string {
+	if i := strings.Index(output, soongIntermediates); i >= 0 {
+		parts := strings.Split(output[i+len(soongIntermediates):], "/")
+		for j := len(parts) - 2; j >= 0; j-- {
+			dir := strings.Join(parts[:j], "/")
+			if hasBlueprintFile(dir) {
+				return "//" + dir + ":" + parts[j]
+			}
+		}
+		return ""
+	}
+
+	if match := makeIntermediatesRe.FindStringSubmatch(output); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// newBlueprintFileChecker returns a function for moduleForOutput that checks
+// for Android.bp files relative to the current directory, caching the results.
+func newBlueprintFileChecker() func(dir string) bool {
+	cache := make(map[string]bool)
+	return func(dir string) bool {
+		if has, ok := cache[dir]; ok {
+			return has
+		}
+		_, err := os.Stat(filepath.Join(dir, "Android.bp"))
+		cache[dir] = err == nil
+		return err == nil
+	}
+}
+
+func newPerfRecord(now time.Time, targets []string, phases, actions map[string]time.Duration,
+	criticalPath []string, hasBlueprintFile func(dir string) bool) *perfRecord {
+
+	record := &perfRecord{
+		Time:         now.Unix(),
+		Targets:      targets,
+		Phases:       make(map[string]int64),
+		Actions:      make(map[string]int64),
+		Modules:      make(map[string]int64),
+		CriticalPath: criticalPath,
+	}
+
+	for name, duration := range phases {
+		record.Phases[name] = duration.Milliseconds()
+	}
+
+	for output, duration := range actions {
+		if duration >= perfHistoryMinActionTime {
+			record.Actions[output] = duration.Milliseconds()
+		}
+		if module := moduleForOutput(output, hasBlueprintFile); module != "" {
+			record.Modules[module] += duration.Milliseconds()
+		}
+	}
+
+	return record
+}
+
+func readPerfHistory(filename string) ([]*perfRecord, error) {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*perfRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		record := &perfRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			// Skip records written by incompatible versions instead of
+			// throwing the whole history away.
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func writePerfHistory(filename string, records []*perfRecord) error {
+	tempFilename := filename + ".tmp"
+	f, err := os.Create(tempFilename)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFilename, filename)
+}
+
+// RecordBuildPerf appends a record of the performance of the current build to
+// the history file in OUT_DIR, dropping the oldest records once there are more
+// than perfHistoryMaxRecords. It must only be called for successful builds.
+// Nothing is recorded if no actions were run, or for builds with
+// --audit-inputs, whose actions are slowed down by tracing.
+func RecordBuildPerf(ctx Context, config Config) {
+	if ctx.CriticalPath == nil || ctx.Metrics == nil || config.AuditInputs() {
+		return
+	}
+
+	actions := ctx.CriticalPath.ActionDurations()
+	if len(actions) == 0 {
+		return
+	}
+
+	record := newPerfRecord(time.Now(), config.Arguments(), ctx.Metrics.PhaseTimes(), actions,
+		ctx.CriticalPath.CriticalPathOutputs(), newBlueprintFileChecker())
+
+	filename := config.PerfHistoryFile()
+	records, err := readPerfHistory(filename)
+	if err != nil {
+		ctx.Verbosef("Failed to read build performance history %s: %s", filename, err)
+	}
+	records = append(records, record)
+	if len(records) > perfHistoryMaxRecords {
+		records = records[len(records)-perfHistoryMaxRecords:]
+	}
+
+	if err := writePerfHistory(filename, records); err != nil {
+		ctx.Verbosef("Failed to write build performance history %s: %s", filename, err)
+	}
+}
+
+type perfRegression struct {
+	name            string
+	current, median int64
+}
+
+func (r perfRegression) delta() int64 {
+	return r.current - r.median
+}
+
+func median(values []int64) int64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// perfRegressions compares each entry in current against the median of the
+// same entry in history, returning the entries that got slower sorted by how
+// much slower they got. Entries that do not appear in history are skipped, as
+// there is nothing to compare them against.
+func perfRegressions(current map[string]int64, history []map[string]int64) []perfRegression {
+	var regressions []perfRegression
+	for name, duration := range current {
+		var previous []int64
+		for _, h := range history {
+			if d, ok := h[name]; ok {
+				previous = append(previous, d)
+			}
+		}
+		if len(previous) == 0 {
+			continue
+		}
+
+		regression := perfRegression{name: name, current: duration, median: median(previous)}
+		if regression.delta() > 0 {
+			regressions = append(regressions, regression)
+		}
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].delta() == regressions[j].delta() {
+			return regressions[i].name < regressions[j].name
+		}
+		return regressions[i].delta() > regressions[j].delta()
+	})
+	return regressions
+}
+
+func formatMillis(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Millisecond).String()
+}
+
+func writePerfReport(w io.Writer, current *perfRecord, history []*perfRecord, top int) {
+	fmt.Fprintf(w, "Comparing the build from %s against the median of %d previous builds.\n",
+		time.Unix(current.Time, 0).Format(time.RFC1123), len(history))
+
+	section := func(title string, get func(*perfRecord) map[string]int64) {
+		var previous []map[string]int64
+		for _, record := range history {
+			previous = append(previous, get(record))
+		}
+		regressions := perfRegressions(get(current), previous)
+
+		fmt.Fprintf(w, "\n%s:\n", title)
+		if len(regressions) == 0 {
+			fmt.Fprintln(w, "  no regressions")
+			return
+		}
+		if top > 0 && len(regressions) > top {
+			regressions = regressions[:top]
+		}
+		for _, r := range regressions {
+			fmt.Fprintf(w, "  +%-10s %10s (median %s)  %s\n",
+				formatMillis(r.delta()), formatMillis(r.current), formatMillis(r.median), r.name)
+		}
+	}
+
+	section("Phases", func(r *perfRecord) map[string]int64 { return r.Phases })
+	section("Modules", func(r *perfRecord) map[string]int64 { return r.Modules })
+	section("Actions", func(r *perfRecord) map[string]int64 { return r.Actions })
+
+	if len(current.CriticalPath) > 0 {
+		var criticalTime int64
+		for _, output := range current.CriticalPath {
+			criticalTime += current.Actions[output]
+		}
+		fmt.Fprintf(w, "\nCritical path (%d actions, at least %s):\n", len(current.CriticalPath), formatMillis(criticalTime))
+		for _, output := range current.CriticalPath {
+			if d, ok := current.Actions[output]; ok {
+				fmt.Fprintf(w, "  %10s  %s\n", formatMillis(d), output)
+			}
+		}
+	}
+}
+
+// PerfReport prints a comparison of the most recent build recorded in the
+// build performance history against the rolling median of up to builds
+// previous builds, listing at most top regressed phases, modules and actions.
+func PerfReport(ctx Context, config Config, builds, top int) {
+	filename := config.PerfHistoryFile()
+	records, err := readPerfHistory(filename)
+	if err != nil {
+		ctx.Fatalf("Failed to read build performance history %s: %s", filename, err)
+	}
+	if len(records) < 2 {
+		ctx.Fatalf("Not enough builds recorded in %s to compare against, found %d", filename, len(records))
+	}
+
+	current := records[len(records)-1]
+	history := records[:len(records)-1]
+	if builds > 0 && len(history) > builds {
+		history = history[len(history)-builds:]
+	}
+
+	writePerfReport(ctx.Writer, current, history, top)
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModuleForOutput(t *testing.T) {
+	blueprintDirs := map[string]bool{
+		"":              true,
+		"frameworks/av": true,
+	}
+	hasBlueprintFile := func(dir string) bool { return blueprintDirs[dir] }
+
+	testCases := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: "out/soong/.intermediates/frameworks/av/libfoo/android_arm64_armv8-a_shared/libfoo.so",
+			want:   "//frameworks/av:libfoo",
+		},
+		{
+			output: "out/soong/.intermediates/libroot/android_common/libroot.jar",
+			want:   "//:libroot",
+		},
+		{
+			output: "out/target/product/generic/obj/SHARED_LIBRARIES/libbar_intermediates/libbar.so",
+			want:   "libbar",
+		},
+		{
+			output: "out/host/linux-x86/obj32/EXECUTABLES/baz_intermediates/baz",
+			want:   "baz",
+		},
+		{
+			output: "out/target/product/generic/system/build.prop",
+			want:   "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.output, func(t *testing.T) {
+			if got := moduleForOutput(tc.output, hasBlueprintFile); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPerfRegressions(t *testing.T) {
+	history := []map[string]int64{
+		{"a": 100, "b": 100, "c": 100},
+		{"a": 200, "b": 100},
+		{"a": 300, "b": 100},
+	}
+	current := map[string]int64{"a": 250, "b": 400, "c": 50, "new": 1000}
+
+	want := []perfRegression{
+		{name: "b", current: 400, median: 100},
+		{name: "a", current: 250, median: 200},
+	}
+	if got := perfRegressions(current, history); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestPerfHistoryRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "build_perf_history.jsonl")
+
+	records, err := readPerfHistory(filename)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("want no records and no error for a missing file, got %v, %v", records, err)
+	}
+
+	record := newPerfRecord(time.Unix(1000, 0), []string{"droid"},
+		map[string]time.Duration{"total": time.Minute},
+		map[string]time.Duration{
+			"out/soong/.intermediates/libfoo/android_arm64/libfoo.so": 2 * time.Second,
+			"out/soong/.intermediates/libfoo/android_arm64/foo.o":     10 * time.Millisecond,
+		},
+		[]string{"out/soong/.intermediates/libfoo/android_arm64/libfoo.so"},
+		func(dir string) bool { return dir == "" })
+
+	if err := writePerfHistory(filename, []*perfRecord{record, record}); err != nil {
+		t.Fatal(err)
+	}
+	records, err = readPerfHistory(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], record) {
+		t.Errorf("want two copies of %#v, got %#v", record, records)
+	}
+
+	if want := map[string]int64{"out/soong/.intermediates/libfoo/android_arm64/libfoo.so": 2000}; !reflect.DeepEqual(record.Actions, want) {
+		t.Errorf("want actions %v, got %v", want, record.Actions)
+	}
+	if want := map[string]int64{"//:libfoo": 2010}; !reflect.DeepEqual(record.Modules, want) {
+		t.Errorf("want modules %v, got %v", want, record.Modules)
+	}
+
+	current := *record
+	current.Phases = map[string]int64{"total": 90000}
+	buf := &bytes.Buffer{}
+	writePerfReport(buf, &current, records, 10)
+	if !strings.Contains(buf.String(), "+30s") || !strings.Contains(buf.String(), "total") {
+		t.Errorf("expected a regression of the total phase in the report, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"android/soong/query"
+)
+
+// GenerateQueryGraph runs soong_build to update the module graph that queries are run against,
+// without generating the ninja file of the build.
+func GenerateQueryGraph(ctx Context, config Config) {
+	// Make sure that no other Soong process is running with the same output directory
+	buildLock := BecomeSingletonOrFail(ctx, config)
+	defer buildLock.Unlock()
+
+	SetupOutDir(ctx, config)
+	ensureEmptyDirectoriesExist(ctx, config.TempDir())
+	SetupPath(ctx, config)
+
+	runMakeProductConfig(ctx, config)
+
+	config.queryGraph = true
+	runSoong(ctx, config)
+}
+
+// RunQuery evaluates the query against the module graph written by GenerateQueryGraph and writes
+// the result to w in one of query.Formats.
+func RunQuery(ctx Context, config Config, w io.Writer, expr string, format string) {
+	write, ok := query.Formats[format]
+	if !ok {
+		ctx.Fatalf("Unknown query output format %q", format)
+	}
+
+	e, err := query.Parse(expr)
+	if err != nil {
+		ctx.Fatalf("Invalid query %q: %s", expr, err)
+	}
+
+	graph := readQueryGraph(ctx, config)
+	result, err := query.Evaluate(graph, e)
+	if err != nil {
+		ctx.Fatalf("Query %q failed: %s", expr, err)
+	}
+	if err := write(w, result); err != nil {
+		ctx.Fatalf("Failed to write the query result: %s", err)
+	}
+}
+
+// whyInstalledChainLimit is the maximum number of chains printed by RunWhyInstalled, there can be
+// a lot of shortest chains to the libraries that everything depends on.
+const whyInstalledChainLimit = 100
+
+// RunWhyInstalled writes to w the shortest chains of dependencies through which the packages
+// matched by pkg contain target, which is either a path in the packages or a module name.
+func RunWhyInstalled(ctx Context, config Config, w io.Writer, pkg string, target string, format string) {
+	var write func(io.Writer, []query.Chain) error
+	switch format {
+	case "text":
+		write = query.WriteChainsText
+	case "json":
+		write = query.WriteChainsJSON
+	default:
+		ctx.Fatalf("Unknown output format %q", format)
+	}
+
+	graph := readQueryGraph(ctx, config)
+	chains, truncated, err := query.PackagingChains(graph, pkg, target, whyInstalledChainLimit)
+	if err != nil {
+		ctx.Fatalf("%s", err)
+	}
+	if err := write(w, chains); err != nil {
+		ctx.Fatalf("Failed to write the chains: %s", err)
+	}
+	if truncated {
+		fmt.Fprintf(ctx.Writer, "Only the first %d chains were printed\n", whyInstalledChainLimit)
+	}
+}
+
+// readQueryGraph reads the module graph written by GenerateQueryGraph.
+func readQueryGraph(ctx Context, config Config) *query.Graph {
+	f, err := os.Open(config.QueryGraphFile())
+	if os.IsNotExist(err) {
+		ctx.Fatalf("The module graph %s does not exist, run the query without --cached", config.QueryGraphFile())
+	} else if err != nil {
+		ctx.Fatalf("Failed to open the module graph: %s", err)
+	}
+	defer f.Close()
+	graph, err := query.ReadGraph(f)
+	if err != nil {
+		ctx.Fatalf("Failed to read %s: %s", config.QueryGraphFile(), err)
+	}
+	return graph
+}
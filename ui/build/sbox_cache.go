@@ -0,0 +1,111 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	smpb "android/soong/ui/metrics/metrics_proto"
+)
+
+// The file that sbox appends a record to for every sandboxed command it runs with the local
+// action cache enabled (SOONG_SBOX_CACHE_DIR).  It must match sboxCacheStatsFile in
+// android/rule_builder.go.
+const sboxCacheStatsFile = "sbox_cache_stats.jsonl"
+
+// sboxCacheStat matches actionCacheStat in cmd/sbox/cache.go.
+type sboxCacheStat struct {
+	Result       string `json:"result"`
+	Bytes        int64  `json:"bytes"`
+	EvictedBytes int64  `json:"evicted_bytes"`
+	TimeMs       int64  `json:"time_ms"`
+}
+
+type sboxCacheSummary struct {
+	hits, misses, uncacheable           int
+	restoredBytes, storedBytes, evicted int64
+	hitTime, missTime                   time.Duration
+}
+
+func summarizeSboxCacheStats(r io.Reader) (sboxCacheSummary, error) {
+	var summary sboxCacheSummary
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var stat sboxCacheStat
+		if err := json.Unmarshal(scanner.Bytes(), &stat); err != nil {
+			continue
+		}
+		duration := time.Duration(stat.TimeMs) * time.Millisecond
+		switch stat.Result {
+		case "hit":
+			summary.hits++
+			summary.restoredBytes += stat.Bytes
+			summary.hitTime += duration
+		case "miss":
+			summary.misses++
+			summary.storedBytes += stat.Bytes
+			summary.evicted += stat.EvictedBytes
+			summary.missTime += duration
+		case "uncacheable":
+			summary.uncacheable++
+		}
+	}
+	return summary, scanner.Err()
+}
+
+func (s sboxCacheSummary) metrics() *smpb.SboxCacheMetrics {
+	return &smpb.SboxCacheMetrics{
+		Hits:           proto.Uint32(uint32(s.hits)),
+		Misses:         proto.Uint32(uint32(s.misses)),
+		Uncacheable:    proto.Uint32(uint32(s.uncacheable)),
+		RestoredBytes:  proto.Uint64(uint64(s.restoredBytes)),
+		StoredBytes:    proto.Uint64(uint64(s.storedBytes)),
+		EvictedBytes:   proto.Uint64(uint64(s.evicted)),
+		HitTimeMicros:  proto.Uint64(uint64(s.hitTime.Microseconds())),
+		MissTimeMicros: proto.Uint64(uint64(s.missTime.Microseconds())),
+	}
+}
+
+// logSboxCacheStats logs a summary of the statistics of the sbox action cache for the current
+// build and records it in the build metrics, and removes the statistics so that the next build
+// starts from scratch.
+func logSboxCacheStats(ctx Context, config Config) {
+	statsFile := filepath.Join(config.SoongOutDir(), sboxCacheStatsFile)
+	f, err := os.Open(statsFile)
+	if err != nil {
+		return
+	}
+	summary, err := summarizeSboxCacheStats(f)
+	f.Close()
+	os.Remove(statsFile)
+	if err != nil {
+		ctx.Verbosef("Failed to read sbox cache stats %s: %s", statsFile, err)
+		return
+	}
+
+	ctx.Verbosef("sbox cache: %d hits (%d bytes restored in %s), %d misses (%d bytes stored in %s, %d bytes evicted), %d uncacheable",
+		summary.hits, summary.restoredBytes, summary.hitTime, summary.misses, summary.storedBytes,
+		summary.missTime, summary.evicted, summary.uncacheable)
+	if ctx.Metrics != nil {
+		ctx.Metrics.SetSboxCacheMetrics(summary.metrics())
+	}
+}
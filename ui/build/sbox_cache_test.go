@@ -0,0 +1,69 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	smpb "android/soong/ui/metrics/metrics_proto"
+)
+
+func TestSummarizeSboxCacheStats(t *testing.T) {
+	stats := strings.Join([]string{
+		`{"key":"a","result":"hit","bytes":100,"time_ms":5}`,
+		`{"key":"b","result":"miss","bytes":200,"evicted_bytes":50,"time_ms":1000}`,
+		`{"key":"c","result":"hit","bytes":10,"time_ms":1}`,
+		`{"result":"uncacheable","time_ms":0}`,
+		`not json`,
+	}, "\n")
+
+	got, err := summarizeSboxCacheStats(strings.NewReader(stats))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := sboxCacheSummary{
+		hits:          2,
+		misses:        1,
+		uncacheable:   1,
+		restoredBytes: 110,
+		storedBytes:   200,
+		evicted:       50,
+		hitTime:       6 * time.Millisecond,
+		missTime:      time.Second,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	wantMetrics := &smpb.SboxCacheMetrics{
+		Hits:           proto.Uint32(2),
+		Misses:         proto.Uint32(1),
+		Uncacheable:    proto.Uint32(1),
+		RestoredBytes:  proto.Uint64(110),
+		StoredBytes:    proto.Uint64(200),
+		EvictedBytes:   proto.Uint64(50),
+		HitTimeMicros:  proto.Uint64(6000),
+		MissTimeMicros: proto.Uint64(1000000),
+	}
+	if gotMetrics := got.metrics(); !proto.Equal(gotMetrics, wantMetrics) {
+		t.Errorf("want metrics %v, got %v", wantMetrics, gotMetrics)
+	}
+}
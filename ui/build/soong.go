@@ -40,10 +40,15 @@ const (
 	bp2buildFilesTag     = "bp2build_files"
 	bp2buildWorkspaceTag = "bp2build_workspace"
 	jsonModuleGraphTag   = "modulegraph"
+	queryGraphTag        = "querygraph"
 	queryviewTag         = "queryview"
 	apiBp2buildTag       = "api_bp2build"
 	soongDocsTag         = "soong_docs"
 
+	// policyViolationWarningsFile is written by soong_build, it must match the file in
+	// android/policy_violations.go.
+	policyViolationWarningsFile = "policy_violation_warnings.txt"
+
 	// bootstrapEpoch is used to determine if an incremental build is incompatible with the current
 	// version of bootstrap and needs cleaning before continuing the build.  Increment this for
 	// incompatible changes, for example when moving the location of the bpglob binary that is
@@ -250,6 +255,7 @@ func bootstrapGlobFileList(config Config) []string {
 		config.NamedGlobFile(soongBuildTag),
 		config.NamedGlobFile(bp2buildFilesTag),
 		config.NamedGlobFile(jsonModuleGraphTag),
+		config.NamedGlobFile(queryGraphTag),
 		config.NamedGlobFile(queryviewTag),
 		config.NamedGlobFile(apiBp2buildTag),
 		config.NamedGlobFile(soongDocsTag),
@@ -326,6 +332,13 @@ func bootstrapBlueprint(ctx Context, config Config) {
 				"--module_actions_file", config.ModuleActionsFile(),
 			},
 		},
+		{
+			name:         queryGraphTag,
+			description:  fmt.Sprintf("generating the Soong module graph for queries at %s", config.QueryGraphFile()),
+			config:       config,
+			output:       config.QueryGraphFile(),
+			specificArgs: []string{"--query_graph_file", config.QueryGraphFile()},
+		},
 		{
 			name:         queryviewTag,
 			description:  fmt.Sprintf("generating the Soong module graph as a Bazel workspace at %s", queryviewDir),
@@ -497,6 +510,10 @@ func runSoong(ctx Context, config Config) {
 			checkEnvironmentFile(soongBuildEnv, config.UsedEnvFile(jsonModuleGraphTag))
 		}
 
+		if config.QueryGraph() {
+			checkEnvironmentFile(soongBuildEnv, config.UsedEnvFile(queryGraphTag))
+		}
+
 		if config.Queryview() {
 			checkEnvironmentFile(soongBuildEnv, config.UsedEnvFile(queryviewTag))
 		}
@@ -566,6 +583,10 @@ func runSoong(ctx Context, config Config) {
 		targets = append(targets, config.ModuleGraphFile())
 	}
 
+	if config.QueryGraph() {
+		targets = append(targets, config.QueryGraphFile())
+	}
+
 	if config.Bp2Build() {
 		targets = append(targets, config.Bp2BuildWorkspaceMarkerFile())
 	}
@@ -589,6 +610,10 @@ func runSoong(ctx Context, config Config) {
 
 	ninja("bootstrap", "bootstrap.ninja", targets...)
 
+	if config.SoongBuildInvocationNeeded() {
+		printPolicyViolationWarnings(ctx, config)
+	}
+
 	distGzipFile(ctx, config, config.SoongNinjaFile(), "soong")
 	distFile(ctx, config, config.SoongVarsFile(), "soong")
 
@@ -602,6 +627,22 @@ func runSoong(ctx Context, config Config) {
 	}
 }
 
+// printPolicyViolationWarnings prints the summary of the policy violations that soong_build
+// reported as warnings. It is read from the output directory so that it is printed again by the
+// builds that do not rerun soong_build.
+func printPolicyViolationWarnings(ctx Context, config Config) {
+	data, err := os.ReadFile(filepath.Join(config.SoongOutDir(), policyViolationWarningsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.Verbosef("cannot read policy violation warnings: %s", err)
+		}
+		return
+	}
+	if len(data) > 0 {
+		fmt.Fprint(ctx.Writer, string(data))
+	}
+}
+
 func runMicrofactory(ctx Context, config Config, name string, pkg string, mapping map[string]string) {
 	ctx.BeginTrace(metrics.RunSoong, name)
 	defer ctx.EndTrace()
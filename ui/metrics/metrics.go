@@ -126,6 +126,27 @@ func (m *Metrics) SetTimeMetrics(perf soong_metrics_proto.PerfInfo) {
 	}
 }
 
+// PhaseTimes returns the real time spent in each of the top level build phases
+// recorded so far (setup, soong, kati, bazel, ninja and total), keyed by the
+// phase name. Phases that ran more than once are summed.
+func (m *Metrics) PhaseTimes() map[string]time.Duration {
+	phases := make(map[string]time.Duration)
+	add := func(perfs ...*soong_metrics_proto.PerfInfo) {
+		for _, perf := range perfs {
+			if perf != nil {
+				phases[perf.GetName()] += time.Duration(perf.GetRealTime())
+			}
+		}
+	}
+	add(m.metrics.SetupTools...)
+	add(m.metrics.SoongRuns...)
+	add(m.metrics.KatiRuns...)
+	add(m.metrics.BazelRuns...)
+	add(m.metrics.NinjaRuns...)
+	add(m.metrics.Total)
+	return phases
+}
+
 func (m *Metrics) SetCriticalPathInfo(criticalPathInfo soong_metrics_proto.CriticalPathInfo) {
 	m.metrics.CriticalPathInfo = &criticalPathInfo
 }
@@ -161,6 +182,11 @@ func (m *Metrics) ExpConfigFetcher(b *soong_metrics_proto.ExpConfigFetcher) {
 	m.metrics.ExpConfigFetcher = b
 }
 
+// SetSboxCacheMetrics stores the statistics of the sbox action cache.
+func (m *Metrics) SetSboxCacheMetrics(b *soong_metrics_proto.SboxCacheMetrics) {
+	m.metrics.SboxCacheMetrics = b
+}
+
 // SetMetadataMetrics sets information about the build such as the target
 // product, host architecture and out directory.
 func (m *Metrics) SetMetadataMetrics(metadata map[string]string) {
@@ -0,0 +1,330 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"android/soong/ui/logger"
+)
+
+const (
+	actionTracePid       = 1
+	criticalPathTracePid = 2
+)
+
+// NewActionTraceLog returns a StatusOutput that writes a Chrome trace-event file (readable by
+// chrome://tracing and ui.perfetto.dev) containing one slice per action on a per-worker track,
+// counter tracks for the number of running actions and their combined max RSS, and a separate
+// track with the actions on the critical path.
+//
+// If criticalPath is nil a new CriticalPath is created and fed by this output, otherwise the
+// passed CriticalPath is expected to be updated elsewhere (for example by a criticalPathLogger)
+// and is only read when the trace is written.
+func NewActionTraceLog(log logger.Logger, filename string, criticalPath *CriticalPath) StatusOutput {
+	// chrome://tracing requires that compressed trace files end in .gz
+	if !strings.HasSuffix(filename, ".gz") {
+		filename += ".gz"
+	}
+
+	ownCriticalPath := false
+	if criticalPath == nil {
+		criticalPath = NewCriticalPath()
+		ownCriticalPath = true
+	}
+
+	return &actionTraceLog{
+		log:             log,
+		filename:        filename,
+		clock:           osClock{},
+		criticalPath:    criticalPath,
+		ownCriticalPath: ownCriticalPath,
+		running:         make(map[*Action]*actionTraceSlice),
+	}
+}
+
+type actionTraceLog struct {
+	log      logger.Logger
+	filename string
+	clock    clock
+
+	criticalPath    *CriticalPath
+	ownCriticalPath bool
+
+	// workers tracks which worker tracks are currently busy.
+	workers []bool
+	running map[*Action]*actionTraceSlice
+	slices  []*actionTraceSlice
+}
+
+type actionTraceSlice struct {
+	action     *Action
+	worker     int
+	start, end time.Time
+	stats      ActionResultStats
+	failed     bool
+}
+
+type traceEvent struct {
+	Name  string      `json:"name,omitempty"`
+	Phase string      `json:"ph"`
+	Time  uint64      `json:"ts"`
+	Dur   uint64      `json:"dur,omitempty"`
+	Pid   uint64      `json:"pid"`
+	Tid   uint64      `json:"tid"`
+	Cname string      `json:"cname,omitempty"`
+	Arg   interface{} `json:"args,omitempty"`
+}
+
+type traceNameArg struct {
+	Name string `json:"name"`
+}
+
+type actionTraceArg struct {
+	Description                string   `json:"description,omitempty"`
+	Outputs                    []string `json:"outputs,omitempty"`
+	Failed                     bool     `json:"failed,omitempty"`
+	CriticalPath               bool     `json:"critical_path,omitempty"`
+	UserTime                   uint32   `json:"user_time_ms"`
+	SystemTime                 uint32   `json:"system_time_ms"`
+	MaxRssKB                   uint64   `json:"max_rss_kb"`
+	MinorPageFaults            uint64   `json:"minor_page_faults"`
+	MajorPageFaults            uint64   `json:"major_page_faults"`
+	IOInputKB                  uint64   `json:"io_input_kb"`
+	IOOutputKB                 uint64   `json:"io_output_kb"`
+	VoluntaryContextSwitches   uint64   `json:"voluntary_context_switches"`
+	InvoluntaryContextSwitches uint64   `json:"involuntary_context_switches"`
+}
+
+type runningActionsArg struct {
+	Actions int `json:"actions"`
+}
+
+type runningRssArg struct {
+	MaxRssKB uint64 `json:"max_rss_kb"`
+}
+
+func traceMicros(t time.Time) uint64 {
+	return uint64(t.UnixNano()) / 1000
+}
+
+func (a *actionTraceLog) StartAction(action *Action, counts Counts) {
+	if a.ownCriticalPath {
+		a.criticalPath.StartAction(action)
+	}
+
+	worker := -1
+	for i, busy := range a.workers {
+		if !busy {
+			worker = i
+			a.workers[i] = true
+			break
+		}
+	}
+	if worker == -1 {
+		worker = len(a.workers)
+		a.workers = append(a.workers, true)
+	}
+
+	a.running[action] = &actionTraceSlice{
+		action: action,
+		worker: worker,
+		start:  a.clock.Now(),
+	}
+}
+
+func (a *actionTraceLog) FinishAction(result ActionResult, counts Counts) {
+	if a.ownCriticalPath {
+		a.criticalPath.FinishAction(result.Action)
+	}
+
+	slice, ok := a.running[result.Action]
+	if !ok {
+		return
+	}
+	delete(a.running, result.Action)
+	a.workers[slice.worker] = false
+
+	slice.end = a.clock.Now()
+	slice.stats = result.Stats
+	slice.failed = result.Error != nil
+	a.slices = append(a.slices, slice)
+}
+
+// traceEvents converts the finished actions into trace events.
+func (a *actionTraceLog) traceEvents() []*traceEvent {
+	var events []*traceEvent
+
+	onCriticalPath := make(map[*Action]bool)
+	path, _, _ := a.criticalPath.criticalPath()
+	for _, node := range path {
+		onCriticalPath[node.action] = true
+	}
+
+	metadata := func(name string, pid, tid uint64, value string) {
+		events = append(events, &traceEvent{
+			Name:  name,
+			Phase: "M",
+			Pid:   pid,
+			Tid:   tid,
+			Arg:   &traceNameArg{Name: value},
+		})
+	}
+
+	metadata("process_name", actionTracePid, 0, "actions")
+	for i := range a.workers {
+		metadata("thread_name", actionTracePid, uint64(i), fmt.Sprintf("worker %d", i))
+	}
+	metadata("process_name", criticalPathTracePid, 0, "critical path")
+	metadata("thread_name", criticalPathTracePid, 0, "critical path")
+
+	type counterChange struct {
+		time    time.Time
+		actions int
+		rss     int64
+	}
+	var changes []counterChange
+
+	for _, slice := range a.slices {
+		name := slice.action.Description
+		if len(slice.action.Outputs) > 0 {
+			name = slice.action.Outputs[0]
+		}
+
+		critical := onCriticalPath[slice.action]
+		arg := &actionTraceArg{
+			Description:                slice.action.Description,
+			Outputs:                    slice.action.Outputs,
+			Failed:                     slice.failed,
+			CriticalPath:               critical,
+			UserTime:                   slice.stats.UserTime,
+			SystemTime:                 slice.stats.SystemTime,
+			MaxRssKB:                   slice.stats.MaxRssKB,
+			MinorPageFaults:            slice.stats.MinorPageFaults,
+			MajorPageFaults:            slice.stats.MajorPageFaults,
+			IOInputKB:                  slice.stats.IOInputKB,
+			IOOutputKB:                 slice.stats.IOOutputKB,
+			VoluntaryContextSwitches:   slice.stats.VoluntaryContextSwitches,
+			InvoluntaryContextSwitches: slice.stats.InvoluntaryContextSwitches,
+		}
+
+		event := &traceEvent{
+			Name:  name,
+			Phase: "X",
+			Time:  traceMicros(slice.start),
+			Dur:   uint64(slice.end.Sub(slice.start).Microseconds()),
+			Pid:   actionTracePid,
+			Tid:   uint64(slice.worker),
+			Arg:   arg,
+		}
+		if critical {
+			// "terrible" is one of the reserved color names in the trace viewer, and
+			// renders as red.
+			event.Cname = "terrible"
+		}
+		events = append(events, event)
+
+		if critical {
+			criticalEvent := *event
+			criticalEvent.Pid = criticalPathTracePid
+			criticalEvent.Tid = 0
+			events = append(events, &criticalEvent)
+		}
+
+		changes = append(changes,
+			counterChange{time: slice.start, actions: 1, rss: int64(slice.stats.MaxRssKB)},
+			counterChange{time: slice.end, actions: -1, rss: -int64(slice.stats.MaxRssKB)})
+	}
+
+	// The max RSS of an action is only known after it finishes, so the counter tracks are
+	// computed once all actions have finished by sweeping over the start and end times of
+	// every action. Actions that end at the same time another one starts are removed first.
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].time.Equal(changes[j].time) {
+			return changes[i].actions < changes[j].actions
+		}
+		return changes[i].time.Before(changes[j].time)
+	})
+
+	actions := 0
+	var rss int64
+	for i, change := range changes {
+		actions += change.actions
+		rss += change.rss
+		if i+1 < len(changes) && changes[i+1].time.Equal(change.time) {
+			continue
+		}
+		events = append(events,
+			&traceEvent{
+				Name:  "running actions",
+				Phase: "C",
+				Time:  traceMicros(change.time),
+				Pid:   actionTracePid,
+				Arg:   &runningActionsArg{Actions: actions},
+			},
+			&traceEvent{
+				Name:  "running actions max rss",
+				Phase: "C",
+				Time:  traceMicros(change.time),
+				Pid:   actionTracePid,
+				Arg:   &runningRssArg{MaxRssKB: uint64(rss)},
+			})
+	}
+
+	return events
+}
+
+func (a *actionTraceLog) Flush() {
+	f, err := logger.CreateFileWithRotation(a.filename, 5)
+	if err != nil {
+		a.log.Println("Failed to create action trace file:", err)
+		return
+	}
+	defer f.Close()
+
+	w := gzip.NewWriter(f)
+	defer w.Close()
+
+	fmt.Fprintln(w, "[")
+	first := true
+	for _, event := range a.traceEvents() {
+		bytes, err := json.Marshal(event)
+		if err != nil {
+			a.log.Println("Failed to marshal action trace event:", err)
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w, ",")
+		}
+		first = false
+		if _, err := w.Write(bytes); err != nil {
+			a.log.Println("Action trace write error:", err)
+			return
+		}
+	}
+	fmt.Fprintln(w, "]")
+}
+
+func (a *actionTraceLog) Message(level MsgLevel, message string) {}
+
+func (a *actionTraceLog) Write(p []byte) (int, error) {
+	return 0, errors.New("not supported")
+}
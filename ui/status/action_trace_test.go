@@ -0,0 +1,90 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActionTraceEvents(t *testing.T) {
+	a := NewActionTraceLog(nil, "action.trace", nil).(*actionTraceLog)
+
+	at := func(ms int) {
+		clock := testClock(time.Unix(0, 0).Add(time.Duration(ms) * time.Millisecond))
+		a.clock = clock
+		a.criticalPath.clock = clock
+	}
+
+	// a and b run in parallel, c depends on b.
+	actionA := &Action{Description: "a", Outputs: []string{"a"}}
+	actionB := &Action{Description: "b", Outputs: []string{"b"}}
+	actionC := &Action{Description: "c", Outputs: []string{"c"}, Inputs: []string{"b"}}
+
+	at(0)
+	a.StartAction(actionA, Counts{})
+	a.StartAction(actionB, Counts{})
+	at(10)
+	a.FinishAction(ActionResult{Action: actionA, Stats: ActionResultStats{MaxRssKB: 100}}, Counts{})
+	at(20)
+	a.FinishAction(ActionResult{Action: actionB, Stats: ActionResultStats{MaxRssKB: 200}}, Counts{})
+	a.StartAction(actionC, Counts{})
+	at(50)
+	a.FinishAction(ActionResult{Action: actionC, Stats: ActionResultStats{MaxRssKB: 300}}, Counts{})
+
+	var slices, criticalSlices []string
+	workers := map[string]uint64{}
+	var runningActions []int
+	var runningRss []uint64
+	for _, event := range a.traceEvents() {
+		switch event.Phase {
+		case "X":
+			if event.Pid == criticalPathTracePid {
+				criticalSlices = append(criticalSlices, event.Name)
+			} else {
+				slices = append(slices, event.Name)
+				workers[event.Name] = event.Tid
+				critical := event.Arg.(*actionTraceArg).CriticalPath
+				if critical != (event.Cname != "") {
+					t.Errorf("slice %q has critical_path %v but cname %q", event.Name, critical, event.Cname)
+				}
+			}
+		case "C":
+			switch arg := event.Arg.(type) {
+			case *runningActionsArg:
+				runningActions = append(runningActions, arg.Actions)
+			case *runningRssArg:
+				runningRss = append(runningRss, arg.MaxRssKB)
+			}
+		}
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(slices, want) {
+		t.Errorf("want slices %q, got %q", want, slices)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(criticalSlices, want) {
+		t.Errorf("want critical path slices %q, got %q", want, criticalSlices)
+	}
+	if workers["a"] == workers["b"] {
+		t.Errorf("want a and b on different workers, got %d and %d", workers["a"], workers["b"])
+	}
+	if want := []int{2, 1, 1, 0}; !reflect.DeepEqual(runningActions, want) {
+		t.Errorf("want running actions %v, got %v", want, runningActions)
+	}
+	if want := []uint64{300, 200, 300, 0}; !reflect.DeepEqual(runningRss, want) {
+		t.Errorf("want running max rss %v, got %v", want, runningRss)
+	}
+}
@@ -137,6 +137,31 @@ func (cp *CriticalPath) longRunningJobs() (nodes []*node) {
 	return
 }
 
+// ActionDurations returns the duration of every finished action, keyed by the
+// first output of the action.
+func (cp *CriticalPath) ActionDurations() map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+	for _, node := range cp.nodes {
+		if len(node.action.Outputs) > 0 {
+			durations[node.action.Outputs[0]] = node.duration
+		}
+	}
+	return durations
+}
+
+// CriticalPathOutputs returns the first output of every action on the critical
+// path, starting with the first action that ran.
+func (cp *CriticalPath) CriticalPathOutputs() []string {
+	path, _, _ := cp.criticalPath()
+	var outputs []string
+	for i := len(path) - 1; i >= 0; i-- {
+		if len(path[i].action.Outputs) > 0 {
+			outputs = append(outputs, path[i].action.Outputs[0])
+		}
+	}
+	return outputs
+}
+
 func addJobInfos(jobInfos *[]*soong_metrics_proto.JobInfo, sources []*node) {
 	for _, job := range sources {
 		jobInfo := soong_metrics_proto.JobInfo{}
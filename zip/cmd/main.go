@@ -153,7 +153,8 @@ func main() {
 	out := flags.String("o", "", "file to write zip file to")
 	manifest := flags.String("m", "", "input jar manifest file name")
 	directories := flags.Bool("d", false, "include directories in zip")
-	compLevel := flags.Int("L", 5, "deflate compression level (0-9)")
+	compLevel := flags.Int("L", 5, "compression level (0-9)")
+	compMethod := flags.String("method", "deflate", "compression method of the files that are not stored, deflate or zstd")
 	emulateJar := flags.Bool("jar", false, "modify the resultant .zip to emulate the output of 'jar'")
 	writeIfChanged := flags.Bool("write_if_changed", false, "only update resultant .zip if it has changed")
 	ignoreMissingFiles := flags.Bool("ignore_missing_files", false, "continue if a requested file does not exist")
@@ -164,6 +165,8 @@ func main() {
 	cpuProfile := flags.String("cpuprofile", "", "write cpu profile to file")
 	traceFile := flags.String("trace", "", "write trace to file")
 	sha256Checksum := flags.Bool("sha256", false, "add a zip header to each file containing its SHA256 digest")
+	reproducible := flags.Bool("reproducible", false, "sort the entries by name and compress each file as a single stream")
+	verify := flags.String("verify", "", "compare the zip file with a reference zip file and fail if any entry differs")
 
 	flags.Var(&rootPrefix{}, "P", "path prefix within the zip at which to place files")
 	flags.Var(&listFiles{}, "l", "file containing list of files to zip")
@@ -212,13 +215,20 @@ func main() {
 		os.Exit(1)
 	}
 
-	err := zip.Zip(zip.ZipArgs{
+	method, err := zip.ParseCompressionMethod(*compMethod)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		flags.Usage()
+	}
+
+	err = zip.Zip(zip.ZipArgs{
 		FileArgs:                 fileArgsBuilder.FileArgs(),
 		OutputFilePath:           *out,
 		EmulateJar:               *emulateJar,
 		SrcJar:                   *srcJar,
 		AddDirectoryEntriesToZip: *directories,
 		CompressionLevel:         *compLevel,
+		CompressionMethod:        method,
 		ManifestSourcePath:       *manifest,
 		NumParallelJobs:          *parallelJobs,
 		NonDeflatedFiles:         nonDeflatedFiles,
@@ -226,9 +236,17 @@ func main() {
 		StoreSymlinks:            *symlinks,
 		IgnoreMissingFiles:       *ignoreMissingFiles,
 		Sha256Checksum:           *sha256Checksum,
+		Reproducible:             *reproducible,
 	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err.Error())
 		os.Exit(1)
 	}
+
+	if *verify != "" {
+		if err := zip.VerifyArchive(*out, *verify); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err.Error())
+			os.Exit(1)
+		}
+	}
 }
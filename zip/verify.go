@@ -0,0 +1,185 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zip
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"android/soong/third_party/zip"
+)
+
+// EntryDiff is an entry of an archive that is not byte-for-byte identical to the entry with the
+// same name in a reference archive.
+type EntryDiff struct {
+	Name string
+	// The fields of the entry that differ, e.g. "crc32" or "data".
+	Diffs []string
+}
+
+func (d EntryDiff) String() string {
+	return d.Name + ": " + strings.Join(d.Diffs, ", ")
+}
+
+// verifyEntry is an entry of the canonical central directory built by Verify, in which the
+// entries are sorted by name and the offsets of their local headers are ignored.
+type verifyEntry struct {
+	fh *zip.FileHeader
+	// The position of the entry in the central directory of the archive.
+	position int
+	// The raw local file header of the entry.
+	localHeader []byte
+	// The SHA256 digest of the compressed data of the entry.
+	digest [sha256.Size]byte
+}
+
+// Verify compares every entry of an archive with the entry with the same name in a reference
+// archive and returns the entries that differ, sorted by name.  The central directory headers,
+// the raw local file headers and the compressed data of the entries are compared, so two archives
+// without differences only differ in the offsets of the local headers if the entries are stored
+// in a different order.
+func Verify(archive, reference string) ([]EntryDiff, error) {
+	entries, err := readVerifyEntries(archive)
+	if err != nil {
+		return nil, err
+	}
+	refEntries, err := readVerifyEntries(reference)
+	if err != nil {
+		return nil, err
+	}
+	return verifyEntries(entries, refEntries), nil
+}
+
+// VerifyArchive calls Verify and returns an error that lists the entries that differ, if any.
+func VerifyArchive(archive, reference string) error {
+	diffs, err := Verify(archive, reference)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+	msg := &strings.Builder{}
+	fmt.Fprintf(msg, "%s differs from %s:", archive, reference)
+	for _, d := range diffs {
+		fmt.Fprintf(msg, "\n  %s", d)
+	}
+	return errors.New(msg.String())
+}
+
+func readVerifyEntries(name string) ([]verifyEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := canonicalDirectory(f, fi.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return entries, nil
+}
+
+// canonicalDirectory reads the central directory of an archive and the digest of the data of
+// each entry.
+func canonicalDirectory(r io.ReaderAt, size int64) ([]verifyEntry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]verifyEntry, 0, len(zr.File))
+	for i, f := range zr.File {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		localHeader, err := f.LocalHeader()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(r, offset, int64(f.CompressedSize64))); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		e := verifyEntry{fh: &f.FileHeader, position: i, localHeader: localHeader}
+		copy(e.digest[:], h.Sum(nil))
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].fh.Name < entries[j].fh.Name
+	})
+	return entries, nil
+}
+
+func verifyEntries(entries, refEntries []verifyEntry) []EntryDiff {
+	var diffs []EntryDiff
+	i, j := 0, 0
+	for i < len(entries) || j < len(refEntries) {
+		switch {
+		case j == len(refEntries) || (i < len(entries) && entries[i].fh.Name < refEntries[j].fh.Name):
+			diffs = append(diffs, EntryDiff{Name: entries[i].fh.Name, Diffs: []string{"not in reference"}})
+			i++
+		case i == len(entries) || refEntries[j].fh.Name < entries[i].fh.Name:
+			diffs = append(diffs, EntryDiff{Name: refEntries[j].fh.Name, Diffs: []string{"missing"}})
+			j++
+		default:
+			if d := compareEntries(entries[i], refEntries[j]); len(d) > 0 {
+				diffs = append(diffs, EntryDiff{Name: entries[i].fh.Name, Diffs: d})
+			}
+			i++
+			j++
+		}
+	}
+	return diffs
+}
+
+// compareEntries returns the fields of the central directory headers, the local headers and the
+// data of two entries that differ.
+func compareEntries(e, ref verifyEntry) []string {
+	var diffs []string
+	check := func(field string, equal bool) {
+		if !equal {
+			diffs = append(diffs, field)
+		}
+	}
+	a, b := e.fh, ref.fh
+	if e.position != ref.position {
+		diffs = append(diffs, fmt.Sprintf("position %d, expected %d", e.position, ref.position))
+	}
+	check("creator version", a.CreatorVersion == b.CreatorVersion)
+	check("reader version", a.ReaderVersion == b.ReaderVersion)
+	check("flags", a.Flags == b.Flags)
+	check("method", a.Method == b.Method)
+	check("modification time", a.ModifiedTime == b.ModifiedTime && a.ModifiedDate == b.ModifiedDate)
+	check("crc32", a.CRC32 == b.CRC32)
+	check("compressed size", a.CompressedSize64 == b.CompressedSize64)
+	check("uncompressed size", a.UncompressedSize64 == b.UncompressedSize64)
+	check("extra", bytes.Equal(a.Extra, b.Extra))
+	check("external attributes", a.ExternalAttrs == b.ExternalAttrs)
+	check("comment", a.Comment == b.Comment)
+	check("local header", bytes.Equal(e.localHeader, ref.localHeader))
+	check("data", e.digest == ref.digest)
+	return diffs
+}
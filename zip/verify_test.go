@@ -0,0 +1,210 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"android/soong/jar"
+	"android/soong/third_party/zip"
+)
+
+type verifyTestEntry struct {
+	name     string
+	contents string
+	method   uint16
+	mode     os.FileMode
+	time     time.Time
+}
+
+func verifyTestZip(t *testing.T, entries ...verifyTestEntry) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{Name: e.name, Method: e.method}
+		fh.SetMode(e.mode)
+		fh.SetModTime(e.time)
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	a := verifyTestEntry{name: "a", contents: "foo", method: zip.Deflate, mode: 0644, time: jar.DefaultTime}
+	b := verifyTestEntry{name: "b", contents: "bar", method: zip.Deflate, mode: 0644, time: jar.DefaultTime}
+	c := verifyTestEntry{name: "c", contents: "baz", method: zip.Store, mode: 0755, time: jar.DefaultTime}
+
+	modified := func(e verifyTestEntry, f func(e *verifyTestEntry)) verifyTestEntry {
+		f(&e)
+		return e
+	}
+
+	testCases := []struct {
+		name      string
+		archive   []verifyTestEntry
+		reference []verifyTestEntry
+		want      []string
+	}{
+		{
+			name:      "identical",
+			archive:   []verifyTestEntry{a, b, c},
+			reference: []verifyTestEntry{a, b, c},
+		},
+		{
+			name:      "missing and extra entries",
+			archive:   []verifyTestEntry{a, c},
+			reference: []verifyTestEntry{a, b},
+			want: []string{
+				"b: missing",
+				"c: not in reference",
+			},
+		},
+		{
+			name:      "order",
+			archive:   []verifyTestEntry{b, a},
+			reference: []verifyTestEntry{a, b},
+			want: []string{
+				"a: position 1, expected 0",
+				"b: position 0, expected 1",
+			},
+		},
+		{
+			name: "headers",
+			archive: []verifyTestEntry{
+				modified(a, func(e *verifyTestEntry) { e.time = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }),
+				modified(b, func(e *verifyTestEntry) { e.mode = 0755 }),
+			},
+			reference: []verifyTestEntry{a, b},
+			want: []string{
+				"a: modification time, local header",
+				"b: external attributes",
+			},
+		},
+		{
+			name: "data",
+			archive: []verifyTestEntry{
+				modified(a, func(e *verifyTestEntry) { e.method = zip.Store }),
+				modified(c, func(e *verifyTestEntry) { e.contents = "qux" }),
+			},
+			reference: []verifyTestEntry{a, c},
+			want: []string{
+				"a: method, compressed size, local header, data",
+				"c: crc32, data",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "archive.zip")
+			reference := filepath.Join(dir, "reference.zip")
+			if err := os.WriteFile(archive, verifyTestZip(t, tc.archive...), 0666); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(reference, verifyTestZip(t, tc.reference...), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			diffs, err := Verify(archive, reference)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, d := range diffs {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want differences %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyLocalHeader(t *testing.T) {
+	dir := t.TempDir()
+	reference := filepath.Join(dir, "reference.zip")
+	data := verifyTestZip(t, verifyTestEntry{name: "a", contents: "foo", method: zip.Deflate, mode: 0644,
+		time: jar.DefaultTime})
+	if err := os.WriteFile(reference, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change the version needed to extract in the local header of the entry, which is not
+	// in the central directory.
+	archive := filepath.Join(dir, "archive.zip")
+	data = append([]byte(nil), data...)
+	data[4]++
+	if err := os.WriteFile(archive, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	err := VerifyArchive(archive, reference)
+	if err == nil {
+		t.Fatal("want an error, got nil")
+	}
+	if want := archive + " differs from " + reference + ":\n  a: local header"; err.Error() != want {
+		t.Errorf("want error %q, got %q", want, err.Error())
+	}
+
+	if err := VerifyArchive(reference, reference); err != nil {
+		t.Errorf("want no error, got %q", err)
+	}
+}
+
+func TestVerifyReproducible(t *testing.T) {
+	dir := t.TempDir()
+	zipWith := func(name string, fileArgs *FileArgsBuilder) string {
+		args := ZipArgs{
+			FileArgs:                 fileArgs.FileArgs(),
+			OutputFilePath:           filepath.Join(dir, name),
+			AddDirectoryEntriesToZip: true,
+			CompressionLevel:         5,
+			Reproducible:             true,
+			Filesystem:               mockFs,
+			Stderr:                   &bytes.Buffer{},
+		}
+		if err := Zip(args); err != nil {
+			t.Fatal(err)
+		}
+		return args.OutputFilePath
+	}
+
+	archive := zipWith("archive.zip", fileArgsBuilder().File("c").File("a/a/b").File("a/a/a"))
+	reference := zipWith("reference.zip", fileArgsBuilder().File("a/a/a").File("a/a/b").File("c"))
+
+	diffs, err := Verify(archive, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) > 0 {
+		t.Errorf("want identical archives, got differences %q", diffs)
+	}
+}
@@ -39,6 +39,7 @@ import (
 
 	"android/soong/jar"
 	"android/soong/third_party/zip"
+	"android/soong/third_party/zstd"
 )
 
 // Sha256HeaderID is a custom Header ID for the `extra` field in
@@ -236,6 +237,8 @@ type ZipWriter struct {
 
 	compressorPool sync.Pool
 	compLevel      int
+	compMethod     uint16
+	zstdEncoder    *zstd.Encoder
 
 	followSymlinks     pathtools.ShouldFollowSymlinks
 	ignoreMissingFiles bool
@@ -244,6 +247,7 @@ type ZipWriter struct {
 	fs     pathtools.FileSystem
 
 	sha256Checksum bool
+	reproducible   bool
 }
 
 type zipEntry struct {
@@ -264,6 +268,7 @@ type ZipArgs struct {
 	SrcJar                   bool
 	AddDirectoryEntriesToZip bool
 	CompressionLevel         int
+	CompressionMethod        uint16 // zip.Deflate or zip.Zstd, the zero value selects zip.Deflate
 	ManifestSourcePath       string
 	NumParallelJobs          int
 	NonDeflatedFiles         map[string]bool
@@ -271,11 +276,18 @@ type ZipArgs struct {
 	StoreSymlinks            bool
 	IgnoreMissingFiles       bool
 	Sha256Checksum           bool
+	Reproducible             bool // sort entries by name and compress each file as a single stream
 
 	Stderr     io.Writer
 	Filesystem pathtools.FileSystem
 }
 
+// ParseCompressionMethod returns the value of ZipArgs.CompressionMethod for the name of a
+// compression method, deflate or zstd.
+func ParseCompressionMethod(name string) (uint16, error) {
+	return zip.ParseCompressionMethod(name)
+}
+
 func zipTo(args ZipArgs, w io.Writer) error {
 	if args.EmulateJar {
 		args.AddDirectoryEntriesToZip = true
@@ -295,6 +307,7 @@ func zipTo(args ZipArgs, w io.Writer) error {
 		stderr:             args.Stderr,
 		fs:                 args.Filesystem,
 		sha256Checksum:     args.Sha256Checksum,
+		reproducible:       args.Reproducible,
 	}
 
 	if z.fs == nil {
@@ -305,6 +318,25 @@ func zipTo(args ZipArgs, w io.Writer) error {
 		z.stderr = os.Stderr
 	}
 
+	switch args.CompressionMethod {
+	case zip.Store, zip.Deflate:
+		z.compMethod = zip.Deflate
+	case zip.Zstd:
+		if args.EmulateJar {
+			return errors.New("zstd compression can't be used with --jar, jars must be readable by javac and d8")
+		}
+		z.compMethod = zip.Zstd
+		var err error
+		z.zstdEncoder, err = zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(args.CompressionLevel)))
+		if err != nil {
+			return err
+		}
+		defer z.zstdEncoder.Close()
+	default:
+		return fmt.Errorf("unsupported compression method %d", args.CompressionMethod)
+	}
+
 	pathMappings := []pathMapping{}
 
 	noCompression := args.CompressionLevel == 0
@@ -368,13 +400,21 @@ func zipTo(args ZipArgs, w io.Writer) error {
 			srcs = append(srcs, result.Matches...)
 		}
 		for _, src := range srcs {
-			err := fillPathPairs(fa, src, &pathMappings, args.NonDeflatedFiles, noCompression)
+			err := fillPathPairs(fa, src, &pathMappings, z.compMethod, args.NonDeflatedFiles, noCompression)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	if args.Reproducible && !args.EmulateJar {
+		// The order of the entries doesn't depend on the order of the arguments, jars are
+		// always sorted by write.
+		sort.SliceStable(pathMappings, func(i, j int) bool {
+			return pathMappings[i].dest < pathMappings[j].dest
+		})
+	}
+
 	return z.write(w, pathMappings, args.ManifestSourcePath, args.EmulateJar, args.SrcJar, args.NumParallelJobs)
 }
 
@@ -420,7 +460,7 @@ func Zip(args ZipArgs) error {
 	return nil
 }
 
-func fillPathPairs(fa FileArg, src string, pathMappings *[]pathMapping,
+func fillPathPairs(fa FileArg, src string, pathMappings *[]pathMapping, method uint16,
 	nonDeflatedFiles map[string]bool, noCompression bool) error {
 
 	var dest string
@@ -442,7 +482,7 @@ func fillPathPairs(fa FileArg, src string, pathMappings *[]pathMapping,
 	}
 	dest = filepath.Join(fa.PathPrefixInZip, dest)
 
-	zipMethod := zip.Deflate
+	zipMethod := method
 	if _, found := nonDeflatedFiles[dest]; found || noCompression {
 		zipMethod = zip.Store
 	}
@@ -547,7 +587,7 @@ func (z *ZipWriter) write(f io.Writer, pathMappings []pathMapping, manifest stri
 			currentWriteOpChan = nil
 
 			var err error
-			if op.fh.Method == zip.Deflate {
+			if op.fh.Method != zip.Store {
 				currentWriter, err = zipw.CreateCompressedHeader(op.fh)
 			} else {
 				var zw io.Writer
@@ -790,7 +830,9 @@ func (z *ZipWriter) writeFileContents(header *zip.FileHeader, r pathtools.Reader
 		fileSize = int64(header.UncompressedSize)
 	}
 
-	if header.Method == zip.Deflate && fileSize >= minParallelFileSize {
+	// Blocks compressed in parallel don't produce the same stream as compressing the whole file,
+	// which would make the output depend on the size threshold.
+	if header.Method == zip.Deflate && fileSize >= minParallelFileSize && !z.reproducible {
 		wg := new(sync.WaitGroup)
 
 		// Allocate enough buffer to hold all readers. We'll limit
@@ -943,6 +985,17 @@ func (z *ZipWriter) compressBlock(r io.Reader, dict []byte, last bool) (*bytes.B
 	return buf, nil
 }
 
+// compressZstd compresses the whole file into a single zstd frame.  Unlike deflate, large files are
+// not split into blocks that are compressed in parallel, but EncodeAll can compress several files
+// concurrently.
+func (z *ZipWriter) compressZstd(r io.Reader) (*bytes.Buffer, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(z.zstdEncoder.EncodeAll(src, nil)), nil
+}
+
 func (z *ZipWriter) compressWholeFile(ze *zipEntry, r io.ReadSeeker, compressChan chan *zipEntry) {
 	z.checksumFile(r, ze)
 
@@ -971,8 +1024,14 @@ func (z *ZipWriter) compressWholeFile(ze *zipEntry, r io.ReadSeeker, compressCha
 	ze.futureReaders <- futureReader
 	close(ze.futureReaders)
 
-	if ze.fh.Method == zip.Deflate {
-		compressed, err := z.compressBlock(r, nil, true)
+	if ze.fh.Method == zip.Deflate || ze.fh.Method == zip.Zstd {
+		var compressed *bytes.Buffer
+		var err error
+		if ze.fh.Method == zip.Zstd {
+			compressed, err = z.compressZstd(r)
+		} else {
+			compressed, err = z.compressBlock(r, nil, true)
+		}
 		if err != nil {
 			z.errors <- err
 			return
@@ -21,6 +21,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -132,6 +133,7 @@ func TestZip(t *testing.T) {
 		name               string
 		args               *FileArgsBuilder
 		compressionLevel   int
+		compressionMethod  uint16
 		emulateJar         bool
 		nonDeflatedFiles   map[string]bool
 		dirEntries         bool
@@ -139,6 +141,7 @@ func TestZip(t *testing.T) {
 		storeSymlinks      bool
 		ignoreMissingFiles bool
 		sha256Checksum     bool
+		reproducible       bool
 
 		files []zip.FileHeader
 		err   error
@@ -165,6 +168,24 @@ func TestZip(t *testing.T) {
 				fh("[", fileEmpty, zip.Store),
 			},
 		},
+		{
+			name: "reproducible",
+			args: fileArgsBuilder().
+				File("c").
+				File("a/a/b").
+				File("a/a/a"),
+			compressionLevel: 9,
+			dirEntries:       true,
+			reproducible:     true,
+
+			files: []zip.FileHeader{
+				fhDir("a/", fhDirOptions{}),
+				fhDir("a/a/", fhDirOptions{}),
+				fh("a/a/a", fileA, zip.Deflate),
+				fh("a/a/b", fileB, zip.Deflate),
+				fh("c", fileC, zip.Deflate),
+			},
+		},
 		{
 			name: "files glob",
 			args: fileArgsBuilder().
@@ -455,6 +476,27 @@ func TestZip(t *testing.T) {
 			},
 		},
 
+		{
+			name: "zstd",
+			args: fileArgsBuilder().
+				File("a/a/a").
+				File("a/a/b").
+				File("c").
+				File(`\[`),
+			compressionLevel:  9,
+			compressionMethod: zip.Zstd,
+			nonDeflatedFiles: map[string]bool{
+				"c": true,
+			},
+
+			files: []zip.FileHeader{
+				fh("a/a/a", fileA, zip.Zstd),
+				fh("a/a/b", fileB, zip.Zstd),
+				fh("c", fileC, zip.Store),
+				fh("[", fileEmpty, zip.Store),
+			},
+		},
+
 		// errors
 		{
 			name: "error missing file",
@@ -501,6 +543,7 @@ func TestZip(t *testing.T) {
 			args := ZipArgs{}
 			args.FileArgs = test.args.FileArgs()
 			args.CompressionLevel = test.compressionLevel
+			args.CompressionMethod = test.compressionMethod
 			args.EmulateJar = test.emulateJar
 			args.AddDirectoryEntriesToZip = test.dirEntries
 			args.NonDeflatedFiles = test.nonDeflatedFiles
@@ -508,6 +551,7 @@ func TestZip(t *testing.T) {
 			args.StoreSymlinks = test.storeSymlinks
 			args.IgnoreMissingFiles = test.ignoreMissingFiles
 			args.Sha256Checksum = test.sha256Checksum
+			args.Reproducible = test.reproducible
 			args.Filesystem = mockFs
 			args.Stderr = &bytes.Buffer{}
 
@@ -608,6 +652,21 @@ func TestZip(t *testing.T) {
 	}
 }
 
+func TestZstdJar(t *testing.T) {
+	args := ZipArgs{}
+	args.FileArgs = fileArgsBuilder().File("a/a/a").FileArgs()
+	args.CompressionLevel = 5
+	args.CompressionMethod = zip.Zstd
+	args.EmulateJar = true
+	args.Filesystem = mockFs
+	args.Stderr = &bytes.Buffer{}
+
+	err := zipTo(args, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "zstd compression can't be used with --jar") {
+		t.Errorf("want error for zstd compression of a jar, got %v", err)
+	}
+}
+
 func TestSrcJar(t *testing.T) {
 	mockFs := pathtools.MockFs(map[string][]byte{
 		"wrong_package.java":       []byte("package foo;"),